@@ -13,3 +13,6 @@ QEMUCMD := qemu-lite-system-x86_64
 
 # Firecracker binary name
 FCCMD := firecracker
+
+# Firecracker jailer binary name
+FCJAILERCMD := jailer
@@ -0,0 +1,438 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+// dirMode is the mode of the host directories created while extracting
+// files copied out of a container.
+const dirMode = os.FileMode(0755)
+
+var cpCLICommand = cli.Command{
+	Name:  "cp",
+	Usage: "copy files between a container and the host",
+	ArgsUsage: `<src> <container-id>:<dst>
+   kata-runtime cp <container-id>:<src> <dst>`,
+	Description: `The cp command copies a file or a whole directory tree into or out of a
+   running container, preserving permissions and ownership. The files are
+   streamed as a tar archive through the agent, to or from a tar process
+   run in the container, so the container image has to provide tar. The
+   setuid and setgid bits of the files copied out of a container are
+   dropped.
+
+   Container paths must be absolute. When the destination ends with a slash,
+   or is an existing host directory, the source is copied into it, otherwise
+   the source is copied to the destination path.`,
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		if context.NArg() != 2 {
+			return fmt.Errorf("Expecting a source and a destination, one of them in a container")
+		}
+
+		return cp(ctx, context.Args().Get(0), context.Args().Get(1))
+	},
+}
+
+// splitCopyPath splits a cp argument into a container ID and a path. The
+// container ID is empty for host paths.
+func splitCopyPath(arg string) (string, string) {
+	i := strings.Index(arg, ":")
+
+	// Host paths with a colon can be disambiguated with a slash,
+	// e.g. ./foo:bar
+	if i <= 0 || strings.Contains(arg[:i], "/") {
+		return "", arg
+	}
+
+	return arg[:i], arg[i+1:]
+}
+
+func cp(ctx context.Context, src, dst string) error {
+	span, ctx := katautils.Trace(ctx, "cp")
+	defer span.Finish()
+
+	srcContainer, srcPath := splitCopyPath(src)
+	dstContainer, dstPath := splitCopyPath(dst)
+
+	if (srcContainer == "") == (dstContainer == "") {
+		return fmt.Errorf("Either the source or the destination must be a container path")
+	}
+
+	if srcPath == "" || dstPath == "" {
+		return fmt.Errorf("Missing source or destination path")
+	}
+
+	containerID, containerPath := srcContainer, srcPath
+	if dstContainer != "" {
+		containerID, containerPath = dstContainer, dstPath
+	}
+
+	if !filepath.IsAbs(containerPath) {
+		return fmt.Errorf("Container path %q must be absolute", containerPath)
+	}
+
+	kataLog = kataLog.WithField("container", containerID)
+	setExternalLoggers(ctx, kataLog)
+	span.SetTag("container", containerID)
+
+	status, sandboxID, err := getExistingContainerInfo(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	containerID = status.ID
+
+	kataLog = kataLog.WithFields(logrus.Fields{
+		"container": containerID,
+		"sandbox":   sandboxID,
+	})
+
+	setExternalLoggers(ctx, kataLog)
+	span.SetTag("container", containerID)
+	span.SetTag("sandbox", sandboxID)
+
+	// container MUST be running
+	if status.State.State != types.StateRunning {
+		return fmt.Errorf("Container %s is not running", containerID)
+	}
+
+	if dstContainer != "" {
+		return copyToContainer(ctx, sandboxID, containerID, srcPath, dstPath)
+	}
+
+	return copyFromContainer(ctx, sandboxID, containerID, srcPath, dstPath)
+}
+
+func copyToContainer(ctx context.Context, sandboxID, containerID, src, dst string) error {
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+
+	// The archive root is named after the destination, and
+	// extracted into its parent directory.
+	dir, name := filepath.Dir(dst), filepath.Base(dst)
+	if strings.HasSuffix(dst, "/") {
+		dir, name = filepath.Clean(dst), filepath.Base(src)
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		w.CloseWithError(writeArchive(w, src, name))
+	}()
+
+	err := vci.CopyToContainer(ctx, sandboxID, containerID, dir, r)
+	r.CloseWithError(err)
+
+	return err
+}
+
+func copyFromContainer(ctx context.Context, sandboxID, containerID, src, dst string) error {
+	// The archive root is named after the source.
+	dir, name := filepath.Dir(dst), filepath.Base(dst)
+	if fi, err := os.Stat(dst); (err == nil && fi.IsDir()) || strings.HasSuffix(dst, "/") {
+		dir, name = filepath.Clean(dst), filepath.Base(src)
+	}
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		w.CloseWithError(vci.CopyFromContainer(ctx, sandboxID, containerID, src, w))
+	}()
+
+	err := extractArchive(r, dir, name)
+	r.CloseWithError(err)
+
+	return err
+}
+
+// writeArchive writes a tar archive of the src tree, its root entry being
+// renamed to name.
+func writeArchive(w io.Writer, src, name string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		// Uid and Gid are set from the file information.
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(filepath.Join(name, rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// archiveEntryPath returns the host path of an archive entry, its root
+// component being renamed to name. Entries escaping dir are rejected.
+func archiveEntryPath(dir, name, entry string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(entry))
+
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Invalid archive entry %q", entry)
+	}
+
+	parts := strings.SplitN(clean, string(filepath.Separator), 2)
+	parts[0] = name
+
+	path := filepath.Join(append([]string{dir}, parts...)...)
+
+	// Make sure no symbolic link extracted earlier redirects the
+	// entry out of the destination directory, checking its closest
+	// existing ancestor.
+	parent := filepath.Dir(path)
+	for {
+		resolved, err := filepath.EvalSymlinks(parent)
+		if err == nil {
+			parent = resolved
+			break
+		}
+		// A dangling symbolic link is not followed either.
+		if _, lerr := os.Lstat(parent); lerr == nil || !os.IsNotExist(err) || parent == dir {
+			return "", fmt.Errorf("Could not resolve archive entry %q: %v", entry, err)
+		}
+		parent = filepath.Dir(parent)
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if parent != root && !strings.HasPrefix(parent, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("Archive entry %q is out of %s", entry, dir)
+	}
+
+	return path, nil
+}
+
+// extractArchive extracts a tar archive into dir, its root entry being
+// renamed to name. Permissions are preserved, except the setuid and setgid
+// bits, and so is ownership when running as root. The symbolic links,
+// whether extracted or already present, are never followed.
+func extractArchive(r io.Reader, dir, name string) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path, err := archiveEntryPath(dir, name, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = extractDir(path, hdr)
+		case tar.TypeReg, tar.TypeRegA:
+			err = extractFile(path, hdr, tr)
+		case tar.TypeSymlink:
+			err = extractSymlink(path, hdr)
+		case tar.TypeLink:
+			err = extractLink(dir, name, path, hdr)
+		default:
+			kataLog.WithField("entry", hdr.Name).Warnf("Skipping unsupported archive entry type %q", hdr.Typeflag)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// removeEntry removes the file an archive entry replaces, if any.
+// Directories are never replaced.
+func removeEntry(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		return fmt.Errorf("Could not replace directory %s", path)
+	}
+
+	return os.Remove(path)
+}
+
+func extractDir(path string, hdr *tar.Header) error {
+	fi, err := os.Lstat(path)
+	if err == nil && !fi.IsDir() {
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+		err = os.ErrNotExist
+	}
+
+	if os.IsNotExist(err) {
+		err = os.Mkdir(path, 0700)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return setEntryMetadata(f, hdr)
+}
+
+func extractFile(path string, hdr *tar.Header, r io.Reader) error {
+	if err := removeEntry(path); err != nil {
+		return err
+	}
+
+	// The file is always created, never opened through a symbolic link
+	// extracted or created concurrently.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|unix.O_NOFOLLOW, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+
+	return setEntryMetadata(f, hdr)
+}
+
+func extractSymlink(path string, hdr *tar.Header) error {
+	if err := removeEntry(path); err != nil {
+		return err
+	}
+
+	if err := os.Symlink(hdr.Linkname, path); err != nil {
+		return err
+	}
+
+	if os.Geteuid() == 0 {
+		return os.Lchown(path, hdr.Uid, hdr.Gid)
+	}
+
+	return nil
+}
+
+// extractLink creates a hard link. Its metadata are the ones of the
+// linked entry, already extracted.
+func extractLink(dir, name, path string, hdr *tar.Header) error {
+	target, err := archiveEntryPath(dir, name, hdr.Linkname)
+	if err != nil {
+		return err
+	}
+
+	if err := removeEntry(path); err != nil {
+		return err
+	}
+
+	// A symbolic link target is linked, not followed.
+	return os.Link(target, path)
+}
+
+// setEntryMetadata sets the ownership, mode and times of an extracted file
+// through its file descriptor.
+func setEntryMetadata(f *os.File, hdr *tar.Header) error {
+	if os.Geteuid() == 0 {
+		if err := f.Chown(hdr.Uid, hdr.Gid); err != nil {
+			return err
+		}
+	}
+
+	// Set the mode once the file is created, as it is filtered by the
+	// umask on creation.
+	mode := hdr.FileInfo().Mode() &^ (os.ModeSetuid | os.ModeSetgid)
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+
+	atime := hdr.AccessTime
+	if atime.IsZero() {
+		atime = hdr.ModTime
+	}
+
+	return unix.Futimes(int(f.Fd()), []unix.Timeval{
+		unix.NsecToTimeval(atime.UnixNano()),
+		unix.NsecToTimeval(hdr.ModTime.UnixNano()),
+	})
+}
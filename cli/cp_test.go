@@ -0,0 +1,300 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCpCliAction(t *testing.T) {
+	assert := assert.New(t)
+
+	actionFunc, ok := cpCLICommand.Action.(func(ctx *cli.Context) error)
+	assert.True(ok)
+
+	flagSet := flag.NewFlagSet("flag", flag.ContinueOnError)
+	flagSet.Parse([]string{"/tmp/foo"})
+	ctx := createCLIContext(flagSet)
+	err := actionFunc(ctx)
+	assert.Error(err)
+}
+
+func TestSplitCopyPath(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		arg         string
+		containerID string
+		path        string
+	}
+
+	data := []testData{
+		{"/tmp/foo", "", "/tmp/foo"},
+		{"foo", "", "foo"},
+		{":/tmp/foo", "", ":/tmp/foo"},
+		{"./foo:bar", "", "./foo:bar"},
+		{"ctr:/tmp/foo", "ctr", "/tmp/foo"},
+		{"ctr:", "ctr", ""},
+	}
+
+	for _, d := range data {
+		containerID, path := splitCopyPath(d.arg)
+		assert.Equal(d.containerID, containerID, "%+v", d)
+		assert.Equal(d.path, path, "%+v", d)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	src, err := ioutil.TempDir("", "cp-src")
+	assert.NoError(err)
+	defer os.RemoveAll(src)
+
+	dst, err := ioutil.TempDir("", "cp-dst")
+	assert.NoError(err)
+	defer os.RemoveAll(dst)
+
+	assert.NoError(os.MkdirAll(filepath.Join(src, "a", "b"), 0700))
+	assert.NoError(ioutil.WriteFile(filepath.Join(src, "a", "b", "file"), []byte("content"), 0640))
+	assert.NoError(ioutil.WriteFile(filepath.Join(src, "exec"), []byte("#!/bin/sh"), 0755))
+	assert.NoError(os.Symlink("a/b/file", filepath.Join(src, "link")))
+
+	var archive bytes.Buffer
+	assert.NoError(writeArchive(&archive, src, "tree"))
+	assert.NoError(extractArchive(&archive, dst, "copy"))
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "copy", "a", "b", "file"))
+	assert.NoError(err)
+	assert.Equal("content", string(content))
+
+	fi, err := os.Stat(filepath.Join(dst, "copy", "a", "b", "file"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0640), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "copy", "exec"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0755), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "copy", "a"))
+	assert.NoError(err)
+	assert.True(fi.IsDir())
+	assert.Equal(os.FileMode(0700), fi.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(dst, "copy", "link"))
+	assert.NoError(err)
+	assert.Equal("a/b/file", link)
+
+	// single file
+	archive.Reset()
+	assert.NoError(writeArchive(&archive, filepath.Join(src, "exec"), "exec"))
+	assert.NoError(extractArchive(&archive, dst, "renamed"))
+
+	content, err = ioutil.ReadFile(filepath.Join(dst, "renamed"))
+	assert.NoError(err)
+	assert.Equal("#!/bin/sh", string(content))
+}
+
+func TestExtractArchiveInvalidEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	dst, err := ioutil.TempDir("", "cp-dst")
+	assert.NoError(err)
+	defer os.RemoveAll(dst)
+
+	outside, err := ioutil.TempDir("", "cp-outside")
+	assert.NoError(err)
+	defer os.RemoveAll(outside)
+
+	writeEntries := func(hdrs ...*tar.Header) io.Reader {
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		for _, hdr := range hdrs {
+			assert.NoError(tw.WriteHeader(hdr))
+		}
+		assert.NoError(tw.Close())
+		return &archive
+	}
+
+	err = extractArchive(writeEntries(&tar.Header{Name: "../foo", Typeflag: tar.TypeReg, Mode: 0644}), dst, "foo")
+	assert.Error(err)
+
+	err = extractArchive(writeEntries(&tar.Header{Name: "/foo", Typeflag: tar.TypeReg, Mode: 0644}), dst, "foo")
+	assert.Error(err)
+
+	// a symbolic link must not redirect the following entries
+	err = extractArchive(writeEntries(
+		&tar.Header{Name: "foo/", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "foo/link", Typeflag: tar.TypeSymlink, Linkname: outside},
+		&tar.Header{Name: "foo/link/file", Typeflag: tar.TypeReg, Mode: 0644},
+	), dst, "foo")
+	assert.Error(err)
+
+	_, err = os.Stat(filepath.Join(outside, "file"))
+	assert.True(os.IsNotExist(err))
+
+	// nor be followed by the entries replacing it
+	outsideFile := filepath.Join(outside, "file")
+	assert.NoError(ioutil.WriteFile(outsideFile, []byte("outside"), 0600))
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, hdr := range []*tar.Header{
+		{Name: "bar/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "bar/file", Typeflag: tar.TypeSymlink, Linkname: outsideFile},
+		{Name: "bar/dir", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "bar/file", Typeflag: tar.TypeReg, Mode: 0777, Size: 6},
+	} {
+		assert.NoError(tw.WriteHeader(hdr))
+	}
+	_, err = tw.Write([]byte("inside"))
+	assert.NoError(err)
+	assert.NoError(tw.WriteHeader(&tar.Header{Name: "bar/dir/", Typeflag: tar.TypeDir, Mode: 0777}))
+	assert.NoError(tw.Close())
+
+	err = extractArchive(&archive, dst, "bar")
+	assert.NoError(err)
+
+	content, err := ioutil.ReadFile(outsideFile)
+	assert.NoError(err)
+	assert.Equal("outside", string(content))
+
+	for _, path := range []string{outsideFile, outside} {
+		fi, err := os.Stat(path)
+		assert.NoError(err)
+		assert.NotEqual(os.FileMode(0777), fi.Mode().Perm())
+	}
+
+	fi, err := os.Lstat(filepath.Join(dst, "bar", "dir"))
+	assert.NoError(err)
+	assert.True(fi.IsDir())
+}
+
+func TestExtractArchiveSetuid(t *testing.T) {
+	assert := assert.New(t)
+
+	dst, err := ioutil.TempDir("", "cp-dst")
+	assert.NoError(err)
+	defer os.RemoveAll(dst)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	assert.NoError(tw.WriteHeader(&tar.Header{Name: "suid", Typeflag: tar.TypeReg, Mode: 06755}))
+	assert.NoError(tw.Close())
+
+	err = extractArchive(&archive, dst, "suid")
+	assert.NoError(err)
+
+	fi, err := os.Stat(filepath.Join(dst, "suid"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0755), fi.Mode())
+}
+
+func TestCp(t *testing.T) {
+	assert := assert.New(t)
+
+	err := cp(context.Background(), "/tmp/foo", "/tmp/bar")
+	assert.Error(err)
+
+	err = cp(context.Background(), "ctr:/tmp/foo", "ctr:/tmp/bar")
+	assert.Error(err)
+
+	err = cp(context.Background(), "/tmp/foo", testContainerID+":relative")
+	assert.Error(err)
+
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+	}
+
+	path, err := ioutil.TempDir("", "containers-mapping")
+	assert.NoError(err)
+	defer os.RemoveAll(path)
+	ctrsMapTreePath = path
+
+	// the container does not exist
+	err = cp(context.Background(), "/tmp/foo", testContainerID+":/tmp/bar")
+	assert.Error(err)
+
+	path, err = createTempContainerIDMapping(testContainerID, sandbox.ID())
+	assert.NoError(err)
+	defer os.RemoveAll(path)
+
+	state := types.StateStopped
+	testingImpl.StatusContainerFunc = func(ctx context.Context, sandboxID, containerID string) (vc.ContainerStatus, error) {
+		return vc.ContainerStatus{
+			ID: testContainerID,
+			Annotations: map[string]string{
+				vcAnnotations.ContainerTypeKey: string(vc.PodContainer),
+			},
+			State: types.State{
+				State: state,
+			},
+		}, nil
+	}
+	defer func() {
+		testingImpl.StatusContainerFunc = nil
+	}()
+
+	src, err := ioutil.TempDir("", "cp-src")
+	assert.NoError(err)
+	defer os.RemoveAll(src)
+	assert.NoError(ioutil.WriteFile(filepath.Join(src, "file"), []byte("content"), 0600))
+
+	// the container is not running
+	err = cp(context.Background(), src, testContainerID+":/tmp/bar")
+	assert.Error(err)
+
+	state = types.StateRunning
+
+	var archive bytes.Buffer
+	testingImpl.CopyToContainerFunc = func(ctx context.Context, sandboxID, containerID, dst string, r io.Reader) error {
+		assert.Equal("/tmp", dst)
+		_, err := io.Copy(&archive, r)
+		return err
+	}
+	defer func() {
+		testingImpl.CopyToContainerFunc = nil
+	}()
+
+	err = cp(context.Background(), src, testContainerID+":/tmp/bar")
+	assert.NoError(err)
+
+	testingImpl.CopyFromContainerFunc = func(ctx context.Context, sandboxID, containerID, src string, w io.Writer) error {
+		assert.Equal("/tmp/bar", src)
+		_, err := io.Copy(w, &archive)
+		return err
+	}
+	defer func() {
+		testingImpl.CopyFromContainerFunc = nil
+	}()
+
+	dst, err := ioutil.TempDir("", "cp-dst")
+	assert.NoError(err)
+	defer os.RemoveAll(dst)
+
+	// copied into the existing directory, keeping the source name
+	err = cp(context.Background(), testContainerID+":/tmp/bar", dst)
+	assert.NoError(err)
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "bar", "file"))
+	assert.NoError(err)
+	assert.Equal("content", string(content))
+}
@@ -123,6 +123,10 @@ func create(ctx context.Context, containerID, bundlePath, console, pidFilePath s
 		return err
 	}
 
+	if err := runtimeConfig.SelectHypervisorProfile(ociSpec); err != nil {
+		return err
+	}
+
 	katautils.HandleFactory(ctx, vci, &runtimeConfig)
 
 	disableOutput := noNeedForOutput(detach, ociSpec.Process.Terminal)
@@ -0,0 +1,230 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+var kataDeviceCLICommand = cli.Command{
+	Name:  "device",
+	Usage: "manage the devices of a running sandbox",
+	Subcommands: []cli.Command{
+		addDeviceCommand,
+		removeDeviceCommand,
+		listDevicesCommand,
+	},
+	Action: func(context *cli.Context) error {
+		return cli.ShowSubcommandHelp(context)
+	},
+}
+
+var addDeviceCommand = cli.Command{
+	Name:      "add",
+	Usage:     "add a device to a sandbox",
+	ArgsUsage: `add <sandbox-id> file or - for stdin`,
+	Flags:     []cli.Flag{},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		return deviceModifyCommand(ctx, context.Args().First(), context.Args().Get(1), true)
+	},
+}
+
+var removeDeviceCommand = cli.Command{
+	Name:      "remove",
+	Usage:     "remove a device from a sandbox",
+	ArgsUsage: `remove <sandbox-id> file or - for stdin`,
+	Flags:     []cli.Flag{},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		return deviceModifyCommand(ctx, context.Args().First(), context.Args().Get(1), false)
+	},
+}
+
+var listDevicesCommand = cli.Command{
+	Name:      "list",
+	Usage:     "list the devices of a sandbox",
+	ArgsUsage: `list <sandbox-id>`,
+	Flags:     []cli.Flag{},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		return deviceListCommand(ctx, context.Args().First())
+	},
+}
+
+// deviceDescription is the description of a sandbox device
+// printed by the device commands.
+type deviceDescription struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	Major       int64       `json:"major"`
+	Minor       int64       `json:"minor"`
+	AttachCount uint        `json:"attachCount"`
+	Info        interface{} `json:"info,omitempty"`
+}
+
+func describeDevice(d api.Device) *deviceDescription {
+	if d == nil {
+		return nil
+	}
+
+	major, minor := d.GetMajorMinor()
+
+	return &deviceDescription{
+		ID:          d.DeviceID(),
+		Type:        string(d.DeviceType()),
+		Major:       major,
+		Minor:       minor,
+		AttachCount: d.GetAttachCount(),
+		Info:        d.GetDeviceInfo(),
+	}
+}
+
+// completeDeviceInfo fills the device type and numbers from the device
+// host path when they are not provided. The container path is not part
+// of the JSON representation of a device, it is set to the host path.
+func completeDeviceInfo(info *config.DeviceInfo) error {
+	if info.HostPath == "" {
+		return nil
+	}
+
+	if info.ContainerPath == "" {
+		info.ContainerPath = info.HostPath
+	}
+
+	if info.DevType != "" && (info.Major != 0 || info.Minor != 0) {
+		return nil
+	}
+
+	var stat unix.Stat_t
+	if err := unix.Stat(info.HostPath, &stat); err != nil {
+		return fmt.Errorf("stat %q failed: %v", info.HostPath, err)
+	}
+
+	switch stat.Mode & unix.S_IFMT {
+	case unix.S_IFBLK:
+		info.DevType = "b"
+	case unix.S_IFCHR:
+		info.DevType = "c"
+	default:
+		return fmt.Errorf("%q is not a device", info.HostPath)
+	}
+
+	info.Major = int64(unix.Major(stat.Rdev))
+	info.Minor = int64(unix.Minor(stat.Rdev))
+
+	return nil
+}
+
+func checkSandboxRunning(ctx context.Context, sandboxID string) error {
+	if sandboxID == "" {
+		return fmt.Errorf("Missing sandbox ID")
+	}
+
+	kataLog = kataLog.WithField("sandbox", sandboxID)
+	setExternalLoggers(ctx, kataLog)
+
+	status, err := vci.StatusSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+
+	// sandbox MUST be running
+	if status.State.State != types.StateRunning {
+		return fmt.Errorf("sandbox %s is not running", sandboxID)
+	}
+
+	return nil
+}
+
+func deviceModifyCommand(ctx context.Context, sandboxID, input string, add bool) (err error) {
+	if err = checkSandboxRunning(ctx, sandboxID); err != nil {
+		return err
+	}
+
+	var (
+		f      *os.File
+		output = defaultOutputFile
+	)
+
+	if input == "-" {
+		f = os.Stdin
+	} else {
+		f, err = os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+
+	var info config.DeviceInfo
+	if err = json.NewDecoder(f).Decode(&info); err != nil {
+		return err
+	}
+
+	if err = completeDeviceInfo(&info); err != nil {
+		return err
+	}
+
+	var dev api.Device
+	if add {
+		dev, err = vci.AddDevice(ctx, sandboxID, info)
+		if err != nil {
+			kataLog.WithField("device", fmt.Sprintf("%+v", info)).
+				WithError(err).Error("add device failed")
+		}
+	} else {
+		dev, err = vci.RemoveDevice(ctx, sandboxID, info)
+		if err != nil {
+			kataLog.WithField("device", fmt.Sprintf("%+v", info)).
+				WithError(err).Error("remove device failed")
+		}
+	}
+
+	json.NewEncoder(output).Encode(describeDevice(dev))
+
+	return err
+}
+
+func deviceListCommand(ctx context.Context, sandboxID string) error {
+	if err := checkSandboxRunning(ctx, sandboxID); err != nil {
+		return err
+	}
+
+	devices, err := vci.ListDevices(ctx, sandboxID)
+	if err != nil {
+		kataLog.WithError(err).Error("list devices failed")
+		return err
+	}
+
+	descriptions := []*deviceDescription{}
+	for _, d := range devices {
+		descriptions = append(descriptions, describeDevice(d))
+	}
+
+	return json.NewEncoder(defaultOutputFile).Encode(descriptions)
+}
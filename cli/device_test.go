@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceCliFunction(t *testing.T) {
+	assert := assert.New(t)
+
+	state := types.State{
+		State: types.StateRunning,
+	}
+
+	testingImpl.AddDeviceFunc = func(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error) {
+		return nil, nil
+	}
+	testingImpl.RemoveDeviceFunc = func(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error) {
+		return nil, nil
+	}
+	testingImpl.ListDevicesFunc = func(ctx context.Context, sandboxID string) ([]api.Device, error) {
+		return nil, nil
+	}
+	testingImpl.StatusSandboxFunc = func(ctx context.Context, sandboxID string) (vc.SandboxStatus, error) {
+		return vc.SandboxStatus{ID: sandboxID, State: state}, nil
+	}
+
+	defer func() {
+		testingImpl.AddDeviceFunc = nil
+		testingImpl.RemoveDeviceFunc = nil
+		testingImpl.ListDevicesFunc = nil
+		testingImpl.StatusSandboxFunc = nil
+	}()
+
+	set := flag.NewFlagSet("", 0)
+	execCLICommandFunc(assert, listDevicesCommand, set, true)
+
+	set.Parse([]string{testSandboxID})
+	execCLICommandFunc(assert, listDevicesCommand, set, false)
+	execCLICommandFunc(assert, addDeviceCommand, set, true)
+
+	f, err := ioutil.TempFile("", "device")
+	defer os.Remove(f.Name())
+	assert.NoError(err)
+	assert.NotNil(f)
+	f.WriteString(`{"ID":"foo"}`)
+	f.Close()
+
+	set.Parse([]string{testSandboxID, f.Name()})
+	execCLICommandFunc(assert, addDeviceCommand, set, false)
+	execCLICommandFunc(assert, removeDeviceCommand, set, false)
+
+	// Devices can only be managed in running sandboxes.
+	state.State = types.StateStopped
+	execCLICommandFunc(assert, removeDeviceCommand, set, true)
+}
+
+func TestCompleteDeviceInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	info := config.DeviceInfo{}
+	assert.NoError(completeDeviceInfo(&info))
+	assert.Equal(config.DeviceInfo{}, info)
+
+	info = config.DeviceInfo{HostPath: "/dev/null"}
+	assert.NoError(completeDeviceInfo(&info))
+	assert.Equal("c", info.DevType)
+	assert.Equal(int64(1), info.Major)
+	assert.Equal(int64(3), info.Minor)
+	assert.Equal("/dev/null", info.ContainerPath)
+
+	f, err := ioutil.TempFile("", "device")
+	assert.NoError(err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	info = config.DeviceInfo{HostPath: f.Name()}
+	assert.Error(completeDeviceInfo(&info))
+}
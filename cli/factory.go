@@ -9,7 +9,7 @@ import (
 	"errors"
 	"fmt"
 
-	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/pkg/katautils"
 	vf "github.com/kata-containers/runtime/virtcontainers/factory"
 	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
 	"github.com/urfave/cli"
@@ -30,30 +30,45 @@ var factoryCLICommand = cli.Command{
 	},
 }
 
+var factoryProfileFlag = cli.StringFlag{
+	Name:  "hypervisor-profile",
+	Usage: "hypervisor profile of the VM factory (default: the default hypervisor profile)",
+}
+
+// factoryRuntimeConfig returns the runtime configuration of the hypervisor
+// profile the factory command applies to.
+func factoryRuntimeConfig(c *cli.Context) (oci.RuntimeConfig, error) {
+	runtimeConfig, ok := c.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
+	if !ok {
+		return oci.RuntimeConfig{}, errors.New("invalid runtime config")
+	}
+
+	if profile := c.String(factoryProfileFlag.Name); profile != "" {
+		if err := runtimeConfig.SetHypervisorProfile(profile); err != nil {
+			return oci.RuntimeConfig{}, err
+		}
+	}
+
+	return runtimeConfig, nil
+}
+
 var initFactoryCommand = cli.Command{
 	Name:  "init",
 	Usage: "initialize a VM factory based on kata-runtime configuration",
+	Flags: []cli.Flag{factoryProfileFlag},
 	Action: func(c *cli.Context) error {
 		ctx, err := cliContextToContext(c)
 		if err != nil {
 			return err
 		}
 
-		runtimeConfig, ok := c.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
-		if !ok {
-			return errors.New("invalid runtime config")
+		runtimeConfig, err := factoryRuntimeConfig(c)
+		if err != nil {
+			return err
 		}
 
 		if runtimeConfig.FactoryConfig.Template {
-			factoryConfig := vf.Config{
-				Template: true,
-				VMConfig: vc.VMConfig{
-					HypervisorType:   runtimeConfig.HypervisorType,
-					HypervisorConfig: runtimeConfig.HypervisorConfig,
-					AgentType:        runtimeConfig.AgentType,
-					AgentConfig:      runtimeConfig.AgentConfig,
-				},
-			}
+			factoryConfig := katautils.GetFactoryConfig(runtimeConfig)
 			kataLog.WithField("factory", factoryConfig).Info("create vm factory")
 			_, err := vf.NewFactory(ctx, factoryConfig, false)
 			if err != nil {
@@ -73,27 +88,20 @@ var initFactoryCommand = cli.Command{
 var destroyFactoryCommand = cli.Command{
 	Name:  "destroy",
 	Usage: "destroy the VM factory",
+	Flags: []cli.Flag{factoryProfileFlag},
 	Action: func(c *cli.Context) error {
 		ctx, err := cliContextToContext(c)
 		if err != nil {
 			return err
 		}
 
-		runtimeConfig, ok := c.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
-		if !ok {
-			return errors.New("invalid runtime config")
+		runtimeConfig, err := factoryRuntimeConfig(c)
+		if err != nil {
+			return err
 		}
 
 		if runtimeConfig.FactoryConfig.Template {
-			factoryConfig := vf.Config{
-				Template: true,
-				VMConfig: vc.VMConfig{
-					HypervisorType:   runtimeConfig.HypervisorType,
-					HypervisorConfig: runtimeConfig.HypervisorConfig,
-					AgentType:        runtimeConfig.AgentType,
-					AgentConfig:      runtimeConfig.AgentConfig,
-				},
-			}
+			factoryConfig := katautils.GetFactoryConfig(runtimeConfig)
 			kataLog.WithField("factory", factoryConfig).Info("load vm factory")
 			f, err := vf.NewFactory(ctx, factoryConfig, true)
 			if err != nil {
@@ -111,27 +119,20 @@ var destroyFactoryCommand = cli.Command{
 var statusFactoryCommand = cli.Command{
 	Name:  "status",
 	Usage: "query the status of VM factory",
+	Flags: []cli.Flag{factoryProfileFlag},
 	Action: func(c *cli.Context) error {
 		ctx, err := cliContextToContext(c)
 		if err != nil {
 			return err
 		}
 
-		runtimeConfig, ok := c.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
-		if !ok {
-			return errors.New("invalid runtime config")
+		runtimeConfig, err := factoryRuntimeConfig(c)
+		if err != nil {
+			return err
 		}
 
 		if runtimeConfig.FactoryConfig.Template {
-			factoryConfig := vf.Config{
-				Template: true,
-				VMConfig: vc.VMConfig{
-					HypervisorType:   runtimeConfig.HypervisorType,
-					HypervisorConfig: runtimeConfig.HypervisorConfig,
-					AgentType:        runtimeConfig.AgentType,
-					AgentConfig:      runtimeConfig.AgentConfig,
-				},
-			}
+			factoryConfig := katautils.GetFactoryConfig(runtimeConfig)
 			kataLog.WithField("factory", factoryConfig).Info("load vm factory")
 			_, err := vf.NewFactory(ctx, factoryConfig, true)
 			if err != nil {
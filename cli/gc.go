@@ -0,0 +1,66 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	"github.com/urfave/cli"
+)
+
+var gcCLICommand = cli.Command{
+	Name:  "gc",
+	Usage: "reclaim the resources left behind by crashed sandboxes",
+	Description: `The gc command looks for the host resources of the sandboxes whose runtime
+   or shim crashed: store and VM directories, hypervisor processes, network
+   namespaces, TAP links and tc filters, shared directory mounts, and
+   cgroups. Each resource found is listed along with the sandbox owning it,
+   then reclaimed.
+
+   Sandboxes are only considered once their hypervisor is gone, and are
+   locked while their resources are reclaimed. Stopped sandboxes are left to
+   the delete command.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "list the resources without reclaiming them",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		return gc(ctx, context.Bool("dry-run"), defaultOutputFile)
+	},
+}
+
+func gc(ctx context.Context, dryRun bool, out io.Writer) error {
+	span, ctx := katautils.Trace(ctx, "gc")
+	defer span.Finish()
+
+	span.SetTag("dry-run", dryRun)
+
+	orphans, err := vci.GarbageCollect(ctx, dryRun)
+
+	if len(orphans) > 0 {
+		w := tabwriter.NewWriter(out, 12, 1, 3, ' ', 0)
+
+		fmt.Fprintln(w, "SANDBOX\tTYPE\tRESOURCE")
+		for _, o := range orphans {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", o.SandboxID, o.Type, o.Resource)
+		}
+
+		w.Flush()
+	}
+
+	return err
+}
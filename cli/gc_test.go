@@ -0,0 +1,53 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGC(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+
+	var dryRun bool
+	var gcErr error
+
+	testingImpl.GarbageCollectFunc = func(ctx context.Context, d bool) ([]vc.OrphanResource, error) {
+		dryRun = d
+
+		return []vc.OrphanResource{
+			{SandboxID: testSandboxID, Type: vc.OrphanNetNS, Resource: "/var/run/netns/cni-1234"},
+		}, gcErr
+	}
+	defer func() {
+		testingImpl.GarbageCollectFunc = nil
+	}()
+
+	err := gc(context.Background(), true, &out)
+	assert.NoError(err)
+	assert.True(dryRun)
+	assert.Contains(out.String(), "SANDBOX")
+	assert.Contains(out.String(), testSandboxID)
+	assert.Contains(out.String(), "/var/run/netns/cni-1234")
+
+	// the resources found are listed even when some could not be
+	// reclaimed
+	out.Reset()
+	gcErr = errors.New("reclaim failure")
+
+	err = gc(context.Background(), false, &out)
+	assert.Error(err)
+	assert.False(dryRun)
+	assert.Contains(out.String(), testSandboxID)
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/kata-containers/runtime/pkg/katautils"
 	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -50,6 +51,8 @@ const (
 	successMessageCreate  = "System can currently create " + project
 	failMessage           = "System is not capable of running " + project
 	kernelPropertyCorrect = "Kernel property value correct"
+	assetsVerified        = "All assets signatures verified"
+	assetsNotVerified     = "Asset signature verification disabled"
 
 	// these refer to fields in the procCPUINFO file
 	genericCPUFlagsTag    = "flags"      // nolint: varcheck, unused
@@ -321,10 +324,59 @@ var kataCheckCLICommand = cli.Command{
 			kataLog.Info(successMessageCreate)
 		}
 
-		return nil
+		runtimeConfig, ok := context.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
+		if !ok {
+			_, runtimeConfig, err = katautils.LoadConfiguration(context.GlobalString(configFilePathOption), true, false)
+			if err != nil {
+				kataLog.WithError(err).Warn("Cannot load configuration, skipping asset signature verification")
+				return nil
+			}
+		}
+
+		return checkAssetSignatures(runtimeConfig.HypervisorConfig)
 	},
 }
 
+// checkAssetSignatures reports the signature verification of the
+// configured VM assets, and fails if any of them cannot be verified.
+func checkAssetSignatures(config vc.HypervisorConfig) error {
+	results, err := config.VerifyAssets()
+	if err != nil {
+		return err
+	}
+
+	if !config.AssetVerificationEnabled() {
+		kataLog.Info(assetsNotVerified)
+		return nil
+	}
+
+	failed := 0
+
+	for _, r := range results {
+		fields := logrus.Fields{
+			"type":      string(r.Type),
+			"path":      r.Path,
+			"signature": r.Signature,
+		}
+
+		if r.Err != nil {
+			kataLog.WithFields(fields).WithError(r.Err).Error("Asset signature verification failed")
+			failed++
+			continue
+		}
+
+		kataLog.WithFields(fields).Info("Asset signature verified")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("ERROR: %d of %d assets could not be verified", failed, len(results))
+	}
+
+	kataLog.Info(assetsVerified)
+
+	return nil
+}
+
 func genericArchKernelParamHandler(onVMM bool, fields logrus.Fields, msg string) bool {
 	param, ok := fields["parameter"].(string)
 	if !ok {
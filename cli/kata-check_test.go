@@ -7,6 +7,13 @@ package main
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"html/template"
 	"io/ioutil"
@@ -16,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/kata-containers/runtime/pkg/katautils"
+	vc "github.com/kata-containers/runtime/virtcontainers"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
@@ -750,3 +758,66 @@ func TestCheckKernelParamHandler(t *testing.T) {
 	// single error (due to "param1"'s value being different)
 	checkKernelParamHandler(assert, testDataToCreate, testDataToExpect, nil, false, uint32(1))
 }
+
+func TestCheckAssetSignatures(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	savedLogOutput := kataLog.Logger.Out
+	defer func() {
+		kataLog.Logger.Out = savedLogOutput
+	}()
+
+	buf := &bytes.Buffer{}
+	kataLog.Logger.Out = buf
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(err)
+
+	keyPath := filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), testFileMode)
+	assert.NoError(err)
+
+	kernel := []byte("kernel")
+	kernelPath := filepath.Join(dir, "vmlinuz")
+	err = ioutil.WriteFile(kernelPath, kernel, testFileMode)
+	assert.NoError(err)
+
+	config := vc.HypervisorConfig{
+		KernelPath: kernelPath,
+	}
+
+	// verification disabled
+	err = checkAssetSignatures(config)
+	assert.NoError(err)
+	assert.Contains(buf.String(), assetsNotVerified)
+
+	// missing signature
+	config.AssetTrustedKeys = []string{keyPath}
+	err = checkAssetSignatures(config)
+	assert.Error(err)
+	assert.Contains(buf.String(), kernelPath)
+
+	digest := sha512.Sum512(kernel)
+	sig, err := key.Sign(rand.Reader, digest[:], crypto.SHA512)
+	assert.NoError(err)
+
+	err = ioutil.WriteFile(kernelPath+".sig", sig, testFileMode)
+	assert.NoError(err)
+
+	buf.Reset()
+	err = checkAssetSignatures(config)
+	assert.NoError(err)
+	assert.Contains(buf.String(), assetsVerified)
+
+	// invalid trusted key
+	config.AssetTrustedKeys = []string{kernelPath}
+	err = checkAssetSignatures(config)
+	assert.Error(err)
+}
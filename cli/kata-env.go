@@ -26,7 +26,7 @@ import (
 //
 // XXX: Increment for every change to the output format
 // (meaning any change to the EnvInfo type).
-const formatVersion = "1.0.20"
+const formatVersion = "1.0.22"
 
 // MetaInfo stores information on the format of the output itself
 type MetaInfo struct {
@@ -90,6 +90,24 @@ type HypervisorInfo struct {
 	MemorySlots       uint32
 	Debug             bool
 	UseVSock          bool
+	Confinement       HypervisorConfinementInfo
+}
+
+// HypervisorConfinementInfo stores the confinement of the hypervisor
+type HypervisorConfinementInfo struct {
+	SeccompSandbox        string
+	RunAsUnprivilegedUser bool
+	Chroot                bool
+}
+
+// HypervisorProfileInfo stores the details of a named hypervisor profile
+type HypervisorProfileInfo struct {
+	Type       string
+	Default    bool
+	Hypervisor HypervisorInfo
+	Image      ImageInfo
+	Kernel     KernelInfo
+	Initrd     InitrdInfo
 }
 
 // ProxyInfo stores proxy details
@@ -137,22 +155,30 @@ type NetmonInfo struct {
 	Enable  bool
 }
 
+// ConfigSourcesInfo stores the configuration files, in the order they are
+// merged, and the file and line each setting comes from
+type ConfigSourcesInfo struct {
+	Files []string
+	Keys  map[string]string
+}
+
 // EnvInfo collects all information that will be displayed by the
 // env command.
 //
 // XXX: Any changes must be coupled with a change to formatVersion.
 type EnvInfo struct {
-	Meta       MetaInfo
-	Runtime    RuntimeInfo
-	Hypervisor HypervisorInfo
-	Image      ImageInfo
-	Kernel     KernelInfo
-	Initrd     InitrdInfo
-	Proxy      ProxyInfo
-	Shim       ShimInfo
-	Agent      AgentInfo
-	Host       HostInfo
-	Netmon     NetmonInfo
+	Meta               MetaInfo
+	Runtime            RuntimeInfo
+	Hypervisor         HypervisorInfo
+	Image              ImageInfo
+	Kernel             KernelInfo
+	Initrd             InitrdInfo
+	HypervisorProfiles map[string]HypervisorProfileInfo
+	Proxy              ProxyInfo
+	Shim               ShimInfo
+	Agent              AgentInfo
+	Host               HostInfo
+	Netmon             NetmonInfo
 }
 
 func getMetaInfo() MetaInfo {
@@ -331,9 +357,54 @@ func getHypervisorInfo(config oci.RuntimeConfig) HypervisorInfo {
 		UseVSock:          config.HypervisorConfig.UseVSock,
 		MemorySlots:       config.HypervisorConfig.MemSlots,
 		EntropySource:     config.HypervisorConfig.EntropySource,
+		Confinement: HypervisorConfinementInfo{
+			SeccompSandbox:        config.HypervisorConfig.SeccompSandbox,
+			RunAsUnprivilegedUser: config.HypervisorConfig.RunAsUnprivilegedUser,
+			Chroot:                config.HypervisorConfig.EnableChroot,
+		},
+	}
+}
+
+func getImageInfo(config oci.RuntimeConfig) ImageInfo {
+	return ImageInfo{
+		Path: config.HypervisorConfig.ImagePath,
 	}
 }
 
+func getKernelInfo(config oci.RuntimeConfig) KernelInfo {
+	return KernelInfo{
+		Path:       config.HypervisorConfig.KernelPath,
+		Parameters: strings.Join(vc.SerializeParams(config.HypervisorConfig.KernelParams, "="), " "),
+	}
+}
+
+func getInitrdInfo(config oci.RuntimeConfig) InitrdInfo {
+	return InitrdInfo{
+		Path: config.HypervisorConfig.InitrdPath,
+	}
+}
+
+func getHypervisorProfilesInfo(config oci.RuntimeConfig) map[string]HypervisorProfileInfo {
+	profiles := make(map[string]HypervisorProfileInfo)
+
+	for name, profile := range config.HypervisorProfiles {
+		profileConfig := config
+		profileConfig.HypervisorType = profile.HypervisorType
+		profileConfig.HypervisorConfig = profile.HypervisorConfig
+
+		profiles[name] = HypervisorProfileInfo{
+			Type:       string(profile.HypervisorType),
+			Default:    name == config.DefaultHypervisorProfile,
+			Hypervisor: getHypervisorInfo(profileConfig),
+			Image:      getImageInfo(profileConfig),
+			Kernel:     getKernelInfo(profileConfig),
+			Initrd:     getInitrdInfo(profileConfig),
+		}
+	}
+
+	return profiles
+}
+
 func getEnvInfo(configFile string, config oci.RuntimeConfig) (env EnvInfo, err error) {
 	err = setCPUtype()
 	if err != nil {
@@ -362,31 +433,27 @@ func getEnvInfo(configFile string, config oci.RuntimeConfig) (env EnvInfo, err e
 
 	hypervisor := getHypervisorInfo(config)
 
-	image := ImageInfo{
-		Path: config.HypervisorConfig.ImagePath,
-	}
+	image := getImageInfo(config)
 
-	kernel := KernelInfo{
-		Path:       config.HypervisorConfig.KernelPath,
-		Parameters: strings.Join(vc.SerializeParams(config.HypervisorConfig.KernelParams, "="), " "),
-	}
+	kernel := getKernelInfo(config)
 
-	initrd := InitrdInfo{
-		Path: config.HypervisorConfig.InitrdPath,
-	}
+	initrd := getInitrdInfo(config)
+
+	profiles := getHypervisorProfilesInfo(config)
 
 	env = EnvInfo{
-		Meta:       meta,
-		Runtime:    runtime,
-		Hypervisor: hypervisor,
-		Image:      image,
-		Kernel:     kernel,
-		Initrd:     initrd,
-		Proxy:      proxy,
-		Shim:       shim,
-		Agent:      agent,
-		Host:       host,
-		Netmon:     netmon,
+		Meta:               meta,
+		Runtime:            runtime,
+		Hypervisor:         hypervisor,
+		Image:              image,
+		Kernel:             kernel,
+		Initrd:             initrd,
+		HypervisorProfiles: profiles,
+		Proxy:              proxy,
+		Shim:               shim,
+		Agent:              agent,
+		Host:               host,
+		Netmon:             netmon,
 	}
 
 	return env, nil
@@ -419,7 +486,42 @@ func handleSettings(file *os.File, c *cli.Context) error {
 	return writeTOMLSettings(env, file)
 }
 
-func writeTOMLSettings(env EnvInfo, file *os.File) error {
+func getConfigSourcesInfo(configPath string) (ConfigSourcesInfo, error) {
+	sources, err := katautils.GetConfigSources(configPath)
+	if err != nil {
+		return ConfigSourcesInfo{}, err
+	}
+
+	info := ConfigSourcesInfo{
+		Files: sources.Files,
+		Keys:  make(map[string]string),
+	}
+
+	for key, source := range sources.Keys {
+		info.Keys[key] = source.String()
+	}
+
+	return info, nil
+}
+
+func handleConfigSources(file *os.File, c *cli.Context) error {
+	if file == nil {
+		return errors.New("Invalid output file specified")
+	}
+
+	info, err := getConfigSourcesInfo(c.GlobalString(configFilePathOption))
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		return writeJSONSettings(info, file)
+	}
+
+	return writeTOMLSettings(info, file)
+}
+
+func writeTOMLSettings(env interface{}, file *os.File) error {
 	encoder := toml.NewEncoder(file)
 
 	err := encoder.Encode(env)
@@ -430,7 +532,7 @@ func writeTOMLSettings(env EnvInfo, file *os.File) error {
 	return nil
 }
 
-func writeJSONSettings(env EnvInfo, file *os.File) error {
+func writeJSONSettings(env interface{}, file *os.File) error {
 	encoder := json.NewEncoder(file)
 
 	// Make it more human readable
@@ -452,6 +554,10 @@ var kataEnvCLICommand = cli.Command{
 			Name:  "json",
 			Usage: "Format output as JSON",
 		},
+		cli.BoolFlag{
+			Name:  "config-sources",
+			Usage: "display the configuration files and where each setting comes from",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		ctx, err := cliContextToContext(context)
@@ -462,6 +568,10 @@ var kataEnvCLICommand = cli.Command{
 		span, _ := katautils.Trace(ctx, "kata-env")
 		defer span.Finish()
 
+		if context.Bool("config-sources") {
+			return handleConfigSources(defaultOutputFile, context)
+		}
+
 		return handleSettings(defaultOutputFile, context)
 	},
 }
@@ -338,6 +338,11 @@ func getExpectedHypervisor(config oci.RuntimeConfig) HypervisorInfo {
 		MemorySlots:       config.HypervisorConfig.MemSlots,
 		Debug:             config.HypervisorConfig.Debug,
 		EntropySource:     config.HypervisorConfig.EntropySource,
+		Confinement: HypervisorConfinementInfo{
+			SeccompSandbox:        config.HypervisorConfig.SeccompSandbox,
+			RunAsUnprivilegedUser: config.HypervisorConfig.RunAsUnprivilegedUser,
+			Chroot:                config.HypervisorConfig.EnableChroot,
+		},
 	}
 }
 
@@ -354,6 +359,18 @@ func getExpectedKernel(config oci.RuntimeConfig) KernelInfo {
 	}
 }
 
+func getExpectedHypervisorProfiles(config oci.RuntimeConfig) map[string]HypervisorProfileInfo {
+	return map[string]HypervisorProfileInfo{
+		"qemu": {
+			Type:       string(vc.QemuHypervisor),
+			Default:    true,
+			Hypervisor: getExpectedHypervisor(config),
+			Image:      getExpectedImage(config),
+			Kernel:     getExpectedKernel(config),
+		},
+	}
+}
+
 func getExpectedRuntimeDetails(config oci.RuntimeConfig, configFile string) RuntimeInfo {
 	runtimePath, _ := os.Executable()
 
@@ -406,18 +423,20 @@ func getExpectedSettings(config oci.RuntimeConfig, tmpdir, configFile string) (E
 	hypervisor := getExpectedHypervisor(config)
 	kernel := getExpectedKernel(config)
 	image := getExpectedImage(config)
+	profiles := getExpectedHypervisorProfiles(config)
 
 	env := EnvInfo{
-		Meta:       meta,
-		Runtime:    runtime,
-		Hypervisor: hypervisor,
-		Image:      image,
-		Kernel:     kernel,
-		Proxy:      proxy,
-		Shim:       shim,
-		Agent:      agent,
-		Host:       host,
-		Netmon:     netmon,
+		Meta:               meta,
+		Runtime:            runtime,
+		Hypervisor:         hypervisor,
+		Image:              image,
+		Kernel:             kernel,
+		HypervisorProfiles: profiles,
+		Proxy:              proxy,
+		Shim:               shim,
+		Agent:              agent,
+		Host:               host,
+		Netmon:             netmon,
 	}
 
 	return env, nil
@@ -550,6 +569,9 @@ func TestEnvGetEnvInfoNoHypervisorVersion(t *testing.T) {
 	assert.NoError(err)
 
 	expectedEnv.Hypervisor.Version = unknown
+	profile := expectedEnv.HypervisorProfiles["qemu"]
+	profile.Hypervisor.Version = unknown
+	expectedEnv.HypervisorProfiles["qemu"] = profile
 
 	env, err := getEnvInfo(configFile, config)
 	assert.NoError(err)
@@ -1053,6 +1075,56 @@ func TestEnvHandleSettings(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestEnvHandleConfigSources(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	configFile, _, err := makeRuntimeConfig(tmpdir)
+	assert.NoError(err)
+
+	dropInDir := filepath.Join(tmpdir, "configuration.d")
+	err = os.Mkdir(dropInDir, testDirMode)
+	assert.NoError(err)
+
+	dropIn := filepath.Join(dropInDir, "10-debug.toml")
+	err = createConfig(dropIn, "[hypervisor.qemu]\nenable_debug = true\n")
+	assert.NoError(err)
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String(configFilePathOption, configFile, "")
+	ctx := createCLIContext(set)
+	ctx.App.Name = "foo"
+
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(err)
+	defer os.Remove(tmpfile.Name())
+
+	err = handleConfigSources(tmpfile, ctx)
+	assert.NoError(err)
+
+	var info ConfigSourcesInfo
+
+	_, err = toml.DecodeFile(tmpfile.Name(), &info)
+	assert.NoError(err)
+
+	assert.Equal([]string{configFile, dropIn}, info.Files)
+	assert.Equal(dropIn+":2", info.Keys["hypervisor.qemu.enable_debug"])
+	assert.True(strings.HasPrefix(info.Keys["hypervisor.qemu.path"], configFile+":"))
+
+	err = handleConfigSources(nil, ctx)
+	assert.Error(err)
+
+	// the configuration file does not exist
+	err = set.Set(configFilePathOption, filepath.Join(tmpdir, "foo.toml"))
+	assert.NoError(err)
+
+	err = handleConfigSources(tmpfile, ctx)
+	assert.Error(err)
+}
+
 func TestEnvHandleSettingsInvalidShimConfig(t *testing.T) {
 	assert := assert.New(t)
 
@@ -1195,7 +1267,8 @@ func TestEnvCLIFunctionFail(t *testing.T) {
 	_, err = getExpectedSettings(config, tmpdir, configFile)
 	assert.NoError(t, err)
 
-	ctx := createCLIContext(nil)
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	ctx := createCLIContext(set)
 	ctx.App.Name = "foo"
 
 	ctx.App.Metadata["configFile"] = configFile
@@ -1235,3 +1308,56 @@ func TestGetHypervisorInfo(t *testing.T) {
 	info = getHypervisorInfo(config)
 	assert.Equal(info.Version, unknown)
 }
+
+func TestGetHypervisorProfilesInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	_, config, err := makeRuntimeConfig(tmpdir)
+	assert.NoError(err)
+
+	profile := config.HypervisorProfiles["qemu"]
+	profile.HypervisorConfig.HypervisorMachineType = "q35"
+	profile.HypervisorConfig.InitrdPath = "/initrd"
+	config.HypervisorProfiles["qemu.gpu"] = profile
+
+	profiles := getHypervisorProfilesInfo(config)
+	assert.Len(profiles, 2)
+
+	assert.True(profiles["qemu"].Default)
+	assert.Equal(config.HypervisorConfig.HypervisorMachineType, profiles["qemu"].Hypervisor.MachineType)
+
+	assert.False(profiles["qemu.gpu"].Default)
+	assert.Equal(string(vc.QemuHypervisor), profiles["qemu.gpu"].Type)
+	assert.Equal("q35", profiles["qemu.gpu"].Hypervisor.MachineType)
+	assert.Equal("/initrd", profiles["qemu.gpu"].Initrd.Path)
+	assert.Equal(testHypervisorVersion, profiles["qemu.gpu"].Hypervisor.Version)
+}
+
+func TestGetHypervisorInfoConfinement(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	_, config, err := makeRuntimeConfig(tmpdir)
+	assert.NoError(err)
+
+	info := getHypervisorInfo(config)
+	assert.Equal(HypervisorConfinementInfo{}, info.Confinement)
+
+	config.HypervisorConfig.SeccompSandbox = "on,obsolete=deny"
+	config.HypervisorConfig.RunAsUnprivilegedUser = true
+	config.HypervisorConfig.EnableChroot = true
+
+	info = getHypervisorInfo(config)
+	assert.Equal(HypervisorConfinementInfo{
+		SeccompSandbox:        "on,obsolete=deny",
+		RunAsUnprivilegedUser: true,
+		Chroot:                true,
+	}, info.Confinement)
+}
@@ -22,6 +22,7 @@ import (
 	vc "github.com/kata-containers/runtime/virtcontainers"
 	vf "github.com/kata-containers/runtime/virtcontainers/factory"
 	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/kata-containers/runtime/virtcontainers/store"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
@@ -100,6 +101,10 @@ var runtimeFlags = []cli.Flag{
 		Value: defaultRootDirectory,
 		Usage: "root directory for storage of container state (this should be located in tmpfs)",
 	},
+	cli.StringFlag{
+		Name:  "vc-storage-root",
+		Usage: "root directory for storage of sandbox state, to run several runtime instances on a host (this should be short, as it holds the VM sockets)",
+	},
 	cli.BoolFlag{
 		Name:  showConfigPathsOption,
 		Usage: "show config file paths that will be checked for (in order)",
@@ -133,7 +138,16 @@ var runtimeCommands = []cli.Command{
 	kataCheckCLICommand,
 	kataEnvCLICommand,
 	kataNetworkCLICommand,
+	kataDeviceCLICommand,
 	factoryCLICommand,
+	stateMigrateCLICommand,
+	profileCLICommand,
+	cpCLICommand,
+	portForwardCLICommand,
+	gcCLICommand,
+	serveCLICommand,
+	migrateCLICommand,
+	incomingCLICommand,
 }
 
 // runtimeBeforeSubcommands is the function to run before command-line
@@ -325,6 +339,12 @@ func beforeSubcommands(c *cli.Context) error {
 		}
 	}
 
+	// Relocating the virtcontainers storage lets several runtime
+	// instances share a host.
+	if root := c.GlobalString("vc-storage-root"); root != "" {
+		store.SetStorageRoot(root)
+	}
+
 	args := strings.Join(c.Args(), " ")
 
 	fields := logrus.Fields{
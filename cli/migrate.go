@@ -0,0 +1,204 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+var migrateCLICommand = cli.Command{
+	Name:  "migrate",
+	Usage: "live migrate a running sandbox to another runtime instance",
+	ArgsUsage: `<sandbox-id>
+
+Where "<sandbox-id>" is the ID of the sandbox to migrate.`,
+	Description: `The migrate command streams a running sandbox to a runtime instance waiting
+   for it through the incoming command. The sandbox state is transferred
+   along with its VM, which keeps running on the target host. The sandbox
+   is removed from this runtime instance once the target has resumed it.
+
+   The sandbox is only streamed over a UNIX socket, to a runtime instance
+   running as the same user. The incoming runtime instance only resumes a
+   sandbox using the hypervisor, guest assets, agent, proxy and network
+   monitor it is configured with.
+
+   Sandboxes using hotplugged vCPUs, memory or devices, or created from a
+   VM template, cannot be migrated. The shims of the container processes
+   are not migrated either.
+
+   Both ends can run on the same host by giving the incoming runtime
+   instance a different --vc-storage-root.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "path of the UNIX socket the incoming runtime instance waits on",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		args := context.Args()
+		if !args.Present() {
+			return errors.New("Missing sandbox ID")
+		}
+
+		return migrate(ctx, args.First(), context.String("to"))
+	},
+}
+
+var incomingCLICommand = cli.Command{
+	Name:  "incoming",
+	Usage: "wait for a sandbox migrated from another runtime instance",
+	Description: `The incoming command waits for a single sandbox sent by the migrate
+   command of another runtime instance, and resumes it. The ID of the
+   sandbox is printed once it is running.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen",
+			Usage: "path of the UNIX socket to wait for the sandbox on",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		runtimeConfig, ok := context.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
+		if !ok {
+			return errors.New("invalid runtime config")
+		}
+
+		return incoming(ctx, context.String("listen"), runtimeConfig, defaultOutputFile)
+	},
+}
+
+// migrationConnFile returns a file duplicating the descriptor of a
+// connection, as the hypervisor is handed the migration stream directly.
+// The peer has to run as the same user, as the sandbox it sends or receives
+// runs with the privileges of this runtime instance.
+func migrationConnFile(conn *net.UnixConn) (*os.File, error) {
+	f, err := conn.File()
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := unix.GetsockoptUcred(int(f.Fd()), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if int(cred.Uid) != os.Geteuid() {
+		f.Close()
+		return nil, fmt.Errorf("Migration peer runs as user %d, expected %d", cred.Uid, os.Geteuid())
+	}
+
+	return f, nil
+}
+
+// migrationSandboxConfig returns the configuration a migrated sandbox has
+// to match to be received.
+func migrationSandboxConfig(runtimeConfig oci.RuntimeConfig) vc.SandboxConfig {
+	return vc.SandboxConfig{
+		HypervisorType:   runtimeConfig.HypervisorType,
+		HypervisorConfig: runtimeConfig.HypervisorConfig,
+
+		AgentType:   runtimeConfig.AgentType,
+		AgentConfig: runtimeConfig.AgentConfig,
+
+		ProxyType:   runtimeConfig.ProxyType,
+		ProxyConfig: runtimeConfig.ProxyConfig,
+
+		NetworkConfig: vc.NetworkConfig{
+			NetmonConfig: runtimeConfig.NetmonConfig,
+		},
+	}
+}
+
+func migrate(ctx context.Context, sandboxID, socket string) error {
+	span, ctx := katautils.Trace(ctx, "migrate")
+	defer span.Finish()
+
+	kataLog = kataLog.WithField("sandbox", sandboxID)
+	setExternalLoggers(ctx, kataLog)
+	span.SetTag("sandbox", sandboxID)
+
+	if socket == "" {
+		return errors.New("Missing socket path")
+	}
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	f, err := migrationConnFile(conn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	kataLog.WithField("socket", socket).Info("Migrating sandbox")
+
+	return vci.MigrateSandbox(ctx, sandboxID, f)
+}
+
+func incoming(ctx context.Context, socket string, runtimeConfig oci.RuntimeConfig, out io.Writer) error {
+	span, ctx := katautils.Trace(ctx, "incoming")
+	defer span.Finish()
+
+	if socket == "" {
+		return errors.New("Missing socket path")
+	}
+
+	l, err := serveListen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	kataLog.WithField("socket", socket).Info("Waiting for an incoming sandbox")
+
+	// A single sandbox is received.
+	conn, err := l.(*net.UnixListener).AcceptUnix()
+	l.Close()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	f, err := migrationConnFile(conn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sandbox, err := vci.IncomingSandbox(ctx, f, migrationSandboxConfig(runtimeConfig))
+	if err != nil {
+		return err
+	}
+
+	kataLog.WithField("sandbox", sandbox.ID()).Info("Incoming sandbox running")
+
+	_, err = fmt.Fprintln(out, sandbox.ID())
+	return err
+}
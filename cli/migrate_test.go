@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationSandboxConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	runtimeConfig, err := newTestRuntimeConfig(tmpdir, testConsole, false)
+	assert.NoError(err)
+	runtimeConfig.ProxyConfig.Path = "/usr/libexec/kata-containers/kata-proxy"
+	runtimeConfig.NetmonConfig.Path = "/usr/libexec/kata-containers/kata-netmon"
+
+	config := migrationSandboxConfig(runtimeConfig)
+	assert.Equal(runtimeConfig.HypervisorType, config.HypervisorType)
+	assert.Equal(runtimeConfig.HypervisorConfig.HypervisorPath, config.HypervisorConfig.HypervisorPath)
+	assert.Equal(runtimeConfig.ProxyConfig.Path, config.ProxyConfig.Path)
+	assert.Equal(runtimeConfig.NetmonConfig.Path, config.NetworkConfig.NetmonConfig.Path)
+}
+
+func TestMigrate(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	socket := filepath.Join(tmpdir, "migrate.sock")
+
+	err = migrate(context.Background(), testSandboxID, "")
+	assert.Error(err)
+
+	err = incoming(context.Background(), "", oci.RuntimeConfig{}, ioutil.Discard)
+	assert.Error(err)
+
+	// nothing is listening
+	err = migrate(context.Background(), testSandboxID, socket)
+	assert.Error(err)
+
+	testingImpl.MigrateSandboxFunc = func(ctx context.Context, sandboxID string, conn *os.File) error {
+		_, err := conn.Write([]byte(sandboxID))
+		return err
+	}
+	testingImpl.IncomingSandboxFunc = func(ctx context.Context, conn *os.File, config vc.SandboxConfig) (vc.VCSandbox, error) {
+		buf := make([]byte, len(testSandboxID))
+		if _, err := conn.Read(buf); err != nil {
+			return nil, err
+		}
+		return &vcmock.Sandbox{MockID: string(buf)}, nil
+	}
+	defer func() {
+		testingImpl.MigrateSandboxFunc = nil
+		testingImpl.IncomingSandboxFunc = nil
+	}()
+
+	var out bytes.Buffer
+	incomingErr := make(chan error, 1)
+	go func() {
+		incomingErr <- incoming(context.Background(), socket, oci.RuntimeConfig{}, &out)
+	}()
+
+	// the sandbox is sent once the incoming runtime instance listens
+	for i := 0; i < 100; i++ {
+		if err = migrate(context.Background(), testSandboxID, socket); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(err)
+	assert.NoError(<-incomingErr)
+	assert.Equal(testSandboxID+"\n", out.String())
+}
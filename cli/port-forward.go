@@ -0,0 +1,123 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	"github.com/urfave/cli"
+)
+
+var portForwardCLICommand = cli.Command{
+	Name:      "port-forward",
+	Usage:     "forward a byte stream to a TCP port of a sandbox",
+	ArgsUsage: `<sandbox-id> <port>`,
+	Description: `The port-forward command connects to a TCP port of the sandbox network
+   namespace, inside the guest, and forwards its standard input and output to
+   the connection, until either side closes it.
+
+   Once the standard input is closed, the sending side of the connection is
+   closed too, the connection still being read until the remote end closes it.
+
+   The connection goes through the agent, so that it does not depend on the
+   host network configuration: it is forwarded by a socat process run in the
+   first running container of the sandbox able to run it, so that the image of
+   at least one of the running containers must provide socat.
+   Container runtime interface implementations can run this command to serve
+   their port forwarding requests.`,
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		if context.NArg() != 2 {
+			return fmt.Errorf("Expecting a sandbox ID and a port")
+		}
+
+		port, err := strconv.ParseUint(context.Args().Get(1), 10, 16)
+		if err != nil || port == 0 {
+			return fmt.Errorf("Invalid port %q", context.Args().Get(1))
+		}
+
+		return portForward(ctx, context.Args().First(), uint32(port), os.Stdin, defaultOutputFile)
+	},
+}
+
+func portForward(ctx context.Context, sandboxID string, port uint32, in io.Reader, out io.Writer) error {
+	span, ctx := katautils.Trace(ctx, "port-forward")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return fmt.Errorf("Missing sandbox ID")
+	}
+
+	kataLog = kataLog.WithField("sandbox", sandboxID)
+	setExternalLoggers(ctx, kataLog)
+	span.SetTag("sandbox", sandboxID)
+	span.SetTag("port", port)
+
+	s, err := vci.FetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer s.Release()
+
+	stream, err := s.PortForward(port)
+	if err != nil {
+		return err
+	}
+
+	return forwardStream(stream, in, out)
+}
+
+// forwardStream copies in to the stream and the stream to out. Once in is
+// exhausted, the stream is half closed, when it supports it, and read until
+// the remote end closes it.
+func forwardStream(stream io.ReadWriteCloser, in io.Reader, out io.Writer) error {
+	inCh := make(chan error, 1)
+	outCh := make(chan error, 1)
+
+	go func() {
+		_, err := io.Copy(stream, in)
+		inCh <- err
+	}()
+
+	go func() {
+		_, err := io.Copy(out, stream)
+		outCh <- err
+	}()
+
+	var err error
+
+	select {
+	case err = <-inCh:
+		if err != nil {
+			break
+		}
+
+		halfCloser, ok := stream.(interface{ CloseWrite() error })
+		if !ok {
+			break
+		}
+
+		if err = halfCloser.CloseWrite(); err == nil {
+			err = <-outCh
+		}
+	case err = <-outCh:
+	}
+
+	if closeErr := stream.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+// testStream echoes back the data written to it, and reports the end of
+// the stream once half closed or closed.
+type testStream struct {
+	data        chan []byte
+	writeClosed bool
+	closed      bool
+}
+
+func newTestStream() *testStream {
+	return &testStream{
+		data: make(chan []byte, 16),
+	}
+}
+
+func (s *testStream) Read(p []byte) (int, error) {
+	data, ok := <-s.data
+	if !ok {
+		return 0, io.EOF
+	}
+
+	return copy(p, data), nil
+}
+
+func (s *testStream) Write(p []byte) (int, error) {
+	s.data <- append([]byte{}, p...)
+	return len(p), nil
+}
+
+func (s *testStream) CloseWrite() error {
+	if s.writeClosed {
+		return errors.New("stream write side closed")
+	}
+
+	s.writeClosed = true
+	close(s.data)
+
+	return nil
+}
+
+func (s *testStream) Close() error {
+	if s.closed {
+		return errors.New("stream closed")
+	}
+
+	s.closed = true
+	if !s.writeClosed {
+		s.writeClosed = true
+		close(s.data)
+	}
+
+	return nil
+}
+
+type testPortForwardSandbox struct {
+	*vcmock.Sandbox
+	stream io.ReadWriteCloser
+	port   uint32
+}
+
+func (s *testPortForwardSandbox) PortForward(port uint32) (io.ReadWriteCloser, error) {
+	s.port = port
+	return s.stream, nil
+}
+
+func TestPortForwardCliAction(t *testing.T) {
+	assert := assert.New(t)
+
+	actionFunc, ok := portForwardCLICommand.Action.(func(ctx *cli.Context) error)
+	assert.True(ok)
+
+	for _, args := range [][]string{
+		{},
+		{testSandboxID},
+		{testSandboxID, "foo"},
+		{testSandboxID, "0"},
+		{testSandboxID, "65536"},
+	} {
+		flagSet := flag.NewFlagSet("flag", flag.ContinueOnError)
+		flagSet.Parse(args)
+		ctx := createCLIContext(flagSet)
+		err := actionFunc(ctx)
+		assert.Error(err, "%v", args)
+	}
+}
+
+func TestPortForward(t *testing.T) {
+	assert := assert.New(t)
+
+	var out bytes.Buffer
+
+	err := portForward(context.Background(), "", 8080, strings.NewReader(""), &out)
+	assert.Error(err)
+
+	// the sandbox does not exist
+	err = portForward(context.Background(), testSandboxID, 8080, strings.NewReader(""), &out)
+	assert.Error(err)
+
+	sandbox := &testPortForwardSandbox{
+		Sandbox: &vcmock.Sandbox{MockID: testSandboxID},
+		stream:  newTestStream(),
+	}
+
+	testingImpl.FetchSandboxFunc = func(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+		return sandbox, nil
+	}
+	defer func() {
+		testingImpl.FetchSandboxFunc = nil
+	}()
+
+	err = portForward(context.Background(), testSandboxID, 8080, strings.NewReader("ping"), &out)
+	assert.NoError(err)
+	assert.Equal(uint32(8080), sandbox.port)
+	assert.Equal("ping", out.String())
+}
+
+// lateStream answers once its write side has been closed.
+type lateStream struct {
+	io.Reader
+	io.Writer
+	w *io.PipeWriter
+}
+
+func (s *lateStream) CloseWrite() error {
+	go func() {
+		s.w.Write([]byte("pong"))
+		s.w.Close()
+	}()
+
+	return nil
+}
+
+func (s *lateStream) Close() error {
+	return nil
+}
+
+func TestForwardStreamHalfClose(t *testing.T) {
+	assert := assert.New(t)
+
+	r, w := io.Pipe()
+	stream := &lateStream{r, &bytes.Buffer{}, w}
+
+	var out bytes.Buffer
+	err := forwardStream(stream, strings.NewReader("ping"), &out)
+	assert.NoError(err)
+	assert.Equal("pong", out.String())
+}
+
+func TestForwardStreamRemoteClose(t *testing.T) {
+	assert := assert.New(t)
+
+	// the standard input is never closed
+	r, w := io.Pipe()
+	defer w.Close()
+
+	stream := newTestStream()
+	stream.CloseWrite()
+
+	var out bytes.Buffer
+	err := forwardStream(stream, r, &out)
+	assert.NoError(err)
+	assert.True(stream.closed)
+}
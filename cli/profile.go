@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+const profileFormatOptions = `text or json`
+
+var profileCLICommand = cli.Command{
+	Name:      "profile",
+	Usage:     "display the startup time breakdown of a sandbox",
+	ArgsUsage: `<sandbox-id>`,
+	Description: `The profile command displays the duration of each phase of the sandbox
+   startup, from the sandbox creation to the creation of its first container,
+   along with the guest kernel boot time. The guest kernel boot time is read
+   from the kernel log printed on the guest console, which is only watched
+   by the builtin kata proxy with debug enabled.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "text",
+			Usage: `select one of: ` + profileFormatOptions,
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		return profile(ctx, context.Args().First(), context.String("format"), defaultOutputFile)
+	},
+}
+
+func profile(ctx context.Context, sandboxID, format string, out io.Writer) error {
+	span, ctx := katautils.Trace(ctx, "profile")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return fmt.Errorf("Missing sandbox ID")
+	}
+
+	kataLog = kataLog.WithField("sandbox", sandboxID)
+	setExternalLoggers(ctx, kataLog)
+	span.SetTag("sandbox", sandboxID)
+
+	timeline, err := vci.ProfileSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "text":
+		writeTimeline(timeline, out)
+		return nil
+	case "json":
+		return json.NewEncoder(out).Encode(timeline)
+	}
+
+	return fmt.Errorf("invalid format option %q, select one of: %s", format, profileFormatOptions)
+}
+
+func writeTimeline(timeline vc.SandboxTimeline, out io.Writer) {
+	w := tabwriter.NewWriter(out, 12, 1, 3, ' ', 0)
+
+	fmt.Fprintln(w, "PHASE\tSTART\tDURATION")
+
+	var origin time.Time
+	if len(timeline.Phases) > 0 {
+		origin = timeline.Phases[0].Start
+	}
+
+	for _, p := range timeline.Phases {
+		fmt.Fprintf(w, "%s\t+%v\t%v\n", p.Name, p.Start.Sub(origin), p.Duration)
+	}
+
+	if timeline.GuestKernelBoot != 0 {
+		fmt.Fprintf(w, "%s\t\t%v\n", "guest kernel boot", timeline.GuestKernelBoot)
+	}
+
+	fmt.Fprintf(w, "%s\t\t%v\n", "total", timeline.Total())
+
+	w.Flush()
+}
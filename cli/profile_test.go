@@ -0,0 +1,79 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"testing"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestProfileCliAction(t *testing.T) {
+	assert := assert.New(t)
+
+	actionFunc, ok := profileCLICommand.Action.(func(ctx *cli.Context) error)
+	assert.True(ok)
+
+	// without sandbox id
+	flagSet := flag.NewFlagSet("flag", flag.ContinueOnError)
+	flagSet.Parse([]string{})
+	ctx := createCLIContext(flagSet)
+	err := actionFunc(ctx)
+	assert.Error(err)
+}
+
+func TestProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	timeline := vc.SandboxTimeline{
+		Phases: []vc.TimelinePhase{
+			{Name: "createSandbox", Start: start, Duration: 10 * time.Millisecond},
+			{Name: "hypervisor.startSandbox", Start: start.Add(10 * time.Millisecond), Duration: 500 * time.Millisecond},
+		},
+		GuestKernelBoot: 300 * time.Millisecond,
+	}
+
+	var out bytes.Buffer
+
+	// the sandbox does not exist
+	err := profile(context.Background(), testSandboxID, "text", &out)
+	assert.Error(err)
+
+	testingImpl.ProfileSandboxFunc = func(ctx context.Context, sandboxID string) (vc.SandboxTimeline, error) {
+		return timeline, nil
+	}
+	defer func() {
+		testingImpl.ProfileSandboxFunc = nil
+	}()
+
+	err = profile(context.Background(), testSandboxID, "text", &out)
+	assert.NoError(err)
+	assert.Contains(out.String(), "hypervisor.startSandbox")
+	assert.Contains(out.String(), "+10ms")
+	assert.Contains(out.String(), "guest kernel boot")
+	assert.Contains(out.String(), "510ms")
+
+	out.Reset()
+	err = profile(context.Background(), testSandboxID, "json", &out)
+	assert.NoError(err)
+
+	var decoded vc.SandboxTimeline
+	assert.NoError(json.Unmarshal(out.Bytes(), &decoded))
+	assert.Len(decoded.Phases, 2)
+	assert.Equal(timeline.GuestKernelBoot, decoded.GuestKernelBoot)
+	assert.Equal(timeline.Total(), decoded.Total())
+
+	err = profile(context.Background(), testSandboxID, "foo", &out)
+	assert.Error(err)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	"github.com/kata-containers/runtime/pkg/vcapi"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+)
+
+var serveCLICommand = cli.Command{
+	Name:  "serve",
+	Usage: "serve the virtcontainers API over gRPC",
+	Description: `The serve command runs a long-lived gRPC service exposing the virtcontainers
+   API, described by pkg/vcapi/vcapi.proto, on a UNIX socket. It lets
+   orchestrators drive sandboxes and containers directly, without emulating
+   the OCI command line.
+
+   The sandboxes are kept in memory until they are deleted, and are locked
+   against the other runtime instances while they are being operated on.
+   The service stops on SIGINT or SIGTERM.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "socket",
+			Usage: "path to the UNIX socket to listen on",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		runtimeConfig, ok := context.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
+		if !ok {
+			return errors.New("invalid runtime config")
+		}
+
+		return serve(ctx, context.String("socket"), runtimeConfig)
+	},
+}
+
+// serveListen listens on a UNIX socket only the owner can connect to,
+// replacing a stale socket.
+func serveListen(socket string) (net.Listener, error) {
+	if fi, err := os.Lstat(socket); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", socket)
+		}
+
+		if err := os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func serve(ctx context.Context, socket string, runtimeConfig oci.RuntimeConfig) error {
+	span, ctx := katautils.Trace(ctx, "serve")
+	defer span.Finish()
+
+	if socket == "" {
+		return errors.New("Missing socket path")
+	}
+
+	katautils.HandleFactory(ctx, vci, &runtimeConfig)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	l, err := serveListen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	vcapi.SetLogger(kataLog)
+
+	server := grpc.NewServer()
+	vcapi.RegisterVirtContainersServer(server, vcapi.NewServer(vci, runtimeConfig))
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case sig := <-sigCh:
+			kataLog.WithField("signal", sig).Info("Stopping the virtcontainers API service")
+			server.Stop()
+		case <-done:
+		}
+	}()
+
+	kataLog.WithField("socket", socket).Info("Serving the virtcontainers API")
+
+	return server.Serve(l)
+}
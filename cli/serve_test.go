@@ -0,0 +1,88 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/kata-containers/runtime/pkg/vcapi"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestServeInvalidSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	err = serve(context.Background(), "", oci.RuntimeConfig{})
+	assert.Error(err)
+
+	// only stale sockets are replaced
+	file := filepath.Join(tmpdir, "file")
+	err = ioutil.WriteFile(file, nil, 0600)
+	assert.NoError(err)
+
+	err = serve(context.Background(), file, oci.RuntimeConfig{})
+	assert.Error(err)
+	_, err = os.Stat(file)
+	assert.NoError(err)
+}
+
+func TestServe(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	socket := filepath.Join(tmpdir, "vcapi.sock")
+
+	// a stale socket
+	l, err := net.Listen("unix", socket)
+	assert.NoError(err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- serve(context.Background(), socket, oci.RuntimeConfig{})
+	}()
+
+	conn, err := grpc.Dial(socket, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(10*time.Second),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}))
+	assert.NoError(err)
+	defer conn.Close()
+
+	fi, err := os.Stat(socket)
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+	list, err := vcapi.NewVirtContainersClient(conn).ListSandbox(context.Background(), &types.Empty{})
+	assert.NoError(err)
+	assert.Empty(list.SandboxIds)
+
+	err = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	assert.NoError(err)
+
+	assert.NoError(<-errCh)
+
+	_, err = os.Stat(socket)
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kata-containers/runtime/pkg/katautils"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/urfave/cli"
+)
+
+var stateMigrateCLICommand = cli.Command{
+	Name:  "state-migrate",
+	Usage: "migrate the on-disk state of existing sandboxes to the current format",
+	ArgsUsage: `[sandbox-id...]
+
+   [sandbox-id...] are the sandboxes to migrate, all sandboxes are
+   considered when none is specified`,
+	Description: `The state-migrate command rewrites the state stored for running sandboxes
+   with the format version supported by this runtime. With --check, nothing is
+   modified and the command reports which sandboxes would fail to load.
+
+   Items whose format did not change since the runtime started versioning them
+   are kept in the legacy format, so that the runtime can be downgraded while
+   sandboxes are running. The OUTDATED column reports the items that will stop
+   being readable by older runtimes once migrated: sandboxes with migrated
+   items have to be deleted before downgrading the runtime.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "only report the sandboxes state format, without migrating it",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		ctx, err := cliContextToContext(context)
+		if err != nil {
+			return err
+		}
+
+		return stateMigrate(ctx, context.Args(), context.Bool("check"), defaultOutputFile)
+	},
+}
+
+// sandboxMigrationStatus is the state format report of a single sandbox.
+type sandboxMigrationStatus struct {
+	id       string
+	items    []store.ItemStatus
+	outdated int
+	err      error
+}
+
+func stateMigrate(ctx context.Context, sandboxIDs []string, check bool, out io.Writer) error {
+	span, ctx := katautils.Trace(ctx, "state-migrate")
+	defer span.Finish()
+
+	setExternalLoggers(ctx, kataLog)
+
+	if len(sandboxIDs) == 0 {
+		ids, err := storedSandboxIDs()
+		if err != nil {
+			return err
+		}
+		sandboxIDs = ids
+	}
+
+	var statuses []sandboxMigrationStatus
+	failed := 0
+
+	for _, id := range sandboxIDs {
+		var status sandboxMigrationStatus
+
+		if check {
+			status = checkSandboxState(ctx, id)
+		} else {
+			status = migrateSandboxState(ctx, id)
+		}
+
+		if status.err != nil {
+			failed++
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	writeMigrationStatus(statuses, check, out)
+
+	if failed > 0 {
+		if check {
+			return fmt.Errorf("%d sandbox(es) would fail to load", failed)
+		}
+		return fmt.Errorf("%d sandbox(es) could not be migrated", failed)
+	}
+
+	return nil
+}
+
+// storedSandboxIDs returns the IDs of all the sandboxes with a
+// configuration store.
+func storedSandboxIDs() ([]string, error) {
+	dir, err := os.Open(store.ConfigStoragePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer dir.Close()
+
+	return dir.Readdirnames(0)
+}
+
+func itemsError(status *sandboxMigrationStatus) error {
+	for _, item := range status.items {
+		if item.Error != nil {
+			return fmt.Errorf("%s: %v", item.Path, item.Error)
+		}
+
+		if item.NeedsMigration() {
+			status.outdated++
+		}
+	}
+
+	return nil
+}
+
+func checkSandboxState(ctx context.Context, sandboxID string) sandboxMigrationStatus {
+	status := sandboxMigrationStatus{id: sandboxID}
+
+	items, err := store.CheckVCSandboxItems(sandboxID)
+	if err != nil {
+		status.err = err
+		return status
+	}
+
+	status.items = items
+
+	if status.err = itemsError(&status); status.err != nil {
+		return status
+	}
+
+	// All items can be migrated, make sure the sandbox actually loads.
+	if _, err := vci.StatusSandbox(ctx, sandboxID); err != nil {
+		status.err = err
+	}
+
+	return status
+}
+
+func migrateSandboxState(ctx context.Context, sandboxID string) sandboxMigrationStatus {
+	status := sandboxMigrationStatus{id: sandboxID}
+
+	vcStore, err := store.NewVCSandboxStore(ctx, sandboxID)
+	if err != nil {
+		status.err = err
+		return status
+	}
+
+	token, err := vcStore.Lock()
+	if err != nil {
+		status.err = err
+		return status
+	}
+	defer vcStore.Unlock(token)
+
+	items, err := store.MigrateVCSandboxItems(sandboxID)
+	if err != nil {
+		status.err = err
+		return status
+	}
+
+	status.items = items
+	status.err = itemsError(&status)
+
+	return status
+}
+
+func writeMigrationStatus(statuses []sandboxMigrationStatus, check bool, out io.Writer) {
+	w := tabwriter.NewWriter(out, 12, 1, 3, ' ', 0)
+
+	fmt.Fprintln(w, "SANDBOX\tITEMS\tOUTDATED\tSTATUS")
+
+	for _, s := range statuses {
+		result := "ok"
+		if s.err != nil {
+			result = fmt.Sprintf("error: %v", s.err)
+		} else if !check {
+			result = "migrated"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", s.id, len(s.items), s.outdated, result)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStateMigrateTest(t *testing.T) func() {
+	tmpdir, err := ioutil.TempDir(testDir, "state-migrate-")
+	assert.NoError(t, err)
+
+	savedConfigPath := store.ConfigStoragePath
+	savedRunPath := store.RunStoragePath
+
+	store.ConfigStoragePath = filepath.Join(tmpdir, "config")
+	store.RunStoragePath = filepath.Join(tmpdir, "run")
+
+	return func() {
+		store.ConfigStoragePath = savedConfigPath
+		store.RunStoragePath = savedRunPath
+		os.RemoveAll(tmpdir)
+	}
+}
+
+func TestStateMigrateNoSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanup := setupStateMigrateTest(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	err := stateMigrate(context.Background(), nil, true, &out)
+	assert.NoError(err)
+	assert.Contains(out.String(), "SANDBOX")
+}
+
+func TestStateMigrateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanup := setupStateMigrateTest(t)
+	defer cleanup()
+
+	configRoot := store.SandboxConfigurationRootPath(testSandboxID)
+	assert.NoError(os.MkdirAll(configRoot, store.DirMode))
+
+	stateFile := filepath.Join(configRoot, store.ConfigurationFile)
+	legacyData := []byte(`{"ID":"` + testSandboxID + `"}`)
+	assert.NoError(ioutil.WriteFile(stateFile, legacyData, testFileMode))
+
+	testingImpl.StatusSandboxFunc = func(ctx context.Context, sandboxID string) (vc.SandboxStatus, error) {
+		return vc.SandboxStatus{ID: sandboxID}, nil
+	}
+	defer func() {
+		testingImpl.StatusSandboxFunc = nil
+	}()
+
+	var out bytes.Buffer
+	err := stateMigrate(context.Background(), nil, true, &out)
+	assert.NoError(err)
+	assert.Contains(out.String(), testSandboxID)
+
+	// Checking does not migrate anything
+	content, err := ioutil.ReadFile(stateFile)
+	assert.NoError(err)
+	assert.Equal(legacyData, content)
+
+	// A sandbox that does not load is reported
+	testingImpl.StatusSandboxFunc = func(ctx context.Context, sandboxID string) (vc.SandboxStatus, error) {
+		return vc.SandboxStatus{}, errors.New("cannot load sandbox")
+	}
+
+	out.Reset()
+	err = stateMigrate(context.Background(), nil, true, &out)
+	assert.Error(err)
+	assert.Contains(out.String(), "cannot load sandbox")
+}
+
+func TestStateMigrate(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanup := setupStateMigrateTest(t)
+	defer cleanup()
+
+	configRoot := store.SandboxConfigurationRootPath(testSandboxID)
+	assert.NoError(os.MkdirAll(configRoot, store.DirMode))
+
+	stateFile := filepath.Join(configRoot, store.ConfigurationFile)
+	legacyData := []byte(`{"ID":"` + testSandboxID + `"}`)
+	assert.NoError(ioutil.WriteFile(stateFile, legacyData, testFileMode))
+
+	var out bytes.Buffer
+	err := stateMigrate(context.Background(), []string{testSandboxID}, false, &out)
+	assert.NoError(err)
+	assert.Contains(out.String(), "migrated")
+
+	statuses, err := store.CheckVCSandboxItems(testSandboxID)
+	assert.NoError(err)
+	assert.Len(statuses, 1)
+	assert.False(statuses[0].NeedsMigration())
+
+	// Legacy items are kept in the legacy format, so that the runtime
+	// can be downgraded.
+	content, err := ioutil.ReadFile(stateFile)
+	assert.NoError(err)
+	assert.Equal(legacyData, content)
+}
@@ -70,6 +70,20 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest, netns
 		}
 	}
 
+	if m := rootfsImageMount(r.Rootfs); m != nil {
+		if ociSpec.Annotations == nil {
+			ociSpec.Annotations = make(map[string]string)
+		}
+
+		for k, v := range rootfsImageAnnotations(m) {
+			ociSpec.Annotations[k] = v
+		}
+	}
+
+	// Record the container IO in the stored container configuration,
+	// so that a restarted shim can re-attach it.
+	setIOAnnotations(&ociSpec, r)
+
 	disableOutput := noNeedForOutput(detach, ociSpec.Process.Terminal)
 
 	switch containerType {
@@ -83,6 +97,17 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest, netns
 			return nil, err
 		}
 
+		if s.config.ShimGC {
+			go garbageCollect(s.context)
+		}
+
+		span, ctx := trace(ctx, "createSandbox")
+		defer span.Finish()
+
+		if err := s.config.SelectHypervisorProfile(ociSpec); err != nil {
+			return nil, err
+		}
+
 		katautils.HandleFactory(ctx, vci, s.config)
 		sandbox, _, err := katautils.CreateSandbox(ctx, vci, ociSpec, *s.config, r.ID, bundlePath, "", disableOutput, false, true)
 		if err != nil {
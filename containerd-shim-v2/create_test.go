@@ -13,10 +13,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	containerd_types "github.com/containerd/containerd/api/types"
 	"github.com/containerd/containerd/namespaces"
 	taskAPI "github.com/containerd/containerd/runtime/v2/task"
 
 	vc "github.com/kata-containers/runtime/virtcontainers"
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
 	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
 
 	"github.com/kata-containers/runtime/pkg/katautils"
@@ -361,3 +363,48 @@ func TestCreateContainerConfigFail(t *testing.T) {
 	_, err = s.Create(ctx, req)
 	assert.Error(err)
 }
+
+func TestRootfsImageMount(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "rootfs-image-")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	image := filepath.Join(dir, "rootfs.qcow2")
+	assert.NoError(ioutil.WriteFile(image, []byte{}, testFileMode))
+
+	// Regular overlay rootfs
+	assert.Nil(rootfsImageMount([]*containerd_types.Mount{
+		{Type: "overlay", Source: "overlay", Options: []string{"lowerdir=/a"}},
+	}))
+
+	// Loop mount of a directory
+	assert.Nil(rootfsImageMount([]*containerd_types.Mount{
+		{Type: "ext4", Source: dir, Options: []string{"loop"}},
+	}))
+
+	m := &containerd_types.Mount{
+		Type:    "xfs",
+		Source:  image,
+		Options: []string{"kata.image-format=qcow2", "ro", "noatime"},
+	}
+	assert.Equal(m, rootfsImageMount([]*containerd_types.Mount{m}))
+
+	assert.Equal(map[string]string{
+		vcAnnotations.RootfsImagePath:    image,
+		vcAnnotations.RootfsImageFstype:  "xfs",
+		vcAnnotations.RootfsImageFormat:  "qcow2",
+		vcAnnotations.RootfsImageOptions: "ro,noatime",
+	}, rootfsImageAnnotations(m))
+
+	m = &containerd_types.Mount{
+		Type:    "ext4",
+		Source:  image,
+		Options: []string{"loop"},
+	}
+	assert.Equal(map[string]string{
+		vcAnnotations.RootfsImagePath:   image,
+		vcAnnotations.RootfsImageFstype: "ext4",
+	}, rootfsImageAnnotations(m))
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package containerdshim
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// garbageCollect reclaims the resources left behind by the sandboxes whose
+// runtime or shim crashed. When enabled by the runtime configuration,
+// it runs in the background when a shim creates a new sandbox, and only logs
+// its failures so that it never delays nor fails the sandbox creation.
+func garbageCollect(ctx context.Context) {
+	orphans, err := vci.GarbageCollect(ctx, false)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not reclaim orphaned resources")
+	}
+
+	if len(orphans) > 0 {
+		logrus.WithField("resources", len(orphans)).Info("Reclaimed orphaned resources")
+	}
+}
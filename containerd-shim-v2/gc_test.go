@@ -0,0 +1,31 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package containerdshim
+
+import (
+	"context"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGarbageCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	called := false
+	testingImpl.GarbageCollectFunc = func(ctx context.Context, dryRun bool) ([]vc.OrphanResource, error) {
+		called = true
+		assert.False(dryRun)
+		return nil, nil
+	}
+	defer func() {
+		testingImpl.GarbageCollectFunc = nil
+	}()
+
+	garbageCollect(context.Background())
+	assert.True(called)
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package containerdshim
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/api/types/task"
+	cdshim "github.com/containerd/containerd/runtime/v2/shim"
+	taskAPI "github.com/containerd/containerd/runtime/v2/task"
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/sirupsen/logrus"
+)
+
+// The annotations recording the containerd IO of a container.
+const (
+	stdinAnnotation    = "io.katacontainers.shimv2.stdin"
+	stdoutAnnotation   = "io.katacontainers.shimv2.stdout"
+	stderrAnnotation   = "io.katacontainers.shimv2.stderr"
+	terminalAnnotation = "io.katacontainers.shimv2.terminal"
+)
+
+func setIOAnnotations(ociSpec *oci.CompatOCISpec, r *taskAPI.CreateTaskRequest) {
+	if ociSpec.Annotations == nil {
+		ociSpec.Annotations = make(map[string]string)
+	}
+
+	ociSpec.Annotations[stdinAnnotation] = r.Stdin
+	ociSpec.Annotations[stdoutAnnotation] = r.Stdout
+	ociSpec.Annotations[stderrAnnotation] = r.Stderr
+	ociSpec.Annotations[terminalAnnotation] = strconv.FormatBool(r.Terminal)
+}
+
+// runningAsDaemon tells whether the shim binary serves the task API, which
+// is the case when no action such as start or delete is given.
+func runningAsDaemon() bool {
+	return flag.NArg() == 0
+}
+
+// sandboxExists tells whether the runtime state of a sandbox is stored,
+// i.e. whether the sandbox has been created and not deleted since the last
+// host boot.
+func sandboxExists(id string) bool {
+	path, err := store.SandboxRuntimeItemPath(id, store.State)
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// execsDir is the directory of a container bundle holding the records of
+// its started exec processes.
+const execsDir = "execs"
+
+// execRecord is what a restarted shim needs to recover a started exec
+// process.
+type execRecord struct {
+	Token    string    `json:"token"`
+	Cmd      types.Cmd `json:"cmd"`
+	Stdin    string    `json:"stdin"`
+	Stdout   string    `json:"stdout"`
+	Stderr   string    `json:"stderr"`
+	Terminal bool      `json:"terminal"`
+	Height   uint32    `json:"height"`
+	Width    uint32    `json:"width"`
+}
+
+// shimServes tells whether a shim serves the task API on address.
+func shimServes(address string) bool {
+	conn, err := cdshim.Connect(address, cdshim.AnonDialer)
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+
+	return true
+}
+
+func execRecordPath(c *container, execID string) string {
+	return filepath.Join(c.bundle, execsDir, execID+".json")
+}
+
+// saveExec records a started exec process in the container bundle.
+func saveExec(c *container, execID string, e *exec) error {
+	if c.bundle == "" {
+		return nil
+	}
+
+	record := execRecord{
+		Token:    e.id,
+		Cmd:      *e.cmds,
+		Stdin:    e.tty.stdin,
+		Stdout:   e.tty.stdout,
+		Stderr:   e.tty.stderr,
+		Terminal: e.tty.terminal,
+		Height:   e.tty.height,
+		Width:    e.tty.width,
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.bundle, execsDir), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(execRecordPath(c, execID), data, 0600)
+}
+
+// removeExec removes the record of an exec process.
+func removeExec(c *container, execID string) error {
+	if c.bundle == "" {
+		return nil
+	}
+
+	if err := os.Remove(execRecordPath(c, execID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// recoverSandbox rebuilds the service state of an existing sandbox, after
+// the shim serving it has been restarted. The containers are reloaded from
+// the store, their IO and the one of their started exec processes are
+// re-attached to the containerd FIFOs, and their processes are waited for
+// again, so that their exit still reaches containerd.
+func recoverSandbox(ctx context.Context, s *service) error {
+	span, ctx := trace(ctx, "recoverSandbox")
+	defer span.Finish()
+
+	sandbox, err := vci.FetchSandbox(ctx, s.id)
+	if err != nil {
+		return err
+	}
+
+	s.sandbox = sandbox
+
+	for _, vcc := range sandbox.GetAllContainers() {
+		c, err := recoverContainer(ctx, s, vcc.ID())
+		if err != nil {
+			return err
+		}
+
+		s.containers[c.id] = c
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"sandbox":    s.id,
+		"containers": len(s.containers),
+	}).Info("Recovered sandbox")
+
+	return nil
+}
+
+func recoverContainer(ctx context.Context, s *service, id string) (*container, error) {
+	status, err := s.sandbox.StatusContainer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ociSpec, err := oci.GetOCIConfig(status)
+	if err != nil {
+		return nil, err
+	}
+
+	containerType, err := ociSpec.ContainerType()
+	if err != nil {
+		return nil, err
+	}
+
+	terminal, _ := strconv.ParseBool(ociSpec.Annotations[terminalAnnotation])
+
+	r := &taskAPI.CreateTaskRequest{
+		ID:       id,
+		Bundle:   status.Annotations[vcAnnotations.BundlePathKey],
+		Stdin:    ociSpec.Annotations[stdinAnnotation],
+		Stdout:   ociSpec.Annotations[stdoutAnnotation],
+		Stderr:   ociSpec.Annotations[stderrAnnotation],
+		Terminal: terminal,
+	}
+
+	c, err := newContainer(s, r, containerType, &ociSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status.State.State {
+	case types.StateReady:
+		// The IO is attached once the container is started.
+		c.status = task.StatusCreated
+		return c, nil
+	case types.StateRunning:
+		c.status = task.StatusRunning
+	case types.StatePaused:
+		c.status = task.StatusPaused
+	default:
+		// The exit code of the container process has been lost
+		// along with the previous shim.
+		c.status = task.StatusStopped
+		c.exit = exitCode255
+		c.exitCh <- c.exit
+		close(c.exitIOch)
+		return c, nil
+	}
+
+	if err := attachContainer(ctx, s, c); err != nil {
+		return nil, err
+	}
+
+	if err := recoverExecs(ctx, s, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// recoverExecs re-attaches the IO of the exec processes recorded in the
+// container bundle, and waits for them again. Exec processes added but not
+// started are lost along with the previous shim.
+func recoverExecs(ctx context.Context, s *service, c *container) error {
+	files, err := ioutil.ReadDir(filepath.Join(c.bundle, execsDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		execID := strings.TrimSuffix(file.Name(), ".json")
+
+		data, err := ioutil.ReadFile(execRecordPath(c, execID))
+		if err != nil {
+			return err
+		}
+
+		var record execRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+
+		cmds := record.Cmd
+		e := &exec{
+			container: c,
+			cmds:      &cmds,
+			tty: &tty{
+				stdin:    record.Stdin,
+				stdout:   record.Stdout,
+				stderr:   record.Stderr,
+				height:   record.Height,
+				width:    record.Width,
+				terminal: record.Terminal,
+			},
+			id:       record.Token,
+			exitCode: exitCode255,
+			exitIOch: make(chan struct{}),
+			exitCh:   make(chan uint32, 1),
+			status:   task.StatusRunning,
+		}
+
+		if err := attachExec(ctx, s, c, execID, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
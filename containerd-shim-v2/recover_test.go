@@ -0,0 +1,278 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package containerdshim
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/api/types/task"
+	"github.com/containerd/containerd/events"
+	taskAPI "github.com/containerd/containerd/runtime/v2/task"
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// recoverTestSandbox reports the status of its containers.
+type recoverTestSandbox struct {
+	*vcmock.Sandbox
+	statuses map[string]vc.ContainerStatus
+}
+
+func (s *recoverTestSandbox) StatusContainer(contID string) (vc.ContainerStatus, error) {
+	return s.statuses[contID], nil
+}
+
+func recoverTestStatus(t *testing.T, id, containerType string, state types.StateString) vc.ContainerStatus {
+	ociSpec := oci.CompatOCISpec{}
+	setIOAnnotations(&ociSpec, &taskAPI.CreateTaskRequest{
+		Terminal: true,
+	})
+	ociSpec.Annotations[testContainerTypeAnnotation] = containerType
+
+	ociSpecJSON, err := json.Marshal(ociSpec)
+	assert.NoError(t, err)
+
+	return vc.ContainerStatus{
+		ID: id,
+		State: types.State{
+			State: state,
+		},
+		Annotations: map[string]string{
+			vcAnnotations.ConfigJSONKey: string(ociSpecJSON),
+			vcAnnotations.BundlePathKey: "/bundle/" + id,
+		},
+	}
+}
+
+func TestSetIOAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	ociSpec := oci.CompatOCISpec{}
+	setIOAnnotations(&ociSpec, &taskAPI.CreateTaskRequest{
+		Stdin:    "/run/stdin",
+		Stdout:   "/run/stdout",
+		Stderr:   "/run/stderr",
+		Terminal: true,
+	})
+
+	assert.Equal("/run/stdin", ociSpec.Annotations[stdinAnnotation])
+	assert.Equal("/run/stdout", ociSpec.Annotations[stdoutAnnotation])
+	assert.Equal("/run/stderr", ociSpec.Annotations[stderrAnnotation])
+	assert.Equal("true", ociSpec.Annotations[terminalAnnotation])
+}
+
+func TestRecoverSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &service{
+		id:         testSandboxID,
+		containers: make(map[string]*container),
+		ec:         make(chan exit, bufferSize),
+	}
+
+	// the sandbox cannot be fetched
+	err := recoverSandbox(context.Background(), s)
+	assert.Error(err)
+
+	sandbox := &recoverTestSandbox{
+		Sandbox: &vcmock.Sandbox{
+			MockID: testSandboxID,
+			MockContainers: []*vcmock.Container{
+				{MockID: testSandboxID},
+				{MockID: "ready"},
+				{MockID: "stopped"},
+			},
+		},
+		statuses: map[string]vc.ContainerStatus{
+			testSandboxID: recoverTestStatus(t, testSandboxID, testContainerTypeSandbox, types.StateRunning),
+			"ready":       recoverTestStatus(t, "ready", testContainerTypeContainer, types.StateReady),
+			"stopped":     recoverTestStatus(t, "stopped", testContainerTypeContainer, types.StateStopped),
+		},
+	}
+
+	testingImpl.FetchSandboxFunc = func(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+		return sandbox, nil
+	}
+	defer func() {
+		testingImpl.FetchSandboxFunc = nil
+	}()
+
+	err = recoverSandbox(context.Background(), s)
+	assert.NoError(err)
+	assert.Equal(sandbox, s.sandbox)
+	assert.Len(s.containers, 3)
+
+	c := s.containers[testSandboxID]
+	assert.Equal(vc.PodSandbox, c.cType)
+	assert.Equal(task.StatusRunning, c.status)
+	assert.Equal("/bundle/"+testSandboxID, c.bundle)
+	assert.True(c.terminal)
+
+	// the sandbox process is waited for again
+	assert.Equal(uint32(0), <-c.exitCh)
+	e := <-s.ec
+	assert.Equal(testSandboxID, e.id)
+
+	c = s.containers["ready"]
+	assert.Equal(vc.PodContainer, c.cType)
+	assert.Equal(task.StatusCreated, c.status)
+
+	c = s.containers["stopped"]
+	assert.Equal(task.StatusStopped, c.status)
+	assert.Equal(uint32(exitCode255), <-c.exitCh)
+}
+
+func TestRecoverSandboxInvalidContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &service{
+		id:         testSandboxID,
+		containers: make(map[string]*container),
+	}
+
+	// the container status does not hold its OCI configuration
+	sandbox := &recoverTestSandbox{
+		Sandbox: &vcmock.Sandbox{
+			MockID:         testSandboxID,
+			MockContainers: []*vcmock.Container{{MockID: testContainerID}},
+		},
+	}
+
+	testingImpl.FetchSandboxFunc = func(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+		return sandbox, nil
+	}
+	defer func() {
+		testingImpl.FetchSandboxFunc = nil
+	}()
+
+	err := recoverSandbox(context.Background(), s)
+	assert.Error(err)
+}
+
+func TestSandboxExists(t *testing.T) {
+	assert.False(t, sandboxExists(testSandboxID))
+	assert.False(t, sandboxExists(""))
+}
+
+type recoverTestPublisher struct{}
+
+func (p *recoverTestPublisher) Publish(ctx context.Context, topic string, event events.Event) error {
+	return nil
+}
+
+func TestSaveExec(t *testing.T) {
+	assert := assert.New(t)
+
+	bundle, err := ioutil.TempDir("", "bundle")
+	assert.NoError(err)
+	defer os.RemoveAll(bundle)
+
+	c := &container{bundle: bundle}
+	e := &exec{
+		id:   "token",
+		cmds: &types.Cmd{Args: []string{"sh"}},
+		tty:  &tty{stdout: "/run/stdout", terminal: true},
+	}
+
+	err = saveExec(c, "exec", e)
+	assert.NoError(err)
+
+	data, err := ioutil.ReadFile(filepath.Join(bundle, execsDir, "exec.json"))
+	assert.NoError(err)
+
+	var record execRecord
+	assert.NoError(json.Unmarshal(data, &record))
+	assert.Equal("token", record.Token)
+	assert.Equal([]string{"sh"}, record.Cmd.Args)
+	assert.Equal("/run/stdout", record.Stdout)
+	assert.True(record.Terminal)
+
+	assert.NoError(removeExec(c, "exec"))
+	assert.NoError(removeExec(c, "exec"))
+	_, err = os.Stat(filepath.Join(bundle, execsDir, "exec.json"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestNewRecoversSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	runDir, err := ioutil.TempDir("", "run")
+	assert.NoError(err)
+	defer os.RemoveAll(runDir)
+
+	bundle, err := ioutil.TempDir("", "bundle")
+	assert.NoError(err)
+	defer os.RemoveAll(bundle)
+
+	savedRunStoragePath := store.RunStoragePath
+	store.RunStoragePath = runDir
+	defer func() {
+		store.RunStoragePath = savedRunStoragePath
+	}()
+
+	// the sandbox has been created by a previous shim
+	err = os.MkdirAll(filepath.Join(runDir, testSandboxID), 0700)
+	assert.NoError(err)
+	err = ioutil.WriteFile(filepath.Join(runDir, testSandboxID, store.StateFile), []byte("{}"), 0600)
+	assert.NoError(err)
+
+	// which started an exec process
+	err = saveExec(&container{bundle: bundle}, "exec", &exec{
+		id:   "token",
+		cmds: &types.Cmd{Args: []string{"sh"}},
+		tty:  &tty{},
+	})
+	assert.NoError(err)
+
+	status := recoverTestStatus(t, testSandboxID, testContainerTypeSandbox, types.StateRunning)
+	status.Annotations[vcAnnotations.BundlePathKey] = bundle
+
+	sandbox := &recoverTestSandbox{
+		Sandbox: &vcmock.Sandbox{
+			MockID:         testSandboxID,
+			MockContainers: []*vcmock.Container{{MockID: testSandboxID}},
+		},
+		statuses: map[string]vc.ContainerStatus{
+			testSandboxID: status,
+		},
+	}
+
+	testingImpl.FetchSandboxFunc = func(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+		return sandbox, nil
+	}
+	defer func() {
+		testingImpl.FetchSandboxFunc = nil
+	}()
+
+	shim, err := New(context.Background(), testSandboxID, &recoverTestPublisher{})
+	assert.NoError(err)
+
+	s, ok := shim.(*service)
+	assert.True(ok)
+	assert.Equal(sandbox, s.sandbox)
+
+	c, ok := s.containers[testSandboxID]
+	assert.True(ok)
+	assert.Equal(task.StatusRunning, c.status)
+
+	e, err := c.getExec("exec")
+	assert.NoError(err)
+	assert.Equal("token", e.id)
+	assert.Equal([]string{"sh"}, e.cmds.Args)
+
+	// the exec process is waited for again
+	assert.Equal(uint32(0), <-e.exitCh)
+}
@@ -65,6 +65,12 @@ func New(ctx context.Context, id string, publisher events.Publisher) (cdshim.Shi
 	vci.SetLogger(ctx, logger)
 	katautils.SetLogger(ctx, logger, logger.Logger.Level)
 
+	// The tracer is created before the first request is traced, from
+	// the configuration found without any CRI runtime option.
+	if err := createTracer(); err != nil {
+		return nil, err
+	}
+
 	s := &service{
 		id:         id,
 		pid:        uint32(os.Getpid()),
@@ -74,6 +80,14 @@ func New(ctx context.Context, id string, publisher events.Publisher) (cdshim.Shi
 		ec:         make(chan exit, bufferSize),
 	}
 
+	// A shim restarted for an existing sandbox takes over from the
+	// previous one.
+	if runningAsDaemon() && sandboxExists(id) {
+		if err := recoverSandbox(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+
 	go s.processExits()
 
 	go s.forward(publisher)
@@ -171,6 +185,17 @@ func (s *service) StartShim(ctx context.Context, id, containerdBinary, container
 		return "", err
 	}
 
+	// Starting the shim of an existing sandbox, e.g. to upgrade it while
+	// containerd is stopped, starts a shim recovering the sandbox when the
+	// previous one is gone: containerd reconnects to the same address
+	// once restarted.
+	if sandboxExists(id) && shimServes(address) {
+		if err := cdshim.WriteAddress("address", address); err != nil {
+			return "", err
+		}
+		return address, nil
+	}
+
 	socket, err := cdshim.NewSocket(address)
 	if err != nil {
 		return "", err
@@ -307,6 +332,9 @@ func (s *service) Cleanup(ctx context.Context) (*taskAPI.DeleteResponse, error)
 
 // Create a new sandbox or container with the underlying OCI runtime
 func (s *service) Create(ctx context.Context, r *taskAPI.CreateTaskRequest) (_ *taskAPI.CreateTaskResponse, err error) {
+	span, ctx := trace(ctx, "Create")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -324,14 +352,18 @@ func (s *service) Create(ctx context.Context, r *taskAPI.CreateTaskRequest) (_ *
 			}
 		}
 	}()
-	for _, rm := range r.Rootfs {
-		m := &mount.Mount{
-			Type:    rm.Type,
-			Source:  rm.Source,
-			Options: rm.Options,
-		}
-		if err := m.Mount(rootfs); err != nil {
-			return nil, errors.Wrapf(err, "failed to mount rootfs component %v", m)
+	// Disk image rootfs are not mounted on the host, they are
+	// directly attached to the VM.
+	if rootfsImageMount(r.Rootfs) == nil {
+		for _, rm := range r.Rootfs {
+			m := &mount.Mount{
+				Type:    rm.Type,
+				Source:  rm.Source,
+				Options: rm.Options,
+			}
+			if err := m.Mount(rootfs); err != nil {
+				return nil, errors.Wrapf(err, "failed to mount rootfs component %v", m)
+			}
 		}
 	}
 
@@ -365,6 +397,9 @@ func (s *service) Create(ctx context.Context, r *taskAPI.CreateTaskRequest) (_ *
 
 // Start a process
 func (s *service) Start(ctx context.Context, r *taskAPI.StartRequest) (*taskAPI.StartResponse, error) {
+	span, ctx := trace(ctx, "Start")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -407,6 +442,9 @@ func (s *service) Start(ctx context.Context, r *taskAPI.StartRequest) (*taskAPI.
 
 // Delete the initial process and container
 func (s *service) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (*taskAPI.DeleteResponse, error) {
+	span, ctx := trace(ctx, "Delete")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -458,6 +496,10 @@ func (s *service) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (*taskAP
 
 	delete(c.execs, r.ExecID)
 
+	if err := removeExec(c, r.ExecID); err != nil {
+		logrus.WithError(err).WithField("exec", r.ExecID).Warn("Could not remove exec process record")
+	}
+
 	return &taskAPI.DeleteResponse{
 		ExitStatus: uint32(execs.exitCode),
 		ExitedAt:   execs.exitTime,
@@ -467,6 +509,9 @@ func (s *service) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (*taskAP
 
 // Exec an additional process inside the container
 func (s *service) Exec(ctx context.Context, r *taskAPI.ExecProcessRequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "Exec")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -496,6 +541,9 @@ func (s *service) Exec(ctx context.Context, r *taskAPI.ExecProcessRequest) (*pty
 
 // ResizePty of a process
 func (s *service) ResizePty(ctx context.Context, r *taskAPI.ResizePtyRequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "ResizePty")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -526,6 +574,9 @@ func (s *service) ResizePty(ctx context.Context, r *taskAPI.ResizePtyRequest) (*
 
 // State returns runtime state information for a process
 func (s *service) State(ctx context.Context, r *taskAPI.StateRequest) (*taskAPI.StateResponse, error) {
+	span, _ := trace(ctx, "State")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -570,6 +621,9 @@ func (s *service) State(ctx context.Context, r *taskAPI.StateRequest) (*taskAPI.
 
 // Pause the container
 func (s *service) Pause(ctx context.Context, r *taskAPI.PauseRequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "Pause")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -600,6 +654,9 @@ func (s *service) Pause(ctx context.Context, r *taskAPI.PauseRequest) (*ptypes.E
 
 // Resume the container
 func (s *service) Resume(ctx context.Context, r *taskAPI.ResumeRequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "Resume")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -628,6 +685,9 @@ func (s *service) Resume(ctx context.Context, r *taskAPI.ResumeRequest) (*ptypes
 
 // Kill a process with the provided signal
 func (s *service) Kill(ctx context.Context, r *taskAPI.KillRequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "Kill")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -666,6 +726,9 @@ func (s *service) Kill(ctx context.Context, r *taskAPI.KillRequest) (*ptypes.Emp
 // Since for kata, it cannot get the process's pid from VM,
 // thus only return the Shim's pid directly.
 func (s *service) Pids(ctx context.Context, r *taskAPI.PidsRequest) (*taskAPI.PidsResponse, error) {
+	span, _ := trace(ctx, "Pids")
+	defer span.Finish()
+
 	var processes []*task.ProcessInfo
 
 	pInfo := task.ProcessInfo{
@@ -680,6 +743,9 @@ func (s *service) Pids(ctx context.Context, r *taskAPI.PidsRequest) (*taskAPI.Pi
 
 // CloseIO of a process
 func (s *service) CloseIO(ctx context.Context, r *taskAPI.CloseIORequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "CloseIO")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -724,6 +790,9 @@ func (s *service) Connect(ctx context.Context, r *taskAPI.ConnectRequest) (*task
 }
 
 func (s *service) Shutdown(ctx context.Context, r *taskAPI.ShutdownRequest) (*ptypes.Empty, error) {
+	span, ctx := trace(ctx, "Shutdown")
+	defer span.Finish()
+
 	s.mu.Lock()
 	if len(s.containers) != 0 {
 		s.mu.Unlock()
@@ -731,6 +800,8 @@ func (s *service) Shutdown(ctx context.Context, r *taskAPI.ShutdownRequest) (*pt
 	}
 	s.mu.Unlock()
 
+	katautils.StopTracing(ctx)
+
 	os.Exit(0)
 
 	// This will never be called, but this is only there to make sure the
@@ -739,6 +810,9 @@ func (s *service) Shutdown(ctx context.Context, r *taskAPI.ShutdownRequest) (*pt
 }
 
 func (s *service) Stats(ctx context.Context, r *taskAPI.StatsRequest) (*taskAPI.StatsResponse, error) {
+	span, _ := trace(ctx, "Stats")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -759,6 +833,9 @@ func (s *service) Stats(ctx context.Context, r *taskAPI.StatsRequest) (*taskAPI.
 
 // Update a running container
 func (s *service) Update(ctx context.Context, r *taskAPI.UpdateTaskRequest) (*ptypes.Empty, error) {
+	span, _ := trace(ctx, "Update")
+	defer span.Finish()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -782,6 +859,9 @@ func (s *service) Update(ctx context.Context, r *taskAPI.UpdateTaskRequest) (*pt
 
 // Wait for a process to exit
 func (s *service) Wait(ctx context.Context, r *taskAPI.WaitRequest) (*taskAPI.WaitResponse, error) {
+	span, _ := trace(ctx, "Wait")
+	defer span.Finish()
+
 	var ret uint32
 
 	s.mu.Lock()
@@ -11,6 +11,7 @@ import (
 
 	"github.com/containerd/containerd/api/types/task"
 	"github.com/kata-containers/runtime/pkg/katautils"
+	"github.com/sirupsen/logrus"
 )
 
 func startContainer(ctx context.Context, s *service, c *container) error {
@@ -47,13 +48,19 @@ func startContainer(ctx context.Context, s *service, c *container) error {
 
 	c.status = task.StatusRunning
 
+	return attachContainer(ctx, s, c)
+}
+
+// attachContainer copies the container process IO to its containerd FIFOs,
+// and waits for the process to exit.
+func attachContainer(ctx context.Context, s *service, c *container) error {
 	stdin, stdout, stderr, err := s.sandbox.IOStream(c.id, c.id)
 	if err != nil {
 		return err
 	}
 
 	if c.stdin != "" || c.stdout != "" || c.stderr != "" {
-		tty, err := newTtyIO(ctx, c.stdin, c.stdout, c.stderr, c.terminal)
+		tty, err := newTtyIO(ctx, c.id, c.stdin, c.stdout, c.stderr, c.terminal)
 		if err != nil {
 			return err
 		}
@@ -97,19 +104,36 @@ func startExec(ctx context.Context, s *service, containerID, execID string) (*ex
 		}
 	}
 
+	// Record the exec process, so that a restarted shim can recover it.
+	if err := saveExec(c, execID, execs); err != nil {
+		logrus.WithError(err).WithField("exec", execID).Warn("Could not record exec process")
+	}
+
+	if err := attachExec(ctx, s, c, execID, execs); err != nil {
+		return nil, err
+	}
+
+	return execs, nil
+}
+
+// attachExec copies the exec process IO to its containerd FIFOs, and waits
+// for the process to exit.
+func attachExec(ctx context.Context, s *service, c *container, execID string, execs *exec) error {
 	stdin, stdout, stderr, err := s.sandbox.IOStream(c.id, execs.id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	tty, err := newTtyIO(ctx, execs.tty.stdin, execs.tty.stdout, execs.tty.stderr, execs.tty.terminal)
+	tty, err := newTtyIO(ctx, c.id, execs.tty.stdin, execs.tty.stdout, execs.tty.stderr, execs.tty.terminal)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	execs.ttyio = tty
 
+	c.execs[execID] = execs
+
 	go ioCopy(execs.exitIOch, tty, stdin, stdout, stderr)
 
 	go wait(s, c, execID)
 
-	return execs, nil
+	return nil
 }
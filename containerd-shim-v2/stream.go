@@ -7,16 +7,34 @@ package containerdshim
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/url"
+	"os"
+	sysexec "os/exec"
+	"path/filepath"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/fifo"
 )
 
 // The buffer size used to specify the buffer for IO streams copy
 const bufSize = 32 << 10
 
+// The URI schemes containerd can use to describe a task's IO.
+const (
+	fifoScheme   = "fifo"
+	binaryScheme = "binary"
+	fileScheme   = "file"
+)
+
+// The time given to a logging binary to flush its output and exit
+// once its pipes have been closed, before it gets killed.
+const binaryIOProcTermTimeout = 12 * time.Second
+
 var (
 	bufPool = sync.Pool{
 		New: func() interface{} {
@@ -30,6 +48,10 @@ type ttyIO struct {
 	Stdin  io.ReadCloser
 	Stdout io.Writer
 	Stderr io.Writer
+
+	// cmd is the logging binary handling the output streams,
+	// only set when the IO is described by a binary:// URI.
+	cmd *sysexec.Cmd
 }
 
 func (tty *ttyIO) close() {
@@ -46,13 +68,18 @@ func (tty *ttyIO) close() {
 		}
 	}
 	cf(tty.Stdout)
-	cf(tty.Stderr)
+	// file:// IOs share the same file for stdout and stderr.
+	if tty.Stderr != tty.Stdout {
+		cf(tty.Stderr)
+	}
+
+	if tty.cmd != nil {
+		waitBinaryIO(tty.cmd)
+	}
 }
 
-func newTtyIO(ctx context.Context, stdin, stdout, stderr string, console bool) (*ttyIO, error) {
+func newTtyIO(ctx context.Context, id, stdin, stdout, stderr string, console bool) (*ttyIO, error) {
 	var in io.ReadCloser
-	var outw io.Writer
-	var errw io.Writer
 	var err error
 
 	if stdin != "" {
@@ -62,6 +89,46 @@ func newTtyIO(ctx context.Context, stdin, stdout, stderr string, console bool) (
 		}
 	}
 
+	uri, err := url.Parse(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse stdout uri %q: %v", stdout, err)
+	}
+
+	if uri.Scheme == "" {
+		uri.Scheme = fifoScheme
+	}
+
+	var tty *ttyIO
+
+	switch uri.Scheme {
+	case fifoScheme:
+		tty, err = newFifoIO(ctx, stdout, stderr, console)
+	case binaryScheme:
+		tty, err = newBinaryIO(ctx, id, uri)
+	case fileScheme:
+		tty, err = newFileIO(uri)
+	default:
+		err = fmt.Errorf("unknown stdout uri scheme %q", uri.Scheme)
+	}
+
+	if err != nil {
+		if in != nil {
+			in.Close()
+		}
+		return nil, err
+	}
+
+	tty.Stdin = in
+
+	return tty, nil
+}
+
+// newFifoIO opens the stdout and stderr fifos created by containerd.
+func newFifoIO(ctx context.Context, stdout, stderr string, console bool) (*ttyIO, error) {
+	var outw io.WriteCloser
+	var errw io.WriteCloser
+	var err error
+
 	if stdout != "" {
 		outw, err = fifo.OpenFifo(ctx, stdout, syscall.O_WRONLY, 0)
 		if err != nil {
@@ -72,22 +139,153 @@ func newTtyIO(ctx context.Context, stdin, stdout, stderr string, console bool) (
 	if !console && stderr != "" {
 		errw, err = fifo.OpenFifo(ctx, stderr, syscall.O_WRONLY, 0)
 		if err != nil {
+			if outw != nil {
+				outw.Close()
+			}
 			return nil, err
 		}
 	}
 
-	ttyIO := &ttyIO{
-		Stdin:  in,
+	return &ttyIO{
 		Stdout: outw,
 		Stderr: errw,
+	}, nil
+}
+
+// newFileIO opens the file described by a file:// URI, to which both
+// stdout and stderr get appended.
+func newFileIO(uri *url.URL) (*ttyIO, error) {
+	path := uri.Path
+	if path == "" {
+		return nil, fmt.Errorf("missing path in file uri %q", uri.String())
 	}
 
-	return ttyIO, nil
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ttyIO{
+		Stdout: f,
+		Stderr: f,
+	}, nil
+}
+
+// newBinaryIO spawns the logging binary described by a binary:// URI.
+// Following the containerd logging protocol, the binary receives the
+// read end of the stdout and stderr pipes as fd 3 and fd 4, and closes
+// fd 5 once it is ready to process the container output. The URI query
+// parameters are passed as the binary arguments.
+func newBinaryIO(ctx context.Context, id string, uri *url.URL) (*ttyIO, error) {
+	ns, err := namespaces.NamespaceRequired(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if uri.Path == "" {
+		return nil, fmt.Errorf("missing binary path in uri %q", uri.String())
+	}
+
+	var args []string
+	for k, vs := range uri.Query() {
+		args = append(args, k)
+		if len(vs) > 0 && vs[0] != "" {
+			args = append(args, vs[0])
+		}
+	}
+
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	pipe := func() (*os.File, *os.File, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, r, w)
+		return r, w, nil
+	}
+
+	outr, outw, err := pipe()
+	if err != nil {
+		closeFiles()
+		return nil, err
+	}
+
+	errr, errw, err := pipe()
+	if err != nil {
+		closeFiles()
+		return nil, err
+	}
+
+	readyr, readyw, err := pipe()
+	if err != nil {
+		closeFiles()
+		return nil, err
+	}
+
+	cmd := sysexec.Command(uri.Path, args...)
+	cmd.Env = append(os.Environ(),
+		"CONTAINER_ID="+id,
+		"CONTAINER_NAMESPACE="+ns,
+	)
+	cmd.ExtraFiles = []*os.File{outr, errr, readyw}
+
+	if err := cmd.Start(); err != nil {
+		closeFiles()
+		return nil, err
+	}
+
+	// The child owns its ends of the pipes now.
+	outr.Close()
+	errr.Close()
+	readyw.Close()
+
+	// Wait for the logging binary to be ready, that is for it to
+	// close its end of the ready pipe.
+	b := make([]byte, 1)
+	_, err = readyr.Read(b)
+	readyr.Close()
+	if err != nil && err != io.EOF {
+		outw.Close()
+		errw.Close()
+		waitBinaryIO(cmd)
+		return nil, fmt.Errorf("logging binary %s failed to start: %v", uri.Path, err)
+	}
+
+	return &ttyIO{
+		Stdout: outw,
+		Stderr: errw,
+		cmd:    cmd,
+	}, nil
+}
+
+// waitBinaryIO waits for the logging binary to exit once its input
+// pipes have been closed, and kills it if it does not exit in time.
+func waitBinaryIO(cmd *sysexec.Cmd) {
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(binaryIOProcTermTimeout):
+		cmd.Process.Kill()
+		<-done
+	}
 }
 
 func ioCopy(exitch chan struct{}, tty *ttyIO, stdinPipe io.WriteCloser, stdoutPipe, stderrPipe io.Reader) {
 	var wg sync.WaitGroup
-	var closeOnce sync.Once
 
 	if tty.Stdin != nil {
 		wg.Add(1)
@@ -107,7 +305,13 @@ func ioCopy(exitch chan struct{}, tty *ttyIO, stdinPipe io.WriteCloser, stdoutPi
 			defer bufPool.Put(p)
 			io.CopyBuffer(tty.Stdout, stdoutPipe, *p)
 			wg.Done()
-			closeOnce.Do(tty.close)
+
+			// Unblock the stdin copy once the process is done. The
+			// output streams are only closed once the stderr copy is
+			// done too, as they may share the same file or binary.
+			if tty.Stdin != nil {
+				tty.Stdin.Close()
+			}
 		}()
 	}
 
@@ -122,6 +326,6 @@ func ioCopy(exitch chan struct{}, tty *ttyIO, stdinPipe io.WriteCloser, stdoutPi
 	}
 
 	wg.Wait()
-	closeOnce.Do(tty.close)
+	tty.close()
 	close(exitch)
 }
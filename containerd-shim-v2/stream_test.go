@@ -0,0 +1,143 @@
+// Copyright (c) 2018 HyperHQ Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package containerdshim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/namespaces"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTtyIOUnknownScheme(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	_, err := newTtyIO(ctx, testContainerID, "", "foo:///bar", "", false)
+	assert.Error(err)
+}
+
+func TestNewTtyIOFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "shimV2-file-io-")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	logFile := filepath.Join(dir, "logs", "container.log")
+	uri := fmt.Sprintf("file://%s", logFile)
+
+	tty, err := newTtyIO(ctx, testContainerID, "", uri, uri, false)
+	assert.NoError(err)
+	assert.NotNil(tty.Stdout)
+	assert.Equal(tty.Stdout, tty.Stderr)
+
+	// The stderr output trailing the stdout one is not lost.
+	stdoutDone := make(chan struct{})
+	stderrReader, stderrWriter := io.Pipe()
+	go func() {
+		<-stdoutDone
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(stderrWriter, "stderr\n")
+		stderrWriter.Close()
+	}()
+
+	exitch := make(chan struct{})
+	ioCopy(exitch, tty, nil, &eofNotifier{Reader: strings.NewReader("stdout\n"), eof: stdoutDone}, stderrReader)
+	<-exitch
+
+	content, err := ioutil.ReadFile(logFile)
+	assert.NoError(err)
+	assert.Equal("stdout\nstderr\n", string(content))
+}
+
+// eofNotifier closes eof once its reader is exhausted.
+type eofNotifier struct {
+	io.Reader
+	eof  chan struct{}
+	once sync.Once
+}
+
+func (r *eofNotifier) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.once.Do(func() { close(r.eof) })
+	}
+	return n, err
+}
+
+func TestNewTtyIOBinary(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "shimV2-binary-io-")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	// Minimal logging binary: signal readiness by closing fd 5, then
+	// dump its environment and stdout stream into the file passed as
+	// argument.
+	logger := filepath.Join(dir, "logger")
+	script := "#!/bin/sh\nexec 5>&-\necho $CONTAINER_ID $CONTAINER_NAMESPACE > $1.env\ncat <&3 > $1\n"
+	err = ioutil.WriteFile(logger, []byte(script), 0755)
+	assert.NoError(err)
+
+	logFile := filepath.Join(dir, "container.log")
+	uri := fmt.Sprintf("binary://%s?%s", logger, logFile)
+
+	tty, err := newTtyIO(ctx, testContainerID, "", uri, uri, false)
+	assert.NoError(err)
+	assert.NotNil(tty.cmd)
+
+	exitch := make(chan struct{})
+	ioCopy(exitch, tty, nil, strings.NewReader("hello\n"), nil)
+	<-exitch
+
+	content, err := ioutil.ReadFile(logFile)
+	assert.NoError(err)
+	assert.Equal("hello\n", string(content))
+
+	env, err := ioutil.ReadFile(logFile + ".env")
+	assert.NoError(err)
+	assert.Equal(fmt.Sprintf("%s UnitTest\n", testContainerID), string(env))
+}
+
+func TestNewTtyIOBinaryNoNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := newTtyIO(context.Background(), testContainerID, "", "binary:///bin/true", "", false)
+	assert.Error(err)
+}
+
+func TestTtyIOCloseFile(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := ioutil.TempFile("", "shimV2-close-")
+	assert.NoError(err)
+	defer os.Remove(f.Name())
+
+	tty := &ttyIO{
+		Stdout: f,
+		Stderr: f,
+	}
+	tty.close()
+
+	_, err = io.WriteString(f, "closed")
+	assert.Error(err)
+}
@@ -11,15 +11,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	containerd_types "github.com/containerd/containerd/api/types"
 	"github.com/containerd/containerd/mount"
 	cdshim "github.com/containerd/containerd/runtime/v2/shim"
 	"github.com/kata-containers/runtime/pkg/katautils"
 	vc "github.com/kata-containers/runtime/virtcontainers"
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
 	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -86,6 +90,32 @@ func cleanupContainer(ctx context.Context, sid, cid, bundlePath string) error {
 	return nil
 }
 
+// createTracer creates the tracer of the shim. Whether tracing is enabled
+// is read from KATA_CONF_FILE, or the default configuration file, as the
+// configuration path passed through the CRI runtime options is only known
+// once the sandbox is created. A configuration that can not be loaded is
+// only reported by the sandbox creation.
+func createTracer() error {
+	if _, _, err := katautils.LoadConfiguration(os.Getenv("KATA_CONF_FILE"), true, true); err != nil {
+		logrus.WithError(err).Warn("Could not load the tracing configuration")
+	}
+
+	_, err := katautils.CreateTracer("kata-shim-v2")
+	return err
+}
+
+// trace creates a new tracing span for a shim request. The ttrpc shim API
+// carries no metadata, so the span is only a child of the span carried by
+// ctx, if any: the shim traces do not join the containerd ones.
+func trace(ctx context.Context, name string) (opentracing.Span, context.Context) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, name)
+
+	span.SetTag("source", "runtime")
+	span.SetTag("component", "containerd-shim-v2")
+
+	return span, ctx
+}
+
 func validBundle(containerID, bundlePath string) (string, error) {
 	// container ID MUST be provided.
 	if containerID == "" {
@@ -167,3 +197,62 @@ func removeNamespace(s *oci.CompatOCISpec, nsType specs.LinuxNamespaceType) {
 		}
 	}
 }
+
+// rootfsImageFormatOption is the rootfs mount option giving the format of
+// a disk image rootfs, raw images being the default.
+const rootfsImageFormatOption = "kata.image-format="
+
+// rootfsImageMount returns the rootfs mount if the container rootfs is a
+// file-backed disk image, i.e. a single loop mount of a regular file. Such
+// an image is hotplugged into the VM instead of being mounted on the host.
+func rootfsImageMount(rootfs []*containerd_types.Mount) *containerd_types.Mount {
+	if len(rootfs) != 1 {
+		return nil
+	}
+
+	m := rootfs[0]
+
+	image := false
+	for _, o := range m.Options {
+		if o == "loop" || strings.HasPrefix(o, rootfsImageFormatOption) {
+			image = true
+			break
+		}
+	}
+
+	if !image {
+		return nil
+	}
+
+	if fi, err := os.Stat(m.Source); err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+
+	return m
+}
+
+// rootfsImageAnnotations converts a disk image rootfs mount into the
+// container annotations describing the rootfs disk image.
+func rootfsImageAnnotations(m *containerd_types.Mount) map[string]string {
+	annotations := map[string]string{
+		vcAnnotations.RootfsImagePath:   m.Source,
+		vcAnnotations.RootfsImageFstype: m.Type,
+	}
+
+	var options []string
+	for _, o := range m.Options {
+		switch {
+		case o == "loop":
+		case strings.HasPrefix(o, rootfsImageFormatOption):
+			annotations[vcAnnotations.RootfsImageFormat] = strings.TrimPrefix(o, rootfsImageFormatOption)
+		default:
+			options = append(options, o)
+		}
+	}
+
+	if len(options) > 0 {
+		annotations[vcAnnotations.RootfsImageOptions] = strings.Join(options, ",")
+	}
+
+	return annotations
+}
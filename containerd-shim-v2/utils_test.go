@@ -7,6 +7,7 @@
 package containerdshim
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,11 +17,15 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"testing"
 
 	"github.com/kata-containers/runtime/pkg/katautils"
 	vc "github.com/kata-containers/runtime/virtcontainers"
 	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
 	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
 )
 
 const (
@@ -373,3 +378,71 @@ func readOCIConfigJSON(configFile string) (string, error) {
 	}
 	return string(ociSpecJSON), err
 }
+
+func TestTrace(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	savedTracer := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(savedTracer)
+
+	// Root span
+	span, ctx := trace(context.Background(), "root")
+	defer span.Finish()
+	assert.Equal(span, opentracing.SpanFromContext(ctx))
+	traceID := span.Context().(jaeger.SpanContext).TraceID()
+
+	// Child of the span carried by the context
+	child, _ := trace(ctx, "child")
+	defer child.Finish()
+	assert.Equal(traceID, child.Context().(jaeger.SpanContext).TraceID())
+}
+
+func TestCreateTracer(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "shim-tracer-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	for _, name := range []string{"hypervisor", "kernel", "image", "proxy", "shim"} {
+		err = ioutil.WriteFile(filepath.Join(tmpdir, name), []byte(name), testFileMode)
+		assert.NoError(err)
+	}
+
+	configPath := filepath.Join(tmpdir, "configuration.toml")
+	err = ioutil.WriteFile(configPath, []byte(`
+	[hypervisor.qemu]
+	path = "`+filepath.Join(tmpdir, "hypervisor")+`"
+	kernel = "`+filepath.Join(tmpdir, "kernel")+`"
+	image = "`+filepath.Join(tmpdir, "image")+`"
+
+	[proxy.kata]
+	path = "`+filepath.Join(tmpdir, "proxy")+`"
+
+	[shim.kata]
+	path = "`+filepath.Join(tmpdir, "shim")+`"
+
+	[agent.kata]
+
+	[runtime]
+	enable_tracing = true
+	`), testFileMode)
+	assert.NoError(err)
+
+	savedConfigFile := os.Getenv("KATA_CONF_FILE")
+	savedTracer := opentracing.GlobalTracer()
+	defer func() {
+		os.Setenv("KATA_CONF_FILE", savedConfigFile)
+		opentracing.SetGlobalTracer(savedTracer)
+	}()
+
+	os.Setenv("KATA_CONF_FILE", configPath)
+	err = createTracer()
+	assert.NoError(err)
+	_, ok := opentracing.GlobalTracer().(*jaeger.Tracer)
+	assert.True(ok)
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package katautils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configDropInDir is the directory, next to a configuration file, holding
+// the drop-in files merged over it.
+const configDropInDir = "configuration.d"
+
+var (
+	configTableRegexp = regexp.MustCompile(`^\s*\[\s*([^\[\]]+)\]`)
+	configKeyRegexp   = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+|"[^"]*")\s*=`)
+)
+
+// ConfigSource is the file and line a configuration setting comes from.
+type ConfigSource struct {
+	File string
+	Line int
+}
+
+func (s ConfigSource) String() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// ConfigSources describes the files a configuration is loaded from, and
+// where each of its settings comes from.
+type ConfigSources struct {
+	// Files lists the configuration file and its drop-in files, in the
+	// order they are merged.
+	Files []string
+
+	// Keys maps the dotted name of each key and table, such as
+	// "hypervisor.qemu.path", to the last file setting it.
+	Keys map[string]ConfigSource
+}
+
+// GetConfigSources returns the files the configuration is loaded from, and
+// where each of its settings comes from. The configuration file is looked
+// for the way LoadConfiguration does.
+func GetConfigSources(configPath string) (ConfigSources, error) {
+	resolved, err := resolveConfigPath(configPath)
+	if err != nil {
+		return ConfigSources{}, err
+	}
+
+	_, sources, err := loadConfigFiles(resolved, configPath == "")
+	return sources, err
+}
+
+// resolveConfigPath returns the resolved path of the configuration file,
+// the first of the default configuration files found if configPath is empty.
+func resolveConfigPath(configPath string) (string, error) {
+	var err error
+	var resolved string
+
+	if configPath == "" {
+		resolved, err = getDefaultConfigFile()
+	} else {
+		resolved, err = ResolvePath(configPath)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("Cannot find usable config file (%v)", err)
+	}
+
+	return resolved, nil
+}
+
+// configDropInFiles returns the drop-in files of a configuration file, in
+// lexical order of their names. When the configuration file is one of the
+// default ones, the drop-in directory next to the system configuration file
+// is also read, its files replacing the ones with the same name.
+func configDropInFiles(configFile string, defaultLookup bool) ([]string, error) {
+	dirs := []string{filepath.Join(filepath.Dir(configFile), configDropInDir)}
+
+	if defaultLookup {
+		sysConfDir := filepath.Join(filepath.Dir(defaultSysConfRuntimeConfiguration), configDropInDir)
+		if sysConfDir != dirs[0] {
+			dirs = append(dirs, sysConfDir)
+		}
+	}
+
+	files := make(map[string]string)
+
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".toml" {
+				continue
+			}
+
+			files[entry.Name()] = filepath.Join(dir, entry.Name())
+		}
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var paths []string
+	for _, name := range names {
+		paths = append(paths, files[name])
+	}
+
+	return paths, nil
+}
+
+// loadConfigFiles reads a configuration file, and merges its drop-in files
+// over it. The merged configuration is returned in TOML form.
+func loadConfigFiles(configFile string, defaultLookup bool) (string, ConfigSources, error) {
+	sources := ConfigSources{
+		Keys: make(map[string]ConfigSource),
+	}
+
+	dropIns, err := configDropInFiles(configFile, defaultLookup)
+	if err != nil {
+		return "", sources, err
+	}
+
+	var configData string
+	merged := make(map[string]interface{})
+
+	for _, file := range append([]string{configFile}, dropIns...) {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", sources, err
+		}
+
+		if file == configFile {
+			configData = string(data)
+		}
+
+		var settings map[string]interface{}
+		if _, err := toml.Decode(string(data), &settings); err != nil {
+			return "", sources, fmt.Errorf("%v: %v", file, err)
+		}
+
+		mergeConfig(merged, settings)
+
+		sources.Files = append(sources.Files, file)
+		for key, line := range configKeyLines(string(data)) {
+			sources.Keys[key] = ConfigSource{
+				File: file,
+				Line: line,
+			}
+		}
+	}
+
+	if len(dropIns) == 0 {
+		return configData, sources, nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(merged); err != nil {
+		return "", sources, err
+	}
+
+	return buf.String(), sources, nil
+}
+
+// mergeConfig merges the settings of a drop-in file into a configuration.
+// Tables are merged key by key, any other value replaces the current one.
+func mergeConfig(config, settings map[string]interface{}) {
+	for k, v := range settings {
+		table, ok := v.(map[string]interface{})
+		current, isTable := config[k].(map[string]interface{})
+
+		if ok && isTable {
+			mergeConfig(current, table)
+			continue
+		}
+
+		config[k] = v
+	}
+}
+
+// configKeyLines returns the line each key and table of a TOML document is
+// defined on, by dotted name.
+func configKeyLines(data string) map[string]int {
+	lines := make(map[string]int)
+	table := ""
+	multiline := false
+
+	for i, line := range strings.Split(data, "\n") {
+		// Multi-line strings open or close on lines holding an odd
+		// number of delimiters.
+		delimiters := strings.Count(line, `"""`) + strings.Count(line, `'''`)
+
+		if multiline {
+			multiline = delimiters%2 == 0
+			continue
+		}
+
+		if m := configTableRegexp.FindStringSubmatch(line); m != nil {
+			var names []string
+			for _, name := range strings.Split(m[1], ".") {
+				names = append(names, strings.Trim(strings.TrimSpace(name), `"`))
+			}
+
+			table = strings.Join(names, ".")
+			lines[table] = i + 1
+			continue
+		}
+
+		if m := configKeyRegexp.FindStringSubmatch(line); m != nil {
+			key := strings.Trim(m[1], `"`)
+			if table != "" {
+				key = table + "." + key
+			}
+
+			lines[key] = i + 1
+			multiline = delimiters%2 == 1
+		}
+	}
+
+	return lines
+}
+
+// checkConfigKeys rejects the keys of the configuration that are not
+// known, and would otherwise be ignored. md is the metadata of the
+// configuration decoding, profilesMD the one of the hypervisor profiles.
+func checkConfigKeys(md, profilesMD toml.MetaData, sources ConfigSources) error {
+	undecodedProfileKeys := make(map[string]bool)
+	for _, key := range profilesMD.Undecoded() {
+		undecodedProfileKeys[key.String()] = true
+	}
+
+	var errs []string
+
+	for _, key := range md.Undecoded() {
+		// The hypervisor profiles are decoded separately.
+		if len(key) >= 3 && key[0] == "hypervisor" && md.Type(key[:3]...) == "Hash" {
+			if len(key) == 3 || !undecodedProfileKeys[key.String()] {
+				continue
+			}
+		}
+
+		msg := fmt.Sprintf("unknown configuration key %q", key.String())
+		if source, ok := sources.Keys[key.String()]; ok {
+			msg = fmt.Sprintf("%v: %s", source, msg)
+		}
+
+		errs = append(errs, msg)
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
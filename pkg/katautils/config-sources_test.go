@@ -0,0 +1,240 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package katautils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigKeyLines(t *testing.T) {
+	assert := assert.New(t)
+
+	data := `# comment
+[hypervisor.qemu]
+path = "/usr/bin/qemu"
+kernel_params = "foo=bar"
+
+  [ hypervisor . qemu . gpu ]
+  default_memory = 4096
+  guest_hook_path = """
+not_a_key = true
+"""
+
+[runtime]
+"enable_debug" = true
+`
+
+	assert.Equal(map[string]int{
+		"hypervisor.qemu":                     2,
+		"hypervisor.qemu.path":                3,
+		"hypervisor.qemu.kernel_params":       4,
+		"hypervisor.qemu.gpu":                 6,
+		"hypervisor.qemu.gpu.default_memory":  7,
+		"hypervisor.qemu.gpu.guest_hook_path": 8,
+		"runtime":                             12,
+		"runtime.enable_debug":                13,
+	}, configKeyLines(data))
+}
+
+func TestMergeConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := map[string]interface{}{
+		"hypervisor": map[string]interface{}{
+			"qemu": map[string]interface{}{
+				"path":   "/usr/bin/qemu",
+				"kernel": "/kernel",
+			},
+		},
+		"runtime": map[string]interface{}{
+			"enable_debug": false,
+		},
+	}
+
+	mergeConfig(config, map[string]interface{}{
+		"hypervisor": map[string]interface{}{
+			"qemu": map[string]interface{}{
+				"kernel": "/other-kernel",
+			},
+		},
+		"runtime": "replaced",
+	})
+
+	assert.Equal(map[string]interface{}{
+		"hypervisor": map[string]interface{}{
+			"qemu": map[string]interface{}{
+				"path":   "/usr/bin/qemu",
+				"kernel": "/other-kernel",
+			},
+		},
+		"runtime": "replaced",
+	}, config)
+}
+
+func TestConfigDropInFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir(testDir, "drop-in-")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "usr", "configuration.toml")
+	sysConfFile := filepath.Join(dir, "etc", "configuration.toml")
+
+	savedSysConf := defaultSysConfRuntimeConfiguration
+	defaultSysConfRuntimeConfiguration = sysConfFile
+	defer func() {
+		defaultSysConfRuntimeConfiguration = savedSysConf
+	}()
+
+	// no drop-in directory
+	files, err := configDropInFiles(configFile, true)
+	assert.NoError(err)
+	assert.Empty(files)
+
+	for _, file := range []string{
+		filepath.Join(dir, "usr", configDropInDir, "20-b.toml"),
+		filepath.Join(dir, "usr", configDropInDir, "10-a.toml"),
+		filepath.Join(dir, "usr", configDropInDir, "README"),
+		filepath.Join(dir, "etc", configDropInDir, "20-b.toml"),
+		filepath.Join(dir, "etc", configDropInDir, "15-c.toml"),
+	} {
+		err = os.MkdirAll(filepath.Dir(file), testDirMode)
+		assert.NoError(err)
+
+		err = WriteFile(file, "", testFileMode)
+		assert.NoError(err)
+	}
+
+	files, err = configDropInFiles(configFile, false)
+	assert.NoError(err)
+	assert.Equal([]string{
+		filepath.Join(dir, "usr", configDropInDir, "10-a.toml"),
+		filepath.Join(dir, "usr", configDropInDir, "20-b.toml"),
+	}, files)
+
+	// the system configuration drop-in files replace the ones with the
+	// same name
+	files, err = configDropInFiles(configFile, true)
+	assert.NoError(err)
+	assert.Equal([]string{
+		filepath.Join(dir, "usr", configDropInDir, "10-a.toml"),
+		filepath.Join(dir, "etc", configDropInDir, "15-c.toml"),
+		filepath.Join(dir, "etc", configDropInDir, "20-b.toml"),
+	}, files)
+}
+
+func TestConfigLoadConfigurationDropIn(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir(testDir, "drop-in-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	testConfig, err := createAllRuntimeConfigFiles(tmpdir, "qemu")
+	assert.NoError(err)
+
+	dropInDir := filepath.Join(tmpdir, configDropInDir)
+	err = os.Mkdir(dropInDir, testDirMode)
+	assert.NoError(err)
+
+	memory := filepath.Join(dropInDir, "10-memory.toml")
+	err = createConfig(memory, `
+[hypervisor.qemu]
+default_memory = 4096
+`)
+	assert.NoError(err)
+
+	debug := filepath.Join(dropInDir, "20-debug.toml")
+	err = createConfig(debug, `
+[hypervisor.qemu]
+default_memory = 8192
+enable_debug = true
+`)
+	assert.NoError(err)
+
+	_, config, err := LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.NoError(err)
+
+	assert.Equal(uint32(8192), config.HypervisorConfig.MemorySize)
+	assert.True(config.HypervisorConfig.Debug)
+	assert.Equal(testConfig.RuntimeConfig.HypervisorConfig.KernelPath, config.HypervisorConfig.KernelPath)
+
+	sources, err := GetConfigSources(testConfig.ConfigPath)
+	assert.NoError(err)
+
+	assert.Equal([]string{testConfig.ConfigPath, memory, debug}, sources.Files)
+	assert.Equal(ConfigSource{File: debug, Line: 3}, sources.Keys["hypervisor.qemu.default_memory"])
+	assert.Equal(ConfigSource{File: debug, Line: 4}, sources.Keys["hypervisor.qemu.enable_debug"])
+	assert.Equal(testConfig.ConfigPath, sources.Keys["hypervisor.qemu.path"].File)
+
+	// invalid drop-in file
+	err = createConfig(debug, "[hypervisor.qemu\n")
+	assert.NoError(err)
+
+	_, _, err = LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.Error(err)
+	assert.True(strings.HasPrefix(err.Error(), debug))
+}
+
+func TestConfigLoadConfigurationStrict(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir(testDir, "strict-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	testConfig, err := createAllRuntimeConfigFiles(tmpdir, "qemu")
+	assert.NoError(err)
+
+	dropInDir := filepath.Join(tmpdir, configDropInDir)
+	err = os.Mkdir(dropInDir, testDirMode)
+	assert.NoError(err)
+
+	dropIn := filepath.Join(dropInDir, "10-typo.toml")
+	err = createConfig(dropIn, `
+[hypervisor.qemu]
+default_memroy = 4096
+
+[hypervisor.qemu.gpu]
+machine_type = "q35"
+enable_debgu = true
+`)
+	assert.NoError(err)
+
+	// unknown keys are ignored by default
+	_, _, err = LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.NoError(err)
+
+	strict := filepath.Join(dropInDir, "00-strict.toml")
+	err = createConfig(strict, `
+[runtime]
+strict_config = true
+`)
+	assert.NoError(err)
+
+	_, _, err = LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.Error(err)
+	assert.Contains(err.Error(), dropIn+`:3: unknown configuration key "hypervisor.qemu.default_memroy"`)
+	assert.Contains(err.Error(), dropIn+`:7: unknown configuration key "hypervisor.qemu.gpu.enable_debgu"`)
+	assert.NotContains(err.Error(), "machine_type")
+
+	err = createConfig(dropIn, `
+[hypervisor.qemu.gpu]
+machine_type = "q35"
+`)
+	assert.NoError(err)
+
+	_, config, err := LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.NoError(err)
+	assert.Len(config.HypervisorProfiles, 2)
+}
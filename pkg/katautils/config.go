@@ -9,7 +9,7 @@ package katautils
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"net"
 	goruntime "runtime"
 	"strings"
 
@@ -32,17 +32,26 @@ var (
 
 	// if true, enable opentracing support.
 	tracing = false
+
+	// address of the Jaeger agent the traces are exported to, the
+	// default Jaeger agent address is used if empty.
+	tracingAgentAddress = ""
 )
 
 // The TOML configuration file contains a number of sections (or
 // tables). The names of these tables are in dotted ("nested table")
 // form:
 //
-//   [<component>.<type>]
+//	[<component>.<type>]
 //
 // The components are hypervisor, proxy, shim and agent. For example,
 //
-//   [proxy.kata]
+//	[proxy.kata]
+//
+// Hypervisor tables can be further nested to define named hypervisor
+// profiles:
+//
+//	[hypervisor.<type>.<name>]
 //
 // The currently supported types are listed below:
 const (
@@ -74,6 +83,10 @@ type tomlConfig struct {
 	Runtime    runtime
 	Factory    factory
 	Netmon     netmon
+
+	// the named hypervisor profiles, i.e. the [hypervisor.<type>.<name>]
+	// tables, keyed by "<type>.<name>".
+	hypervisorProfiles map[string]hypervisor
 }
 
 type factory struct {
@@ -81,37 +94,48 @@ type factory struct {
 }
 
 type hypervisor struct {
-	Path                    string `toml:"path"`
-	Kernel                  string `toml:"kernel"`
-	Initrd                  string `toml:"initrd"`
-	Image                   string `toml:"image"`
-	Firmware                string `toml:"firmware"`
-	MachineAccelerators     string `toml:"machine_accelerators"`
-	KernelParams            string `toml:"kernel_params"`
-	MachineType             string `toml:"machine_type"`
-	BlockDeviceDriver       string `toml:"block_device_driver"`
-	EntropySource           string `toml:"entropy_source"`
-	BlockDeviceCacheSet     bool   `toml:"block_device_cache_set"`
-	BlockDeviceCacheDirect  bool   `toml:"block_device_cache_direct"`
-	BlockDeviceCacheNoflush bool   `toml:"block_device_cache_noflush"`
-	NumVCPUs                int32  `toml:"default_vcpus"`
-	DefaultMaxVCPUs         uint32 `toml:"default_maxvcpus"`
-	MemorySize              uint32 `toml:"default_memory"`
-	MemSlots                uint32 `toml:"memory_slots"`
-	MemOffset               uint32 `toml:"memory_offset"`
-	DefaultBridges          uint32 `toml:"default_bridges"`
-	Msize9p                 uint32 `toml:"msize_9p"`
-	DisableBlockDeviceUse   bool   `toml:"disable_block_device_use"`
-	MemPrealloc             bool   `toml:"enable_mem_prealloc"`
-	HugePages               bool   `toml:"enable_hugepages"`
-	Swap                    bool   `toml:"enable_swap"`
-	Debug                   bool   `toml:"enable_debug"`
-	DisableNestingChecks    bool   `toml:"disable_nesting_checks"`
-	EnableIOThreads         bool   `toml:"enable_iothreads"`
-	UseVSock                bool   `toml:"use_vsock"`
-	HotplugVFIOOnRootBus    bool   `toml:"hotplug_vfio_on_root_bus"`
-	DisableVhostNet         bool   `toml:"disable_vhost_net"`
-	GuestHookPath           string `toml:"guest_hook_path"`
+	Path                    string   `toml:"path"`
+	JailerPath              string   `toml:"jailer_path"`
+	JailerNUMANode          int      `toml:"jailer_numa_node"`
+	UnprivilegedUIDBase     uint32   `toml:"unprivileged_uid_base"`
+	UnprivilegedUIDCount    uint32   `toml:"unprivileged_uid_count"`
+	Kernel                  string   `toml:"kernel"`
+	Initrd                  string   `toml:"initrd"`
+	Image                   string   `toml:"image"`
+	Firmware                string   `toml:"firmware"`
+	MachineAccelerators     string   `toml:"machine_accelerators"`
+	KernelParams            string   `toml:"kernel_params"`
+	MachineType             string   `toml:"machine_type"`
+	BlockDeviceDriver       string   `toml:"block_device_driver"`
+	EntropySource           string   `toml:"entropy_source"`
+	BlockDeviceCacheSet     bool     `toml:"block_device_cache_set"`
+	BlockDeviceCacheDirect  bool     `toml:"block_device_cache_direct"`
+	BlockDeviceCacheNoflush bool     `toml:"block_device_cache_noflush"`
+	NumVCPUs                int32    `toml:"default_vcpus"`
+	DefaultMaxVCPUs         uint32   `toml:"default_maxvcpus"`
+	MemorySize              uint32   `toml:"default_memory"`
+	MemSlots                uint32   `toml:"memory_slots"`
+	MemOffset               uint32   `toml:"memory_offset"`
+	DefaultBridges          uint32   `toml:"default_bridges"`
+	Msize9p                 uint32   `toml:"msize_9p"`
+	DisableBlockDeviceUse   bool     `toml:"disable_block_device_use"`
+	MemPrealloc             bool     `toml:"enable_mem_prealloc"`
+	HugePages               bool     `toml:"enable_hugepages"`
+	Swap                    bool     `toml:"enable_swap"`
+	Debug                   bool     `toml:"enable_debug"`
+	DisableNestingChecks    bool     `toml:"disable_nesting_checks"`
+	EnableIOThreads         bool     `toml:"enable_iothreads"`
+	PinVCPUs                bool     `toml:"enable_vcpus_pinning"`
+	UseVSock                bool     `toml:"use_vsock"`
+	HotplugVFIOOnRootBus    bool     `toml:"hotplug_vfio_on_root_bus"`
+	ColdPlugVFIO            bool     `toml:"cold_plug_vfio"`
+	DisableVhostNet         bool     `toml:"disable_vhost_net"`
+	GuestHookPath           string   `toml:"guest_hook_path"`
+	AssetTrustedKeys        []string `toml:"asset_trusted_keys"`
+	AssetSignaturesDir      string   `toml:"asset_signatures_dir"`
+	SeccompSandbox          string   `toml:"seccompsandbox"`
+	RunAsUnprivilegedUser   bool     `toml:"run_as_unprivileged_user"`
+	EnableChroot            bool     `toml:"enable_chroot"`
 }
 
 type proxy struct {
@@ -120,11 +144,17 @@ type proxy struct {
 }
 
 type runtime struct {
-	Debug               bool   `toml:"enable_debug"`
-	Tracing             bool   `toml:"enable_tracing"`
-	DisableNewNetNs     bool   `toml:"disable_new_netns"`
-	DisableGuestSeccomp bool   `toml:"disable_guest_seccomp"`
-	InterNetworkModel   string `toml:"internetworking_model"`
+	Debug                bool     `toml:"enable_debug"`
+	Tracing              bool     `toml:"enable_tracing"`
+	TracingAgentAddress  string   `toml:"tracing_agent_address"`
+	DisableNewNetNs      bool     `toml:"disable_new_netns"`
+	DisableGuestSeccomp  bool     `toml:"disable_guest_seccomp"`
+	InterNetworkModel    string   `toml:"internetworking_model"`
+	HypervisorProfile    string   `toml:"default_hypervisor_profile"`
+	StrictConfig         bool     `toml:"strict_config"`
+	AllowedKernelParams  []string `toml:"allowed_kernel_params"`
+	AllowedKernelModules []string `toml:"allowed_kernel_modules"`
+	ShimGC               bool     `toml:"enable_shim_gc"`
 }
 
 type shim struct {
@@ -134,6 +164,7 @@ type shim struct {
 }
 
 type agent struct {
+	KernelModules []string `toml:"kernel_modules"`
 }
 
 type netmon struct {
@@ -152,6 +183,14 @@ func (h hypervisor) path() (string, error) {
 	return ResolvePath(p)
 }
 
+func (h hypervisor) jailerPath() (string, error) {
+	if h.JailerPath == "" {
+		return "", nil
+	}
+
+	return ResolvePath(h.JailerPath)
+}
+
 func (h hypervisor) kernel() (string, error) {
 	p := h.Kernel
 
@@ -339,6 +378,29 @@ func (h hypervisor) guestHookPath() string {
 	return h.GuestHookPath
 }
 
+func (h hypervisor) assetTrustedKeys() ([]string, error) {
+	var keys []string
+
+	for _, k := range h.AssetTrustedKeys {
+		path, err := ResolvePath(k)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid asset trusted key: %v", err)
+		}
+
+		keys = append(keys, path)
+	}
+
+	return keys, nil
+}
+
+func (h hypervisor) assetSignaturesDir() (string, error) {
+	if h.AssetSignaturesDir == "" {
+		return "", nil
+	}
+
+	return ResolvePath(h.AssetSignaturesDir)
+}
+
 func (h hypervisor) getInitrdAndImage() (initrd string, image string, err error) {
 	initrd, errInitrd := h.initrd()
 
@@ -407,6 +469,11 @@ func newFirecrackerHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		return vc.HypervisorConfig{}, err
 	}
 
+	jailer, err := h.jailerPath()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
 	kernel, err := h.kernel()
 	if err != nil {
 		return vc.HypervisorConfig{}, err
@@ -433,28 +500,44 @@ func newFirecrackerHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		return vc.HypervisorConfig{}, errors.New("No vsock support, firecracker cannot be used")
 	}
 
+	trustedKeys, err := h.assetTrustedKeys()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
+	signaturesDir, err := h.assetSignaturesDir()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
 	return vc.HypervisorConfig{
-		HypervisorPath:        hypervisor,
-		KernelPath:            kernel,
-		InitrdPath:            initrd,
-		ImagePath:             image,
-		FirmwarePath:          firmware,
-		KernelParams:          vc.DeserializeParams(strings.Fields(kernelParams)),
-		NumVCPUs:              h.defaultVCPUs(),
-		DefaultMaxVCPUs:       h.defaultMaxVCPUs(),
-		MemorySize:            h.defaultMemSz(),
-		MemSlots:              h.defaultMemSlots(),
-		EntropySource:         h.GetEntropySource(),
-		DefaultBridges:        h.defaultBridges(),
-		DisableBlockDeviceUse: h.DisableBlockDeviceUse,
-		HugePages:             h.HugePages,
-		Mlock:                 !h.Swap,
-		Debug:                 h.Debug,
-		DisableNestingChecks:  h.DisableNestingChecks,
-		BlockDeviceDriver:     blockDriver,
-		EnableIOThreads:       h.EnableIOThreads,
-		UseVSock:              true,
-		GuestHookPath:         h.guestHookPath(),
+		HypervisorPath:          hypervisor,
+		JailerPath:              jailer,
+		JailerNUMANode:          h.JailerNUMANode,
+		UnprivilegedUserIDBase:  h.UnprivilegedUIDBase,
+		UnprivilegedUserIDCount: h.UnprivilegedUIDCount,
+		KernelPath:              kernel,
+		InitrdPath:              initrd,
+		ImagePath:               image,
+		FirmwarePath:            firmware,
+		KernelParams:            vc.DeserializeParams(strings.Fields(kernelParams)),
+		NumVCPUs:                h.defaultVCPUs(),
+		DefaultMaxVCPUs:         h.defaultMaxVCPUs(),
+		MemorySize:              h.defaultMemSz(),
+		MemSlots:                h.defaultMemSlots(),
+		EntropySource:           h.GetEntropySource(),
+		DefaultBridges:          h.defaultBridges(),
+		DisableBlockDeviceUse:   h.DisableBlockDeviceUse,
+		HugePages:               h.HugePages,
+		Mlock:                   !h.Swap,
+		Debug:                   h.Debug,
+		DisableNestingChecks:    h.DisableNestingChecks,
+		BlockDeviceDriver:       blockDriver,
+		EnableIOThreads:         h.EnableIOThreads,
+		UseVSock:                true,
+		GuestHookPath:           h.guestHookPath(),
+		AssetTrustedKeys:        trustedKeys,
+		AssetSignaturesDir:      signaturesDir,
 	}, nil
 }
 
@@ -508,6 +591,16 @@ func newQemuHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		}
 	}
 
+	trustedKeys, err := h.assetTrustedKeys()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
+	signaturesDir, err := h.assetSignaturesDir()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
 	return vc.HypervisorConfig{
 		HypervisorPath:          hypervisor,
 		KernelPath:              kernel,
@@ -535,11 +628,20 @@ func newQemuHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		BlockDeviceCacheDirect:  h.BlockDeviceCacheDirect,
 		BlockDeviceCacheNoflush: h.BlockDeviceCacheNoflush,
 		EnableIOThreads:         h.EnableIOThreads,
+		PinVCPUs:                h.PinVCPUs,
 		Msize9p:                 h.msize9p(),
 		UseVSock:                useVSock,
 		HotplugVFIOOnRootBus:    h.HotplugVFIOOnRootBus,
+		ColdPlugVFIO:            h.ColdPlugVFIO,
 		DisableVhostNet:         h.DisableVhostNet,
 		GuestHookPath:           h.guestHookPath(),
+		AssetTrustedKeys:        trustedKeys,
+		AssetSignaturesDir:      signaturesDir,
+		SeccompSandbox:          h.SeccompSandbox,
+		RunAsUnprivilegedUser:   h.RunAsUnprivilegedUser,
+		EnableChroot:            h.EnableChroot,
+		UnprivilegedUserIDBase:  h.UnprivilegedUIDBase,
+		UnprivilegedUserIDCount: h.UnprivilegedUIDCount,
 	}, nil
 }
 
@@ -560,26 +662,104 @@ func newShimConfig(s shim) (vc.ShimConfig, error) {
 	}, nil
 }
 
+func newHypervisorProfile(hypervisorType string, h hypervisor) (oci.HypervisorProfile, error) {
+	var err error
+	var profile oci.HypervisorProfile
+
+	switch hypervisorType {
+	case firecrackerHypervisorTableType:
+		profile.HypervisorType = vc.FirecrackerHypervisor
+		profile.HypervisorConfig, err = newFirecrackerHypervisorConfig(h)
+	case qemuHypervisorTableType:
+		profile.HypervisorType = vc.QemuHypervisor
+		profile.HypervisorConfig, err = newQemuHypervisorConfig(h)
+	default:
+		err = fmt.Errorf("Unknown hypervisor type %q", hypervisorType)
+	}
+
+	return profile, err
+}
+
+// decodeHypervisorProfiles decodes the [hypervisor.<type>.<name>] tables of
+// the configuration. A profile inherits the settings of its
+// [hypervisor.<type>] table, and overrides some of them.
+func decodeHypervisorProfiles(configData string, tomlConf *tomlConfig) (toml.MetaData, error) {
+	var profiles struct {
+		Hypervisor map[string]map[string]toml.Primitive
+	}
+
+	md, err := toml.Decode(configData, &profiles)
+	if err != nil {
+		return md, err
+	}
+
+	tomlConf.hypervisorProfiles = make(map[string]hypervisor)
+
+	for k, tables := range profiles.Hypervisor {
+		for name, table := range tables {
+			if md.Type("hypervisor", k, name) != "Hash" {
+				continue
+			}
+
+			h := tomlConf.Hypervisor[k]
+			if err := md.PrimitiveDecode(table, &h); err != nil {
+				return md, err
+			}
+
+			tomlConf.hypervisorProfiles[k+"."+name] = h
+		}
+	}
+
+	return md, nil
+}
+
 func updateRuntimeConfigHypervisor(configPath string, tomlConf tomlConfig, config *oci.RuntimeConfig) error {
-	for k, hypervisor := range tomlConf.Hypervisor {
-		var err error
-		var hConfig vc.HypervisorConfig
+	tables := make(map[string]hypervisor)
+	for k, h := range tomlConf.Hypervisor {
+		tables[k] = h
+	}
+	for name, h := range tomlConf.hypervisorProfiles {
+		tables[name] = h
+	}
 
-		switch k {
-		case firecrackerHypervisorTableType:
-			config.HypervisorType = vc.FirecrackerHypervisor
-			hConfig, err = newFirecrackerHypervisorConfig(hypervisor)
-		case qemuHypervisorTableType:
-			config.HypervisorType = vc.QemuHypervisor
-			hConfig, err = newQemuHypervisorConfig(hypervisor)
+	if len(tables) == 0 {
+		return nil
+	}
+
+	defaultProfile := tomlConf.Runtime.HypervisorProfile
+	if defaultProfile == "" {
+		if len(tomlConf.Hypervisor) != 1 {
+			return fmt.Errorf("%v: default_hypervisor_profile must be set when several hypervisors are configured", configPath)
 		}
 
+		for k := range tomlConf.Hypervisor {
+			defaultProfile = k
+		}
+	}
+
+	if _, ok := tables[defaultProfile]; !ok {
+		return fmt.Errorf("%v: Unknown default hypervisor profile %q", configPath, defaultProfile)
+	}
+
+	profiles := make(map[string]oci.HypervisorProfile)
+
+	for name, h := range tables {
+		profile, err := newHypervisorProfile(strings.SplitN(name, ".", 2)[0], h)
 		if err != nil {
-			return fmt.Errorf("%v: %v", configPath, err)
+			return fmt.Errorf("%v: hypervisor profile %q: %v", configPath, name, err)
 		}
-		config.HypervisorConfig = hConfig
+
+		profiles[name] = profile
 	}
 
+	profile := profiles[defaultProfile]
+
+	config.HypervisorType = profile.HypervisorType
+	config.HypervisorConfig = profile.HypervisorConfig
+	config.HypervisorProfile = defaultProfile
+	config.DefaultHypervisorProfile = defaultProfile
+	config.HypervisorProfiles = profiles
+
 	return nil
 }
 
@@ -610,14 +790,15 @@ func updateRuntimeConfigAgent(configPath string, tomlConf tomlConfig, config *oc
 	if builtIn {
 		config.AgentType = vc.KataContainersAgent
 		config.AgentConfig = vc.KataAgentConfig{
-			LongLiveConn: true,
-			UseVSock:     config.HypervisorConfig.UseVSock,
+			LongLiveConn:  true,
+			UseVSock:      config.HypervisorConfig.UseVSock,
+			KernelModules: tomlConf.Agent[kataAgentTableType].KernelModules,
 		}
 
 		return nil
 	}
 
-	for k := range tomlConf.Agent {
+	for k, agent := range tomlConf.Agent {
 		switch k {
 		case hyperstartAgentTableType:
 			config.AgentType = vc.HyperstartAgent
@@ -626,7 +807,8 @@ func updateRuntimeConfigAgent(configPath string, tomlConf tomlConfig, config *oc
 		case kataAgentTableType:
 			config.AgentType = vc.KataContainersAgent
 			config.AgentConfig = vc.KataAgentConfig{
-				UseVSock: config.HypervisorConfig.UseVSock,
+				UseVSock:      config.HypervisorConfig.UseVSock,
+				KernelModules: agent.KernelModules,
 			}
 		}
 	}
@@ -724,6 +906,18 @@ func updateRuntimeConfig(configPath string, tomlConf tomlConfig, config *oci.Run
 		Enable: tomlConf.Netmon.enable(),
 	}
 
+	for name, profile := range config.HypervisorProfiles {
+		profileConfig := *config
+		profileConfig.HypervisorConfig = profile.HypervisorConfig
+
+		if err := SetKernelParams(&profileConfig); err != nil {
+			return err
+		}
+
+		profile.HypervisorConfig = profileConfig.HypervisorConfig
+		config.HypervisorProfiles[name] = profile
+	}
+
 	err = SetKernelParams(config)
 	if err != nil {
 		return err
@@ -783,7 +977,9 @@ func initConfig() (config oci.RuntimeConfig, err error) {
 }
 
 // LoadConfiguration loads the configuration file and converts it into a
-// runtime configuration.
+// runtime configuration. The drop-in files of the configuration.d
+// directory next to the configuration file are merged over it, in lexical
+// order.
 //
 // If ignoreLogging is true, the system logger will not be initialised nor
 // will this function make any log calls.
@@ -791,34 +987,38 @@ func initConfig() (config oci.RuntimeConfig, err error) {
 // All paths are resolved fully meaning if this function does not return an
 // error, all paths are valid at the time of the call.
 func LoadConfiguration(configPath string, ignoreLogging, builtIn bool) (resolvedConfigPath string, config oci.RuntimeConfig, err error) {
-	var resolved string
-
 	config, err = initConfig()
 	if err != nil {
 		return "", oci.RuntimeConfig{}, err
 	}
 
-	if configPath == "" {
-		resolved, err = getDefaultConfigFile()
-	} else {
-		resolved, err = ResolvePath(configPath)
+	resolved, err := resolveConfigPath(configPath)
+	if err != nil {
+		return "", config, err
 	}
 
+	configData, sources, err := loadConfigFiles(resolved, configPath == "")
 	if err != nil {
-		return "", config, fmt.Errorf("Cannot find usable config file (%v)", err)
+		return "", config, err
 	}
 
-	configData, err := ioutil.ReadFile(resolved)
+	var tomlConf tomlConfig
+	md, err := toml.Decode(configData, &tomlConf)
 	if err != nil {
 		return "", config, err
 	}
 
-	var tomlConf tomlConfig
-	_, err = toml.Decode(string(configData), &tomlConf)
+	profilesMD, err := decodeHypervisorProfiles(configData, &tomlConf)
 	if err != nil {
 		return "", config, err
 	}
 
+	if tomlConf.Runtime.StrictConfig {
+		if err := checkConfigKeys(md, profilesMD, sources); err != nil {
+			return "", config, err
+		}
+	}
+
 	config.Debug = tomlConf.Runtime.Debug
 	if !tomlConf.Runtime.Debug {
 		// If debug is not required, switch back to the original
@@ -829,6 +1029,13 @@ func LoadConfiguration(configPath string, ignoreLogging, builtIn bool) (resolved
 	config.Trace = tomlConf.Runtime.Tracing
 	tracing = config.Trace
 
+	if tomlConf.Runtime.TracingAgentAddress != "" {
+		if _, _, err = net.SplitHostPort(tomlConf.Runtime.TracingAgentAddress); err != nil {
+			return "", config, fmt.Errorf("Invalid tracing agent address %q: %v", tomlConf.Runtime.TracingAgentAddress, err)
+		}
+	}
+	tracingAgentAddress = tomlConf.Runtime.TracingAgentAddress
+
 	if tomlConf.Runtime.InterNetworkModel != "" {
 		err = config.InterNetworkModel.SetModel(tomlConf.Runtime.InterNetworkModel)
 		if err != nil {
@@ -854,6 +1061,9 @@ func LoadConfiguration(configPath string, ignoreLogging, builtIn bool) (resolved
 	}
 
 	config.DisableGuestSeccomp = tomlConf.Runtime.DisableGuestSeccomp
+	config.AllowedKernelParams = tomlConf.Runtime.AllowedKernelParams
+	config.AllowedKernelModules = tomlConf.Runtime.AllowedKernelModules
+	config.ShimGC = tomlConf.Runtime.ShimGC
 
 	// use no proxy if HypervisorConfig.UseVSock is true
 	if config.HypervisorConfig.UseVSock {
@@ -885,6 +1095,30 @@ func checkConfig(config oci.RuntimeConfig) error {
 		return err
 	}
 
+	return checkHypervisorProfiles(config)
+}
+
+// checkHypervisorProfiles performs the hypervisor and factory checks on
+// every hypervisor profile. All the profiles must agree on the use of VSOCK,
+// which the agent and proxy configurations depend on.
+func checkHypervisorProfiles(config oci.RuntimeConfig) error {
+	for name, profile := range config.HypervisorProfiles {
+		if profile.HypervisorConfig.UseVSock != config.HypervisorConfig.UseVSock {
+			return fmt.Errorf("hypervisor profile %q: use_vsock differs from the default hypervisor profile", name)
+		}
+
+		profileConfig := config
+		profileConfig.HypervisorConfig = profile.HypervisorConfig
+
+		if err := checkHypervisorConfig(profileConfig.HypervisorConfig); err != nil {
+			return fmt.Errorf("hypervisor profile %q: %v", name, err)
+		}
+
+		if err := checkFactoryConfig(profileConfig); err != nil {
+			return fmt.Errorf("hypervisor profile %q: %v", name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -206,6 +206,15 @@ func createAllRuntimeConfigFiles(dir, hypervisor string) (config testRuntimeConf
 		return config, err
 	}
 
+	runtimeConfig.HypervisorProfile = hypervisor
+	runtimeConfig.DefaultHypervisorProfile = hypervisor
+	runtimeConfig.HypervisorProfiles = map[string]oci.HypervisorProfile{
+		hypervisor: {
+			HypervisorType:   runtimeConfig.HypervisorType,
+			HypervisorConfig: runtimeConfig.HypervisorConfig,
+		},
+	}
+
 	config = testRuntimeConfig{
 		RuntimeConfig:     runtimeConfig,
 		RuntimeConfigFile: configPath,
@@ -498,6 +507,136 @@ func TestConfigLoadConfigurationFailTOMLConfigFileDuplicatedData(t *testing.T) {
 		})
 }
 
+func TestConfigLoadConfigurationHypervisorProfiles(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir(testDir, "hypervisor-profiles-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	testConfig, err := createAllRuntimeConfigFiles(tmpdir, "qemu")
+	assert.NoError(err)
+
+	data, err := ioutil.ReadFile(testConfig.ConfigPath)
+	assert.NoError(err)
+
+	profiles := `
+	[hypervisor.qemu.gpu]
+	machine_type = "q35"
+	default_memory = 4096
+	kernel_params = "gpu=on"
+
+	[hypervisor.qemu.small]
+	default_vcpus = 1
+`
+	err = createConfig(testConfig.ConfigPath, string(data)+profiles)
+	assert.NoError(err)
+
+	_, config, err := LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.NoError(err)
+
+	assert.Equal("qemu", config.HypervisorProfile)
+	assert.Equal("qemu", config.DefaultHypervisorProfile)
+	assert.Len(config.HypervisorProfiles, 3)
+	assert.Equal(testConfig.RuntimeConfig.HypervisorConfig, config.HypervisorConfig)
+
+	// a profile inherits the settings of its hypervisor table
+	gpu := config.HypervisorProfiles["qemu.gpu"]
+	assert.Equal(vc.QemuHypervisor, gpu.HypervisorType)
+	assert.Equal("q35", gpu.HypervisorConfig.HypervisorMachineType)
+	assert.Equal(uint32(4096), gpu.HypervisorConfig.MemorySize)
+	assert.Equal(config.HypervisorConfig.KernelPath, gpu.HypervisorConfig.KernelPath)
+	assert.Contains(gpu.HypervisorConfig.KernelParams, vc.Param{Key: "gpu", Value: "on"})
+	assert.NotContains(gpu.HypervisorConfig.KernelParams, vc.Param{Key: "foo", Value: "bar"})
+
+	small := config.HypervisorProfiles["qemu.small"]
+	assert.Equal(uint32(1), small.HypervisorConfig.NumVCPUs)
+	assert.Equal(config.HypervisorConfig.MemorySize, small.HypervisorConfig.MemorySize)
+	assert.Equal(config.HypervisorConfig.KernelParams, small.HypervisorConfig.KernelParams)
+
+	// select a non default profile as the default one
+	err = createConfig(testConfig.ConfigPath, string(data)+profiles+`
+	default_hypervisor_profile = "qemu.gpu"
+`)
+	assert.NoError(err)
+
+	// default_hypervisor_profile has to be part of the [runtime] table
+	_, config, err = LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.NoError(err)
+	assert.Equal("qemu", config.DefaultHypervisorProfile)
+
+	err = createConfig(testConfig.ConfigPath, strings.Replace(string(data), "[runtime]", "[runtime]\n\tdefault_hypervisor_profile = \"qemu.gpu\"", 1)+profiles)
+	assert.NoError(err)
+
+	_, config, err = LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.NoError(err)
+	assert.Equal("qemu.gpu", config.HypervisorProfile)
+	assert.Equal("qemu.gpu", config.DefaultHypervisorProfile)
+	assert.Equal("q35", config.HypervisorConfig.HypervisorMachineType)
+
+	// unknown default profile
+	err = createConfig(testConfig.ConfigPath, strings.Replace(string(data), "[runtime]", "[runtime]\n\tdefault_hypervisor_profile = \"qemu.foo\"", 1)+profiles)
+	assert.NoError(err)
+
+	_, _, err = LoadConfiguration(testConfig.ConfigPath, true, false)
+	assert.Error(err)
+
+}
+
+func TestUpdateRuntimeConfigurationHypervisorProfiles(t *testing.T) {
+	assert := assert.New(t)
+
+	qemu := hypervisor{
+		Path:   "/",
+		Kernel: "/",
+		Image:  "/",
+	}
+
+	// several hypervisors and no default profile
+	tomlConf := tomlConfig{
+		Hypervisor: map[string]hypervisor{
+			qemuHypervisorTableType:        qemu,
+			firecrackerHypervisorTableType: qemu,
+		},
+	}
+
+	config := oci.RuntimeConfig{}
+	err := updateRuntimeConfigHypervisor("", tomlConf, &config)
+	assert.Error(err)
+
+	small := qemu
+	small.NumVCPUs = 1
+
+	tomlConf = tomlConfig{
+		Hypervisor: map[string]hypervisor{
+			qemuHypervisorTableType: qemu,
+		},
+		hypervisorProfiles: map[string]hypervisor{
+			"qemu.small": small,
+		},
+	}
+	tomlConf.Runtime.HypervisorProfile = "qemu.small"
+
+	err = updateRuntimeConfigHypervisor("", tomlConf, &config)
+	assert.NoError(err)
+	assert.Equal(vc.QemuHypervisor, config.HypervisorType)
+	assert.Equal("qemu.small", config.HypervisorProfile)
+	assert.Equal("qemu.small", config.DefaultHypervisorProfile)
+	assert.Equal(uint32(1), config.HypervisorConfig.NumVCPUs)
+	assert.Len(config.HypervisorProfiles, 2)
+
+	// unknown default profile
+	tomlConf.Runtime.HypervisorProfile = "qemu.foo"
+	err = updateRuntimeConfigHypervisor("", tomlConf, &config)
+	assert.Error(err)
+
+	// unknown hypervisor type
+	tomlConf.Runtime.HypervisorProfile = "qemu.small"
+	tomlConf.Hypervisor["foo"] = qemu
+	err = updateRuntimeConfigHypervisor("", tomlConf, &config)
+	assert.Error(err)
+}
+
 func TestMinimalRuntimeConfig(t *testing.T) {
 	dir, err := ioutil.TempDir(testDir, "minimal-runtime-config-")
 	if err != nil {
@@ -753,6 +892,7 @@ func TestNewQemuHypervisorConfig(t *testing.T) {
 	disableBlock := true
 	enableIOThreads := true
 	hotplugVFIOOnRootBus := true
+	pinVCPUs := true
 	orgVSockDevicePath := utils.VSockDevicePath
 	orgVHostVSockDevicePath := utils.VHostVSockDevicePath
 	defer func() {
@@ -770,6 +910,7 @@ func TestNewQemuHypervisorConfig(t *testing.T) {
 		DisableBlockDeviceUse: disableBlock,
 		EnableIOThreads:       enableIOThreads,
 		HotplugVFIOOnRootBus:  hotplugVFIOOnRootBus,
+		PinVCPUs:              pinVCPUs,
 		UseVSock:              true,
 	}
 
@@ -828,6 +969,10 @@ func TestNewQemuHypervisorConfig(t *testing.T) {
 	if config.HotplugVFIOOnRootBus != hotplugVFIOOnRootBus {
 		t.Errorf("Expected value for HotplugVFIOOnRootBus %v, got %v", hotplugVFIOOnRootBus, config.HotplugVFIOOnRootBus)
 	}
+
+	if config.PinVCPUs != pinVCPUs {
+		t.Errorf("Expected value for PinVCPUs %v, got %v", pinVCPUs, config.PinVCPUs)
+	}
 }
 
 func TestNewQemuHypervisorConfigImageAndInitrd(t *testing.T) {
@@ -1109,6 +1254,73 @@ func TestHypervisorDefaultsGuestHookPath(t *testing.T) {
 	assert.Equal(guestHookPath, testGuestHookPath, "custom guest hook path wrong")
 }
 
+func TestHypervisorJailerPath(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir(testDir, "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	h := hypervisor{}
+	jailer, err := h.jailerPath()
+	assert.NoError(err)
+	assert.Empty(jailer)
+
+	h.JailerPath = filepath.Join(tmpdir, "jailer")
+	_, err = h.jailerPath()
+	assert.Error(err)
+
+	err = createEmptyFile(h.JailerPath)
+	assert.NoError(err)
+
+	jailer, err = h.jailerPath()
+	assert.NoError(err)
+	assert.Equal(h.JailerPath, jailer)
+}
+
+func TestHypervisorAssetVerification(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir(testDir, "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	h := hypervisor{}
+	keys, err := h.assetTrustedKeys()
+	assert.NoError(err)
+	assert.Empty(keys)
+
+	dir, err := h.assetSignaturesDir()
+	assert.NoError(err)
+	assert.Empty(dir)
+
+	keyPath := filepath.Join(tmpdir, "key.pem")
+	h = hypervisor{
+		AssetTrustedKeys:   []string{keyPath},
+		AssetSignaturesDir: filepath.Join(tmpdir, "signatures"),
+	}
+
+	_, err = h.assetTrustedKeys()
+	assert.Error(err)
+
+	_, err = h.assetSignaturesDir()
+	assert.Error(err)
+
+	err = createEmptyFile(keyPath)
+	assert.NoError(err)
+
+	err = os.Mkdir(h.AssetSignaturesDir, testDirMode)
+	assert.NoError(err)
+
+	keys, err = h.assetTrustedKeys()
+	assert.NoError(err)
+	assert.Equal([]string{keyPath}, keys)
+
+	dir, err = h.assetSignaturesDir()
+	assert.NoError(err)
+	assert.Equal(h.AssetSignaturesDir, dir)
+}
+
 func TestProxyDefaults(t *testing.T) {
 	p := proxy{}
 
@@ -1343,6 +1555,28 @@ func TestUpdateRuntimeConfiguration(t *testing.T) {
 	assert.Equal(config.AgentConfig, vc.KataAgentConfig{})
 }
 
+func TestUpdateRuntimeConfigurationAgentKernelModules(t *testing.T) {
+	assert := assert.New(t)
+
+	modules := []string{"e1000e InterruptThrottleRate=3000,3000,3000", "i915"}
+
+	tomlConf := tomlConfig{
+		Agent: map[string]agent{
+			kataAgentTableType: {
+				KernelModules: modules,
+			},
+		},
+	}
+
+	for _, builtIn := range []bool{false, true} {
+		config := oci.RuntimeConfig{}
+
+		err := updateRuntimeConfigAgent("", tomlConf, &config, builtIn)
+		assert.NoError(err)
+		assert.Equal(modules, config.AgentConfig.(vc.KataAgentConfig).KernelModules)
+	}
+}
+
 func TestUpdateRuntimeConfigurationVMConfig(t *testing.T) {
 	assert := assert.New(t)
 
@@ -1579,6 +1813,39 @@ func TestCheckFactoryConfig(t *testing.T) {
 	}
 }
 
+func TestCheckHypervisorProfiles(t *testing.T) {
+	assert := assert.New(t)
+
+	hypervisorConfig := vc.HypervisorConfig{MemorySize: defaultMemSize}
+
+	config := oci.RuntimeConfig{
+		HypervisorConfig: hypervisorConfig,
+		HypervisorProfiles: map[string]oci.HypervisorProfile{
+			"qemu":     {HypervisorConfig: hypervisorConfig},
+			"qemu.gpu": {HypervisorConfig: hypervisorConfig},
+		},
+	}
+
+	err := checkHypervisorProfiles(config)
+	assert.NoError(err)
+
+	// the profiles have to agree on the use of VSOCK
+	hypervisorConfig.UseVSock = true
+	config.HypervisorProfiles["qemu.gpu"] = oci.HypervisorProfile{HypervisorConfig: hypervisorConfig}
+	err = checkHypervisorProfiles(config)
+	assert.Error(err)
+
+	// every profile is checked
+	config.HypervisorProfiles["qemu.gpu"] = oci.HypervisorProfile{}
+	err = checkHypervisorProfiles(config)
+	assert.Error(err)
+
+	config.FactoryConfig.Template = true
+	config.HypervisorProfiles["qemu.gpu"] = oci.HypervisorProfile{HypervisorConfig: config.HypervisorConfig}
+	err = checkHypervisorProfiles(config)
+	assert.Error(err)
+}
+
 func TestCheckNetNsConfigShimTrace(t *testing.T) {
 	assert := assert.New(t)
 
@@ -51,14 +51,12 @@ func needSystemd(config vc.HypervisorConfig) bool {
 	return config.ImagePath != ""
 }
 
-// HandleFactory  set the factory
-func HandleFactory(ctx context.Context, vci vc.VC, runtimeConfig *oci.RuntimeConfig) {
-	if !runtimeConfig.FactoryConfig.Template {
-		return
-	}
-
+// GetFactoryConfig returns the VM factory configuration of the selected
+// hypervisor profile. Only the factories of the non default profiles are
+// keyed by the profile name.
+func GetFactoryConfig(runtimeConfig oci.RuntimeConfig) vf.Config {
 	factoryConfig := vf.Config{
-		Template: true,
+		Template: runtimeConfig.FactoryConfig.Template,
 		VMConfig: vc.VMConfig{
 			HypervisorType:   runtimeConfig.HypervisorType,
 			HypervisorConfig: runtimeConfig.HypervisorConfig,
@@ -67,6 +65,21 @@ func HandleFactory(ctx context.Context, vci vc.VC, runtimeConfig *oci.RuntimeCon
 		},
 	}
 
+	if runtimeConfig.HypervisorProfile != runtimeConfig.DefaultHypervisorProfile {
+		factoryConfig.Profile = runtimeConfig.HypervisorProfile
+	}
+
+	return factoryConfig
+}
+
+// HandleFactory  set the factory
+func HandleFactory(ctx context.Context, vci vc.VC, runtimeConfig *oci.RuntimeConfig) {
+	if !runtimeConfig.FactoryConfig.Template {
+		return
+	}
+
+	factoryConfig := GetFactoryConfig(*runtimeConfig)
+
 	kataUtilsLogger.WithField("factory", factoryConfig).Info("load vm factory")
 
 	f, err := vf.NewFactory(ctx, factoryConfig, true)
@@ -85,8 +98,9 @@ func HandleFactory(ctx context.Context, vci vc.VC, runtimeConfig *oci.RuntimeCon
 // SetEphemeralStorageType sets the mount type to 'ephemeral'
 // if the mount source path is provisioned by k8s for ephemeral storage.
 // For the given pod ephemeral volume is created only once
-// backed by tmpfs inside the VM. For successive containers
-// of the same pod the already existing volume is reused.
+// backed by tmpfs inside the VM, or by a block device when the
+// sandbox EmptyDirMode annotation selects it. For successive
+// containers of the same pod the already existing volume is reused.
 func SetEphemeralStorageType(ociSpec oci.CompatOCISpec) oci.CompatOCISpec {
 	for idx, mnt := range ociSpec.Mounts {
 		if IsEphemeralStorage(mnt.Source) {
@@ -46,6 +46,12 @@ func CreateTracer(name string) (opentracing.Tracer, error) {
 		},
 	}
 
+	if tracingAgentAddress != "" {
+		cfg.Reporter = &config.ReporterConfig{
+			LocalAgentHostPort: tracingAgentAddress,
+		}
+	}
+
 	logger := traceLogger{}
 
 	tracer, closer, err := cfg.NewTracer(config.Logger(logger))
@@ -0,0 +1,44 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package katautils
+
+import (
+	"context"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTracerAgentAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	savedTracing := tracing
+	savedTracingAgentAddress := tracingAgentAddress
+	savedTracer := opentracing.GlobalTracer()
+
+	defer func() {
+		tracing = savedTracing
+		tracingAgentAddress = savedTracingAgentAddress
+		opentracing.SetGlobalTracer(savedTracer)
+	}()
+
+	tracing = true
+	tracingAgentAddress = "127.0.0.1:6831"
+
+	tracer, err := CreateTracer("test")
+	assert.NoError(err)
+	assert.NotNil(tracer)
+
+	span, ctx := Trace(context.Background(), "test")
+	assert.NotNil(span)
+	StopTracing(ctx)
+
+	// The exporter can not be created for an invalid address
+	tracingAgentAddress = "invalid:address:6831"
+	_, err = CreateTracer("test")
+	assert.Error(err)
+}
@@ -0,0 +1,824 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package vcapi exposes the virtcontainers API over gRPC, for orchestrators
+// that drive sandboxes without going through the OCI command line.
+package vcapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/kata-containers/runtime/pkg/katautils"
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	vcTypes "github.com/kata-containers/runtime/virtcontainers/pkg/types"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	vcs "github.com/kata-containers/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+)
+
+var serverLog = logrus.WithField("source", "vcapi")
+
+// SetLogger sets the logger of the vcapi package.
+func SetLogger(logger *logrus.Entry) {
+	serverLog = logger.WithField("source", "vcapi")
+}
+
+var emptyResp = &types.Empty{}
+
+// container is the OCI side of a served container, needed to run its hooks.
+type container struct {
+	spec   oci.CompatOCISpec
+	bundle string
+}
+
+// sandbox is a served sandbox. Its lock serializes the requests of the
+// server, the store lock the ones of the other runtime instances.
+type sandbox struct {
+	sync.RWMutex
+
+	vc.VCSandbox
+	containers map[string]container
+
+	// deleted is set once the sandbox is gone, for the requests that
+	// were waiting for the lock.
+	deleted bool
+}
+
+// Server implements the VirtContainers service on top of stateful
+// virtcontainers sandboxes.
+type Server struct {
+	vci    vc.VC
+	config oci.RuntimeConfig
+
+	sync.Mutex
+	sandboxes map[string]*sandbox
+}
+
+// NewServer returns a server creating its sandboxes through vci, with the
+// runtime configuration config.
+func NewServer(vci vc.VC, config oci.RuntimeConfig) *Server {
+	return &Server{
+		vci:       vci,
+		config:    config,
+		sandboxes: make(map[string]*sandbox),
+	}
+}
+
+// lockSandbox locks the sandbox, exclusively or not, in the server and in
+// the store. The returned function releases both locks.
+func (s *Server) lockSandbox(ctx context.Context, sandboxID string, exclusive bool) (*sandbox, func(), error) {
+	s.Lock()
+	sb, ok := s.sandboxes[sandboxID]
+	s.Unlock()
+
+	if !ok {
+		return nil, nil, grpcStatus.Errorf(codes.NotFound, "Sandbox %q not found", sandboxID)
+	}
+
+	unlock := sb.Unlock
+	if exclusive {
+		sb.Lock()
+	} else {
+		sb.RLock()
+		unlock = sb.RUnlock
+	}
+
+	if sb.deleted {
+		unlock()
+		return nil, nil, grpcStatus.Errorf(codes.NotFound, "Sandbox %q not found", sandboxID)
+	}
+
+	vcStore, err := store.NewVCSandboxStore(ctx, sandboxID)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	var token string
+	if exclusive {
+		token, err = vcStore.Lock()
+	} else {
+		token, err = vcStore.RLock()
+	}
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	return sb, func() {
+		// The store is gone along with a deleted sandbox.
+		if store.VCSandboxStoreExists(ctx, sandboxID) {
+			if err := vcStore.Unlock(token); err != nil {
+				serverLog.WithError(err).WithField("sandbox", sandboxID).Warn("Could not unlock sandbox")
+			}
+		}
+		unlock()
+	}, nil
+}
+
+// CreateSandbox creates a sandbox along with its first container.
+func (s *Server) CreateSandbox(ctx context.Context, req *CreateSandboxRequest) (*types.Empty, error) {
+	if req.SandboxId == "" {
+		return nil, grpcStatus.Error(codes.InvalidArgument, "Missing sandbox ID")
+	}
+
+	spec, err := oci.ParseConfig(req.Spec)
+	if err != nil {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid sandbox specification: %v", err)
+	}
+
+	sb := &sandbox{containers: make(map[string]container)}
+	sb.Lock()
+	defer sb.Unlock()
+
+	s.Lock()
+	if _, ok := s.sandboxes[req.SandboxId]; ok {
+		s.Unlock()
+		return nil, grpcStatus.Errorf(codes.AlreadyExists, "Sandbox %q already exists", req.SandboxId)
+	}
+	s.sandboxes[req.SandboxId] = sb
+	s.Unlock()
+
+	sb.VCSandbox, _, err = katautils.CreateSandbox(ctx, s.vci, spec, s.config, req.SandboxId, req.BundlePath, "", true, false, true)
+	if err != nil {
+		sb.deleted = true
+		s.removeSandbox(req.SandboxId)
+		return nil, err
+	}
+
+	sb.containers[req.SandboxId] = container{spec, req.BundlePath}
+
+	serverLog.WithField("sandbox", req.SandboxId).Info("Sandbox created")
+
+	return emptyResp, nil
+}
+
+func (s *Server) removeSandbox(sandboxID string) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.sandboxes, sandboxID)
+}
+
+// StartSandbox starts a sandbox and its containers.
+func (s *Server) StartSandbox(ctx context.Context, req *SandboxRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := sb.Start(); err != nil {
+		return nil, err
+	}
+
+	return emptyResp, sb.postStartHooks(ctx, sb.ID())
+}
+
+// StopSandbox stops a sandbox and its containers.
+func (s *Server) StopSandbox(ctx context.Context, req *SandboxRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.Stop()
+}
+
+// DeleteSandbox deletes a stopped sandbox.
+func (s *Server) DeleteSandbox(ctx context.Context, req *SandboxRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := sb.Delete(); err != nil {
+		return nil, err
+	}
+
+	sb.deleted = true
+	s.removeSandbox(req.SandboxId)
+
+	for id := range sb.containers {
+		if err := sb.postStopHooks(ctx, id); err != nil {
+			serverLog.WithError(err).WithField("container", id).Warn("Could not run post-stop hooks")
+		}
+	}
+
+	serverLog.WithField("sandbox", req.SandboxId).Info("Sandbox deleted")
+
+	return emptyResp, nil
+}
+
+// PauseSandbox pauses a sandbox.
+func (s *Server) PauseSandbox(ctx context.Context, req *SandboxRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.Pause()
+}
+
+// ResumeSandbox resumes a paused sandbox.
+func (s *Server) ResumeSandbox(ctx context.Context, req *SandboxRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.Resume()
+}
+
+// StatusSandbox returns the status of a sandbox.
+func (s *Server) StatusSandbox(ctx context.Context, req *SandboxRequest) (*StatusResponse, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	status, err := json.Marshal(sb.Status())
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatusResponse{Status: status}, nil
+}
+
+// ListSandbox lists the sandboxes of the server.
+func (s *Server) ListSandbox(ctx context.Context, req *types.Empty) (*ListSandboxResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	resp := &ListSandboxResponse{}
+	for id := range s.sandboxes {
+		resp.SandboxIds = append(resp.SandboxIds, id)
+	}
+	sort.Strings(resp.SandboxIds)
+
+	return resp, nil
+}
+
+// CreateContainer creates a container in a sandbox.
+func (s *Server) CreateContainer(ctx context.Context, req *CreateContainerRequest) (*types.Empty, error) {
+	if req.ContainerId == "" {
+		return nil, grpcStatus.Error(codes.InvalidArgument, "Missing container ID")
+	}
+
+	spec, err := oci.ParseConfig(req.Spec)
+	if err != nil {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid container specification: %v", err)
+	}
+
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := sb.containers[req.ContainerId]; ok {
+		return nil, grpcStatus.Errorf(codes.AlreadyExists, "Container %q already exists", req.ContainerId)
+	}
+
+	spec = katautils.SetEphemeralStorageType(spec)
+
+	contConfig, err := oci.ContainerConfig(spec, req.BundlePath, req.ContainerId, "", true)
+	if err != nil {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid container specification: %v", err)
+	}
+
+	// The container joins an existing sandbox, whatever its annotations.
+	contConfig.Annotations[vcAnnotations.ContainerTypeKey] = string(vc.PodContainer)
+
+	if _, err := sb.CreateContainer(contConfig); err != nil {
+		return nil, err
+	}
+
+	sb.containers[req.ContainerId] = container{spec, req.BundlePath}
+
+	// Run pre-start OCI hooks.
+	err = katautils.EnterNetNS(sb.GetNetNs(), func() error {
+		return katautils.PreStartHooks(ctx, spec, req.ContainerId, req.BundlePath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return emptyResp, nil
+}
+
+// StartContainer starts a container of a sandbox.
+func (s *Server) StartContainer(ctx context.Context, req *ContainerRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := sb.StartContainer(req.ContainerId); err != nil {
+		return nil, err
+	}
+
+	return emptyResp, sb.postStartHooks(ctx, req.ContainerId)
+}
+
+// StopContainer stops a container of a sandbox.
+func (s *Server) StopContainer(ctx context.Context, req *ContainerRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	_, err = sb.StopContainer(req.ContainerId)
+	return emptyResp, err
+}
+
+// DeleteContainer deletes a stopped container of a sandbox.
+func (s *Server) DeleteContainer(ctx context.Context, req *ContainerRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := sb.DeleteContainer(req.ContainerId); err != nil {
+		return nil, err
+	}
+
+	err = sb.postStopHooks(ctx, req.ContainerId)
+	delete(sb.containers, req.ContainerId)
+
+	return emptyResp, err
+}
+
+// PauseContainer pauses a container of a sandbox.
+func (s *Server) PauseContainer(ctx context.Context, req *ContainerRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.PauseContainer(req.ContainerId)
+}
+
+// ResumeContainer resumes a paused container of a sandbox.
+func (s *Server) ResumeContainer(ctx context.Context, req *ContainerRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.ResumeContainer(req.ContainerId)
+}
+
+// KillContainer signals the processes of a container.
+func (s *Server) KillContainer(ctx context.Context, req *KillContainerRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.KillContainer(req.ContainerId, syscall.Signal(req.Signal), req.All)
+}
+
+// StatusContainer returns the status of a container.
+func (s *Server) StatusContainer(ctx context.Context, req *ContainerRequest) (*StatusResponse, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	contStatus, err := sb.StatusContainer(req.ContainerId)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := json.Marshal(contStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatusResponse{Status: status}, nil
+}
+
+// StatsContainer returns the resource usage of a container.
+func (s *Server) StatsContainer(ctx context.Context, req *ContainerRequest) (*StatsResponse, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	contStats, err := sb.StatsContainer(req.ContainerId)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := json.Marshal(contStats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsResponse{Stats: stats}, nil
+}
+
+// UpdateContainer updates the resources of a container.
+func (s *Server) UpdateContainer(ctx context.Context, req *UpdateContainerRequest) (*types.Empty, error) {
+	var resources specs.LinuxResources
+	if err := json.Unmarshal(req.Resources, &resources); err != nil {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid resources: %v", err)
+	}
+
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.UpdateContainer(req.ContainerId, resources)
+}
+
+// EnterContainer runs a new process in a container, and streams its IO.
+func (s *Server) EnterContainer(stream VirtContainers_EnterContainerServer) error {
+	return s.streamProcess(stream, true)
+}
+
+// AttachProcess streams the IO of a container process.
+func (s *Server) AttachProcess(stream VirtContainers_AttachProcessServer) error {
+	return s.streamProcess(stream, false)
+}
+
+// SignalProcess signals a container process.
+func (s *Server) SignalProcess(ctx context.Context, req *SignalProcessRequest) (*types.Empty, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return emptyResp, sb.SignalProcess(req.ContainerId, req.ProcessId, syscall.Signal(req.Signal), req.All)
+}
+
+// WaitProcess waits for a container process to exit. The sandbox is not
+// locked while waiting.
+func (s *Server) WaitProcess(ctx context.Context, req *ProcessRequest) (*WaitProcessResponse, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	unlock()
+
+	exitCode, err := sb.WaitProcess(req.ContainerId, req.ProcessId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WaitProcessResponse{ExitCode: exitCode}, nil
+}
+
+// AddInterface adds a network interface to a sandbox.
+func (s *Server) AddInterface(ctx context.Context, req *InterfaceRequest) (*InterfaceResponse, error) {
+	return s.updateInterface(ctx, req, true)
+}
+
+// RemoveInterface removes a network interface from a sandbox.
+func (s *Server) RemoveInterface(ctx context.Context, req *InterfaceRequest) (*InterfaceResponse, error) {
+	return s.updateInterface(ctx, req, false)
+}
+
+func (s *Server) updateInterface(ctx context.Context, req *InterfaceRequest, add bool) (*InterfaceResponse, error) {
+	var inf vcTypes.Interface
+	if err := json.Unmarshal(req.Interface, &inf); err != nil {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid interface: %v", err)
+	}
+
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var result *vcTypes.Interface
+	if add {
+		result, err = sb.AddInterface(&inf)
+	} else {
+		result, err = sb.RemoveInterface(&inf)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InterfaceResponse{Interface: data}, nil
+}
+
+// ListInterfaces lists the network interfaces of a sandbox.
+func (s *Server) ListInterfaces(ctx context.Context, req *SandboxRequest) (*ListInterfacesResponse, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	infs, err := sb.ListInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(infs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListInterfacesResponse{Interfaces: data}, nil
+}
+
+// UpdateRoutes replaces the routes of a sandbox.
+func (s *Server) UpdateRoutes(ctx context.Context, req *RoutesRequest) (*RoutesResponse, error) {
+	var routes []*vcTypes.Route
+	if err := json.Unmarshal(req.Routes, &routes); err != nil {
+		return nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid routes: %v", err)
+	}
+
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	routes, err = sb.UpdateRoutes(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	return routesResponse(routes)
+}
+
+// ListRoutes lists the routes of a sandbox.
+func (s *Server) ListRoutes(ctx context.Context, req *SandboxRequest) (*RoutesResponse, error) {
+	sb, unlock, err := s.lockSandbox(ctx, req.SandboxId, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	routes, err := sb.ListRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	return routesResponse(routes)
+}
+
+func routesResponse(routes []*vcTypes.Route) (*RoutesResponse, error) {
+	data, err := json.Marshal(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RoutesResponse{Routes: data}, nil
+}
+
+func (sb *sandbox) postStartHooks(ctx context.Context, containerID string) error {
+	c, ok := sb.containers[containerID]
+	if !ok {
+		return nil
+	}
+
+	// Run post-start OCI hooks.
+	return katautils.EnterNetNS(sb.GetNetNs(), func() error {
+		return katautils.PostStartHooks(ctx, c.spec, sb.ID(), c.bundle)
+	})
+}
+
+func (sb *sandbox) postStopHooks(ctx context.Context, containerID string) error {
+	c, ok := sb.containers[containerID]
+	if !ok {
+		return nil
+	}
+
+	// Run post-stop OCI hooks.
+	return katautils.PostStopHooks(ctx, c.spec, sb.ID(), c.bundle)
+}
+
+// processStream is the server side of the EnterContainer and AttachProcess
+// streams.
+type processStream interface {
+	Send(*ProcessOutput) error
+	Recv() (*ProcessInput, error)
+	Context() context.Context
+}
+
+// processCmd converts an OCI process to the command virtcontainers runs.
+func processCmd(process specs.Process) vcs.Cmd {
+	envs := []vcs.EnvVar{}
+	for _, env := range process.Env {
+		pair := strings.SplitN(env, "=", 2)
+		if len(pair) == 1 {
+			pair = append(pair, "")
+		}
+		envs = append(envs, vcs.EnvVar{Var: pair[0], Value: pair[1]})
+	}
+
+	return vcs.Cmd{
+		Args:            process.Args,
+		Envs:            envs,
+		User:            fmt.Sprintf("%d", process.User.UID),
+		PrimaryGroup:    fmt.Sprintf("%d", process.User.GID),
+		WorkDir:         process.Cwd,
+		Interactive:     process.Terminal,
+		Detach:          !process.Terminal,
+		NoNewPrivileges: process.NoNewPrivileges,
+	}
+}
+
+// openProcess enters a new process in a container, or looks up an
+// existing one, and returns its ID and IO streams.
+func (s *Server) openProcess(ctx context.Context, in *ProcessInput, enter bool) (string, io.WriteCloser, io.Reader, io.Reader, error) {
+	sb, unlock, err := s.lockSandbox(ctx, in.SandboxId, enter)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+	defer unlock()
+
+	processID := in.ProcessId
+
+	if enter {
+		var process specs.Process
+		if err := json.Unmarshal(in.Process, &process); err != nil {
+			return "", nil, nil, nil, grpcStatus.Errorf(codes.InvalidArgument, "Invalid process specification: %v", err)
+		}
+
+		_, p, err := sb.EnterContainer(in.ContainerId, processCmd(process))
+		if err != nil {
+			return "", nil, nil, nil, err
+		}
+		processID = p.Token
+	} else if processID == "" {
+		c := sb.GetContainer(in.ContainerId)
+		if c == nil {
+			return "", nil, nil, nil, grpcStatus.Errorf(codes.NotFound, "Container %q not found", in.ContainerId)
+		}
+		processID = c.Process().Token
+	}
+
+	stdin, stdout, stderr, err := sb.IOStream(in.ContainerId, processID)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	return processID, stdin, stdout, stderr, nil
+}
+
+// streamProcess streams the IO of the process selected by the first input
+// until it exits, then sends its exit code. The sandbox is locked while the
+// process is looked up, and shared locked while each input is handled and
+// while the process is waited for, but not while its IO is streamed.
+func (s *Server) streamProcess(stream processStream, enter bool) error {
+	ctx := stream.Context()
+
+	in, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	sandboxID := in.SandboxId
+	containerID := in.ContainerId
+
+	processID, stdin, stdout, stderr, err := s.openProcess(ctx, in, enter)
+	if err != nil {
+		return err
+	}
+
+	// Send is not safe for concurrent use.
+	var sendLock sync.Mutex
+	send := func(out *ProcessOutput) error {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+
+		return stream.Send(out)
+	}
+
+	if err := send(&ProcessOutput{ProcessId: processID}); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				// The client is done with the standard input.
+				stdin.Close()
+				return
+			}
+
+			if err := s.handleProcessInput(ctx, sandboxID, containerID, processID, in, stdin); err != nil {
+				serverLog.WithError(err).WithField("process", processID).Warn("Could not handle process input")
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	copyOutput := func(r io.Reader, output func([]byte) *ProcessOutput) {
+		defer wg.Done()
+
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				if send(output(data)) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	wg.Add(1)
+	go copyOutput(stdout, func(data []byte) *ProcessOutput { return &ProcessOutput{Stdout: data} })
+
+	if stderr != nil {
+		wg.Add(1)
+		go copyOutput(stderr, func(data []byte) *ProcessOutput { return &ProcessOutput{Stderr: data} })
+	}
+
+	wg.Wait()
+
+	sb, unlock, err := s.lockSandbox(ctx, sandboxID, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	exitCode, err := sb.WaitProcess(containerID, processID)
+	if err != nil {
+		return err
+	}
+
+	return send(&ProcessOutput{ProcessId: processID, Exited: true, ExitCode: exitCode})
+}
+
+func (s *Server) handleProcessInput(ctx context.Context, sandboxID, containerID, processID string, in *ProcessInput, stdin io.WriteCloser) error {
+	sb, unlock, err := s.lockSandbox(ctx, sandboxID, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if len(in.Stdin) > 0 {
+		if _, err := stdin.Write(in.Stdin); err != nil {
+			return err
+		}
+	}
+
+	if in.CloseStdin {
+		if err := stdin.Close(); err != nil {
+			return err
+		}
+	}
+
+	if in.Height > 0 && in.Width > 0 {
+		if err := sb.WinsizeProcess(containerID, processID, in.Height, in.Width); err != nil {
+			return err
+		}
+	}
+
+	if in.Signal > 0 {
+		return sb.SignalProcess(containerID, processID, syscall.Signal(in.Signal), false)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package vcapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/vcmock"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	vcs "github.com/kata-containers/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+)
+
+const (
+	testSandboxID   = "sandbox"
+	testContainerID = "container"
+)
+
+// testSandbox echoes the standard input of its processes to their standard
+// output.
+type testSandbox struct {
+	*vcmock.Sandbox
+
+	started bool
+	killed  syscall.Signal
+	cmd     vcs.Cmd
+}
+
+func (s *testSandbox) Start() error {
+	s.started = true
+	return nil
+}
+
+func (s *testSandbox) KillContainer(containerID string, signal syscall.Signal, all bool) error {
+	if containerID != testContainerID {
+		return errors.New("unknown container")
+	}
+
+	s.killed = signal
+	return nil
+}
+
+func (s *testSandbox) EnterContainer(containerID string, cmd vcs.Cmd) (vc.VCContainer, *vc.Process, error) {
+	s.cmd = cmd
+	return &vcmock.Container{}, &vc.Process{Token: "exec"}, nil
+}
+
+func (s *testSandbox) IOStream(containerID, processID string) (io.WriteCloser, io.Reader, io.Reader, error) {
+	r, w := io.Pipe()
+	return w, r, strings.NewReader("oops"), nil
+}
+
+func (s *testSandbox) WaitProcess(containerID, processID string) (int32, error) {
+	return 3, nil
+}
+
+func testSpec(t *testing.T) []byte {
+	spec := oci.CompatOCISpec{
+		Spec: specs.Spec{
+			Root: &specs.Root{Path: "rootfs"},
+			Linux: &specs.Linux{
+				Resources: &specs.LinuxResources{},
+			},
+		},
+		Process: &oci.CompatOCIProcess{},
+	}
+
+	data, err := json.Marshal(spec)
+	assert.NoError(t, err)
+
+	return data
+}
+
+func testServer(t *testing.T) (VirtContainersClient, *testSandbox, func()) {
+	dir, err := ioutil.TempDir("", "vcapi-")
+	assert.NoError(t, err)
+
+	savedConfigStoragePath := store.ConfigStoragePath
+	savedRunStoragePath := store.RunStoragePath
+	store.ConfigStoragePath = filepath.Join(dir, "config")
+	store.RunStoragePath = filepath.Join(dir, "run")
+
+	sandbox := &testSandbox{
+		Sandbox: &vcmock.Sandbox{
+			MockID:         testSandboxID,
+			MockContainers: []*vcmock.Container{{MockID: testSandboxID}},
+		},
+	}
+
+	vci := &vcmock.VCMock{
+		CreateSandboxFunc: func(ctx context.Context, sandboxConfig vc.SandboxConfig) (vc.VCSandbox, error) {
+			if !sandboxConfig.Stateful {
+				return nil, errors.New("stateless sandbox")
+			}
+			return sandbox, nil
+		},
+	}
+
+	socket := filepath.Join(dir, "vcapi.sock")
+	l, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+
+	server := grpc.NewServer()
+	RegisterVirtContainersServer(server, NewServer(vci, oci.RuntimeConfig{DisableNewNetNs: true}))
+	go server.Serve(l)
+
+	conn, err := grpc.Dial(socket, grpc.WithInsecure(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("unix", addr, timeout)
+	}))
+	assert.NoError(t, err)
+
+	return NewVirtContainersClient(conn), sandbox, func() {
+		conn.Close()
+		server.Stop()
+		store.ConfigStoragePath = savedConfigStoragePath
+		store.RunStoragePath = savedRunStoragePath
+		os.RemoveAll(dir)
+	}
+}
+
+func TestServerSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	client, sandbox, cleanup := testServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := client.StartSandbox(ctx, &SandboxRequest{SandboxId: testSandboxID})
+	assert.Equal(codes.NotFound, grpcStatus.Code(err))
+
+	_, err = client.CreateSandbox(ctx, &CreateSandboxRequest{SandboxId: testSandboxID, Spec: []byte("foo")})
+	assert.Equal(codes.InvalidArgument, grpcStatus.Code(err))
+
+	_, err = client.CreateSandbox(ctx, &CreateSandboxRequest{SandboxId: testSandboxID, Spec: testSpec(t)})
+	assert.NoError(err)
+
+	_, err = client.CreateSandbox(ctx, &CreateSandboxRequest{SandboxId: testSandboxID, Spec: testSpec(t)})
+	assert.Equal(codes.AlreadyExists, grpcStatus.Code(err))
+
+	list, err := client.ListSandbox(ctx, &types.Empty{})
+	assert.NoError(err)
+	assert.Equal([]string{testSandboxID}, list.SandboxIds)
+
+	_, err = client.StartSandbox(ctx, &SandboxRequest{SandboxId: testSandboxID})
+	assert.NoError(err)
+	assert.True(sandbox.started)
+
+	_, err = client.KillContainer(ctx, &KillContainerRequest{SandboxId: testSandboxID, ContainerId: testContainerID, Signal: uint32(syscall.SIGTERM)})
+	assert.NoError(err)
+	assert.Equal(syscall.SIGTERM, sandbox.killed)
+
+	_, err = client.KillContainer(ctx, &KillContainerRequest{SandboxId: testSandboxID, ContainerId: "foo"})
+	assert.Error(err)
+
+	status, err := client.StatusSandbox(ctx, &SandboxRequest{SandboxId: testSandboxID})
+	assert.NoError(err)
+	var sandboxStatus vc.SandboxStatus
+	assert.NoError(json.Unmarshal(status.Status, &sandboxStatus))
+
+	_, err = client.DeleteSandbox(ctx, &SandboxRequest{SandboxId: testSandboxID})
+	assert.NoError(err)
+
+	_, err = client.StatusSandbox(ctx, &SandboxRequest{SandboxId: testSandboxID})
+	assert.Equal(codes.NotFound, grpcStatus.Code(err))
+}
+
+func TestServerEnterContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	client, sandbox, cleanup := testServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := client.CreateSandbox(ctx, &CreateSandboxRequest{SandboxId: testSandboxID, Spec: testSpec(t)})
+	assert.NoError(err)
+
+	process, err := json.Marshal(specs.Process{
+		Args: []string{"cat"},
+		Env:  []string{"FOO=bar"},
+	})
+	assert.NoError(err)
+
+	stream, err := client.EnterContainer(ctx)
+	assert.NoError(err)
+
+	err = stream.Send(&ProcessInput{SandboxId: testSandboxID, ContainerId: testSandboxID, Process: process})
+	assert.NoError(err)
+
+	out, err := stream.Recv()
+	assert.NoError(err)
+	assert.Equal("exec", out.ProcessId)
+
+	assert.NoError(stream.Send(&ProcessInput{Stdin: []byte("hello")}))
+	assert.NoError(stream.Send(&ProcessInput{CloseStdin: true}))
+
+	var stdout, stderr string
+	for {
+		out, err = stream.Recv()
+		assert.NoError(err)
+
+		stdout += string(out.Stdout)
+		stderr += string(out.Stderr)
+
+		if out.Exited {
+			break
+		}
+	}
+
+	assert.Equal("hello", stdout)
+	assert.Equal("oops", stderr)
+	assert.Equal(int32(3), out.ExitCode)
+	assert.Equal([]string{"cat"}, sandbox.cmd.Args)
+	assert.Equal([]vcs.EnvVar{{Var: "FOO", Value: "bar"}}, sandbox.cmd.Envs)
+
+	_, err = stream.Recv()
+	assert.Equal(io.EOF, err)
+}
@@ -0,0 +1,1720 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: vcapi.proto
+
+/*
+Package vcapi is a generated protocol buffer package.
+
+It is generated from these files:
+
+	vcapi.proto
+
+It has these top-level messages:
+
+	SandboxRequest
+	CreateSandboxRequest
+	ListSandboxResponse
+	ContainerRequest
+	CreateContainerRequest
+	KillContainerRequest
+	UpdateContainerRequest
+	StatusResponse
+	StatsResponse
+	ProcessInput
+	ProcessOutput
+	ProcessRequest
+	SignalProcessRequest
+	WaitProcessResponse
+	InterfaceRequest
+	InterfaceResponse
+	ListInterfacesResponse
+	RoutesRequest
+	RoutesResponse
+*/
+package vcapi
+
+import proto "github.com/gogo/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import google_protobuf "github.com/gogo/protobuf/types"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+type SandboxRequest struct {
+	SandboxId string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+}
+
+func (m *SandboxRequest) Reset()                    { *m = SandboxRequest{} }
+func (m *SandboxRequest) String() string            { return proto.CompactTextString(m) }
+func (*SandboxRequest) ProtoMessage()               {}
+func (*SandboxRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{0} }
+
+func (m *SandboxRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+type CreateSandboxRequest struct {
+	SandboxId string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	// OCI runtime specification of the sandbox container, JSON encoded.
+	Spec       []byte `protobuf:"bytes,2,opt,name=spec,proto3" json:"spec,omitempty"`
+	BundlePath string `protobuf:"bytes,3,opt,name=bundle_path,json=bundlePath,proto3" json:"bundle_path,omitempty"`
+}
+
+func (m *CreateSandboxRequest) Reset()                    { *m = CreateSandboxRequest{} }
+func (m *CreateSandboxRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateSandboxRequest) ProtoMessage()               {}
+func (*CreateSandboxRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{1} }
+
+func (m *CreateSandboxRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *CreateSandboxRequest) GetSpec() []byte {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+func (m *CreateSandboxRequest) GetBundlePath() string {
+	if m != nil {
+		return m.BundlePath
+	}
+	return ""
+}
+
+type ListSandboxResponse struct {
+	SandboxIds []string `protobuf:"bytes,1,rep,name=sandbox_ids,json=sandboxIds" json:"sandbox_ids,omitempty"`
+}
+
+func (m *ListSandboxResponse) Reset()                    { *m = ListSandboxResponse{} }
+func (m *ListSandboxResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListSandboxResponse) ProtoMessage()               {}
+func (*ListSandboxResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{2} }
+
+func (m *ListSandboxResponse) GetSandboxIds() []string {
+	if m != nil {
+		return m.SandboxIds
+	}
+	return nil
+}
+
+type ContainerRequest struct {
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+}
+
+func (m *ContainerRequest) Reset()                    { *m = ContainerRequest{} }
+func (m *ContainerRequest) String() string            { return proto.CompactTextString(m) }
+func (*ContainerRequest) ProtoMessage()               {}
+func (*ContainerRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{3} }
+
+func (m *ContainerRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *ContainerRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+type CreateContainerRequest struct {
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// OCI runtime specification of the container, JSON encoded.
+	Spec       []byte `protobuf:"bytes,3,opt,name=spec,proto3" json:"spec,omitempty"`
+	BundlePath string `protobuf:"bytes,4,opt,name=bundle_path,json=bundlePath,proto3" json:"bundle_path,omitempty"`
+}
+
+func (m *CreateContainerRequest) Reset()                    { *m = CreateContainerRequest{} }
+func (m *CreateContainerRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateContainerRequest) ProtoMessage()               {}
+func (*CreateContainerRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{4} }
+
+func (m *CreateContainerRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *CreateContainerRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *CreateContainerRequest) GetSpec() []byte {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+func (m *CreateContainerRequest) GetBundlePath() string {
+	if m != nil {
+		return m.BundlePath
+	}
+	return ""
+}
+
+type KillContainerRequest struct {
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	Signal      uint32 `protobuf:"varint,3,opt,name=signal,proto3" json:"signal,omitempty"`
+	All         bool   `protobuf:"varint,4,opt,name=all,proto3" json:"all,omitempty"`
+}
+
+func (m *KillContainerRequest) Reset()                    { *m = KillContainerRequest{} }
+func (m *KillContainerRequest) String() string            { return proto.CompactTextString(m) }
+func (*KillContainerRequest) ProtoMessage()               {}
+func (*KillContainerRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{5} }
+
+func (m *KillContainerRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *KillContainerRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *KillContainerRequest) GetSignal() uint32 {
+	if m != nil {
+		return m.Signal
+	}
+	return 0
+}
+
+func (m *KillContainerRequest) GetAll() bool {
+	if m != nil {
+		return m.All
+	}
+	return false
+}
+
+type UpdateContainerRequest struct {
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// OCI Linux resources, JSON encoded.
+	Resources []byte `protobuf:"bytes,3,opt,name=resources,proto3" json:"resources,omitempty"`
+}
+
+func (m *UpdateContainerRequest) Reset()                    { *m = UpdateContainerRequest{} }
+func (m *UpdateContainerRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateContainerRequest) ProtoMessage()               {}
+func (*UpdateContainerRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{6} }
+
+func (m *UpdateContainerRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *UpdateContainerRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *UpdateContainerRequest) GetResources() []byte {
+	if m != nil {
+		return m.Resources
+	}
+	return nil
+}
+
+type StatusResponse struct {
+	// Sandbox or container status, JSON encoded.
+	Status []byte `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (m *StatusResponse) Reset()                    { *m = StatusResponse{} }
+func (m *StatusResponse) String() string            { return proto.CompactTextString(m) }
+func (*StatusResponse) ProtoMessage()               {}
+func (*StatusResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{7} }
+
+func (m *StatusResponse) GetStatus() []byte {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type StatsResponse struct {
+	// Container stats, JSON encoded.
+	Stats []byte `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+}
+
+func (m *StatsResponse) Reset()                    { *m = StatsResponse{} }
+func (m *StatsResponse) String() string            { return proto.CompactTextString(m) }
+func (*StatsResponse) ProtoMessage()               {}
+func (*StatsResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{8} }
+
+func (m *StatsResponse) GetStats() []byte {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+type ProcessInput struct {
+	// The process selection, only read from the first input. The
+	// container init process is attached to when process_id is empty.
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	ProcessId   string `protobuf:"bytes,3,opt,name=process_id,json=processId,proto3" json:"process_id,omitempty"`
+	// OCI process specification, JSON encoded, of the process entering
+	// the container.
+	Process    []byte `protobuf:"bytes,4,opt,name=process,proto3" json:"process,omitempty"`
+	Stdin      []byte `protobuf:"bytes,5,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	CloseStdin bool   `protobuf:"varint,6,opt,name=close_stdin,json=closeStdin,proto3" json:"close_stdin,omitempty"`
+	// The terminal is resized when both are set.
+	Height uint32 `protobuf:"varint,7,opt,name=height,proto3" json:"height,omitempty"`
+	Width  uint32 `protobuf:"varint,8,opt,name=width,proto3" json:"width,omitempty"`
+	Signal uint32 `protobuf:"varint,9,opt,name=signal,proto3" json:"signal,omitempty"`
+}
+
+func (m *ProcessInput) Reset()                    { *m = ProcessInput{} }
+func (m *ProcessInput) String() string            { return proto.CompactTextString(m) }
+func (*ProcessInput) ProtoMessage()               {}
+func (*ProcessInput) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{9} }
+
+func (m *ProcessInput) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *ProcessInput) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *ProcessInput) GetProcessId() string {
+	if m != nil {
+		return m.ProcessId
+	}
+	return ""
+}
+
+func (m *ProcessInput) GetProcess() []byte {
+	if m != nil {
+		return m.Process
+	}
+	return nil
+}
+
+func (m *ProcessInput) GetStdin() []byte {
+	if m != nil {
+		return m.Stdin
+	}
+	return nil
+}
+
+func (m *ProcessInput) GetCloseStdin() bool {
+	if m != nil {
+		return m.CloseStdin
+	}
+	return false
+}
+
+func (m *ProcessInput) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *ProcessInput) GetWidth() uint32 {
+	if m != nil {
+		return m.Width
+	}
+	return 0
+}
+
+func (m *ProcessInput) GetSignal() uint32 {
+	if m != nil {
+		return m.Signal
+	}
+	return 0
+}
+
+type ProcessOutput struct {
+	ProcessId string `protobuf:"bytes,1,opt,name=process_id,json=processId,proto3" json:"process_id,omitempty"`
+	Stdout    []byte `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr    []byte `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	Exited    bool   `protobuf:"varint,4,opt,name=exited,proto3" json:"exited,omitempty"`
+	ExitCode  int32  `protobuf:"varint,5,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+}
+
+func (m *ProcessOutput) Reset()                    { *m = ProcessOutput{} }
+func (m *ProcessOutput) String() string            { return proto.CompactTextString(m) }
+func (*ProcessOutput) ProtoMessage()               {}
+func (*ProcessOutput) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{10} }
+
+func (m *ProcessOutput) GetProcessId() string {
+	if m != nil {
+		return m.ProcessId
+	}
+	return ""
+}
+
+func (m *ProcessOutput) GetStdout() []byte {
+	if m != nil {
+		return m.Stdout
+	}
+	return nil
+}
+
+func (m *ProcessOutput) GetStderr() []byte {
+	if m != nil {
+		return m.Stderr
+	}
+	return nil
+}
+
+func (m *ProcessOutput) GetExited() bool {
+	if m != nil {
+		return m.Exited
+	}
+	return false
+}
+
+func (m *ProcessOutput) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+type ProcessRequest struct {
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	ProcessId   string `protobuf:"bytes,3,opt,name=process_id,json=processId,proto3" json:"process_id,omitempty"`
+}
+
+func (m *ProcessRequest) Reset()                    { *m = ProcessRequest{} }
+func (m *ProcessRequest) String() string            { return proto.CompactTextString(m) }
+func (*ProcessRequest) ProtoMessage()               {}
+func (*ProcessRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{11} }
+
+func (m *ProcessRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *ProcessRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *ProcessRequest) GetProcessId() string {
+	if m != nil {
+		return m.ProcessId
+	}
+	return ""
+}
+
+type SignalProcessRequest struct {
+	SandboxId   string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	ProcessId   string `protobuf:"bytes,3,opt,name=process_id,json=processId,proto3" json:"process_id,omitempty"`
+	Signal      uint32 `protobuf:"varint,4,opt,name=signal,proto3" json:"signal,omitempty"`
+	All         bool   `protobuf:"varint,5,opt,name=all,proto3" json:"all,omitempty"`
+}
+
+func (m *SignalProcessRequest) Reset()                    { *m = SignalProcessRequest{} }
+func (m *SignalProcessRequest) String() string            { return proto.CompactTextString(m) }
+func (*SignalProcessRequest) ProtoMessage()               {}
+func (*SignalProcessRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{12} }
+
+func (m *SignalProcessRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *SignalProcessRequest) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *SignalProcessRequest) GetProcessId() string {
+	if m != nil {
+		return m.ProcessId
+	}
+	return ""
+}
+
+func (m *SignalProcessRequest) GetSignal() uint32 {
+	if m != nil {
+		return m.Signal
+	}
+	return 0
+}
+
+func (m *SignalProcessRequest) GetAll() bool {
+	if m != nil {
+		return m.All
+	}
+	return false
+}
+
+type WaitProcessResponse struct {
+	ExitCode int32 `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+}
+
+func (m *WaitProcessResponse) Reset()                    { *m = WaitProcessResponse{} }
+func (m *WaitProcessResponse) String() string            { return proto.CompactTextString(m) }
+func (*WaitProcessResponse) ProtoMessage()               {}
+func (*WaitProcessResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{13} }
+
+func (m *WaitProcessResponse) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+type InterfaceRequest struct {
+	SandboxId string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	// Network interface, JSON encoded.
+	Interface []byte `protobuf:"bytes,2,opt,name=interface,proto3" json:"interface,omitempty"`
+}
+
+func (m *InterfaceRequest) Reset()                    { *m = InterfaceRequest{} }
+func (m *InterfaceRequest) String() string            { return proto.CompactTextString(m) }
+func (*InterfaceRequest) ProtoMessage()               {}
+func (*InterfaceRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{14} }
+
+func (m *InterfaceRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *InterfaceRequest) GetInterface() []byte {
+	if m != nil {
+		return m.Interface
+	}
+	return nil
+}
+
+type InterfaceResponse struct {
+	Interface []byte `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+}
+
+func (m *InterfaceResponse) Reset()                    { *m = InterfaceResponse{} }
+func (m *InterfaceResponse) String() string            { return proto.CompactTextString(m) }
+func (*InterfaceResponse) ProtoMessage()               {}
+func (*InterfaceResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{15} }
+
+func (m *InterfaceResponse) GetInterface() []byte {
+	if m != nil {
+		return m.Interface
+	}
+	return nil
+}
+
+type ListInterfacesResponse struct {
+	// Network interface list, JSON encoded.
+	Interfaces []byte `protobuf:"bytes,1,opt,name=interfaces,proto3" json:"interfaces,omitempty"`
+}
+
+func (m *ListInterfacesResponse) Reset()                    { *m = ListInterfacesResponse{} }
+func (m *ListInterfacesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListInterfacesResponse) ProtoMessage()               {}
+func (*ListInterfacesResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{16} }
+
+func (m *ListInterfacesResponse) GetInterfaces() []byte {
+	if m != nil {
+		return m.Interfaces
+	}
+	return nil
+}
+
+type RoutesRequest struct {
+	SandboxId string `protobuf:"bytes,1,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	// Route list, JSON encoded.
+	Routes []byte `protobuf:"bytes,2,opt,name=routes,proto3" json:"routes,omitempty"`
+}
+
+func (m *RoutesRequest) Reset()                    { *m = RoutesRequest{} }
+func (m *RoutesRequest) String() string            { return proto.CompactTextString(m) }
+func (*RoutesRequest) ProtoMessage()               {}
+func (*RoutesRequest) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{17} }
+
+func (m *RoutesRequest) GetSandboxId() string {
+	if m != nil {
+		return m.SandboxId
+	}
+	return ""
+}
+
+func (m *RoutesRequest) GetRoutes() []byte {
+	if m != nil {
+		return m.Routes
+	}
+	return nil
+}
+
+type RoutesResponse struct {
+	Routes []byte `protobuf:"bytes,1,opt,name=routes,proto3" json:"routes,omitempty"`
+}
+
+func (m *RoutesResponse) Reset()                    { *m = RoutesResponse{} }
+func (m *RoutesResponse) String() string            { return proto.CompactTextString(m) }
+func (*RoutesResponse) ProtoMessage()               {}
+func (*RoutesResponse) Descriptor() ([]byte, []int) { return fileDescriptorVcapi, []int{18} }
+
+func (m *RoutesResponse) GetRoutes() []byte {
+	if m != nil {
+		return m.Routes
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*SandboxRequest)(nil), "vcapi.SandboxRequest")
+	proto.RegisterType((*CreateSandboxRequest)(nil), "vcapi.CreateSandboxRequest")
+	proto.RegisterType((*ListSandboxResponse)(nil), "vcapi.ListSandboxResponse")
+	proto.RegisterType((*ContainerRequest)(nil), "vcapi.ContainerRequest")
+	proto.RegisterType((*CreateContainerRequest)(nil), "vcapi.CreateContainerRequest")
+	proto.RegisterType((*KillContainerRequest)(nil), "vcapi.KillContainerRequest")
+	proto.RegisterType((*UpdateContainerRequest)(nil), "vcapi.UpdateContainerRequest")
+	proto.RegisterType((*StatusResponse)(nil), "vcapi.StatusResponse")
+	proto.RegisterType((*StatsResponse)(nil), "vcapi.StatsResponse")
+	proto.RegisterType((*ProcessInput)(nil), "vcapi.ProcessInput")
+	proto.RegisterType((*ProcessOutput)(nil), "vcapi.ProcessOutput")
+	proto.RegisterType((*ProcessRequest)(nil), "vcapi.ProcessRequest")
+	proto.RegisterType((*SignalProcessRequest)(nil), "vcapi.SignalProcessRequest")
+	proto.RegisterType((*WaitProcessResponse)(nil), "vcapi.WaitProcessResponse")
+	proto.RegisterType((*InterfaceRequest)(nil), "vcapi.InterfaceRequest")
+	proto.RegisterType((*InterfaceResponse)(nil), "vcapi.InterfaceResponse")
+	proto.RegisterType((*ListInterfacesResponse)(nil), "vcapi.ListInterfacesResponse")
+	proto.RegisterType((*RoutesRequest)(nil), "vcapi.RoutesRequest")
+	proto.RegisterType((*RoutesResponse)(nil), "vcapi.RoutesResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for VirtContainers service
+
+type VirtContainersClient interface {
+	// sandbox
+	CreateSandbox(ctx context.Context, in *CreateSandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	StartSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	StopSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	DeleteSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	PauseSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	ResumeSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	StatusSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	ListSandbox(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ListSandboxResponse, error)
+	// container
+	CreateContainer(ctx context.Context, in *CreateContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	StartContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	StopContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	DeleteContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	PauseContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	ResumeContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	KillContainer(ctx context.Context, in *KillContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	StatusContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	StatsContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	UpdateContainer(ctx context.Context, in *UpdateContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// process
+	//
+	// The first input of a stream selects the process, the process
+	// identifier being the first output. The standard input is then
+	// streamed in, the standard output and error out, until the process
+	// exits.
+	EnterContainer(ctx context.Context, opts ...grpc.CallOption) (VirtContainers_EnterContainerClient, error)
+	AttachProcess(ctx context.Context, opts ...grpc.CallOption) (VirtContainers_AttachProcessClient, error)
+	SignalProcess(ctx context.Context, in *SignalProcessRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	WaitProcess(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*WaitProcessResponse, error)
+	// network
+	AddInterface(ctx context.Context, in *InterfaceRequest, opts ...grpc.CallOption) (*InterfaceResponse, error)
+	RemoveInterface(ctx context.Context, in *InterfaceRequest, opts ...grpc.CallOption) (*InterfaceResponse, error)
+	ListInterfaces(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*ListInterfacesResponse, error)
+	UpdateRoutes(ctx context.Context, in *RoutesRequest, opts ...grpc.CallOption) (*RoutesResponse, error)
+	ListRoutes(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*RoutesResponse, error)
+}
+
+type virtContainersClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewVirtContainersClient(cc *grpc.ClientConn) VirtContainersClient {
+	return &virtContainersClient{cc}
+}
+
+func (c *virtContainersClient) CreateSandbox(ctx context.Context, in *CreateSandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/CreateSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StartSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StartSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StopSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StopSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) DeleteSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/DeleteSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) PauseSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/PauseSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) ResumeSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/ResumeSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StatusSandbox(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StatusSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) ListSandbox(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ListSandboxResponse, error) {
+	out := new(ListSandboxResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/ListSandbox", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) CreateContainer(ctx context.Context, in *CreateContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/CreateContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StartContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StartContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StopContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StopContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) DeleteContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/DeleteContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) PauseContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/PauseContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) ResumeContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/ResumeContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) KillContainer(ctx context.Context, in *KillContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/KillContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StatusContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StatusContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) StatsContainer(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	out := new(StatsResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/StatsContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) UpdateContainer(ctx context.Context, in *UpdateContainerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/UpdateContainer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) EnterContainer(ctx context.Context, opts ...grpc.CallOption) (VirtContainers_EnterContainerClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_VirtContainers_serviceDesc.Streams[0], c.cc, "/vcapi.VirtContainers/EnterContainer", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &virtContainersEnterContainerClient{stream}
+	return x, nil
+}
+
+type VirtContainers_EnterContainerClient interface {
+	Send(*ProcessInput) error
+	Recv() (*ProcessOutput, error)
+	grpc.ClientStream
+}
+
+type virtContainersEnterContainerClient struct {
+	grpc.ClientStream
+}
+
+func (x *virtContainersEnterContainerClient) Send(m *ProcessInput) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *virtContainersEnterContainerClient) Recv() (*ProcessOutput, error) {
+	m := new(ProcessOutput)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *virtContainersClient) AttachProcess(ctx context.Context, opts ...grpc.CallOption) (VirtContainers_AttachProcessClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_VirtContainers_serviceDesc.Streams[1], c.cc, "/vcapi.VirtContainers/AttachProcess", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &virtContainersAttachProcessClient{stream}
+	return x, nil
+}
+
+type VirtContainers_AttachProcessClient interface {
+	Send(*ProcessInput) error
+	Recv() (*ProcessOutput, error)
+	grpc.ClientStream
+}
+
+type virtContainersAttachProcessClient struct {
+	grpc.ClientStream
+}
+
+func (x *virtContainersAttachProcessClient) Send(m *ProcessInput) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *virtContainersAttachProcessClient) Recv() (*ProcessOutput, error) {
+	m := new(ProcessOutput)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *virtContainersClient) SignalProcess(ctx context.Context, in *SignalProcessRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/SignalProcess", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) WaitProcess(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*WaitProcessResponse, error) {
+	out := new(WaitProcessResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/WaitProcess", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) AddInterface(ctx context.Context, in *InterfaceRequest, opts ...grpc.CallOption) (*InterfaceResponse, error) {
+	out := new(InterfaceResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/AddInterface", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) RemoveInterface(ctx context.Context, in *InterfaceRequest, opts ...grpc.CallOption) (*InterfaceResponse, error) {
+	out := new(InterfaceResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/RemoveInterface", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) ListInterfaces(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*ListInterfacesResponse, error) {
+	out := new(ListInterfacesResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/ListInterfaces", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) UpdateRoutes(ctx context.Context, in *RoutesRequest, opts ...grpc.CallOption) (*RoutesResponse, error) {
+	out := new(RoutesResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/UpdateRoutes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *virtContainersClient) ListRoutes(ctx context.Context, in *SandboxRequest, opts ...grpc.CallOption) (*RoutesResponse, error) {
+	out := new(RoutesResponse)
+	err := grpc.Invoke(ctx, "/vcapi.VirtContainers/ListRoutes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for VirtContainers service
+
+type VirtContainersServer interface {
+	// sandbox
+	CreateSandbox(context.Context, *CreateSandboxRequest) (*google_protobuf.Empty, error)
+	StartSandbox(context.Context, *SandboxRequest) (*google_protobuf.Empty, error)
+	StopSandbox(context.Context, *SandboxRequest) (*google_protobuf.Empty, error)
+	DeleteSandbox(context.Context, *SandboxRequest) (*google_protobuf.Empty, error)
+	PauseSandbox(context.Context, *SandboxRequest) (*google_protobuf.Empty, error)
+	ResumeSandbox(context.Context, *SandboxRequest) (*google_protobuf.Empty, error)
+	StatusSandbox(context.Context, *SandboxRequest) (*StatusResponse, error)
+	ListSandbox(context.Context, *google_protobuf.Empty) (*ListSandboxResponse, error)
+	// container
+	CreateContainer(context.Context, *CreateContainerRequest) (*google_protobuf.Empty, error)
+	StartContainer(context.Context, *ContainerRequest) (*google_protobuf.Empty, error)
+	StopContainer(context.Context, *ContainerRequest) (*google_protobuf.Empty, error)
+	DeleteContainer(context.Context, *ContainerRequest) (*google_protobuf.Empty, error)
+	PauseContainer(context.Context, *ContainerRequest) (*google_protobuf.Empty, error)
+	ResumeContainer(context.Context, *ContainerRequest) (*google_protobuf.Empty, error)
+	KillContainer(context.Context, *KillContainerRequest) (*google_protobuf.Empty, error)
+	StatusContainer(context.Context, *ContainerRequest) (*StatusResponse, error)
+	StatsContainer(context.Context, *ContainerRequest) (*StatsResponse, error)
+	UpdateContainer(context.Context, *UpdateContainerRequest) (*google_protobuf.Empty, error)
+	// process
+	//
+	// The first input of a stream selects the process, the process
+	// identifier being the first output. The standard input is then
+	// streamed in, the standard output and error out, until the process
+	// exits.
+	EnterContainer(VirtContainers_EnterContainerServer) error
+	AttachProcess(VirtContainers_AttachProcessServer) error
+	SignalProcess(context.Context, *SignalProcessRequest) (*google_protobuf.Empty, error)
+	WaitProcess(context.Context, *ProcessRequest) (*WaitProcessResponse, error)
+	// network
+	AddInterface(context.Context, *InterfaceRequest) (*InterfaceResponse, error)
+	RemoveInterface(context.Context, *InterfaceRequest) (*InterfaceResponse, error)
+	ListInterfaces(context.Context, *SandboxRequest) (*ListInterfacesResponse, error)
+	UpdateRoutes(context.Context, *RoutesRequest) (*RoutesResponse, error)
+	ListRoutes(context.Context, *SandboxRequest) (*RoutesResponse, error)
+}
+
+func RegisterVirtContainersServer(s *grpc.Server, srv VirtContainersServer) {
+	s.RegisterService(&_VirtContainers_serviceDesc, srv)
+}
+
+func _VirtContainers_CreateSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).CreateSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/CreateSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).CreateSandbox(ctx, req.(*CreateSandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StartSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StartSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StartSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StartSandbox(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StopSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StopSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StopSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StopSandbox(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_DeleteSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).DeleteSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/DeleteSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).DeleteSandbox(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_PauseSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).PauseSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/PauseSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).PauseSandbox(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_ResumeSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).ResumeSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/ResumeSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).ResumeSandbox(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StatusSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StatusSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StatusSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StatusSandbox(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_ListSandbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).ListSandbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/ListSandbox",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).ListSandbox(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_CreateContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).CreateContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/CreateContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).CreateContainer(ctx, req.(*CreateContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StartContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StartContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StartContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StartContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StopContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StopContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StopContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StopContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_DeleteContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).DeleteContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/DeleteContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).DeleteContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_PauseContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).PauseContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/PauseContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).PauseContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_ResumeContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).ResumeContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/ResumeContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).ResumeContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_KillContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KillContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).KillContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/KillContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).KillContainer(ctx, req.(*KillContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StatusContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StatusContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StatusContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StatusContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_StatsContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).StatsContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/StatsContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).StatsContainer(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_UpdateContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).UpdateContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/UpdateContainer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).UpdateContainer(ctx, req.(*UpdateContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_EnterContainer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VirtContainersServer).EnterContainer(&virtContainersEnterContainerServer{stream})
+}
+
+type VirtContainers_EnterContainerServer interface {
+	Send(*ProcessOutput) error
+	Recv() (*ProcessInput, error)
+	grpc.ServerStream
+}
+
+type virtContainersEnterContainerServer struct {
+	grpc.ServerStream
+}
+
+func (x *virtContainersEnterContainerServer) Send(m *ProcessOutput) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *virtContainersEnterContainerServer) Recv() (*ProcessInput, error) {
+	m := new(ProcessInput)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _VirtContainers_AttachProcess_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VirtContainersServer).AttachProcess(&virtContainersAttachProcessServer{stream})
+}
+
+type VirtContainers_AttachProcessServer interface {
+	Send(*ProcessOutput) error
+	Recv() (*ProcessInput, error)
+	grpc.ServerStream
+}
+
+type virtContainersAttachProcessServer struct {
+	grpc.ServerStream
+}
+
+func (x *virtContainersAttachProcessServer) Send(m *ProcessOutput) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *virtContainersAttachProcessServer) Recv() (*ProcessInput, error) {
+	m := new(ProcessInput)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _VirtContainers_SignalProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignalProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).SignalProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/SignalProcess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).SignalProcess(ctx, req.(*SignalProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_WaitProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).WaitProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/WaitProcess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).WaitProcess(ctx, req.(*ProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_AddInterface_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InterfaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).AddInterface(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/AddInterface",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).AddInterface(ctx, req.(*InterfaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_RemoveInterface_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InterfaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).RemoveInterface(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/RemoveInterface",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).RemoveInterface(ctx, req.(*InterfaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_ListInterfaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).ListInterfaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/ListInterfaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).ListInterfaces(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_UpdateRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoutesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).UpdateRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/UpdateRoutes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).UpdateRoutes(ctx, req.(*RoutesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VirtContainers_ListRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SandboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VirtContainersServer).ListRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/vcapi.VirtContainers/ListRoutes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VirtContainersServer).ListRoutes(ctx, req.(*SandboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _VirtContainers_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "vcapi.VirtContainers",
+	HandlerType: (*VirtContainersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSandbox",
+			Handler:    _VirtContainers_CreateSandbox_Handler,
+		},
+		{
+			MethodName: "StartSandbox",
+			Handler:    _VirtContainers_StartSandbox_Handler,
+		},
+		{
+			MethodName: "StopSandbox",
+			Handler:    _VirtContainers_StopSandbox_Handler,
+		},
+		{
+			MethodName: "DeleteSandbox",
+			Handler:    _VirtContainers_DeleteSandbox_Handler,
+		},
+		{
+			MethodName: "PauseSandbox",
+			Handler:    _VirtContainers_PauseSandbox_Handler,
+		},
+		{
+			MethodName: "ResumeSandbox",
+			Handler:    _VirtContainers_ResumeSandbox_Handler,
+		},
+		{
+			MethodName: "StatusSandbox",
+			Handler:    _VirtContainers_StatusSandbox_Handler,
+		},
+		{
+			MethodName: "ListSandbox",
+			Handler:    _VirtContainers_ListSandbox_Handler,
+		},
+		{
+			MethodName: "CreateContainer",
+			Handler:    _VirtContainers_CreateContainer_Handler,
+		},
+		{
+			MethodName: "StartContainer",
+			Handler:    _VirtContainers_StartContainer_Handler,
+		},
+		{
+			MethodName: "StopContainer",
+			Handler:    _VirtContainers_StopContainer_Handler,
+		},
+		{
+			MethodName: "DeleteContainer",
+			Handler:    _VirtContainers_DeleteContainer_Handler,
+		},
+		{
+			MethodName: "PauseContainer",
+			Handler:    _VirtContainers_PauseContainer_Handler,
+		},
+		{
+			MethodName: "ResumeContainer",
+			Handler:    _VirtContainers_ResumeContainer_Handler,
+		},
+		{
+			MethodName: "KillContainer",
+			Handler:    _VirtContainers_KillContainer_Handler,
+		},
+		{
+			MethodName: "StatusContainer",
+			Handler:    _VirtContainers_StatusContainer_Handler,
+		},
+		{
+			MethodName: "StatsContainer",
+			Handler:    _VirtContainers_StatsContainer_Handler,
+		},
+		{
+			MethodName: "UpdateContainer",
+			Handler:    _VirtContainers_UpdateContainer_Handler,
+		},
+		{
+			MethodName: "SignalProcess",
+			Handler:    _VirtContainers_SignalProcess_Handler,
+		},
+		{
+			MethodName: "WaitProcess",
+			Handler:    _VirtContainers_WaitProcess_Handler,
+		},
+		{
+			MethodName: "AddInterface",
+			Handler:    _VirtContainers_AddInterface_Handler,
+		},
+		{
+			MethodName: "RemoveInterface",
+			Handler:    _VirtContainers_RemoveInterface_Handler,
+		},
+		{
+			MethodName: "ListInterfaces",
+			Handler:    _VirtContainers_ListInterfaces_Handler,
+		},
+		{
+			MethodName: "UpdateRoutes",
+			Handler:    _VirtContainers_UpdateRoutes_Handler,
+		},
+		{
+			MethodName: "ListRoutes",
+			Handler:    _VirtContainers_ListRoutes_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EnterContainer",
+			Handler:       _VirtContainers_EnterContainer_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "AttachProcess",
+			Handler:       _VirtContainers_AttachProcess_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "vcapi.proto",
+}
+
+func init() { proto.RegisterFile("vcapi.proto", fileDescriptorVcapi) }
+
+var fileDescriptorVcapi = []byte{
+	// 954 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0xdf, 0x6f, 0xe3, 0x44,
+	0x10, 0x96, 0xdb, 0x26, 0xd7, 0x4c, 0xec, 0xb4, 0x6c, 0x73, 0xc1, 0x4a, 0xaf, 0x50, 0x2c, 0x21,
+	0xf5, 0x29, 0x85, 0x43, 0x42, 0x08, 0x38, 0x8e, 0xd0, 0xdc, 0x89, 0x08, 0xa4, 0xab, 0x1c, 0x7e,
+	0x3c, 0x56, 0xae, 0x77, 0xaf, 0x31, 0x72, 0xbd, 0x3e, 0xef, 0xfa, 0x28, 0xaf, 0x3c, 0xf0, 0xca,
+	0x03, 0xe2, 0x0f, 0xe0, 0x3f, 0x45, 0xfb, 0xc3, 0x1b, 0xdb, 0xe7, 0xf4, 0x72, 0x49, 0xef, 0xde,
+	0x3c, 0xe3, 0xf9, 0x66, 0x67, 0x67, 0xbe, 0xdd, 0x6f, 0xa1, 0xfb, 0x32, 0x0c, 0xd2, 0x68, 0x94,
+	0x66, 0x94, 0x53, 0xd4, 0x92, 0xc6, 0xf0, 0xf0, 0x8a, 0xd2, 0xab, 0x98, 0x9c, 0x4a, 0xe7, 0x65,
+	0xfe, 0xfc, 0x94, 0x5c, 0xa7, 0xfc, 0x0f, 0x15, 0xe3, 0x9d, 0x42, 0x6f, 0x16, 0x24, 0xf8, 0x92,
+	0xde, 0xf8, 0xe4, 0x45, 0x4e, 0x18, 0x47, 0x47, 0x00, 0x4c, 0x79, 0x2e, 0x22, 0xec, 0x5a, 0xc7,
+	0xd6, 0x49, 0xc7, 0xef, 0x68, 0xcf, 0x14, 0x7b, 0xbf, 0x41, 0xff, 0x2c, 0x23, 0x01, 0x27, 0x6f,
+	0x04, 0x43, 0x08, 0x76, 0x58, 0x4a, 0x42, 0x77, 0xeb, 0xd8, 0x3a, 0xb1, 0x7d, 0xf9, 0x8d, 0x3e,
+	0x84, 0xee, 0x65, 0x9e, 0xe0, 0x98, 0x5c, 0xa4, 0x01, 0x9f, 0xbb, 0xdb, 0x12, 0x03, 0xca, 0x75,
+	0x1e, 0xf0, 0xb9, 0xf7, 0x39, 0x1c, 0xfc, 0x18, 0x31, 0x6e, 0x56, 0x62, 0x29, 0x4d, 0x18, 0x11,
+	0xb8, 0xc5, 0x52, 0xcc, 0xb5, 0x8e, 0xb7, 0x05, 0xce, 0xac, 0xc5, 0xbc, 0x9f, 0x60, 0xff, 0x8c,
+	0x26, 0x3c, 0x88, 0x12, 0x92, 0xad, 0x58, 0xdf, 0x47, 0x60, 0x87, 0x05, 0x44, 0x04, 0x6c, 0xc9,
+	0x80, 0xae, 0xf1, 0x4d, 0xb1, 0xf7, 0xb7, 0x05, 0x03, 0xb5, 0xf5, 0xbb, 0x4f, 0x6e, 0xfa, 0xb3,
+	0xbd, 0xbc, 0x3f, 0x3b, 0xaf, 0xf4, 0xe7, 0x4f, 0x0b, 0xfa, 0x3f, 0x44, 0x71, 0xfc, 0x16, 0xea,
+	0x19, 0x40, 0x9b, 0x45, 0x57, 0x49, 0x10, 0xcb, 0x8a, 0x1c, 0x5f, 0x5b, 0x68, 0x1f, 0xb6, 0x83,
+	0x38, 0x96, 0xb5, 0xec, 0xfa, 0xe2, 0xd3, 0xbb, 0x81, 0xc1, 0xcf, 0x29, 0x7e, 0x3b, 0x5d, 0x79,
+	0x00, 0x9d, 0x8c, 0x30, 0x9a, 0x67, 0x21, 0x61, 0xba, 0x35, 0x0b, 0x87, 0x77, 0x02, 0xbd, 0x19,
+	0x0f, 0x78, 0xce, 0x0c, 0x33, 0x44, 0xd5, 0xd2, 0x23, 0x57, 0xb3, 0x7d, 0x6d, 0x79, 0x1f, 0x83,
+	0x23, 0x22, 0x17, 0x81, 0x7d, 0x68, 0x89, 0x5f, 0x45, 0x9c, 0x32, 0xbc, 0xbf, 0xb6, 0xc0, 0x3e,
+	0xcf, 0x68, 0x48, 0x18, 0x9b, 0x26, 0x69, 0x7e, 0x17, 0x3b, 0x38, 0x02, 0x48, 0x55, 0x46, 0x11,
+	0xa0, 0x28, 0xde, 0xd1, 0x9e, 0x29, 0x46, 0x2e, 0xdc, 0xd3, 0x86, 0x6c, 0xa9, 0xed, 0x17, 0xa6,
+	0xaa, 0x10, 0x47, 0x89, 0xdb, 0x2a, 0x2a, 0xc4, 0x51, 0x22, 0x28, 0x11, 0xc6, 0x94, 0x91, 0x0b,
+	0xf5, 0xaf, 0x2d, 0xc7, 0x00, 0xd2, 0x35, 0x93, 0x01, 0x03, 0x68, 0xcf, 0x49, 0x74, 0x35, 0xe7,
+	0xee, 0x3d, 0x35, 0x37, 0x65, 0x89, 0x74, 0xbf, 0x47, 0x98, 0xcf, 0xdd, 0x5d, 0xe9, 0x56, 0x46,
+	0x69, 0xca, 0x9d, 0xf2, 0x94, 0xbd, 0x7f, 0x2c, 0x70, 0x74, 0x23, 0x9e, 0xe5, 0x5c, 0x77, 0xa2,
+	0xb4, 0x0f, 0xab, 0xbe, 0x0f, 0xd9, 0x78, 0x4c, 0x73, 0xae, 0x0f, 0xb8, 0xb6, 0xb4, 0x9f, 0x64,
+	0x99, 0x9e, 0x9e, 0xb6, 0x84, 0x9f, 0xdc, 0x44, 0x9c, 0x60, 0xcd, 0x24, 0x6d, 0xa1, 0x43, 0xe8,
+	0x88, 0xaf, 0x8b, 0x90, 0x62, 0x22, 0x77, 0xde, 0xf2, 0x77, 0x85, 0xe3, 0x8c, 0x62, 0xe2, 0xbd,
+	0x80, 0x9e, 0x2e, 0xea, 0xee, 0x18, 0x76, 0xfb, 0x7c, 0xbc, 0xff, 0x2c, 0xe8, 0xcf, 0x64, 0x4f,
+	0xde, 0xf1, 0xca, 0xa5, 0xd1, 0xec, 0x34, 0x1d, 0xc0, 0xd6, 0xe2, 0x00, 0x3e, 0x84, 0x83, 0x5f,
+	0x83, 0x88, 0x9b, 0x02, 0x35, 0xc5, 0x2b, 0xad, 0xb4, 0x6a, 0xad, 0x7c, 0x06, 0xfb, 0xd3, 0x84,
+	0x93, 0xec, 0x79, 0x10, 0x92, 0x15, 0xb7, 0xf4, 0x00, 0x3a, 0x51, 0x01, 0xd1, 0x53, 0x5e, 0x38,
+	0xbc, 0x4f, 0xe1, 0xbd, 0x52, 0x42, 0x5d, 0x42, 0x05, 0x62, 0xd5, 0x21, 0x5f, 0xc0, 0x40, 0xdc,
+	0xee, 0x06, 0xb6, 0x28, 0xfd, 0x03, 0x00, 0x13, 0x56, 0x1c, 0xd1, 0x92, 0xc7, 0x7b, 0x0a, 0x8e,
+	0x4f, 0x73, 0x4e, 0x56, 0x9d, 0xc6, 0x00, 0xda, 0x99, 0x8c, 0x2f, 0xd8, 0xa9, 0x2c, 0x71, 0x81,
+	0x14, 0x79, 0x16, 0x17, 0x88, 0x8e, 0xb4, 0xca, 0x91, 0x0f, 0xff, 0xed, 0x41, 0xef, 0x97, 0x28,
+	0xe3, 0xe6, 0x8e, 0x63, 0x68, 0x02, 0x4e, 0x45, 0x08, 0xd1, 0xe1, 0x48, 0x89, 0x6f, 0x93, 0x3c,
+	0x0e, 0x07, 0x23, 0xa5, 0xc2, 0xa3, 0x42, 0x85, 0x47, 0x4f, 0x84, 0x0a, 0xa3, 0x47, 0x60, 0xcf,
+	0x78, 0x90, 0x15, 0x1a, 0x87, 0xee, 0xeb, 0x24, 0x2b, 0xc2, 0xbf, 0x86, 0xee, 0x8c, 0xd3, 0x74,
+	0x4d, 0xf4, 0x37, 0xe0, 0x4c, 0x48, 0x4c, 0x38, 0x59, 0x13, 0xff, 0x08, 0xec, 0xf3, 0x20, 0x67,
+	0x64, 0xfd, 0xe5, 0x7d, 0xc2, 0xf2, 0xeb, 0xf5, 0x97, 0x77, 0xd4, 0xfd, 0xff, 0x1a, 0xbc, 0x71,
+	0x57, 0xc5, 0x62, 0x0c, 0xdd, 0xd2, 0xeb, 0x02, 0x2d, 0x59, 0x65, 0x38, 0xd4, 0xe8, 0xa6, 0x97,
+	0xc8, 0xf7, 0xb0, 0x57, 0x7b, 0x11, 0xa0, 0xa3, 0x0a, 0x0b, 0xea, 0x9a, 0xb8, 0x74, 0x2f, 0x63,
+	0xa9, 0x65, 0x25, 0x82, 0xa1, 0xf7, 0x8b, 0x44, 0xab, 0xa6, 0xf8, 0x56, 0xb4, 0x83, 0xa6, 0x1b,
+	0x64, 0xf8, 0x0e, 0xf6, 0x14, 0x1f, 0x36, 0xc8, 0x31, 0x86, 0x9e, 0xe4, 0xc4, 0x66, 0x65, 0x28,
+	0x5e, 0x6c, 0x90, 0x63, 0x02, 0x4e, 0xe5, 0x65, 0x64, 0x4e, 0x67, 0xd3, 0x7b, 0xe9, 0x96, 0xcd,
+	0xec, 0x29, 0xd2, 0xac, 0x50, 0xc9, 0x12, 0x96, 0x3d, 0x56, 0x8f, 0x94, 0x55, 0x32, 0xf4, 0x4b,
+	0x19, 0x58, 0x99, 0x63, 0xb5, 0xf7, 0x95, 0xe1, 0x58, 0xf3, 0xbb, 0x6b, 0xe9, 0x6e, 0x1e, 0x43,
+	0xef, 0x89, 0xb8, 0x44, 0x17, 0x89, 0x0e, 0x74, 0xa2, 0xf2, 0xa3, 0x67, 0xd8, 0xaf, 0x3a, 0xd5,
+	0x03, 0xe0, 0xc4, 0xfa, 0xc4, 0x12, 0x07, 0x76, 0xcc, 0x79, 0x10, 0xce, 0xf5, 0xaf, 0x37, 0xc5,
+	0x4f, 0xc0, 0xa9, 0x88, 0xa9, 0x19, 0x4a, 0x93, 0xc4, 0xde, 0xc2, 0xf3, 0x6e, 0x49, 0xef, 0xcc,
+	0xa1, 0xaf, 0xa1, 0x8b, 0x63, 0xdb, 0x24, 0x8d, 0x63, 0xb0, 0xc7, 0x18, 0x1b, 0xe1, 0x31, 0x13,
+	0xa9, 0x4b, 0xe2, 0xd0, 0x7d, 0xf5, 0x87, 0x4e, 0x31, 0x11, 0x1c, 0xbd, 0xa6, 0x2f, 0xc9, 0x46,
+	0x59, 0x9e, 0x42, 0xaf, 0x2a, 0x81, 0xcb, 0xae, 0xb0, 0xa3, 0xd2, 0x25, 0xd4, 0x20, 0x98, 0x5f,
+	0x81, 0xad, 0xb8, 0xa0, 0xe4, 0x0c, 0x15, 0x23, 0xa8, 0xa8, 0xe4, 0xf0, 0x7e, 0xcd, 0xab, 0xc1,
+	0x5f, 0x02, 0x88, 0xb4, 0x1a, 0xfa, 0x9a, 0x3b, 0xb4, 0x8a, 0xbd, 0x6c, 0xcb, 0xd9, 0x7c, 0xf6,
+	0xff, 0x00, 0xca, 0x74, 0x45, 0xc7, 0x78, 0x0e, 0x00, 0x00,
+}
@@ -7,6 +7,7 @@ package virtcontainers
 
 import (
 	"fmt"
+	"io"
 	"syscall"
 	"time"
 
@@ -255,6 +256,18 @@ type agent interface {
 	// copyFile copies file from host to container's rootfs
 	copyFile(src, dst string) error
 
+	// copyToContainer extracts a tar archive into the dst directory of a
+	// container's rootfs
+	copyToContainer(c *Container, dst string, archive io.Reader) error
+
+	// copyFromContainer writes a tar archive of the src path of a
+	// container's rootfs
+	copyFromContainer(c *Container, src string, archive io.Writer) error
+
+	// portForward opens a connection to a TCP port of the sandbox network
+	// namespace, through a process forwarding it run in container c
+	portForward(c *Container, port uint32) (io.ReadWriteCloser, error)
+
 	// cleanup removes all on disk information generated by the agent
 	cleanup(id string)
 }
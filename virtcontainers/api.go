@@ -7,9 +7,11 @@ package virtcontainers
 
 import (
 	"context"
+	"io"
 	"os"
 	"runtime"
 	"syscall"
+	"time"
 
 	deviceApi "github.com/kata-containers/runtime/virtcontainers/device/api"
 	deviceConfig "github.com/kata-containers/runtime/virtcontainers/device/config"
@@ -72,10 +74,12 @@ func createSandboxFromConfig(ctx context.Context, sandboxConfig SandboxConfig, f
 	var err error
 
 	// Create the sandbox.
+	start := time.Now()
 	s, err := createSandbox(ctx, sandboxConfig, factory)
 	if err != nil {
 		return nil, err
 	}
+	s.timeline.record(phaseCreateSandbox, start)
 
 	// cleanup sandbox resources in case of any failure
 	defer func() {
@@ -367,6 +371,31 @@ func StatusSandbox(ctx context.Context, sandboxID string) (SandboxStatus, error)
 	return sandboxStatus, nil
 }
 
+// ProfileSandbox is the virtcontainers sandbox startup profile entry point.
+// It returns the timeline recorded while the sandbox was started.
+func ProfileSandbox(ctx context.Context, sandboxID string) (SandboxTimeline, error) {
+	span, ctx := trace(ctx, "ProfileSandbox")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return SandboxTimeline{}, errNeedSandboxID
+	}
+
+	lockFile, err := rLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return SandboxTimeline{}, err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err := fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return SandboxTimeline{}, err
+	}
+	defer s.releaseStatelessSandbox()
+
+	return s.timeline, nil
+}
+
 // CreateContainer is the virtcontainers container creation entry point.
 // CreateContainer creates a container on a given sandbox.
 func CreateContainer(ctx context.Context, sandboxID string, containerConfig ContainerConfig) (VCSandbox, VCContainer, error) {
@@ -676,6 +705,66 @@ func ProcessListContainer(ctx context.Context, sandboxID, containerID string, op
 	return s.ProcessListContainer(containerID, options)
 }
 
+// CopyToContainer is the virtcontainers entry point to copy files into a
+// container. The tar archive is extracted into the dst directory of the
+// container root filesystem.
+func CopyToContainer(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error {
+	span, ctx := trace(ctx, "CopyToContainer")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return errNeedContainerID
+	}
+
+	lockFile, err := rLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err := fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer s.releaseStatelessSandbox()
+
+	return s.CopyToContainer(containerID, dst, archive)
+}
+
+// CopyFromContainer is the virtcontainers entry point to copy files out of
+// a container. A tar archive of the src path of the container root
+// filesystem is written to archive.
+func CopyFromContainer(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error {
+	span, ctx := trace(ctx, "CopyFromContainer")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return errNeedContainerID
+	}
+
+	lockFile, err := rLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err := fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer s.releaseStatelessSandbox()
+
+	return s.CopyFromContainer(containerID, src, archive)
+}
+
 // UpdateContainer is the virtcontainers entry point to update
 // container's resources.
 func UpdateContainer(ctx context.Context, sandboxID, containerID string, resources specs.LinuxResources) error {
@@ -802,6 +891,54 @@ func AddDevice(ctx context.Context, sandboxID string, info deviceConfig.DeviceIn
 	return s.AddDevice(info)
 }
 
+// RemoveDevice will remove a device from sandbox
+func RemoveDevice(ctx context.Context, sandboxID string, info deviceConfig.DeviceInfo) (deviceApi.Device, error) {
+	span, ctx := trace(ctx, "RemoveDevice")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	lockFile, err := rwLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err := fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseStatelessSandbox()
+
+	return s.RemoveDevice(info)
+}
+
+// ListDevices will list all the devices of a sandbox
+func ListDevices(ctx context.Context, sandboxID string) ([]deviceApi.Device, error) {
+	span, ctx := trace(ctx, "ListDevices")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	lockFile, err := rLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err := fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseStatelessSandbox()
+
+	return s.ListDevices()
+}
+
 func toggleInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface, add bool) (*vcTypes.Interface, error) {
 	if sandboxID == "" {
 		return nil, errNeedSandboxID
@@ -908,6 +908,39 @@ func TestStatusSandboxSuccessfulStateReady(t *testing.T) {
 	}
 }
 
+func TestProfileSandbox(t *testing.T) {
+	cleanUp()
+
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	_, err := ProfileSandbox(ctx, "")
+	assert.Error(err)
+
+	config := newTestSandboxConfigNoop()
+
+	p, err := CreateSandbox(ctx, config, nil)
+	assert.NoError(err)
+	assert.NotNil(p)
+
+	timeline, err := ProfileSandbox(ctx, p.ID())
+	assert.NoError(err)
+
+	var phases []string
+	for _, phase := range timeline.Phases {
+		phases = append(phases, phase.Name)
+	}
+
+	assert.Equal([]string{
+		phaseCreateSandbox,
+		phaseHypervisorStart,
+		phaseAgentStart,
+		phaseGuestDetails,
+		phaseCreateContainer,
+	}, phases)
+	assert.NotZero(timeline.Total())
+}
+
 func TestStatusSandboxSuccessfulStateRunning(t *testing.T) {
 	cleanUp()
 
@@ -1992,6 +2025,45 @@ func TestProcessListContainer(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestCopyContainer(t *testing.T) {
+	cleanUp()
+
+	assert := assert.New(t)
+
+	contID := "abc"
+	ctx := context.Background()
+
+	err := CopyToContainer(ctx, "", "", "/tmp", nil)
+	assert.Error(err)
+
+	err = CopyFromContainer(ctx, "xyz", "", "/tmp", nil)
+	assert.Error(err)
+
+	err = CopyToContainer(ctx, "xyz", "xyz", "/tmp", nil)
+	assert.Error(err)
+
+	config := newTestSandboxConfigNoop()
+	p, err := CreateSandbox(ctx, config, nil)
+	assert.NoError(err)
+	assert.NotNil(p)
+	defer store.DeleteAll()
+
+	contConfig := newTestContainerConfigNoop(contID)
+	_, c, err := CreateContainer(ctx, p.ID(), contConfig)
+	assert.NoError(err)
+	assert.NotNil(c)
+
+	err = CopyToContainer(ctx, p.ID(), "xyz", "/tmp", nil)
+	assert.Error(err)
+
+	// Sandbox not running, impossible to copy files
+	err = CopyToContainer(ctx, p.ID(), contID, "/tmp", nil)
+	assert.Error(err)
+
+	err = CopyFromContainer(ctx, p.ID(), contID, "/tmp", nil)
+	assert.Error(err)
+}
+
 /*
  * Benchmarks
  */
@@ -2275,6 +2347,43 @@ func TestFetchStatefulSandbox(t *testing.T) {
 	assert.Equal(t, fetched, s, "fetched stateful sandboxed should match")
 }
 
+func TestFetchSandboxHugePages(t *testing.T) {
+	assert := assert.New(t)
+	cleanUp()
+
+	config := newTestSandboxConfigNoop()
+	config.HypervisorConfig.MemorySize = 2048
+
+	ctx := context.Background()
+
+	s, err := CreateSandbox(ctx, config, nil)
+	assert.NoError(err)
+
+	// the hugepages of the containers added to the sandbox cannot be
+	// reserved once the guest runs
+	contConfig := newTestContainerConfigNoop("100")
+	contConfig.Resources.HugepageLimits = []specs.LinuxHugepageLimit{
+		{Pagesize: "2MB", Limit: 4 << 20},
+	}
+	_, _, err = CreateContainer(ctx, s.ID(), contConfig)
+	assert.Error(err)
+
+	// nor are they when the sandbox is fetched
+	vcStore, err := store.NewVCSandboxStore(ctx, s.ID())
+	assert.NoError(err)
+
+	var stored SandboxConfig
+	assert.NoError(vcStore.Load(store.Configuration, &stored))
+	stored.Containers = append(stored.Containers, contConfig)
+	assert.NoError(vcStore.Store(store.Configuration, stored))
+
+	fetched, err := fetchSandbox(ctx, s.ID())
+	assert.NoError(err)
+	defer fetched.Release()
+	assert.Empty(fetched.config.HypervisorConfig.KernelParams)
+	assert.Equal(uint32(2048), fetched.config.HypervisorConfig.MemorySize)
+}
+
 func TestFetchNonExistingSandbox(t *testing.T) {
 	cleanUp()
 
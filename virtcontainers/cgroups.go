@@ -158,6 +158,10 @@ func (s *Sandbox) updateCgroups() error {
 		return nil
 	}
 
+	if err := s.bindCgroupMemory(s.state.CgroupPath); err != nil {
+		return err
+	}
+
 	resources, err := s.resources()
 	if err != nil {
 		return err
@@ -203,6 +203,23 @@ type ContainerResources struct {
 	MemByte int64
 }
 
+// RootfsImage describes a container rootfs stored in a file-backed
+// disk image, attached to the VM as a block device.
+type RootfsImage struct {
+	// Path is the disk image file path on the host.
+	Path string
+
+	// Format is the disk image format, raw or qcow2.
+	Format string
+
+	// Fstype is the type of the filesystem stored in the disk image.
+	Fstype string
+
+	// Options are the options used to mount the disk image
+	// filesystem inside the VM.
+	Options []string
+}
+
 // ContainerConfig describes one container runtime configuration.
 type ContainerConfig struct {
 	ID string
@@ -210,6 +227,10 @@ type ContainerConfig struct {
 	// RootFs is the container workload image on the host.
 	RootFs string
 
+	// RootfsImage is the disk image holding the container rootfs, when
+	// the rootfs is not a host directory. RootFs is ignored if it is set.
+	RootfsImage *RootfsImage
+
 	// ReadOnlyRootfs indicates if the rootfs should be mounted readonly
 	ReadonlyRootfs bool
 
@@ -476,7 +497,13 @@ func (c *Container) mountSharedDirMounts(hostSharedDir, guestSharedDir string) (
 	var sharedDirMounts []Mount
 	var ignoredMounts []Mount
 	for idx, m := range c.mounts {
-		if isSystemMount(m.Destination) || m.Type != "bind" {
+		if isSystemMount(m.Destination) {
+			continue
+		}
+
+		// Block-backed emptyDir volumes are attached like the block
+		// device files.
+		if m.Type != "bind" && (m.Type != kataEphemeralDevType || len(m.BlockDeviceID) == 0) {
 			continue
 		}
 
@@ -589,6 +616,23 @@ func filterDevices(sandbox *Sandbox, c *Container, devices []ContainerDevice) (r
 func (c *Container) createBlockDevices() error {
 	// iterate all mounts and create block device if it's block based.
 	for i, m := range c.mounts {
+		if len(m.BlockDeviceID) == 0 && m.Type == kataEphemeralDevType {
+			block, err := c.isBlockEmptyDir(m)
+			if err != nil {
+				return fmt.Errorf("stat %q failed: %v", m.Source, err)
+			}
+
+			if block {
+				id, err := c.createEmptyDirDevice(m)
+				if err != nil {
+					return err
+				}
+
+				c.mounts[i].BlockDeviceID = id
+			}
+			continue
+		}
+
 		if len(m.BlockDeviceID) > 0 || m.Type != "bind" {
 			// Non-empty m.BlockDeviceID indicates there's already one device
 			// associated with the mount,so no need to create a new device for it
@@ -736,7 +780,7 @@ func (c *Container) create() (err error) {
 		}
 	}()
 
-	if c.checkBlockDeviceSupport() {
+	if c.rootfsImage() != nil || c.checkBlockDeviceSupport() {
 		if err = c.hotplugDrive(); err != nil {
 			return
 		}
@@ -788,6 +832,8 @@ func (c *Container) delete() error {
 		return err
 	}
 
+	c.removeEmptyDirImages()
+
 	if err := c.deleteCgroups(); err != nil {
 		return err
 	}
@@ -1025,6 +1071,30 @@ func (c *Container) processList(options ProcessListOptions) (ProcessList, error)
 	return c.sandbox.agent.processListContainer(c.sandbox, *c, options)
 }
 
+func (c *Container) copyTo(dst string, archive io.Reader) error {
+	if err := c.checkSandboxRunning("cp"); err != nil {
+		return err
+	}
+
+	if c.state.State != types.StateRunning {
+		return fmt.Errorf("Container not running, impossible to copy files")
+	}
+
+	return c.sandbox.agent.copyToContainer(c, dst, archive)
+}
+
+func (c *Container) copyFrom(src string, archive io.Writer) error {
+	if err := c.checkSandboxRunning("cp"); err != nil {
+		return err
+	}
+
+	if c.state.State != types.StateRunning {
+		return fmt.Errorf("Container not running, impossible to copy files")
+	}
+
+	return c.sandbox.agent.copyFromContainer(c, src, archive)
+}
+
 func (c *Container) stats() (*ContainerStats, error) {
 	if err := c.checkSandboxRunning("stats"); err != nil {
 		return nil, err
@@ -1106,6 +1176,10 @@ func (c *Container) resume() error {
 }
 
 func (c *Container) hotplugDrive() error {
+	if image := c.rootfsImage(); image != nil {
+		return c.hotplugRootfsImage(image)
+	}
+
 	dev, err := getDeviceForPath(c.rootFs)
 
 	if err == errMountPointNotFound {
@@ -1153,30 +1227,80 @@ func (c *Container) hotplugDrive() error {
 	}
 
 	if c.checkBlockDeviceSupport() && stat.Mode&unix.S_IFBLK == unix.S_IFBLK {
-		b, err := c.sandbox.devManager.NewDevice(config.DeviceInfo{
+		if err := c.attachRootfsDevice(config.DeviceInfo{
 			HostPath:      devicePath,
 			ContainerPath: filepath.Join(kataGuestSharedDir, c.id),
 			DevType:       "b",
 			Major:         int64(unix.Major(stat.Rdev)),
 			Minor:         int64(unix.Minor(stat.Rdev)),
-		})
-		if err != nil {
-			return fmt.Errorf("device manager failed to create rootfs device for %q: %v", devicePath, err)
+		}); err != nil {
+			return err
 		}
+	}
 
-		c.state.BlockDeviceID = b.DeviceID()
+	return c.setStateFstype(fsType)
+}
 
-		// attach rootfs device
-		if err := c.sandbox.devManager.AttachDevice(b.DeviceID(), c.sandbox); err != nil {
-			return err
-		}
+// hotplugRootfsImage attaches the container rootfs disk image to the VM,
+// the image file is handed to the hypervisor without any host loop device.
+func (c *Container) hotplugRootfsImage(image *RootfsImage) error {
+	if !c.checkBlockDeviceSupport() {
+		return fmt.Errorf("container rootfs disk image %q requires block device support", image.Path)
+	}
 
-		if err := c.sandbox.storeSandboxDevices(); err != nil {
-			return err
-		}
+	switch image.Format {
+	case config.ImageFormatRaw, config.ImageFormatQcow2:
+	default:
+		return fmt.Errorf("unsupported rootfs disk image format %q", image.Format)
 	}
 
-	return c.setStateFstype(fsType)
+	if image.Fstype == "" {
+		return fmt.Errorf("missing filesystem type for rootfs disk image %q", image.Path)
+	}
+
+	c.Logger().WithFields(logrus.Fields{
+		"image-path":   image.Path,
+		"image-format": image.Format,
+		"fs-type":      image.Fstype,
+	}).Info("Rootfs disk image detected")
+
+	if err := c.attachRootfsDevice(config.DeviceInfo{
+		HostPath:      image.Path,
+		ContainerPath: filepath.Join(kataGuestSharedDir, c.id),
+		DevType:       "b",
+		DriverOptions: map[string]string{
+			config.BlockImageFormat: image.Format,
+		},
+	}); err != nil {
+		return err
+	}
+
+	return c.setStateFstype(image.Fstype)
+}
+
+func (c *Container) attachRootfsDevice(devInfo config.DeviceInfo) error {
+	b, err := c.sandbox.devManager.NewDevice(devInfo)
+	if err != nil {
+		return fmt.Errorf("device manager failed to create rootfs device for %q: %v", devInfo.HostPath, err)
+	}
+
+	c.state.BlockDeviceID = b.DeviceID()
+
+	// attach rootfs device
+	if err := c.sandbox.devManager.AttachDevice(b.DeviceID(), c.sandbox); err != nil {
+		return err
+	}
+
+	return c.sandbox.storeSandboxDevices()
+}
+
+// rootfsImage returns the disk image holding the container rootfs, if any.
+func (c *Container) rootfsImage() *RootfsImage {
+	if c.config == nil {
+		return nil
+	}
+
+	return c.config.RootfsImage
 }
 
 // isDriveUsed checks if a drive has been used for container rootfs
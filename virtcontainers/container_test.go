@@ -275,6 +275,98 @@ func TestContainerAddDriveDir(t *testing.T) {
 	}
 }
 
+// blockHotplugHypervisor is a mock hypervisor able to hotplug block devices.
+type blockHotplugHypervisor struct {
+	mockHypervisor
+}
+
+func (h *blockHotplugHypervisor) capabilities() types.Capabilities {
+	var caps types.Capabilities
+
+	caps.SetBlockDeviceHotplugSupport()
+
+	return caps
+}
+
+func TestContainerAddDriveRootfsImage(t *testing.T) {
+	assert := assert.New(t)
+
+	sandbox := &Sandbox{
+		ctx:        context.Background(),
+		id:         testSandboxID,
+		devManager: manager.NewDeviceManager(manager.VirtioBlock, nil),
+		hypervisor: &mockHypervisor{},
+		agent:      &noopAgent{},
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				BlockDeviceDriver: config.VirtioBlock,
+			},
+		},
+	}
+
+	defer store.DeleteAll()
+
+	sandboxStore, err := store.NewVCSandboxStore(sandbox.ctx, sandbox.id)
+	assert.NoError(err)
+	sandbox.store = sandboxStore
+
+	image := &RootfsImage{
+		Path:    "/images/rootfs.qcow2",
+		Format:  "vmdk",
+		Fstype:  "xfs",
+		Options: []string{"ro"},
+	}
+
+	container := Container{
+		sandbox: sandbox,
+		id:      "100",
+		config: &ContainerConfig{
+			RootfsImage: image,
+		},
+	}
+
+	containerStore, err := store.NewVCContainerStore(sandbox.ctx, sandbox.id, container.id)
+	assert.NoError(err)
+	container.store = containerStore
+
+	// No block device support
+	image.Format = config.ImageFormatQcow2
+	err = container.hotplugDrive()
+	assert.Error(err)
+
+	sandbox.hypervisor = &blockHotplugHypervisor{}
+	sandbox.agent = &kataAgent{}
+
+	// Unsupported image format
+	image.Format = "vmdk"
+	err = container.hotplugDrive()
+	assert.Error(err)
+
+	image.Format = config.ImageFormatQcow2
+	err = container.hotplugDrive()
+	assert.NoError(err)
+	assert.Equal("xfs", container.state.Fstype)
+
+	device := sandbox.devManager.GetDeviceByID(container.state.BlockDeviceID)
+	assert.NotNil(device)
+
+	drive, ok := device.GetDeviceInfo().(*config.BlockDrive)
+	assert.True(ok)
+	assert.Equal(image.Path, drive.File)
+	assert.Equal(config.ImageFormatQcow2, drive.Format)
+
+	k := &kataAgent{ctx: context.Background()}
+	rootfs, err := k.buildContainerRootfs(sandbox, &container, "/rootfs")
+	assert.NoError(err)
+	assert.Equal(kataBlkDevType, rootfs.Driver)
+	assert.Equal("xfs", rootfs.Fstype)
+	assert.Equal([]string{"nouuid", "ro"}, rootfs.Options)
+
+	err = container.removeDrive()
+	assert.NoError(err)
+	assert.Empty(sandbox.devManager.GetAllDevices())
+}
+
 func TestCheckSandboxRunningEmptyCmdFailure(t *testing.T) {
 	c := &Container{}
 	err := c.checkSandboxRunning("")
@@ -52,6 +52,18 @@ const (
 	Nvdimm = "nvdimm"
 )
 
+const (
+	// BlockImageFormat is the DeviceInfo driver option holding the format
+	// of a file-backed disk image attached as a block device.
+	BlockImageFormat = "image-format"
+
+	// ImageFormatRaw is the format of raw disk images.
+	ImageFormatRaw = "raw"
+
+	// ImageFormatQcow2 is the format of QEMU copy-on-write disk images.
+	ImageFormatQcow2 = "qcow2"
+)
+
 // Defining these as a variable instead of a const, to allow
 // overriding this in the tests.
 
@@ -95,6 +107,11 @@ type DeviceInfo struct {
 	// DriverOptions is specific options for each device driver
 	// for example, for BlockDevice, we can set DriverOptions["blockDriver"]="virtio-blk"
 	DriverOptions map[string]string
+
+	// ColdPlug specifies whether the device must be cold plugged, i.e.
+	// appended to the hypervisor boot command line, instead of being
+	// hotplugged into the running VM.
+	ColdPlug bool
 }
 
 // BlockDrive represents a block storage drive which may be used in case the storage
@@ -156,6 +173,11 @@ type VFIODev struct {
 
 	// sysfsdev of VFIO mediated device
 	SysfsDev string
+
+	// GuestPCIAddr is the PCI address of the device inside the VM, in the
+	// bridge-addr/device-addr format, eg. "02/01". It is only known for
+	// devices attached to a PCI bridge.
+	GuestPCIAddr string
 }
 
 // RNGDev represents a random number generator device
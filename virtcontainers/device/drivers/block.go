@@ -62,12 +62,15 @@ func (device *BlockDevice) Attach(devReceiver api.DeviceReceiver) (err error) {
 
 	drive := &config.BlockDrive{
 		File:   device.DeviceInfo.HostPath,
-		Format: "raw",
+		Format: config.ImageFormatRaw,
 		ID:     utils.MakeNameID("drive", device.DeviceInfo.ID, maxDevIDSize),
 		Index:  index,
 	}
 
 	customOptions := device.DeviceInfo.DriverOptions
+	if format := customOptions[config.BlockImageFormat]; format != "" {
+		drive.Format = format
+	}
 	if customOptions == nil ||
 		customOptions["block-driver"] == "virtio-scsi" {
 		// User has not chosen a specific block device type
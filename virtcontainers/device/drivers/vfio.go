@@ -80,15 +80,24 @@ func (device *VFIODevice) Attach(devReceiver api.DeviceReceiver) error {
 		device.VfioDevs = append(device.VfioDevs, vfio)
 	}
 
-	// hotplug a VFIO device is actually hotplugging a group of iommu devices
-	if err := devReceiver.HotplugAddDevice(device, config.DeviceVFIO); err != nil {
-		deviceLogger().WithError(err).Error("Failed to add device")
-		return err
+	if device.DeviceInfo.ColdPlug {
+		// cold plugged devices are appended to the VM boot command line
+		if err := devReceiver.AppendDevice(device); err != nil {
+			deviceLogger().WithError(err).Error("Failed to append device")
+			return err
+		}
+	} else {
+		// hotplug a VFIO device is actually hotplugging a group of iommu devices
+		if err := devReceiver.HotplugAddDevice(device, config.DeviceVFIO); err != nil {
+			deviceLogger().WithError(err).Error("Failed to add device")
+			return err
+		}
 	}
 
 	deviceLogger().WithFields(logrus.Fields{
 		"device-group": device.DeviceInfo.HostPath,
 		"device-type":  "vfio-passthrough",
+		"cold-plug":    device.DeviceInfo.ColdPlug,
 	}).Info("Device group attached")
 	device.AttachCount = 1
 	return nil
@@ -105,6 +114,13 @@ func (device *VFIODevice) Detach(devReceiver api.DeviceReceiver) error {
 		return nil
 	}
 
+	// Cold plugged devices can not be removed from the VM,
+	// they are released when the VM is stopped.
+	if device.DeviceInfo.ColdPlug {
+		device.AttachCount = 0
+		return nil
+	}
+
 	// hotplug a VFIO device is actually hotplugging a group of iommu devices
 	if err := devReceiver.HotplugRemoveDevice(device, config.DeviceVFIO); err != nil {
 		deviceLogger().WithError(err).Error("Failed to remove device")
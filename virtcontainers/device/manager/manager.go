@@ -87,11 +87,17 @@ func (dm *deviceManager) findDeviceByMajorMinor(major, minor int64) api.Device {
 
 // createDevice creates one device based on DeviceInfo
 func (dm *deviceManager) createDevice(devInfo config.DeviceInfo) (dev api.Device, err error) {
-	path, err := config.GetHostPathFunc(devInfo)
-	if err != nil {
-		return nil, err
+	// Disk images are regular files, they have no device numbers to
+	// resolve their host path from, nor to share them between containers.
+	image := isBlockImage(devInfo)
+
+	path := devInfo.HostPath
+	if !image {
+		if path, err = config.GetHostPathFunc(devInfo); err != nil {
+			return nil, err
+		}
+		devInfo.HostPath = path
 	}
-	devInfo.HostPath = path
 
 	defer func() {
 		if err == nil {
@@ -99,8 +105,10 @@ func (dm *deviceManager) createDevice(devInfo config.DeviceInfo) (dev api.Device
 		}
 	}()
 
-	if existingDev := dm.findDeviceByMajorMinor(devInfo.Major, devInfo.Minor); existingDev != nil {
-		return existingDev, nil
+	if !image {
+		if existingDev := dm.findDeviceByMajorMinor(devInfo.Major, devInfo.Minor); existingDev != nil {
+			return existingDev, nil
+		}
 	}
 
 	// device ID must be generated by manager instead of device itself
@@ -108,7 +116,7 @@ func (dm *deviceManager) createDevice(devInfo config.DeviceInfo) (dev api.Device
 	if devInfo.ID, err = dm.newDeviceID(); err != nil {
 		return nil, err
 	}
-	if isVFIO(path) {
+	if IsVFIO(path) {
 		return drivers.NewVFIODevice(&devInfo), nil
 	} else if isBlock(devInfo) {
 		if devInfo.DriverOptions == nil {
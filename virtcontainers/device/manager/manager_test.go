@@ -202,6 +202,44 @@ func TestAttachBlockDevice(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestAttachBlockImageDevice(t *testing.T) {
+	assert := assert.New(t)
+	dm := &deviceManager{
+		blockDriver: VirtioBlock,
+		devices:     make(map[string]api.Device),
+	}
+
+	path := "/images/rootfs.qcow2"
+	deviceInfo := config.DeviceInfo{
+		HostPath:      path,
+		ContainerPath: "/run/kata-containers/shared/containers/foo",
+		DevType:       "b",
+		DriverOptions: map[string]string{
+			config.BlockImageFormat: config.ImageFormatQcow2,
+		},
+	}
+
+	device, err := dm.NewDevice(deviceInfo)
+	assert.NoError(err)
+
+	// Disk images have no device numbers, they are never shared.
+	other, err := dm.NewDevice(deviceInfo)
+	assert.NoError(err)
+	assert.NotEqual(device.DeviceID(), other.DeviceID())
+
+	devReceiver := &api.MockDeviceReceiver{}
+	err = device.Attach(devReceiver)
+	assert.NoError(err)
+
+	drive, ok := device.GetDeviceInfo().(*config.BlockDrive)
+	assert.True(ok)
+	assert.Equal(path, drive.File)
+	assert.Equal(config.ImageFormatQcow2, drive.Format)
+
+	err = device.Detach(devReceiver)
+	assert.NoError(err)
+}
+
 func TestAttachDetachDevice(t *testing.T) {
 	dm := NewDeviceManager(VirtioSCSI, nil)
 
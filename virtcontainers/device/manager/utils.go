@@ -17,8 +17,8 @@ const (
 	vfioPath = "/dev/vfio/"
 )
 
-// isVFIO checks if the device provided is a vfio group.
-func isVFIO(hostPath string) bool {
+// IsVFIO checks if the device provided is a vfio group.
+func IsVFIO(hostPath string) bool {
 	// Ignore /dev/vfio/vfio character device
 	if strings.HasPrefix(hostPath, filepath.Join(vfioPath, "vfio")) {
 		return false
@@ -39,3 +39,9 @@ func isBlock(devInfo config.DeviceInfo) bool {
 
 	return false
 }
+
+// isBlockImage checks if the device is a file-backed disk image,
+// attached to the VM as a block device without any host loop device.
+func isBlockImage(devInfo config.DeviceInfo) bool {
+	return isBlock(devInfo) && devInfo.DriverOptions[config.BlockImageFormat] != ""
+}
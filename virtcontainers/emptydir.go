@@ -0,0 +1,277 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// EmptyDirMode selects how the Kubernetes emptyDir volumes of a sandbox are
+// backed in the guest.
+type EmptyDirMode string
+
+const (
+	// EmptyDirTmpfs backs the emptyDir volumes with guest tmpfs, which
+	// consumes guest memory.
+	EmptyDirTmpfs EmptyDirMode = "tmpfs"
+
+	// EmptyDirBlock backs the disk emptyDir volumes with sized block
+	// devices, while the emptyDir volumes of medium Memory keep using
+	// guest tmpfs.
+	EmptyDirBlock EmptyDirMode = "block"
+)
+
+// DefaultEmptyDirSize is the size of the block-backed emptyDir volumes
+// without an explicit size.
+const DefaultEmptyDirSize uint64 = 1024 * 1024 * 1024
+
+// emptyDirImage is the sparse file backing a block-backed emptyDir volume,
+// kept in the host directory of the volume so that the kubelet accounts for
+// its disk usage.
+const emptyDirImage = ".kata-emptydir.img"
+
+// emptyDirFstype is the filesystem of the block-backed emptyDir volumes.
+const emptyDirFstype = "ext4"
+
+const (
+	// tmpfsMagic is the statfs type of tmpfs filesystems.
+	tmpfsMagic = 0x01021994
+
+	// hugetlbfsMagic is the statfs type of hugetlbfs filesystems.
+	hugetlbfsMagic = 0x958458f6
+)
+
+// EmptyDirConfig describes how the emptyDir volumes of a sandbox are backed.
+type EmptyDirConfig struct {
+	// Mode selects the guest backing of the emptyDir volumes, guest tmpfs
+	// when empty.
+	Mode EmptyDirMode
+
+	// Size is the size in bytes of the block-backed volumes missing
+	// from Sizes, DefaultEmptyDirSize when zero.
+	Size uint64
+
+	// Sizes holds the size in bytes of block-backed volumes, indexed by
+	// volume name.
+	Sizes map[string]uint64
+}
+
+// volumeSize returns the size of the block-backed emptyDir volume "name".
+func (e EmptyDirConfig) volumeSize(name string) uint64 {
+	if size, ok := e.Sizes[name]; ok && size > 0 {
+		return size
+	}
+
+	if e.Size > 0 {
+		return e.Size
+	}
+
+	return DefaultEmptyDirSize
+}
+
+// formatEmptyDirImage formats the sparse file backing a block-backed
+// emptyDir volume, as the agent only mounts block devices.
+var formatEmptyDirImage = func(image string) error {
+	out, err := exec.Command("mkfs."+emptyDirFstype, "-q", "-F", image).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Could not format emptyDir image %s: %v: %s", image, err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// isMemoryBacked checks if a host directory lives in memory, as the ones of
+// the emptyDir volumes of medium Memory or HugePages do.
+func isMemoryBacked(path string) (bool, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return false, err
+	}
+
+	return st.Type == tmpfsMagic || st.Type == hugetlbfsMagic, nil
+}
+
+// hugePageSize returns the size of the hugepages backing a host directory,
+// as the ones of the emptyDir volumes of medium HugePages are, or zero if
+// the directory is not backed by hugepages.
+func hugePageSize(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+
+	if st.Type != hugetlbfsMagic {
+		return 0, nil
+	}
+
+	return uint64(st.Bsize), nil
+}
+
+// hugePageLimit returns the hugetlb limit of a container for the hugepages
+// of size "pageSize".
+func hugePageLimit(limits []specs.LinuxHugepageLimit, pageSize uint64) (uint64, error) {
+	for _, l := range limits {
+		size, err := units.RAMInBytes(l.Pagesize)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid hugepage size %q: %v", l.Pagesize, err)
+		}
+
+		if uint64(size) == pageSize {
+			return l.Limit, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Missing hugetlb limit for hugepages of %s", units.BytesSize(float64(pageSize)))
+}
+
+// isBlockEmptyDir checks if an ephemeral mount has to be backed by a block
+// device.
+func (c *Container) isBlockEmptyDir(m Mount) (bool, error) {
+	if m.Type != kataEphemeralDevType || c.sandbox.config.EmptyDir.Mode != EmptyDirBlock {
+		return false, nil
+	}
+
+	if !c.checkBlockDeviceSupport() {
+		return false, nil
+	}
+
+	memory, err := isMemoryBacked(m.Source)
+	if err != nil {
+		return false, err
+	}
+
+	return !memory, nil
+}
+
+// createEmptyDirDevice returns the ID of the block device backing an
+// emptyDir volume, creating its sparse file if needed. The device is shared
+// by all the containers of the sandbox mounting the volume.
+func (c *Container) createEmptyDirDevice(m Mount) (string, error) {
+	for _, ctr := range c.sandbox.containers {
+		if ctr.id == c.id {
+			continue
+		}
+
+		for _, cm := range ctr.mounts {
+			if cm.Source != m.Source || cm.BlockDeviceID == "" {
+				continue
+			}
+
+			if dev := c.sandbox.devManager.GetDeviceByID(cm.BlockDeviceID); dev != nil {
+				dev.Reference()
+				return cm.BlockDeviceID, nil
+			}
+		}
+	}
+
+	image := filepath.Join(m.Source, emptyDirImage)
+	size := c.sandbox.config.EmptyDir.volumeSize(filepath.Base(m.Source))
+
+	// An existing file is reused, the volume content outliving the
+	// containers of the sandbox.
+	f, err := os.OpenFile(image, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if uint64(st.Size()) < size {
+		if err := f.Truncate(int64(size)); err != nil {
+			return "", err
+		}
+	}
+
+	// A new file is formatted on the host before being hotplugged.
+	if st.Size() == 0 {
+		if err := formatEmptyDirImage(image); err != nil {
+			os.Remove(image)
+			return "", err
+		}
+	}
+
+	c.Logger().WithFields(logrus.Fields{
+		"volume": m.Source,
+		"size":   size,
+	}).Info("Backing emptyDir volume with a block device")
+
+	dev, err := c.sandbox.devManager.NewDevice(config.DeviceInfo{
+		HostPath:      image,
+		ContainerPath: m.Destination,
+		DevType:       "b",
+		DriverOptions: map[string]string{
+			config.BlockImageFormat: config.ImageFormatRaw,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("device manager failed to create emptyDir device for %q: %v", m.Source, err)
+	}
+
+	return dev.DeviceID(), nil
+}
+
+// emptyDirDeviceID returns the ID of the block device backing the emptyDir
+// volume "source" of a container, if any.
+func (c *Container) emptyDirDeviceID(source string) string {
+	for _, m := range c.mounts {
+		if m.Type == kataEphemeralDevType && m.Source == source {
+			return m.BlockDeviceID
+		}
+	}
+
+	return ""
+}
+
+// removeEmptyDirImages removes the files backing the emptyDir volumes of a
+// container no other container of the sandbox mounts.
+func (c *Container) removeEmptyDirImages() {
+	for _, m := range c.mounts {
+		if m.Type != kataEphemeralDevType || m.BlockDeviceID == "" {
+			continue
+		}
+
+		if c.sandbox.isEmptyDirMounted(c.id, m.Source) {
+			continue
+		}
+
+		image := filepath.Join(m.Source, emptyDirImage)
+		if err := os.Remove(image); err != nil && !os.IsNotExist(err) {
+			c.Logger().WithError(err).WithField("image", image).Warn("Could not remove emptyDir image")
+		}
+	}
+}
+
+// isEmptyDirMounted checks if a container of the sandbox, other than
+// "containerID", mounts the emptyDir volume "source".
+func (s *Sandbox) isEmptyDirMounted(containerID, source string) bool {
+	for _, c := range s.containers {
+		if c.id == containerID {
+			continue
+		}
+
+		for _, m := range c.mounts {
+			if m.Type == kataEphemeralDevType && m.Source == source {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmptyDirVolumeSize(t *testing.T) {
+	assert := assert.New(t)
+
+	var e EmptyDirConfig
+	assert.Equal(DefaultEmptyDirSize, e.volumeSize("cache"))
+
+	e.Size = 4096
+	assert.Equal(uint64(4096), e.volumeSize("cache"))
+
+	e.Sizes = map[string]uint64{"cache": 8192}
+	assert.Equal(uint64(8192), e.volumeSize("cache"))
+	assert.Equal(uint64(4096), e.volumeSize("data"))
+}
+
+func TestIsMemoryBacked(t *testing.T) {
+	assert := assert.New(t)
+
+	memory, err := isMemoryBacked("/proc")
+	assert.NoError(err)
+	assert.False(memory)
+
+	if _, err := os.Stat("/dev/shm"); err == nil {
+		memory, err = isMemoryBacked("/dev/shm")
+		assert.NoError(err)
+		assert.True(memory)
+	}
+
+	_, err = isMemoryBacked("/does/not/exist")
+	assert.Error(err)
+}
+
+func TestEmptyDirDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "emptydir-")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	var formatted []string
+	savedFormatEmptyDirImage := formatEmptyDirImage
+	formatEmptyDirImage = func(image string) error {
+		formatted = append(formatted, image)
+		return nil
+	}
+	defer func() {
+		formatEmptyDirImage = savedFormatEmptyDirImage
+	}()
+
+	sandbox := &Sandbox{
+		id:         testSandboxID,
+		devManager: manager.NewDeviceManager(manager.VirtioBlock, nil),
+		config: &SandboxConfig{
+			EmptyDir: EmptyDirConfig{
+				Mode: EmptyDirBlock,
+				Size: 4096,
+			},
+		},
+		containers: map[string]*Container{},
+	}
+
+	m := Mount{
+		Source:      dir,
+		Destination: "/cache",
+		Type:        kataEphemeralDevType,
+	}
+
+	var ids []string
+	for _, cid := range []string{"foo", "bar"} {
+		c := &Container{
+			id:      cid,
+			sandbox: sandbox,
+			mounts:  []Mount{m},
+		}
+
+		id, err := c.createEmptyDirDevice(m)
+		assert.NoError(err)
+		c.mounts[0].BlockDeviceID = id
+		assert.Equal(id, c.emptyDirDeviceID(dir))
+
+		sandbox.containers[cid] = c
+		ids = append(ids, id)
+	}
+
+	// the containers share the device backing the volume
+	assert.Equal(ids[0], ids[1])
+
+	image := filepath.Join(dir, emptyDirImage)
+	st, err := os.Stat(image)
+	assert.NoError(err)
+	assert.Equal(int64(4096), st.Size())
+
+	// the image is formatted once, when created
+	assert.Equal([]string{image}, formatted)
+
+	assert.NoError(sandbox.devManager.RemoveDevice(ids[0]))
+	assert.NotNil(sandbox.devManager.GetDeviceByID(ids[0]))
+	assert.NoError(sandbox.devManager.RemoveDevice(ids[0]))
+	assert.Nil(sandbox.devManager.GetDeviceByID(ids[0]))
+
+	// the image is removed along with the last container mounting it
+	foo := sandbox.containers["foo"]
+	delete(sandbox.containers, "foo")
+	foo.removeEmptyDirImages()
+	_, err = os.Stat(image)
+	assert.NoError(err)
+
+	bar := sandbox.containers["bar"]
+	delete(sandbox.containers, "bar")
+	bar.removeEmptyDirImages()
+	_, err = os.Stat(image)
+	assert.True(os.IsNotExist(err))
+
+	// an image failing to be formatted is not left behind
+	formatEmptyDirImage = func(image string) error {
+		return os.ErrInvalid
+	}
+	_, err = bar.createEmptyDirDevice(m)
+	assert.Error(err)
+	_, err = os.Stat(image)
+	assert.True(os.IsNotExist(err))
+}
@@ -26,6 +26,10 @@ type Config struct {
 	Template bool
 	Cache    uint
 
+	// Profile is the hypervisor profile the factory VMs are created
+	// with. Each profile has its own template VM.
+	Profile string
+
 	VMConfig vc.VMConfig
 }
 
@@ -58,12 +62,12 @@ func NewFactory(ctx context.Context, config Config, fetchOnly bool) (vc.Factory,
 	var b base.FactoryBase
 	if config.Template {
 		if fetchOnly {
-			b, err = template.Fetch(config.VMConfig)
+			b, err = template.Fetch(config.VMConfig, config.Profile)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			b = template.New(ctx, config.VMConfig)
+			b = template.New(ctx, config.VMConfig, config.Profile)
 		}
 	} else {
 		b = direct.New(ctx, config.VMConfig)
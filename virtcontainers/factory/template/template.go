@@ -27,11 +27,20 @@ type template struct {
 var templateProxyType = vc.KataBuiltInProxyType
 var templateWaitForAgent = 2 * time.Second
 
+// statePath returns the directory the template VM of a hypervisor profile
+// is saved to. The template of the default profile has no suffix.
+func statePath(profile string) string {
+	if profile == "" {
+		return store.RunVMStoragePath + "/template"
+	}
+
+	return store.RunVMStoragePath + "/template-" + profile
+}
+
 // Fetch finds and returns a pre-built template factory.
 // TODO: save template metadata and fetch from storage.
-func Fetch(config vc.VMConfig) (base.FactoryBase, error) {
-	statePath := store.RunVMStoragePath + "/template"
-	t := &template{statePath, config}
+func Fetch(config vc.VMConfig, profile string) (base.FactoryBase, error) {
+	t := &template{statePath(profile), config}
 
 	err := t.checkTemplateVM()
 	if err != nil {
@@ -42,9 +51,8 @@ func Fetch(config vc.VMConfig) (base.FactoryBase, error) {
 }
 
 // New creates a new VM template factory.
-func New(ctx context.Context, config vc.VMConfig) base.FactoryBase {
-	statePath := store.RunVMStoragePath + "/template"
-	t := &template{statePath, config}
+func New(ctx context.Context, config vc.VMConfig, profile string) base.FactoryBase {
+	t := &template{statePath(profile), config}
 
 	err := t.prepareTemplateFiles()
 	if err != nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/store"
 )
 
 func TestTemplateFactory(t *testing.T) {
@@ -40,7 +41,7 @@ func TestTemplateFactory(t *testing.T) {
 	ctx := context.Background()
 
 	// New
-	f := New(ctx, vmConfig)
+	f := New(ctx, vmConfig, "")
 
 	// Config
 	assert.Equal(f.Config(), vmConfig)
@@ -108,3 +109,10 @@ func TestTemplateFactory(t *testing.T) {
 	f.CloseFactory(ctx)
 	tt.CloseFactory(ctx)
 }
+
+func TestTemplateStatePath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(store.RunVMStoragePath+"/template", statePath(""))
+	assert.Equal(store.RunVMStoragePath+"/template-qemu.gpu", statePath("qemu.gpu"))
+}
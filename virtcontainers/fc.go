@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
 	"os/exec"
 	"path/filepath"
 
@@ -24,6 +25,8 @@ import (
 	"github.com/kata-containers/runtime/virtcontainers/device/config"
 	"github.com/kata-containers/runtime/virtcontainers/store"
 	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/kata-containers/runtime/virtcontainers/utils"
+	"golang.org/x/sys/unix"
 
 	"net"
 	"net/http"
@@ -56,6 +59,18 @@ const (
 	fcDiskPoolSize = 8
 	// The boot source is the first partition of the first block device added
 	rootDevice = "root=/dev/vda1"
+	// The API socket created by a jailed firecracker, relative to the jail root.
+	fcJailedSocket = "api.socket"
+)
+
+// The jailer creates the jail of a sandbox under
+// <fcJailerChrootBase>/<firecracker binary name>/<sandbox ID>/root, and
+// its cgroups under <fcCgroupRoot>/<controller>/<firecracker binary name>/<sandbox ID>.
+// This location must not be mounted noexec, the firecracker binary being
+// copied into the jail and executed from there.
+var (
+	fcJailerChrootBase = "/srv/kata"
+	fcCgroupRoot       = "/sys/fs/cgroup"
 )
 
 func (s vmmState) String() string {
@@ -75,6 +90,13 @@ func (s vmmState) String() string {
 // want to store on disk
 type FirecrackerInfo struct {
 	PID int
+
+	// UID is the uid/gid allocated to the jailed firecracker.
+	UID int
+
+	// LoopDevices are the loop devices the files jailed for writing are
+	// attached to, indexed by their path in the jail.
+	LoopDevices map[string]string
 }
 
 type firecrackerState struct {
@@ -99,6 +121,9 @@ type firecracker struct {
 	fcClient     *client.Firecracker //Tracks the current active connection
 	socketPath   string
 
+	jailed     bool   //Set when firecracker is started through the jailer
+	jailerRoot string //The chroot directory of the jailed firecracker
+
 	store          *store.VCStore
 	config         HypervisorConfig
 	pendingDevices []firecrackerDevice // Devices to be added when the FC API is ready
@@ -145,6 +170,12 @@ func (fc *firecracker) createSandbox(ctx context.Context, id string, hypervisorC
 	fc.config = *hypervisorConfig
 	fc.state.set(notReady)
 
+	if fc.config.JailerPath != "" {
+		fc.jailed = true
+		fc.jailerRoot = filepath.Join(fcJailerChrootBase, filepath.Base(fc.config.HypervisorPath), fc.id, "root")
+		fc.socketPath = filepath.Join(fc.jailerRoot, fcJailedSocket)
+	}
+
 	// No need to return an error from there since there might be nothing
 	// to fetch if this is the first time the hypervisor is created.
 	if err := fc.store.Load(store.Hypervisor, &fc.info); err != nil {
@@ -228,9 +259,37 @@ func (fc *firecracker) fcInit(timeout int) error {
 	span, _ := fc.trace("fcInit")
 	defer span.Finish()
 
-	args := []string{"--api-sock", fc.socketPath}
+	var cmd *exec.Cmd
+
+	if fc.jailed {
+		if fc.info.UID == 0 {
+			uid, err := allocateHypervisorUserID(&fc.config, fc.id)
+			if err != nil {
+				return err
+			}
+
+			fc.info.UID = uid
+			if err := fc.store.Store(store.Hypervisor, fc.info); err != nil {
+				return err
+			}
+		}
+
+		args := []string{
+			"--id", fc.id,
+			"--node", strconv.Itoa(fc.fcJailerNUMANode()),
+			"--exec-file", fc.config.HypervisorPath,
+			"--uid", strconv.Itoa(fc.info.UID),
+			"--gid", strconv.Itoa(fc.info.UID),
+			"--chroot-base-dir", fcJailerChrootBase,
+		}
+
+		cmd = exec.Command(fc.config.JailerPath, args...)
+	} else {
+		args := []string{"--api-sock", fc.socketPath}
+
+		cmd = exec.Command(fc.config.HypervisorPath, args...)
+	}
 
-	cmd := exec.Command(fc.config.HypervisorPath, args...)
 	if err := cmd.Start(); err != nil {
 		fc.Logger().WithField("Error starting firecracker", err).Debug()
 		return err
@@ -292,7 +351,10 @@ func (fc *firecracker) fcSetVMRootfs(path string) error {
 	driveID := "rootfs"
 	driveParams := ops.NewPutGuestDriveByIDParams()
 	driveParams.SetDriveID(driveID)
-	isReadOnly := false
+	// The rootfs is shared by all the sandboxes and is only bind mounted
+	// read-only into the jail, the jailed firecracker can not open it for
+	// writing.
+	isReadOnly := fc.jailed
 	//Add it as a regular block device
 	//This allows us to use a paritioned root block device
 	isRootDevice := false
@@ -352,6 +414,11 @@ func (fc *firecracker) startSandbox(timeout int) error {
 		return err
 	}
 
+	kernelPath, err = fc.fcJailResource(kernelPath, filepath.Base(kernelPath), true)
+	if err != nil {
+		return err
+	}
+
 	strParams := SerializeParams(fc.config.KernelParams, "=")
 	formattedParams := strings.Join(strParams, " ")
 
@@ -369,6 +436,11 @@ func (fc *firecracker) startSandbox(timeout int) error {
 		}
 	}
 
+	image, err = fc.fcJailResource(image, filepath.Base(image), true)
+	if err != nil {
+		return err
+	}
+
 	fc.fcSetVMRootfs(image)
 	fc.createDiskPool()
 
@@ -397,13 +469,7 @@ func (fc *firecracker) createDiskPool() error {
 		isRootDevice := false
 
 		// Create a temporary file as a placeholder backend for the drive
-		hostURL, err := fc.store.Raw("")
-		if err != nil {
-			return err
-		}
-
-		// We get a full URL from Raw(), we need to parse it.
-		u, err := url.Parse(hostURL)
+		path, err := fc.fcDrivePlaceholder(driveID)
 		if err != nil {
 			return err
 		}
@@ -412,7 +478,7 @@ func (fc *firecracker) createDiskPool() error {
 			DriveID:      &driveID,
 			IsReadOnly:   &isReadOnly,
 			IsRootDevice: &isRootDevice,
-			PathOnHost:   &u.Path,
+			PathOnHost:   &path,
 		}
 		driveParams.SetBody(drive)
 		_, err = fc.client().Operations.PutGuestDriveByID(driveParams)
@@ -436,6 +502,7 @@ func (fc *firecracker) stopSandbox() (err error) {
 			fc.Logger().Info("stopSandbox failed")
 		} else {
 			fc.Logger().Info("Firecracker VM stopped")
+			fc.fcCleanupJail()
 		}
 	}()
 
@@ -485,10 +552,22 @@ func (fc *firecracker) resumeSandbox() error {
 	return nil
 }
 
+func (fc *firecracker) migrateSandbox(conn *os.File) error {
+	return fmt.Errorf("firecracker does not support live migration")
+}
+
+func (fc *firecracker) incomingSandbox(conn *os.File, timeout int, ready func() error) error {
+	return fmt.Errorf("firecracker does not support live migration")
+}
+
 func (fc *firecracker) fcAddVsock(vs kataVSOCK) error {
 	span, _ := fc.trace("fcAddVsock")
 	defer span.Finish()
 
+	if _, err := fc.fcJailResource(utils.VHostVSockDevicePath, utils.VHostVSockDevicePath, false); err != nil {
+		return err
+	}
+
 	vsockParams := ops.NewPutGuestVsockByIDParams()
 	vsockID := "root"
 	vsock := &models.Vsock{
@@ -534,6 +613,11 @@ func (fc *firecracker) fcAddBlockDrive(drive config.BlockDrive) error {
 	span, _ := fc.trace("fcAddBlockDrive")
 	defer span.Finish()
 
+	path, err := fc.fcJailResource(drive.File, drive.ID, false)
+	if err != nil {
+		return err
+	}
+
 	driveID := drive.ID
 	driveParams := ops.NewPutGuestDriveByIDParams()
 	driveParams.SetDriveID(driveID)
@@ -543,10 +627,10 @@ func (fc *firecracker) fcAddBlockDrive(drive config.BlockDrive) error {
 		DriveID:      &driveID,
 		IsReadOnly:   &isReadOnly,
 		IsRootDevice: &isRootDevice,
-		PathOnHost:   &drive.File,
+		PathOnHost:   &path,
 	}
 	driveParams.SetBody(driveFc)
-	_, err := fc.client().Operations.PutGuestDriveByID(driveParams)
+	_, err = fc.client().Operations.PutGuestDriveByID(driveParams)
 	if err != nil {
 		return err
 	}
@@ -565,12 +649,17 @@ func (fc *firecracker) fcUpdateBlockDrive(drive config.BlockDrive) error {
 	driveParams := ops.NewPatchGuestDriveByIDParams()
 	driveParams.SetDriveID(driveID)
 
+	path, err := fc.fcJailResource(drive.File, drive.ID, false)
+	if err != nil {
+		return err
+	}
+
 	driveFc := &models.PartialDrive{
 		DriveID:    &driveID,
-		PathOnHost: &drive.File, //This is the only property that can be modified
+		PathOnHost: &path, //This is the only property that can be modified
 	}
 	driveParams.SetBody(driveFc)
-	_, err := fc.client().Operations.PatchGuestDriveByID(driveParams)
+	_, err = fc.client().Operations.PatchGuestDriveByID(driveParams)
 	if err != nil {
 		return err
 	}
@@ -636,8 +725,13 @@ func (fc *firecracker) hotplugAddDevice(devInfo interface{}, devType deviceType)
 
 	switch devType {
 	case blockDev:
+		drive := *devInfo.(*config.BlockDrive)
+		if drive.Format != "" && drive.Format != config.ImageFormatRaw {
+			return nil, fmt.Errorf("hotplugAddDevice: unsupported %s disk image format", drive.Format)
+		}
+
 		//The drive placeholder has to exist prior to Update
-		return nil, fc.fcUpdateBlockDrive(*devInfo.(*config.BlockDrive))
+		return nil, fc.fcUpdateBlockDrive(drive)
 	default:
 		fc.Logger().WithFields(logrus.Fields{"devInfo": devInfo,
 			"deviceType": devType}).Warn("hotplugAddDevice: unsupported device")
@@ -700,9 +794,210 @@ func (fc *firecracker) getThreadIDs() (*threadIDs, error) {
 }
 
 func (fc *firecracker) cleanup() error {
+	fc.fcCleanupJail()
+
 	return nil
 }
 
+// fcDrivePlaceholder creates an empty file as a placeholder backend for a
+// drive, and returns the path firecracker has to use to access it. The
+// placeholder of a jailed firecracker is created inside the jail.
+func (fc *firecracker) fcDrivePlaceholder(driveID string) (string, error) {
+	if !fc.jailed {
+		hostURL, err := fc.store.Raw("")
+		if err != nil {
+			return "", err
+		}
+
+		// We get a full URL from Raw(), we need to parse it.
+		u, err := url.Parse(hostURL)
+		if err != nil {
+			return "", err
+		}
+
+		return u.Path, nil
+	}
+
+	jailedPath := filepath.Join(fc.jailerRoot, driveID)
+
+	f, err := os.OpenFile(jailedPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+
+	if err := os.Chown(jailedPath, fc.info.UID, fc.info.UID); err != nil {
+		return "", err
+	}
+
+	return filepath.Join("/", driveID), nil
+}
+
+// fcJailResource makes the host resource src available to the jailed
+// firecracker as dst, relative to the jail root, and returns the path
+// firecracker has to use to access it.
+// The host resources are never handed over to the jailed firecracker:
+// device nodes are created inside the jail and owned by the jailed
+// firecracker, and files are bind mounted read-only into the jail, or
+// attached to a loop device created inside the jail when written to.
+// The host path is returned as is when firecracker is not jailed.
+func (fc *firecracker) fcJailResource(src, dst string, readonly bool) (string, error) {
+	if !fc.jailed {
+		return src, nil
+	}
+
+	if src == "" || dst == "" {
+		return "", fmt.Errorf("fcJailResource: invalid jail locations: src:%v, dst:%v", src, dst)
+	}
+
+	jailedPath := filepath.Join(fc.jailerRoot, dst)
+
+	var st unix.Stat_t
+	if err := unix.Stat(src, &st); err != nil {
+		return "", err
+	}
+
+	// The resource might already be jailed, when a drive is updated.
+	if err := fc.fcUnjailResource(jailedPath); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(jailedPath), store.DirMode); err != nil {
+		return "", err
+	}
+
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFBLK, unix.S_IFCHR:
+		if err := fc.fcJailDevice(jailedPath, st.Mode, st.Rdev); err != nil {
+			return "", err
+		}
+	case unix.S_IFREG:
+		if readonly {
+			if err := bindMount(fc.ctx, src, jailedPath, true); err != nil {
+				return "", err
+			}
+			break
+		}
+
+		loop, err := utils.AttachLoopDevice(src)
+		if err != nil {
+			return "", err
+		}
+
+		if fc.info.LoopDevices == nil {
+			fc.info.LoopDevices = make(map[string]string)
+		}
+		fc.info.LoopDevices[jailedPath] = loop
+
+		if err := fc.store.Store(store.Hypervisor, fc.info); err != nil {
+			return "", err
+		}
+
+		if err := unix.Stat(loop, &st); err != nil {
+			return "", err
+		}
+
+		if err := fc.fcJailDevice(jailedPath, unix.S_IFBLK|0600, st.Rdev); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("fcJailResource: unsupported resource %v", src)
+	}
+
+	fc.Logger().WithFields(logrus.Fields{"src": src, "dst": jailedPath}).Debug("Resource jailed")
+
+	return filepath.Join("/", dst), nil
+}
+
+// fcJailDevice creates a device node inside the jail, owned by the jailed
+// firecracker.
+func (fc *firecracker) fcJailDevice(jailedPath string, mode uint32, rdev uint64) error {
+	if err := unix.Mknod(jailedPath, mode, int(rdev)); err != nil {
+		return fmt.Errorf("Could not create device %v: %v", jailedPath, err)
+	}
+
+	return os.Chown(jailedPath, fc.info.UID, fc.info.UID)
+}
+
+// fcUnjailResource removes a resource from the jail.
+func (fc *firecracker) fcUnjailResource(jailedPath string) error {
+	// Read-only files are bind mounted into the jail.
+	unix.Unmount(jailedPath, unix.MNT_DETACH)
+	os.Remove(jailedPath)
+
+	loop, ok := fc.info.LoopDevices[jailedPath]
+	if !ok {
+		return nil
+	}
+
+	if err := utils.DetachLoopDevice(loop); err != nil {
+		fc.Logger().WithError(err).WithField("loop", loop).Warn("Could not detach loop device")
+	}
+
+	delete(fc.info.LoopDevices, jailedPath)
+
+	return fc.store.Store(store.Hypervisor, fc.info)
+}
+
+// fcCleanupJail removes the jail and the cgroups created by the jailer, and
+// releases the uid of the jailed firecracker.
+func (fc *firecracker) fcCleanupJail() {
+	if !fc.jailed {
+		return
+	}
+
+	for jailedPath := range fc.info.LoopDevices {
+		if err := fc.fcUnjailResource(jailedPath); err != nil {
+			fc.Logger().WithError(err).WithField("resource", jailedPath).Warn("Could not remove resource from the jail")
+		}
+	}
+
+	// Unmount the jailed files before removing the jail, so that
+	// the host files are left untouched.
+	filepath.Walk(fc.jailerRoot, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			unix.Unmount(path, unix.MNT_DETACH)
+		}
+		return nil
+	})
+
+	if err := os.RemoveAll(filepath.Dir(fc.jailerRoot)); err != nil {
+		fc.Logger().WithError(err).Warn("Could not remove the jail")
+	}
+
+	cgroups, _ := filepath.Glob(filepath.Join(fcCgroupRoot, "*", filepath.Base(fc.config.HypervisorPath), fc.id))
+	for _, cgroup := range cgroups {
+		if err := os.Remove(cgroup); err != nil {
+			fc.Logger().WithError(err).WithField("cgroup", cgroup).Warn("Could not remove the jailer cgroup")
+		}
+	}
+
+	if fc.info.UID == 0 {
+		return
+	}
+
+	if err := releaseHypervisorUserID(fc.info.UID, fc.id); err != nil {
+		fc.Logger().WithError(err).WithField("uid", fc.info.UID).Warn("Could not release the jailer uid")
+		return
+	}
+
+	fc.info.UID = 0
+	if err := fc.store.Store(store.Hypervisor, fc.info); err != nil {
+		fc.Logger().WithError(err).Warn("Could not store the firecracker info")
+	}
+}
+
+// fcJailerNUMANode returns the host NUMA node the jailed firecracker is
+// confined to: the one of the sandbox cpuset if it spans a single NUMA
+// node, or else the configured one.
+func (fc *firecracker) fcJailerNUMANode() int {
+	if len(fc.config.MemoryHostNodes) == 1 {
+		return fc.config.MemoryHostNodes[0]
+	}
+
+	return fc.config.JailerNUMANode
+}
+
 func (fc *firecracker) pid() int {
 	return fc.info.PID
 }
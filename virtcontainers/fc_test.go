@@ -0,0 +1,177 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestFCJailResourceNotJailed(t *testing.T) {
+	assert := assert.New(t)
+
+	fc := &firecracker{}
+
+	path, err := fc.fcJailResource("/foo/bar", "bar", false)
+	assert.NoError(err)
+	assert.Equal("/foo/bar", path)
+
+	// nothing to clean up
+	fc.fcCleanupJail()
+}
+
+func TestFCJailResource(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "fc-jail-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	savedUserIDsPath := hypervisorUserIDsPath
+	hypervisorUserIDsPath = filepath.Join(tmpdir, "uids")
+	defer func() {
+		hypervisorUserIDsPath = savedUserIDsPath
+	}()
+
+	vcStore, err := store.NewVCSandboxStore(context.Background(), testSandboxID)
+	assert.NoError(err)
+	defer store.DeleteAll()
+
+	fc := &firecracker{
+		id:  testSandboxID,
+		ctx: context.Background(),
+		config: HypervisorConfig{
+			HypervisorPath:          "/usr/bin/firecracker-test",
+			JailerPath:              "/usr/bin/jailer",
+			UnprivilegedUserIDBase:  4000000000,
+			UnprivilegedUserIDCount: 10,
+		},
+		jailed:     true,
+		jailerRoot: filepath.Join(tmpdir, "jail", "root"),
+		store:      vcStore,
+	}
+
+	fc.info.UID, err = allocateHypervisorUserID(&fc.config, fc.id)
+	assert.NoError(err)
+	uid := uint32(fc.info.UID)
+
+	_, err = fc.fcJailResource("", "foo", false)
+	assert.Error(err)
+
+	_, err = fc.fcJailResource(filepath.Join(tmpdir, "missing"), "missing", false)
+	assert.Error(err)
+
+	// Read-only files are bind mounted
+	kernel := filepath.Join(tmpdir, "kernel")
+	err = ioutil.WriteFile(kernel, []byte("kernel"), 0644)
+	assert.NoError(err)
+
+	path, err := fc.fcJailResource(kernel, "kernel", true)
+	assert.NoError(err)
+	assert.Equal("/kernel", path)
+
+	content, err := ioutil.ReadFile(filepath.Join(fc.jailerRoot, "kernel"))
+	assert.NoError(err)
+	assert.Equal("kernel", string(content))
+
+	// Drive placeholders are created inside the jail
+	path, err = fc.fcDrivePlaceholder("drive-0")
+	assert.NoError(err)
+	assert.Equal("/drive-0", path)
+
+	var st unix.Stat_t
+	jailedDrive := filepath.Join(fc.jailerRoot, "drive-0")
+	err = unix.Stat(jailedDrive, &st)
+	assert.NoError(err)
+	assert.Equal(uid, st.Uid)
+
+	// Files written to are attached to a loop device, replacing the
+	// drive placeholder
+	drive := filepath.Join(tmpdir, "drive")
+	err = ioutil.WriteFile(drive, make([]byte, 4096), 0600)
+	assert.NoError(err)
+
+	path, err = fc.fcJailResource(drive, "drive-0", false)
+	assert.NoError(err)
+	assert.Equal("/drive-0", path)
+	assert.Len(fc.info.LoopDevices, 1)
+
+	err = unix.Stat(jailedDrive, &st)
+	assert.NoError(err)
+	assert.Equal(uint32(unix.S_IFBLK), st.Mode&unix.S_IFMT)
+	assert.Equal(uid, st.Uid)
+	assert.Equal(uid, st.Gid)
+
+	err = ioutil.WriteFile(jailedDrive, []byte("drive"), 0)
+	assert.NoError(err)
+
+	// Jailing a resource again replaces it
+	path, err = fc.fcJailResource(drive, "drive-0", false)
+	assert.NoError(err)
+	assert.Equal("/drive-0", path)
+	assert.Len(fc.info.LoopDevices, 1)
+
+	// Device nodes are recreated
+	path, err = fc.fcJailResource("/dev/null", "/dev/null", false)
+	assert.NoError(err)
+	assert.Equal("/dev/null", path)
+
+	var devSt unix.Stat_t
+	err = unix.Stat("/dev/null", &devSt)
+	assert.NoError(err)
+
+	err = unix.Stat(filepath.Join(fc.jailerRoot, "dev", "null"), &st)
+	assert.NoError(err)
+	assert.Equal(devSt.Rdev, st.Rdev)
+	assert.Equal(uid, st.Uid)
+
+	fc.fcCleanupJail()
+
+	_, err = os.Stat(filepath.Dir(fc.jailerRoot))
+	assert.True(os.IsNotExist(err))
+	assert.Empty(fc.info.LoopDevices)
+	assert.Zero(fc.info.UID)
+
+	// The host files are left untouched, but for the written content
+	content, err = ioutil.ReadFile(drive)
+	assert.NoError(err)
+	assert.Equal("drive", string(content[:5]))
+
+	for _, f := range []string{kernel, drive, "/dev/null"} {
+		err = unix.Stat(f, &st)
+		assert.NoError(err)
+		assert.Zero(st.Uid)
+	}
+}
+
+func TestFCJailerNUMANode(t *testing.T) {
+	assert := assert.New(t)
+
+	fc := &firecracker{
+		config: HypervisorConfig{
+			JailerNUMANode: 1,
+		},
+	}
+	assert.Equal(1, fc.fcJailerNUMANode())
+
+	// The NUMA node of the sandbox cpuset takes precedence
+	fc.config.MemoryHostNodes = []int{2}
+	assert.Equal(2, fc.fcJailerNUMANode())
+
+	fc.config.MemoryHostNodes = []int{0, 2}
+	assert.Equal(1, fc.fcJailerNUMANode())
+}
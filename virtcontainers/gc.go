@@ -0,0 +1,653 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/containerd/cgroups"
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// OrphanType is the type of a host resource left behind by a sandbox.
+type OrphanType string
+
+const (
+	// OrphanProcess is a hypervisor process.
+	OrphanProcess OrphanType = "process"
+
+	// OrphanMount is a mount point of the sandbox shared directory.
+	OrphanMount OrphanType = "mount"
+
+	// OrphanLink is a TAP or macvtap link, along with the tc filters
+	// redirecting the traffic of the container interface to it.
+	OrphanLink OrphanType = "link"
+
+	// OrphanNetNS is a network namespace created for the sandbox.
+	OrphanNetNS OrphanType = "netns"
+
+	// OrphanCgroup is the cgroup holding the unconstrained sandbox
+	// threads.
+	OrphanCgroup OrphanType = "cgroup"
+
+	// OrphanDir is a VM or shared directory.
+	OrphanDir OrphanType = "dir"
+
+	// OrphanStore is the store of the sandbox.
+	OrphanStore OrphanType = "store"
+)
+
+// OrphanResource is a host resource owned by a sandbox which is not running
+// anymore, and that nothing will ever release.
+type OrphanResource struct {
+	// SandboxID is the ID of the sandbox, or of the VM, owning the
+	// resource.
+	SandboxID string
+
+	Type OrphanType
+
+	// Resource identifies the resource, such as a path or a PID.
+	Resource string
+
+	reclaim func() error
+}
+
+// gcGracePeriod is how long a resource is left alone after having been
+// created, since the sandbox owning it may still be setting up.
+var gcGracePeriod = time.Minute
+
+var (
+	gcProcPath      = "/proc"
+	gcMountInfoPath = "/proc/self/mountinfo"
+)
+
+// GarbageCollect is the virtcontainers garbage collection entry point.
+// It looks for the resources left behind by the sandboxes whose runtime or
+// shim crashed, and reclaims them unless dryRun is set. The resources found
+// are returned along with the reclaim errors, if any.
+func GarbageCollect(ctx context.Context, dryRun bool) ([]OrphanResource, error) {
+	span, ctx := trace(ctx, "GarbageCollect")
+	defer span.Finish()
+
+	return garbageCollect(ctx, dryRun)
+}
+
+func garbageCollect(ctx context.Context, dryRun bool) ([]OrphanResource, error) {
+	storeIDs, err := gcStoreIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []OrphanResource
+	var failed int
+
+	reclaim := func(resources []OrphanResource) {
+		for _, r := range resources {
+			logger := virtLog.WithFields(logrus.Fields{
+				"sandbox":  r.SandboxID,
+				"type":     r.Type,
+				"resource": r.Resource,
+			})
+
+			orphans = append(orphans, r)
+
+			if dryRun {
+				logger.Info("Found orphaned resource")
+				continue
+			}
+
+			if err := r.reclaim(); err != nil {
+				logger.WithError(err).Warn("Could not reclaim orphaned resource")
+				failed++
+				continue
+			}
+
+			logger.Info("Reclaimed orphaned resource")
+		}
+	}
+
+	for _, id := range storeIDs {
+		if err := gcSandbox(ctx, id, reclaim); err != nil {
+			virtLog.WithError(err).WithField("sandbox", id).Warn("Could not check sandbox resources")
+			failed++
+		}
+	}
+
+	// The VMs without any store are looked for once the orphaned
+	// sandboxes have been removed, so that their resources are not
+	// reported twice.
+	resources, err := gcUnknownVMs(storeIDs)
+	if err != nil {
+		return orphans, err
+	}
+
+	reclaim(resources)
+
+	if failed > 0 {
+		return orphans, fmt.Errorf("Could not reclaim %d orphaned resources", failed)
+	}
+
+	return orphans, nil
+}
+
+// gcStoreIDs returns the IDs of the sandboxes, and of the factory VMs,
+// having a store.
+func gcStoreIDs() ([]string, error) {
+	ids := make(map[string]bool)
+
+	for _, dir := range []string{store.ConfigStoragePath, store.RunStoragePath} {
+		names, err := gcDirNames(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			ids[name] = true
+		}
+	}
+
+	var sorted []string
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	return sorted, nil
+}
+
+func gcDirNames(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Mode()&os.ModeSymlink != 0 {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// gcRecent tells whether a path has been modified within the grace period.
+// Missing paths are not recent.
+func gcRecent(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(info.ModTime()) < gcGracePeriod
+}
+
+// gcVMID returns the ID of the VM backing a sandbox, which differs from the
+// sandbox ID when the VM comes from a factory.
+func gcVMID(id string) string {
+	target, err := os.Readlink(filepath.Join(store.RunVMStoragePath, id))
+	if err != nil {
+		return id
+	}
+
+	return filepath.Base(target)
+}
+
+// gcSandbox reclaims the resources of a sandbox if it is orphaned, i.e. if
+// it should be running but its hypervisor is gone. The sandbox lock is held
+// while they are.
+func gcSandbox(ctx context.Context, id string, reclaim func([]OrphanResource)) error {
+	if gcRecent(store.SandboxConfigurationRootPath(id)) || gcRecent(store.SandboxRuntimeRootPath(id)) {
+		return nil
+	}
+
+	vmID := gcVMID(id)
+
+	processes, err := gcHypervisorProcesses()
+	if err != nil {
+		return err
+	}
+
+	if len(processes[vmID]) > 0 {
+		return nil
+	}
+
+	lockFile, err := rwLockSandbox(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer unlockSandbox(ctx, id, lockFile)
+
+	// Look again now that nobody else can touch the sandbox, in case it
+	// was started in the meantime.
+	processes, err = gcHypervisorProcesses()
+	if err != nil {
+		return err
+	}
+
+	if len(processes[vmID]) > 0 {
+		return nil
+	}
+
+	vcStore, err := store.NewVCSandboxStore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	state, err := vcStore.LoadState()
+	if err == nil && state.State == types.StateStopped {
+		// The sandbox is stopped, and waits to be deleted.
+		return nil
+	}
+
+	resources, err := gcMounts(id)
+	if err != nil {
+		return err
+	}
+
+	var networkNS NetworkNamespace
+	if err := vcStore.Load(store.Network, &networkNS); err == nil {
+		resources = append(resources, gcNetwork(id, networkNS)...)
+	}
+
+	if state.CgroupPath != "" {
+		resources = append(resources, gcCgroup(id, state)...)
+	}
+
+	resources = append(resources, gcDirs(id, vmID)...)
+
+	resources = append(resources, OrphanResource{
+		SandboxID: id,
+		Type:      OrphanStore,
+		Resource:  store.SandboxConfigurationRootPath(id),
+		reclaim:   vcStore.Delete,
+	})
+
+	reclaim(resources)
+
+	return nil
+}
+
+// gcUnknownVMs returns the resources of the VMs, and of the sandboxes, which
+// have no store.
+func gcUnknownVMs(storeIDs []string) ([]OrphanResource, error) {
+	known := make(map[string]bool)
+	for _, id := range storeIDs {
+		known[id] = true
+		known[gcVMID(id)] = true
+	}
+
+	unknown := func(id string) bool {
+		// The VM templates are owned by the factory.
+		return !known[id] && !strings.HasPrefix(id, "template")
+	}
+
+	processes, err := gcHypervisorProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for id := range processes {
+		ids = append(ids, id)
+	}
+
+	for _, dir := range []string{store.RunVMStoragePath, kataHostSharedDir} {
+		names, err := gcDirNames(dir)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, names...)
+	}
+
+	sort.Strings(ids)
+
+	var resources []OrphanResource
+	seen := make(map[string]bool)
+
+	for _, id := range ids {
+		if seen[id] || !unknown(id) {
+			continue
+		}
+		seen[id] = true
+
+		for _, pid := range processes[id] {
+			// Only the hypervisors of the VMs created by the runtime
+			// are killed.
+			if !gcVMExists(id) || gcRecent(filepath.Join(gcProcPath, strconv.Itoa(pid))) {
+				continue
+			}
+
+			resources = append(resources, gcProcess(id, pid))
+		}
+
+		if gcRecent(filepath.Join(store.RunVMStoragePath, id)) || gcRecent(filepath.Join(kataHostSharedDir, id)) {
+			continue
+		}
+
+		mounts, err := gcMounts(id)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, mounts...)
+
+		resources = append(resources, gcDirs(id, id)...)
+	}
+
+	return resources, nil
+}
+
+// gcHypervisorProcesses returns the PIDs of the QEMU and Firecracker
+// processes, by the ID of the VM they run. The ID is found from the VM or
+// sandbox directory their command line refers to, or from the jailer chroot
+// they run in, all of them being created by the runtime.
+func gcHypervisorProcesses() (map[string][]int, error) {
+	entries, err := ioutil.ReadDir(gcProcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make(map[string][]int)
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(gcProcPath, entry.Name(), "cmdline"))
+		if err != nil {
+			// The process is gone.
+			continue
+		}
+
+		// The root of processes we cannot inspect is left empty.
+		root, _ := os.Readlink(filepath.Join(gcProcPath, entry.Name(), "root"))
+
+		id := gcHypervisorVMID(strings.Split(strings.TrimRight(string(data), "\x00"), "\x00"), root)
+		if id != "" {
+			processes[id] = append(processes[id], pid)
+		}
+	}
+
+	return processes, nil
+}
+
+// gcHypervisorVMID returns the ID of the VM a hypervisor process runs, from
+// its command line and root directory, or an empty string if the process is
+// not a hypervisor started by the runtime.
+func gcHypervisorVMID(args []string, root string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	name := filepath.Base(args[0])
+	if !strings.Contains(name, "qemu") && !strings.Contains(name, "firecracker") {
+		return ""
+	}
+
+	// The jailed firecracker and the chrooted QEMU run in
+	// <base>/<hypervisor>/<id>/root.
+	for _, base := range []string{fcJailerChrootBase, qemuChrootBase} {
+		if !strings.HasPrefix(root, base+"/") {
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(root, base+"/"), "/")
+		if len(parts) == 3 && parts[2] == "root" && parts[1] != "" {
+			return parts[1]
+		}
+	}
+
+	prefixes := []string{
+		store.RunVMStoragePath + "/",
+		store.RunStoragePath + "/",
+	}
+
+	for _, arg := range args {
+		for _, prefix := range prefixes {
+			index := strings.Index(arg, prefix)
+			if index < 0 {
+				continue
+			}
+
+			if id := strings.SplitN(arg[index+len(prefix):], "/", 2)[0]; id != "" {
+				return id
+			}
+		}
+	}
+
+	return ""
+}
+
+// gcVMExists tells whether the runtime created the VM directory, or the
+// hypervisor chroot, of a VM.
+func gcVMExists(id string) bool {
+	if _, err := os.Lstat(filepath.Join(store.RunVMStoragePath, id)); err == nil {
+		return true
+	}
+
+	for _, base := range []string{fcJailerChrootBase, qemuChrootBase} {
+		if chroots, _ := filepath.Glob(filepath.Join(base, "*", id)); len(chroots) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func gcProcess(id string, pid int) OrphanResource {
+	return OrphanResource{
+		SandboxID: id,
+		Type:      OrphanProcess,
+		Resource:  strconv.Itoa(pid),
+		reclaim: func() error {
+			err := syscall.Kill(pid, syscall.SIGKILL)
+			if err == syscall.ESRCH {
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+// gcMounts returns the mount points of the shared and VM directories of a
+// sandbox, deepest first so that they can be unmounted in order.
+func gcMounts(id string) ([]OrphanResource, error) {
+	f, err := os.Open(gcMountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dirs := []string{
+		filepath.Join(kataHostSharedDir, id),
+		filepath.Join(store.RunVMStoragePath, id),
+	}
+
+	var mountPoints []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), " ")
+		if len(fields) < 5 {
+			continue
+		}
+
+		// mountinfo encodes the spaces of the mount points as \040.
+		mountPoint := strings.Replace(fields[4], `\040`, " ", -1)
+
+		for _, dir := range dirs {
+			if mountPoint == dir || strings.HasPrefix(mountPoint, dir+"/") {
+				mountPoints = append(mountPoints, mountPoint)
+				break
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(mountPoints, func(i, j int) bool {
+		return len(mountPoints[i]) > len(mountPoints[j])
+	})
+
+	var resources []OrphanResource
+	for _, mountPoint := range mountPoints {
+		mountPoint := mountPoint
+
+		resources = append(resources, OrphanResource{
+			SandboxID: id,
+			Type:      OrphanMount,
+			Resource:  mountPoint,
+			reclaim: func() error {
+				err := unix.Unmount(mountPoint, unix.MNT_DETACH)
+				if err == unix.EINVAL || err == unix.ENOENT {
+					// Already unmounted along with a parent.
+					return nil
+				}
+				return err
+			},
+		})
+	}
+
+	return resources, nil
+}
+
+// gcNetwork returns the network resources of a sandbox. A network namespace
+// created by virtcontainers goes away with everything it holds, while the
+// TAP links and tc filters set up in a namespace created by someone else are
+// removed one by one.
+func gcNetwork(id string, networkNS NetworkNamespace) []OrphanResource {
+	if networkNS.NetNsPath == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(networkNS.NetNsPath); err != nil {
+		return nil
+	}
+
+	if networkNS.NetNsCreated {
+		return []OrphanResource{{
+			SandboxID: id,
+			Type:      OrphanNetNS,
+			Resource:  networkNS.NetNsPath,
+			reclaim: func() error {
+				return deleteNetNS(networkNS.NetNsPath)
+			},
+		}}
+	}
+
+	var resources []OrphanResource
+
+	for _, endpoint := range networkNS.Endpoints {
+		endpoint := endpoint
+
+		netPair := endpoint.NetworkPair()
+		if netPair == nil || netPair.TAPIface.Name == "" {
+			continue
+		}
+
+		exists := false
+		doNetNS(networkNS.NetNsPath, func(_ ns.NetNS) error {
+			_, err := netlink.LinkByName(netPair.TAPIface.Name)
+			exists = err == nil
+			return nil
+		})
+
+		if !exists {
+			continue
+		}
+
+		resources = append(resources, OrphanResource{
+			SandboxID: id,
+			Type:      OrphanLink,
+			Resource:  fmt.Sprintf("%s@%s", netPair.TAPIface.Name, networkNS.NetNsPath),
+			reclaim: func() error {
+				return doNetNS(networkNS.NetNsPath, func(_ ns.NetNS) error {
+					return xDisconnectVMNetwork(endpoint)
+				})
+			},
+		})
+	}
+
+	return resources
+}
+
+func gcCgroup(id string, state types.State) []OrphanResource {
+	path := cgroupNoConstraintsPath(state.CgroupPath)
+
+	if _, err := cgroupsLoadFunc(V1NoConstraints, cgroups.StaticPath(path)); err != nil {
+		return nil
+	}
+
+	s := &Sandbox{
+		id:    id,
+		state: state,
+	}
+
+	return []OrphanResource{{
+		SandboxID: id,
+		Type:      OrphanCgroup,
+		Resource:  path,
+		reclaim:   s.deleteCgroups,
+	}}
+}
+
+// gcDirs returns the shared and VM directories of a sandbox, and the ones of
+// the VM backing it.
+func gcDirs(id, vmID string) []OrphanResource {
+	dirs := []string{
+		filepath.Join(kataHostSharedDir, id),
+		filepath.Join(store.RunVMStoragePath, id),
+	}
+
+	if vmID != id {
+		dirs = append(dirs,
+			filepath.Join(kataHostSharedDir, vmID),
+			filepath.Join(store.RunVMStoragePath, vmID))
+	}
+
+	var resources []OrphanResource
+
+	for _, dir := range dirs {
+		dir := dir
+
+		if _, err := os.Lstat(dir); err != nil {
+			continue
+		}
+
+		resources = append(resources, OrphanResource{
+			SandboxID: id,
+			Type:      OrphanDir,
+			Resource:  dir,
+			reclaim: func() error {
+				return os.RemoveAll(dir)
+			},
+		})
+	}
+
+	return resources
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// A PID above the largest possible one, which cannot be signaled.
+const gcTestPID = 1 << 30
+
+func TestGcHypervisorVMID(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, d := range []struct {
+		args []string
+		root string
+		id   string
+	}{
+		{nil, "", ""},
+		{[]string{"/usr/bin/qemu-system-x86_64", "-qmp", "unix:" + store.RunVMStoragePath + "/foo/qmp.sock,server,nowait"}, "/", "foo"},
+		{[]string{"/usr/bin/qemu-lite-system-x86_64", "-pidfile", store.RunVMStoragePath + "/bar/pid"}, "/", "bar"},
+		{[]string{"/usr/bin/firecracker", "--api-sock", store.RunStoragePath + "/foo/firecracker.socket"}, "/", "foo"},
+		{[]string{"/firecracker", "--id", "foo", "--seccomp-level", "2"}, fcJailerChrootBase + "/firecracker/foo/root", "foo"},
+		{[]string{"/usr/bin/qemu-system-x86_64", "-m", "2048"}, qemuChrootBase + "/qemu-system-x86_64/bar/root", "bar"},
+		// only the paths created by the runtime are considered
+		{[]string{"/usr/bin/firecracker", "--id", "foo", "--seccomp-level", "2"}, "/", ""},
+		{[]string{"/firecracker", "--id", "foo"}, "/srv/jailer/firecracker/foo/root", ""},
+		{[]string{"/usr/bin/qemu-system-x86_64", "-m", "2048"}, "/", ""},
+		// only the hypervisors are considered
+		{[]string{"/bin/ls", store.RunVMStoragePath + "/foo"}, "/", ""},
+	} {
+		assert.Equal(d.id, gcHypervisorVMID(d.args, d.root), "%v", d.args)
+	}
+}
+
+func gcTestSetup(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir(testDir, "gc-")
+	assert.NoError(t, err)
+
+	savedRunVMStoragePath := store.RunVMStoragePath
+	savedSharedDir := kataHostSharedDir
+	savedProcPath := gcProcPath
+	savedMountInfoPath := gcMountInfoPath
+	savedGracePeriod := gcGracePeriod
+
+	store.RunVMStoragePath = filepath.Join(dir, "vm")
+	kataHostSharedDir = filepath.Join(dir, "shared")
+	gcProcPath = filepath.Join(dir, "proc")
+	gcMountInfoPath = filepath.Join(dir, "mountinfo")
+	gcGracePeriod = 0
+
+	for _, d := range []string{store.RunVMStoragePath, kataHostSharedDir, gcProcPath} {
+		err = os.MkdirAll(d, store.DirMode)
+		assert.NoError(t, err)
+	}
+
+	return dir, func() {
+		store.RunVMStoragePath = savedRunVMStoragePath
+		kataHostSharedDir = savedSharedDir
+		gcProcPath = savedProcPath
+		gcMountInfoPath = savedMountInfoPath
+		gcGracePeriod = savedGracePeriod
+
+		os.RemoveAll(dir)
+	}
+}
+
+func gcTestProcess(t *testing.T, pid int, args ...string) {
+	dir := filepath.Join(gcProcPath, fmt.Sprintf("%d", pid))
+	err := os.MkdirAll(dir, store.DirMode)
+	assert.NoError(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "cmdline"), []byte(strings.Join(args, "\x00")+"\x00"), 0644)
+	assert.NoError(t, err)
+}
+
+func gcTestSandbox(t *testing.T, id string, state types.StateString) {
+	vcStore, err := store.NewVCSandboxStore(context.Background(), id)
+	assert.NoError(t, err)
+
+	err = vcStore.Store(store.State, types.State{State: state})
+	assert.NoError(t, err)
+
+	for _, dir := range []string{store.RunVMStoragePath, kataHostSharedDir} {
+		err = os.MkdirAll(filepath.Join(dir, id), store.DirMode)
+		assert.NoError(t, err)
+	}
+}
+
+func gcTestOrphans(orphans []OrphanResource) []string {
+	var result []string
+	for _, o := range orphans {
+		result = append(result, fmt.Sprintf("%s %s %s", o.SandboxID, o.Type, o.Resource))
+	}
+
+	return result
+}
+
+func TestGarbageCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanUp()
+	_, cleanup := gcTestSetup(t)
+	defer cleanup()
+	defer cleanUp()
+
+	gcTestSandbox(t, "orphan", types.StateRunning)
+	gcTestSandbox(t, "running", types.StateRunning)
+	gcTestSandbox(t, "stopped", types.StateStopped)
+
+	qemu := "/usr/bin/qemu-system-x86_64"
+	gcTestProcess(t, 10, qemu, "-pidfile", filepath.Join(store.RunVMStoragePath, "running", "pid"))
+	gcTestProcess(t, gcTestPID, qemu, "-pidfile", filepath.Join(store.RunVMStoragePath, "leaked", "pid"))
+	gcTestProcess(t, 11, "/bin/sh")
+	// the VM directory of this one has not been created by the runtime
+	gcTestProcess(t, gcTestPID+1, qemu, "-pidfile", filepath.Join(store.RunVMStoragePath, "unknown", "pid"))
+
+	err := os.Mkdir(filepath.Join(store.RunVMStoragePath, "leaked"), store.DirMode)
+	assert.NoError(err)
+
+	mountPoint := filepath.Join(kataHostSharedDir, "orphan", "rootfs")
+	err = ioutil.WriteFile(gcMountInfoPath, []byte(fmt.Sprintf(
+		"100 25 0:45 / %s rw,relatime shared:1 - tmpfs tmpfs rw\n"+
+			"101 25 0:46 / %s rw,relatime shared:1 - tmpfs tmpfs rw\n",
+		mountPoint, filepath.Join(kataHostSharedDir, "running", "rootfs"))), 0644)
+	assert.NoError(err)
+
+	expected := []string{
+		"orphan mount " + mountPoint,
+		"orphan dir " + filepath.Join(kataHostSharedDir, "orphan"),
+		"orphan dir " + filepath.Join(store.RunVMStoragePath, "orphan"),
+		"orphan store " + store.SandboxConfigurationRootPath("orphan"),
+		fmt.Sprintf("leaked process %d", gcTestPID),
+		"leaked dir " + filepath.Join(store.RunVMStoragePath, "leaked"),
+	}
+
+	// nothing is reclaimed on a dry run
+	orphans, err := GarbageCollect(context.Background(), true)
+	assert.NoError(err)
+	assert.Equal(expected, gcTestOrphans(orphans))
+	_, err = os.Stat(store.SandboxConfigurationRootPath("orphan"))
+	assert.NoError(err)
+	_, err = os.Stat(filepath.Join(store.RunVMStoragePath, "leaked"))
+	assert.NoError(err)
+
+	orphans, err = GarbageCollect(context.Background(), false)
+	assert.NoError(err)
+	assert.Equal(expected, gcTestOrphans(orphans))
+
+	for _, path := range []string{
+		store.SandboxConfigurationRootPath("orphan"),
+		store.SandboxRuntimeRootPath("orphan"),
+		filepath.Join(kataHostSharedDir, "orphan"),
+		filepath.Join(store.RunVMStoragePath, "orphan"),
+		filepath.Join(store.RunVMStoragePath, "leaked"),
+	} {
+		_, err = os.Stat(path)
+		assert.True(os.IsNotExist(err), path)
+	}
+
+	for _, id := range []string{"running", "stopped"} {
+		_, err = os.Stat(store.SandboxConfigurationRootPath(id))
+		assert.NoError(err)
+		_, err = os.Stat(filepath.Join(store.RunVMStoragePath, id))
+		assert.NoError(err)
+	}
+}
+
+func TestGarbageCollectGracePeriod(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanUp()
+	_, cleanup := gcTestSetup(t)
+	defer cleanup()
+	defer cleanUp()
+
+	gcGracePeriod = time.Hour
+
+	gcTestSandbox(t, "creating", types.StateReady)
+
+	err := ioutil.WriteFile(gcMountInfoPath, nil, 0644)
+	assert.NoError(err)
+
+	orphans, err := GarbageCollect(context.Background(), false)
+	assert.NoError(err)
+	assert.Empty(orphans)
+	_, err = os.Stat(store.SandboxConfigurationRootPath("creating"))
+	assert.NoError(err)
+}
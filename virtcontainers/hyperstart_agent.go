@@ -7,6 +7,7 @@ package virtcontainers
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"os"
@@ -1014,6 +1015,18 @@ func (h *hyper) copyFile(src, dst string) error {
 	return nil
 }
 
+func (h *hyper) copyToContainer(c *Container, dst string, archive io.Reader) error {
+	return fmt.Errorf("hyperstart-agent does not support copying files to containers")
+}
+
+func (h *hyper) copyFromContainer(c *Container, src string, archive io.Writer) error {
+	return fmt.Errorf("hyperstart-agent does not support copying files from containers")
+}
+
+func (h *hyper) portForward(c *Container, port uint32) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("hyperstart-agent does not support port forwarding")
+}
+
 func (h *hyper) cleanup(id string) {
 	path := h.getSharePath(id)
 	if err := os.RemoveAll(path); err != nil {
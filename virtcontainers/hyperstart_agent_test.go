@@ -269,6 +269,25 @@ func TestHyperGetAgentUrl(t *testing.T) {
 	assert.Empty(url)
 }
 
+func TestHyperCopyContainerArchive(t *testing.T) {
+	assert := assert.New(t)
+	h := &hyper{}
+
+	err := h.copyToContainer(&Container{}, "", nil)
+	assert.Error(err)
+
+	err = h.copyFromContainer(&Container{}, "", nil)
+	assert.Error(err)
+}
+
+func TestHyperPortForward(t *testing.T) {
+	assert := assert.New(t)
+	h := &hyper{}
+
+	_, err := h.portForward(&Container{}, 8080)
+	assert.Error(err)
+}
+
 func TestHyperCopyFile(t *testing.T) {
 	assert := assert.New(t)
 	h := &hyper{}
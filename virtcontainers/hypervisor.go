@@ -9,14 +9,19 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/signature"
 	"github.com/kata-containers/runtime/virtcontainers/store"
 	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/sirupsen/logrus"
 )
 
 // HypervisorType describes an hypervisor type.
@@ -199,6 +204,36 @@ type HypervisorConfig struct {
 	// HypervisorPath is the hypervisor executable host path.
 	HypervisorPath string
 
+	// JailerPath is the jailer executable host path. When set, the
+	// hypervisor is started through the jailer, which chroots it and
+	// drops its privileges. Only supported by firecracker.
+	JailerPath string
+
+	// JailerNUMANode is the host NUMA node the jailed hypervisor is
+	// confined to, unless the sandbox cpuset spans a single NUMA node.
+	JailerNUMANode int
+
+	// UnprivilegedUserIDBase and UnprivilegedUserIDCount define the range
+	// of uids/gids reserved to the hypervisors dropping their privileges,
+	// each of them being allocated its own uid/gid from this range.
+	UnprivilegedUserIDBase  uint32
+	UnprivilegedUserIDCount uint32
+
+	// SeccompSandbox is the seccomp policy enabled by the hypervisor.
+	// Only supported by qemu, see its -sandbox option.
+	SeccompSandbox string
+
+	// RunAsUnprivilegedUser is used to indicate if the hypervisor has
+	// to switch to an unprivileged uid/gid, specific to the sandbox,
+	// once initialized. Only supported by qemu, along with EnableChroot.
+	// The files shared through 9p are then accessed with this uid/gid.
+	RunAsUnprivilegedUser bool
+
+	// EnableChroot is used to indicate if the hypervisor has to chroot
+	// into a sandbox specific directory, only holding the resources it
+	// needs, once initialized. Only supported by qemu.
+	EnableChroot bool
+
 	// BlockDeviceDriver specifies the driver to be used for block device
 	// either VirtioSCSI or VirtioBlock with the default driver being defaultBlockDriver
 	BlockDeviceDriver string
@@ -261,6 +296,19 @@ type HypervisorConfig struct {
 	// enabling higher density
 	Mlock bool
 
+	// PinVCPUs is used to indicate if each vCPU thread has to be pinned
+	// onto one of the host CPUs of the sandbox cpuset, and the guest
+	// memory bound to the NUMA nodes of those CPUs.
+	PinVCPUs bool
+
+	// MemoryHostNodes are the host NUMA nodes the guest memory is bound
+	// to when the hypervisor is launched. They are found from the sandbox
+	// cpuset, when the sandbox is created, if PinVCPUs or JailerPath is
+	// set. Only supported by qemu, firecracker only uses them to select
+	// the jailer NUMA node. With PinVCPUs, the memory is bound again
+	// through the sandbox cgroup once containers join the sandbox.
+	MemoryHostNodes []int
+
 	// DisableNestingChecks is used to override customizations performed
 	// when running on top of another VMM.
 	DisableNestingChecks bool
@@ -272,6 +320,11 @@ type HypervisorConfig struct {
 	// root bus instead of a bridge.
 	HotplugVFIOOnRootBus bool
 
+	// ColdPlugVFIO is used to indicate if the VFIO devices known when the
+	// sandbox is created need to be cold plugged, i.e. attached when the
+	// VM boots, instead of being hotplugged into the running VM.
+	ColdPlugVFIO bool
+
 	// BootToBeTemplate used to indicate if the VM is created to be a template VM
 	BootToBeTemplate bool
 
@@ -283,6 +336,90 @@ type HypervisorConfig struct {
 
 	// GuestHookPath is the path within the VM that will be used for 'drop-in' hooks
 	GuestHookPath string
+
+	// AssetTrustedKeys are the paths to the PEM encoded public keys trusted
+	// to sign the VM assets. When set, the detached signature of every asset
+	// is verified before launching the VM.
+	AssetTrustedKeys []string
+
+	// AssetSignaturesDir is the directory holding the detached signatures
+	// of the VM assets, named after the asset file with a ".sig" suffix.
+	// Signatures are looked up next to the assets when empty.
+	AssetSignaturesDir string
+}
+
+// hypervisorUserIDsPath holds one file per uid allocated to an unprivileged
+// hypervisor, named after the uid and holding the ID of its sandbox. The
+// uids are host wide, so is this directory: it is not relocated along with
+// the storage root.
+var hypervisorUserIDsPath = filepath.Join("/run", store.StoragePathSuffix, "uids")
+
+// allocateHypervisorUserID allocates the uid/gid used to run the unprivileged
+// hypervisor of a sandbox, unique on the host. It is picked from the range
+// reserved to the unprivileged hypervisors, which must not overlap the uids
+// of any user or any subordinate uid range.
+func allocateHypervisorUserID(conf *HypervisorConfig, sandboxID string) (int, error) {
+	if conf.UnprivilegedUserIDCount == 0 {
+		return 0, fmt.Errorf("Missing range of uids reserved for the unprivileged hypervisors")
+	}
+
+	if err := os.MkdirAll(hypervisorUserIDsPath, store.DirMode); err != nil {
+		return 0, err
+	}
+
+	for i := uint32(0); i < conf.UnprivilegedUserIDCount; i++ {
+		id := int(conf.UnprivilegedUserIDBase + i)
+		path := filepath.Join(hypervisorUserIDsPath, strconv.Itoa(id))
+
+		// The exclusive creation of the file allocates the uid.
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = f.WriteString(sandboxID)
+		f.Close()
+		if err != nil {
+			os.Remove(path)
+			return 0, err
+		}
+
+		return id, nil
+	}
+
+	return 0, fmt.Errorf("All the %d uids reserved for the unprivileged hypervisors are in use", conf.UnprivilegedUserIDCount)
+}
+
+// releaseHypervisorUserID releases the uid/gid allocated to the unprivileged
+// hypervisor of a sandbox.
+func releaseHypervisorUserID(id int, sandboxID string) error {
+	path := filepath.Join(hypervisorUserIDsPath, strconv.Itoa(id))
+
+	owner, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The uid has already been released and allocated again.
+	if string(owner) != sandboxID {
+		return nil
+	}
+
+	return os.Remove(path)
+}
+
+// AssetVerification is the result of the signature verification of a VM asset.
+type AssetVerification struct {
+	Type      types.AssetType
+	Path      string
+	Signature string
+	Err       error
 }
 
 type threadIDs struct {
@@ -344,6 +481,18 @@ func (conf *HypervisorConfig) valid() error {
 		conf.Msize9p = defaultMsize9p
 	}
 
+	if (conf.JailerPath != "" || conf.RunAsUnprivilegedUser) && conf.UnprivilegedUserIDCount == 0 {
+		return fmt.Errorf("Missing range of uids reserved for the unprivileged hypervisors")
+	}
+
+	if conf.RunAsUnprivilegedUser && !conf.EnableChroot {
+		return fmt.Errorf("Running the hypervisor as an unprivileged user requires its chroot")
+	}
+
+	if uint64(conf.UnprivilegedUserIDBase)+uint64(conf.UnprivilegedUserIDCount) > math.MaxUint32 {
+		return fmt.Errorf("Invalid range of uids reserved for the unprivileged hypervisors")
+	}
+
 	return nil
 }
 
@@ -464,6 +613,79 @@ func (conf *HypervisorConfig) CustomFirmwareAsset() bool {
 	return conf.isCustomAsset(types.FirmwareAsset)
 }
 
+// AssetVerificationEnabled returns true if the VM assets signatures have to
+// be verified before launching the VM.
+func (conf *HypervisorConfig) AssetVerificationEnabled() bool {
+	return len(conf.AssetTrustedKeys) > 0
+}
+
+func (conf *HypervisorConfig) assetSignaturePath(path string) string {
+	if conf.AssetSignaturesDir != "" {
+		return filepath.Join(conf.AssetSignaturesDir, filepath.Base(path)+".sig")
+	}
+
+	return path + ".sig"
+}
+
+// VerifyAssets verifies the detached signatures of the VM assets against
+// the trusted public keys, and returns the result for every asset.
+// Nothing is verified if no trusted key is configured.
+func (conf *HypervisorConfig) VerifyAssets() ([]AssetVerification, error) {
+	if !conf.AssetVerificationEnabled() {
+		return nil, nil
+	}
+
+	keys, err := signature.LoadPublicKeys(conf.AssetTrustedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []AssetVerification
+
+	for _, t := range []types.AssetType{types.HypervisorAsset, types.KernelAsset, types.ImageAsset, types.InitrdAsset, types.FirmwareAsset} {
+		path, err := conf.assetPath(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if path == "" {
+			continue
+		}
+
+		sigPath := conf.assetSignaturePath(path)
+
+		results = append(results, AssetVerification{
+			Type:      t,
+			Path:      path,
+			Signature: sigPath,
+			Err:       signature.VerifyFile(path, sigPath, keys),
+		})
+	}
+
+	return results, nil
+}
+
+func (conf *HypervisorConfig) verifyAssets() error {
+	results, err := conf.VerifyAssets()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("Could not verify %s asset %s: %v", r.Type, r.Path, r.Err)
+		}
+
+		virtLog.WithFields(logrus.Fields{
+			"asset":     r.Type,
+			"path":      r.Path,
+			"signature": r.Signature,
+		}).Debug("Asset signature verified")
+	}
+
+	return nil
+}
+
 func appendParam(params []Param, parameter string, value string) []Param {
 	return append(params, Param{parameter, value})
 }
@@ -597,6 +819,8 @@ type hypervisor interface {
 	stopSandbox() error
 	pauseSandbox() error
 	saveSandbox() error
+	migrateSandbox(conn *os.File) error
+	incomingSandbox(conn *os.File, timeout int, ready func() error) error
 	resumeSandbox() error
 	addDevice(devInfo interface{}, devType deviceType) error
 	hotplugAddDevice(devInfo interface{}, devType deviceType) (interface{}, error)
@@ -474,3 +474,120 @@ func genericTestRunningOnVMM(t *testing.T, data []testNestedVMMData) {
 		}
 	}
 }
+
+func TestAllocateHypervisorUserID(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "uids-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	savedUserIDsPath := hypervisorUserIDsPath
+	hypervisorUserIDsPath = filepath.Join(tmpdir, "uids")
+	defer func() {
+		hypervisorUserIDsPath = savedUserIDsPath
+	}()
+
+	conf := &HypervisorConfig{}
+
+	if _, err := allocateHypervisorUserID(conf, "sandbox0"); err == nil {
+		t.Fatalf("Expecting an error without any reserved uid range")
+	}
+
+	conf.UnprivilegedUserIDBase = 4000000000
+	conf.UnprivilegedUserIDCount = 2
+
+	id0, err := allocateHypervisorUserID(conf, "sandbox0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id1, err := allocateHypervisorUserID(conf, "sandbox1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id0 == id1 {
+		t.Fatalf("Expecting unique user IDs, got %d twice", id0)
+	}
+
+	for _, id := range []int{id0, id1} {
+		if id < 4000000000 || id >= 4000000002 {
+			t.Fatalf("User ID %d out of the reserved range", id)
+		}
+	}
+
+	if _, err := allocateHypervisorUserID(conf, "sandbox2"); err == nil {
+		t.Fatalf("Expecting an error once all the reserved uids are in use")
+	}
+
+	// Only the owner of a uid can release it
+	if err := releaseHypervisorUserID(id0, "sandbox1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := allocateHypervisorUserID(conf, "sandbox2"); err == nil {
+		t.Fatalf("Expecting an error once all the reserved uids are in use")
+	}
+
+	if err := releaseHypervisorUserID(id0, "sandbox0"); err != nil {
+		t.Fatal(err)
+	}
+
+	id2, err := allocateHypervisorUserID(conf, "sandbox2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id2 != id0 {
+		t.Fatalf("Expecting the released user ID %d to be allocated again, got %d", id0, id2)
+	}
+
+	// Releasing a uid twice is fine
+	if err := releaseHypervisorUserID(id1, "sandbox1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := releaseHypervisorUserID(id1, "sandbox1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHypervisorConfigUserIDRange(t *testing.T) {
+	conf := HypervisorConfig{
+		KernelPath: "/kernel",
+		ImagePath:  "/image",
+		JailerPath: "/jailer",
+	}
+
+	if err := conf.valid(); err == nil {
+		t.Fatalf("Expecting an error without any reserved uid range")
+	}
+
+	conf.UnprivilegedUserIDBase = 4294967000
+	conf.UnprivilegedUserIDCount = 65536
+	if err := conf.valid(); err == nil {
+		t.Fatalf("Expecting an error for an overflowing uid range")
+	}
+
+	conf.UnprivilegedUserIDBase = 4000000000
+	if err := conf.valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.JailerPath = ""
+	conf.RunAsUnprivilegedUser = true
+	if err := conf.valid(); err == nil {
+		t.Fatalf("Expecting an error for an unprivileged hypervisor not chrooted")
+	}
+
+	conf.EnableChroot = true
+	if err := conf.valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.UnprivilegedUserIDCount = 0
+	if err := conf.valid(); err == nil {
+		t.Fatalf("Expecting an error without any reserved uid range")
+	}
+}
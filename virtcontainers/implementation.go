@@ -11,6 +11,8 @@ package virtcontainers
 
 import (
 	"context"
+	"io"
+	"os"
 	"syscall"
 
 	"github.com/kata-containers/runtime/virtcontainers/device/api"
@@ -87,6 +89,11 @@ func (impl *VCImpl) ResumeSandbox(ctx context.Context, sandboxID string) (VCSand
 	return ResumeSandbox(ctx, sandboxID)
 }
 
+// ProfileSandbox implements the VC function of the same name.
+func (impl *VCImpl) ProfileSandbox(ctx context.Context, sandboxID string) (SandboxTimeline, error) {
+	return ProfileSandbox(ctx, sandboxID)
+}
+
 // CreateContainer implements the VC function of the same name.
 func (impl *VCImpl) CreateContainer(ctx context.Context, sandboxID string, containerConfig ContainerConfig) (VCSandbox, VCContainer, error) {
 	return CreateContainer(ctx, sandboxID, containerConfig)
@@ -127,6 +134,16 @@ func (impl *VCImpl) KillContainer(ctx context.Context, sandboxID, containerID st
 	return KillContainer(ctx, sandboxID, containerID, signal, all)
 }
 
+// CopyToContainer implements the VC function of the same name.
+func (impl *VCImpl) CopyToContainer(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error {
+	return CopyToContainer(ctx, sandboxID, containerID, dst, archive)
+}
+
+// CopyFromContainer implements the VC function of the same name.
+func (impl *VCImpl) CopyFromContainer(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error {
+	return CopyFromContainer(ctx, sandboxID, containerID, src, archive)
+}
+
 // ProcessListContainer implements the VC function of the same name.
 func (impl *VCImpl) ProcessListContainer(ctx context.Context, sandboxID, containerID string, options ProcessListOptions) (ProcessList, error) {
 	return ProcessListContainer(ctx, sandboxID, containerID, options)
@@ -152,6 +169,16 @@ func (impl *VCImpl) AddDevice(ctx context.Context, sandboxID string, info config
 	return AddDevice(ctx, sandboxID, info)
 }
 
+// RemoveDevice will remove a device from sandbox
+func (impl *VCImpl) RemoveDevice(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error) {
+	return RemoveDevice(ctx, sandboxID, info)
+}
+
+// ListDevices will list all the devices of a sandbox
+func (impl *VCImpl) ListDevices(ctx context.Context, sandboxID string) ([]api.Device, error) {
+	return ListDevices(ctx, sandboxID)
+}
+
 // AddInterface implements the VC function of the same name.
 func (impl *VCImpl) AddInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error) {
 	return AddInterface(ctx, sandboxID, inf)
@@ -176,3 +203,18 @@ func (impl *VCImpl) UpdateRoutes(ctx context.Context, sandboxID string, routes [
 func (impl *VCImpl) ListRoutes(ctx context.Context, sandboxID string) ([]*vcTypes.Route, error) {
 	return ListRoutes(ctx, sandboxID)
 }
+
+// GarbageCollect implements the VC function of the same name.
+func (impl *VCImpl) GarbageCollect(ctx context.Context, dryRun bool) ([]OrphanResource, error) {
+	return GarbageCollect(ctx, dryRun)
+}
+
+// MigrateSandbox implements the VC function of the same name.
+func (impl *VCImpl) MigrateSandbox(ctx context.Context, sandboxID string, conn *os.File) error {
+	return MigrateSandbox(ctx, sandboxID, conn)
+}
+
+// IncomingSandbox implements the VC function of the same name.
+func (impl *VCImpl) IncomingSandbox(ctx context.Context, conn *os.File, config SandboxConfig) (VCSandbox, error) {
+	return IncomingSandbox(ctx, conn, config)
+}
@@ -8,6 +8,7 @@ package virtcontainers
 import (
 	"context"
 	"io"
+	"os"
 	"syscall"
 
 	"github.com/kata-containers/runtime/virtcontainers/device/api"
@@ -33,6 +34,7 @@ type VC interface {
 	StartSandbox(ctx context.Context, sandboxID string) (VCSandbox, error)
 	StatusSandbox(ctx context.Context, sandboxID string) (SandboxStatus, error)
 	StopSandbox(ctx context.Context, sandboxID string) (VCSandbox, error)
+	ProfileSandbox(ctx context.Context, sandboxID string) (SandboxTimeline, error)
 
 	CreateContainer(ctx context.Context, sandboxID string, containerConfig ContainerConfig) (VCSandbox, VCContainer, error)
 	DeleteContainer(ctx context.Context, sandboxID, containerID string) (VCContainer, error)
@@ -43,17 +45,26 @@ type VC interface {
 	StatsContainer(ctx context.Context, sandboxID, containerID string) (ContainerStats, error)
 	StopContainer(ctx context.Context, sandboxID, containerID string) (VCContainer, error)
 	ProcessListContainer(ctx context.Context, sandboxID, containerID string, options ProcessListOptions) (ProcessList, error)
+	CopyToContainer(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error
+	CopyFromContainer(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error
 	UpdateContainer(ctx context.Context, sandboxID, containerID string, resources specs.LinuxResources) error
 	PauseContainer(ctx context.Context, sandboxID, containerID string) error
 	ResumeContainer(ctx context.Context, sandboxID, containerID string) error
 
 	AddDevice(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error)
+	RemoveDevice(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error)
+	ListDevices(ctx context.Context, sandboxID string) ([]api.Device, error)
 
 	AddInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error)
 	RemoveInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error)
 	ListInterfaces(ctx context.Context, sandboxID string) ([]*vcTypes.Interface, error)
 	UpdateRoutes(ctx context.Context, sandboxID string, routes []*vcTypes.Route) ([]*vcTypes.Route, error)
 	ListRoutes(ctx context.Context, sandboxID string) ([]*vcTypes.Route, error)
+
+	GarbageCollect(ctx context.Context, dryRun bool) ([]OrphanResource, error)
+
+	MigrateSandbox(ctx context.Context, sandboxID string, conn *os.File) error
+	IncomingSandbox(ctx context.Context, conn *os.File, config SandboxConfig) (VCSandbox, error)
 }
 
 // VCSandbox is the Sandbox interface
@@ -87,12 +98,17 @@ type VCSandbox interface {
 	EnterContainer(containerID string, cmd types.Cmd) (VCContainer, *Process, error)
 	UpdateContainer(containerID string, resources specs.LinuxResources) error
 	ProcessListContainer(containerID string, options ProcessListOptions) (ProcessList, error)
+	CopyToContainer(containerID, dst string, archive io.Reader) error
+	CopyFromContainer(containerID, src string, archive io.Writer) error
 	WaitProcess(containerID, processID string) (int32, error)
 	SignalProcess(containerID, processID string, signal syscall.Signal, all bool) error
 	WinsizeProcess(containerID, processID string, height, width uint32) error
 	IOStream(containerID, processID string) (io.WriteCloser, io.Reader, io.Reader, error)
+	PortForward(port uint32) (io.ReadWriteCloser, error)
 
 	AddDevice(info config.DeviceInfo) (api.Device, error)
+	RemoveDevice(info config.DeviceInfo) (api.Device, error)
+	ListDevices() ([]api.Device, error)
 
 	AddInterface(inf *vcTypes.Interface) (*vcTypes.Interface, error)
 	RemoveInterface(inf *vcTypes.Interface) (*vcTypes.Interface, error)
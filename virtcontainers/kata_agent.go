@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -16,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -40,6 +42,7 @@ import (
 	"golang.org/x/sys/unix"
 	golangGrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	grpcStatus "google.golang.org/grpc/status"
 )
 
@@ -77,6 +80,10 @@ var (
 type KataAgentConfig struct {
 	LongLiveConn bool
 	UseVSock     bool
+	// KernelModules are the guest kernel modules loaded when the guest
+	// boots, each one being the module name optionally followed by its
+	// space separated parameters.
+	KernelModules []string
 }
 
 type kataVSOCK struct {
@@ -109,6 +116,14 @@ type kataAgent struct {
 	keepConn     bool
 	proxyBuiltIn bool
 
+	// kmodules are the guest kernel modules of the agent configuration.
+	kmodules []string
+
+	// streams counts the open streams, port forwarding streams or
+	// processes started by the runtime, the connection being kept
+	// while some of them are open.
+	streams int32
+
 	vmSocket interface{}
 	ctx      context.Context
 }
@@ -177,6 +192,7 @@ func (k *kataAgent) init(ctx context.Context, sandbox *Sandbox, config interface
 			return err
 		}
 		k.keepConn = c.LongLiveConn
+		k.kmodules = c.KernelModules
 	default:
 		return fmt.Errorf("Invalid config type")
 	}
@@ -517,6 +533,8 @@ func (k *kataAgent) startProxy(sandbox *Sandbox) error {
 		consoleURL: consoleURL,
 		logger:     k.Logger().WithField("sandbox", sandbox.id),
 		debug:      sandbox.config.ProxyConfig.Debug,
+
+		consoleHandler: sandbox.readGuestConsole,
 	}
 
 	// Start the proxy here
@@ -612,6 +630,10 @@ func (k *kataAgent) startSandbox(sandbox *Sandbox) error {
 		return err
 	}
 
+	if err = k.checkKernelModules(); err != nil {
+		return err
+	}
+
 	//
 	// Setup network interfaces and routes
 	//
@@ -650,18 +672,7 @@ func (k *kataAgent) startSandbox(sandbox *Sandbox) error {
 	}
 
 	if sandbox.shmSize > 0 {
-		path := filepath.Join(kataGuestSandboxDir, shmDir)
-		shmSizeOption := fmt.Sprintf("size=%d", sandbox.shmSize)
-
-		shmStorage := &grpc.Storage{
-			Driver:     kataEphemeralDevType,
-			MountPoint: path,
-			Source:     "shm",
-			Fstype:     "tmpfs",
-			Options:    []string{"noexec", "nosuid", "nodev", "mode=1777", shmSizeOption},
-		}
-
-		storages = append(storages, shmStorage)
+		storages = append(storages, sandboxShmStorage(sandbox))
 	}
 
 	req := &grpc.CreateSandboxRequest{
@@ -676,6 +687,69 @@ func (k *kataAgent) startSandbox(sandbox *Sandbox) error {
 	return err
 }
 
+// kernelModuleNameRegex matches the guest kernel module names.
+var kernelModuleNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// kernelModulesParams returns the guest kernel parameters loading the
+// kernel modules of the agent configuration, each one being a module name
+// optionally followed by its space separated parameters. The modules are
+// loaded by systemd-modules-load from the modules-load parameter, with the
+// <module>.<parameter> kernel parameters as module parameters.
+func kernelModulesParams(kmodules []string) ([]Param, error) {
+	var names []string
+	var params []Param
+	loaded := make(map[string]bool)
+
+	for _, m := range kmodules {
+		fields := strings.Fields(m)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := fields[0]
+		if !kernelModuleNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("Invalid guest kernel module name %q", name)
+		}
+
+		if !loaded[name] {
+			loaded[name] = true
+			names = append(names, name)
+		}
+
+		for _, p := range DeserializeParams(fields[1:]) {
+			p.Key = name + "." + p.Key
+			params = append(params, p)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	return append(params, Param{Key: "modules-load", Value: strings.Join(names, ",")}), nil
+}
+
+// checkKernelModules checks that the guest can load the kernel modules of
+// the agent configuration. The agent protocol has no request loading them,
+// they can only be loaded by systemd-modules-load, which does not run when
+// the agent is the guest init.
+func (k *kataAgent) checkKernelModules() error {
+	if len(k.kmodules) == 0 {
+		return nil
+	}
+
+	details, err := k.getGuestDetails(&grpc.GuestDetailsRequest{})
+	if err != nil {
+		return err
+	}
+
+	if details.AgentDetails != nil && details.AgentDetails.InitDaemon {
+		return fmt.Errorf("Guest kernel modules %v can not be loaded: the agent is the guest init and the guest has no systemd-modules-load", k.kmodules)
+	}
+
+	return nil
+}
+
 func (k *kataAgent) stopSandbox(sandbox *Sandbox) error {
 	span, _ := k.trace("stopSandbox")
 	defer span.Finish()
@@ -787,13 +861,13 @@ func constraintGRPCSpec(grpcSpec *grpc.Spec, systemdCgroup bool, passSeccomp boo
 		grpcSpec.Linux.Seccomp = nil
 	}
 
-	// By now only CPU constraints are supported
+	// By now only CPU, memory and hugetlb constraints are supported, the
+	// guest hugepages being reserved at boot.
 	// Issue: https://github.com/kata-containers/runtime/issues/158
 	// Issue: https://github.com/kata-containers/runtime/issues/204
 	grpcSpec.Linux.Resources.Devices = nil
 	grpcSpec.Linux.Resources.Pids = nil
 	grpcSpec.Linux.Resources.BlockIO = nil
-	grpcSpec.Linux.Resources.HugepageLimits = nil
 	grpcSpec.Linux.Resources.Network = nil
 
 	// There are three main reasons to do not apply systemd cgroups in the VM
@@ -833,6 +907,33 @@ func constraintGRPCSpec(grpcSpec *grpc.Spec, systemdCgroup bool, passSeccomp boo
 	grpcSpec.Linux.Namespaces = tmpNamespaces
 }
 
+// sandboxShmStorage returns the storage of the shm shared by the containers
+// of a sandbox.
+func sandboxShmStorage(sandbox *Sandbox) *grpc.Storage {
+	path := filepath.Join(kataGuestSandboxDir, shmDir)
+	shmSizeOption := fmt.Sprintf("size=%d", sandbox.shmSize)
+
+	// The hugepages backing the shm are reserved at boot, see
+	// addHugePagesParams.
+	if sandbox.shmPageSize > 0 {
+		return &grpc.Storage{
+			Driver:     kataEphemeralDevType,
+			MountPoint: path,
+			Source:     "nodev",
+			Fstype:     "hugetlbfs",
+			Options:    []string{"noexec", "nosuid", "nodev", "mode=1777", fmt.Sprintf("pagesize=%d", sandbox.shmPageSize), shmSizeOption},
+		}
+	}
+
+	return &grpc.Storage{
+		Driver:     kataEphemeralDevType,
+		MountPoint: path,
+		Source:     "shm",
+		Fstype:     "tmpfs",
+		Options:    []string{"noexec", "nosuid", "nodev", "mode=1777", shmSizeOption},
+	}
+}
+
 func (k *kataAgent) handleShm(grpcSpec *grpc.Spec, sandbox *Sandbox) {
 	for idx, mnt := range grpcSpec.Mounts {
 		if mnt.Destination != "/dev/shm" {
@@ -953,6 +1054,10 @@ func (k *kataAgent) buildContainerRootfs(sandbox *Sandbox, c *Container, rootPat
 			rootfs.Options = []string{"nouuid"}
 		}
 
+		if image := c.rootfsImage(); image != nil {
+			rootfs.Options = append(rootfs.Options, image.Options...)
+		}
+
 		return rootfs, nil
 	}
 
@@ -1017,7 +1122,15 @@ func (k *kataAgent) createContainer(sandbox *Sandbox, c *Container) (p *Process,
 		return nil, err
 	}
 
-	epheStorages := k.handleEphemeralStorage(ociSpec.Mounts)
+	var hugepageLimits []specs.LinuxHugepageLimit
+	if ociSpec.Linux != nil && ociSpec.Linux.Resources != nil {
+		hugepageLimits = ociSpec.Linux.Resources.HugepageLimits
+	}
+
+	epheStorages, err := k.handleEphemeralStorage(c, ociSpec.Mounts, hugepageLimits)
+	if err != nil {
+		return nil, err
+	}
 	ctrStorages = append(ctrStorages, epheStorages...)
 
 	// We replace all OCI mount sources that match our container mount
@@ -1095,13 +1208,56 @@ func (k *kataAgent) createContainer(sandbox *Sandbox, c *Container) (p *Process,
 
 // handleEphemeralStorage handles ephemeral storages by
 // creating a Storage from corresponding source of the mount point
-func (k *kataAgent) handleEphemeralStorage(mounts []specs.Mount) []*grpc.Storage {
+func (k *kataAgent) handleEphemeralStorage(c *Container, mounts []specs.Mount, hugepageLimits []specs.LinuxHugepageLimit) ([]*grpc.Storage, error) {
 	var epheStorages []*grpc.Storage
 	for idx, mnt := range mounts {
 		if mnt.Type == kataEphemeralDevType {
 			// Set the mount source path to a path that resides inside the VM
 			mounts[idx].Source = filepath.Join(ephemeralPath, filepath.Base(mnt.Source))
 
+			// Hugepage-backed emptyDir volumes are mounted from a
+			// guest hugetlbfs, sized from the container hugetlb
+			// limit. The hugepages backing it are reserved at
+			// boot, see addHugePagesParams and checkHugePages.
+			pageSize, err := hugePageSize(mnt.Source)
+			if err != nil {
+				return nil, err
+			}
+
+			if pageSize > 0 {
+				limit, err := hugePageLimit(hugepageLimits, pageSize)
+				if err != nil {
+					return nil, err
+				}
+
+				epheStorage := &grpc.Storage{
+					Driver:     kataEphemeralDevType,
+					Source:     "nodev",
+					Fstype:     "hugetlbfs",
+					MountPoint: mounts[idx].Source,
+					Options:    []string{fmt.Sprintf("pagesize=%d", pageSize), fmt.Sprintf("size=%d", limit)},
+				}
+				epheStorages = append(epheStorages, epheStorage)
+				continue
+			}
+
+			// Block-backed emptyDir volumes are mounted from their
+			// device, formatted when created on the host.
+			if id := c.emptyDirDeviceID(mnt.Source); id != "" {
+				epheStorage, err := k.blockVolumeStorage(c, id, mnt.Destination)
+				if err != nil {
+					return nil, err
+				}
+				if epheStorage == nil {
+					continue
+				}
+
+				epheStorage.MountPoint = mounts[idx].Source
+				epheStorage.Fstype = emptyDirFstype
+				epheStorages = append(epheStorages, epheStorage)
+				continue
+			}
+
 			// Create a storage struct so that kata agent is able to create
 			// tmpfs backed volume inside the VM
 			epheStorage := &grpc.Storage{
@@ -1113,7 +1269,7 @@ func (k *kataAgent) handleEphemeralStorage(mounts []specs.Mount) []*grpc.Storage
 			epheStorages = append(epheStorages, epheStorage)
 		}
 	}
-	return epheStorages
+	return epheStorages, nil
 }
 
 // handleBlockVolumes handles volumes that are block devices files
@@ -1123,42 +1279,18 @@ func (k *kataAgent) handleBlockVolumes(c *Container) []*grpc.Storage {
 	var volumeStorages []*grpc.Storage
 
 	for _, m := range c.mounts {
-		id := m.BlockDeviceID
-
-		if len(id) == 0 {
+		// Block-backed emptyDir volumes are ephemeral storages.
+		if len(m.BlockDeviceID) == 0 || m.Type == kataEphemeralDevType {
 			continue
 		}
 
-		// Add the block device to the list of container devices, to make sure the
-		// device is detached with detachDevices() for a container.
-		c.devices = append(c.devices, ContainerDevice{ID: id, ContainerPath: m.Destination})
-		if err := c.storeDevices(); err != nil {
-			k.Logger().WithField("device", id).WithError(err).Error("store device failed")
-			return nil
-		}
-
-		vol := &grpc.Storage{}
-
-		device := c.sandbox.devManager.GetDeviceByID(id)
-		if device == nil {
-			k.Logger().WithField("device", id).Error("failed to find device by id")
+		vol, err := k.blockVolumeStorage(c, m.BlockDeviceID, m.Destination)
+		if err != nil {
 			return nil
 		}
-		blockDrive, ok := device.GetDeviceInfo().(*config.BlockDrive)
-		if !ok || blockDrive == nil {
-			k.Logger().Error("malformed block drive")
+		if vol == nil {
 			continue
 		}
-		if c.sandbox.config.HypervisorConfig.BlockDeviceDriver == config.VirtioBlock {
-			vol.Driver = kataBlkDevType
-			vol.Source = blockDrive.PCIAddr
-		} else if c.sandbox.config.HypervisorConfig.BlockDeviceDriver == config.VirtioMmio {
-			vol.Driver = kataMmioBlkDevType
-			vol.Source = blockDrive.VirtPath
-		} else {
-			vol.Driver = kataSCSIDevType
-			vol.Source = blockDrive.SCSIAddr
-		}
 
 		vol.MountPoint = m.Destination
 		vol.Fstype = "bind"
@@ -1170,6 +1302,44 @@ func (k *kataAgent) handleBlockVolumes(c *Container) []*grpc.Storage {
 	return volumeStorages
 }
 
+// blockVolumeStorage returns the Storage through which the agent finds the
+// block device "id" backing a container volume, nil if the device is
+// malformed.
+func (k *kataAgent) blockVolumeStorage(c *Container, id, containerPath string) (*grpc.Storage, error) {
+	// Add the block device to the list of container devices, to make sure the
+	// device is detached with detachDevices() for a container.
+	c.devices = append(c.devices, ContainerDevice{ID: id, ContainerPath: containerPath})
+	if err := c.storeDevices(); err != nil {
+		k.Logger().WithField("device", id).WithError(err).Error("store device failed")
+		return nil, err
+	}
+
+	vol := &grpc.Storage{}
+
+	device := c.sandbox.devManager.GetDeviceByID(id)
+	if device == nil {
+		k.Logger().WithField("device", id).Error("failed to find device by id")
+		return nil, fmt.Errorf("failed to find device by id %q", id)
+	}
+	blockDrive, ok := device.GetDeviceInfo().(*config.BlockDrive)
+	if !ok || blockDrive == nil {
+		k.Logger().Error("malformed block drive")
+		return nil, nil
+	}
+	if c.sandbox.config.HypervisorConfig.BlockDeviceDriver == config.VirtioBlock {
+		vol.Driver = kataBlkDevType
+		vol.Source = blockDrive.PCIAddr
+	} else if c.sandbox.config.HypervisorConfig.BlockDeviceDriver == config.VirtioMmio {
+		vol.Driver = kataMmioBlkDevType
+		vol.Source = blockDrive.VirtPath
+	} else {
+		vol.Driver = kataSCSIDevType
+		vol.Source = blockDrive.SCSIAddr
+	}
+
+	return vol, nil
+}
+
 // handlePidNamespace checks if Pid namespace for a container needs to be shared with its sandbox
 // pid namespace. This function also modifies the grpc spec to remove the pid namespace
 // from the list of namespaces passed to the agent.
@@ -1562,10 +1732,53 @@ func (k *kataAgent) installReqFunc(c *kataclient.AgentClient) {
 	k.reqHandlers["grpc.SetGuestDateTimeRequest"] = func(ctx context.Context, req interface{}, opts ...golangGrpc.CallOption) (interface{}, error) {
 		return k.client.SetGuestDateTime(ctx, req.(*grpc.SetGuestDateTimeRequest), opts...)
 	}
+
+	for name, handler := range k.reqHandlers {
+		k.reqHandlers[name] = traceReqFunc(handler)
+	}
+}
+
+// spanContextCarrier writes a span context into gRPC metadata.
+type spanContextCarrier metadata.MD
+
+func (c spanContextCarrier) Set(key, val string) {
+	// gRPC metadata keys are lowercase.
+	c[strings.ToLower(key)] = []string{val}
+}
+
+// injectSpanContext returns a context carrying the span context of ctx in
+// its outgoing gRPC metadata, allowing the agent to continue the trace.
+func injectSpanContext(ctx context.Context) context.Context {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+
+	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, spanContextCarrier(md)); err != nil {
+		virtLog.WithError(err).Warn("Could not inject span context into agent request")
+		return ctx
+	}
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// traceReqFunc wraps an agent request handler, sending the span context of
+// the request to the agent.
+func traceReqFunc(handler reqFunc) reqFunc {
+	return func(ctx context.Context, req interface{}, opts ...golangGrpc.CallOption) (interface{}, error) {
+		return handler(injectSpanContext(ctx), req, opts...)
+	}
 }
 
 func (k *kataAgent) sendReq(request interface{}) (interface{}, error) {
-	span, _ := k.trace("sendReq")
+	span, ctx := k.trace("sendReq")
 	span.SetTag("request", request)
 	defer span.Finish()
 
@@ -1579,7 +1792,7 @@ func (k *kataAgent) sendReq(request interface{}) (interface{}, error) {
 	if err := k.connect(); err != nil {
 		return nil, err
 	}
-	if !k.keepConn {
+	if !k.keepConn && atomic.LoadInt32(&k.streams) == 0 {
 		defer k.disconnect()
 	}
 
@@ -1591,7 +1804,7 @@ func (k *kataAgent) sendReq(request interface{}) (interface{}, error) {
 	message := request.(proto.Message)
 	k.Logger().WithField("name", msgName).WithField("req", message.String()).Debug("sending request")
 
-	return handler(k.ctx, request)
+	return handler(ctx, request)
 }
 
 // readStdout and readStderr are special that we cannot differentiate them with the request types...
@@ -1599,7 +1812,7 @@ func (k *kataAgent) readProcessStdout(c *Container, processID string, data []byt
 	if err := k.connect(); err != nil {
 		return 0, err
 	}
-	if !k.keepConn {
+	if !k.keepConn && atomic.LoadInt32(&k.streams) == 0 {
 		defer k.disconnect()
 	}
 
@@ -1611,7 +1824,7 @@ func (k *kataAgent) readProcessStderr(c *Container, processID string, data []byt
 	if err := k.connect(); err != nil {
 		return 0, err
 	}
-	if !k.keepConn {
+	if !k.keepConn && atomic.LoadInt32(&k.streams) == 0 {
 		defer k.disconnect()
 	}
 
@@ -1844,6 +2057,62 @@ func (k *kataAgent) copyFile(src, dst string) error {
 	return nil
 }
 
+func (k *kataAgent) copyToContainer(c *Container, dst string, archive io.Reader) error {
+	k.Logger().WithFields(logrus.Fields{
+		"container": c.id,
+		"dest":      dst,
+	}).Debug("Copying archive from host to container")
+
+	// The archive is streamed to a tar process extracting it in the
+	// container, its stdin being closed once the archive is sent.
+	if err := k.runExecStream(c, []string{"tar", "-x", "-p", "-f", "-", "-C", dst}, archive, nil); err != nil {
+		return fmt.Errorf("Could not extract archive in container: %v", err)
+	}
+
+	return nil
+}
+
+func (k *kataAgent) copyFromContainer(c *Container, src string, archive io.Writer) error {
+	k.Logger().WithFields(logrus.Fields{
+		"container": c.id,
+		"source":    src,
+	}).Debug("Copying archive from container to host")
+
+	// The archive is streamed from a tar process creating it in the
+	// container.
+	args := []string{"tar", "-c", "-f", "-", "-C", filepath.Dir(src), filepath.Base(src)}
+	if err := k.runExecStream(c, args, nil, archive); err != nil {
+		return fmt.Errorf("Could not create archive in container: %v", err)
+	}
+
+	return nil
+}
+
+// portForwardSocatTimeout is how long, in seconds, socat keeps forwarding
+// one direction of a connection once the other one is closed, rather than
+// its default half a second: the stream is closed by its owner instead.
+const portForwardSocatTimeout = "2147483647"
+
+func (k *kataAgent) portForward(c *Container, port uint32) (io.ReadWriteCloser, error) {
+	k.Logger().WithFields(logrus.Fields{
+		"container": c.id,
+		"port":      port,
+	}).Debug("Opening port forwarding stream")
+
+	// socat half closes the connection once its stdin is closed, and
+	// keeps forwarding the other direction until the connection is
+	// closed, the runtime killing it once the stream is closed.
+	s, err := k.startExecStream(c, []string{"socat", "-t", portForwardSocatTimeout, "STDIO", fmt.Sprintf("TCP:localhost:%d", port)})
+	if err != nil {
+		return nil, fmt.Errorf("Could not run socat, which the container image has to provide: %v", err)
+	}
+
+	// Nothing but errors is written to stderr.
+	go io.Copy(ioutil.Discard, s.stderr())
+
+	return s, nil
+}
+
 func (k *kataAgent) cleanup(id string) {
 	path := k.getSharePath(id)
 	k.Logger().WithField("path", path).Infof("cleanup agent")
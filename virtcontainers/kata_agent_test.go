@@ -6,22 +6,28 @@
 package virtcontainers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"syscall"
 	"testing"
 
 	gpb "github.com/gogo/protobuf/types"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	aTypes "github.com/kata-containers/agent/pkg/types"
 	pb "github.com/kata-containers/agent/protocols/grpc"
@@ -126,7 +132,39 @@ func TestKataAgentDisconnect(t *testing.T) {
 	}
 }
 
-type gRPCProxy struct{}
+type gRPCProxy struct {
+	sync.Mutex
+
+	// execs holds the processes started by ExecProcess, if not nil.
+	// Their stdin is recorded, they write execOutput to their stdout
+	// and exit with execStatus.
+	execs      map[string]*gRPCProxyExec
+	execOutput []byte
+	execStatus int32
+
+	// execFailures holds the containers in which ExecProcess fails, as
+	// if their image did not provide the executable.
+	execFailures map[string]bool
+
+	// initDaemon is returned by GetGuestDetails as the agent being the
+	// guest init.
+	initDaemon bool
+}
+
+type gRPCProxyExec struct {
+	args   []string
+	stdin  []byte
+	stdout []byte
+}
+
+// exec returns the process started by ExecProcess, if any.
+func (p *gRPCProxy) exec(execID string) *gRPCProxyExec {
+	if p.execs == nil {
+		return nil
+	}
+
+	return p.execs[execID]
+}
 
 var emptyResp = &gpb.Empty{}
 
@@ -139,6 +177,20 @@ func (p *gRPCProxy) StartContainer(ctx context.Context, req *pb.StartContainerRe
 }
 
 func (p *gRPCProxy) ExecProcess(ctx context.Context, req *pb.ExecProcessRequest) (*gpb.Empty, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.execFailures[req.ContainerId] {
+		return nil, fmt.Errorf("exec: %q: executable file not found in $PATH", req.Process.Args[0])
+	}
+
+	if p.execs != nil {
+		p.execs[req.ExecId] = &gRPCProxyExec{
+			args:   req.Process.Args,
+			stdout: p.execOutput,
+		}
+	}
+
 	return emptyResp, nil
 }
 
@@ -147,6 +199,13 @@ func (p *gRPCProxy) SignalProcess(ctx context.Context, req *pb.SignalProcessRequ
 }
 
 func (p *gRPCProxy) WaitProcess(ctx context.Context, req *pb.WaitProcessRequest) (*pb.WaitProcessResponse, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.exec(req.ExecId) != nil {
+		return &pb.WaitProcessResponse{Status: p.execStatus}, nil
+	}
+
 	return &pb.WaitProcessResponse{}, nil
 }
 
@@ -163,14 +222,50 @@ func (p *gRPCProxy) RemoveContainer(ctx context.Context, req *pb.RemoveContainer
 }
 
 func (p *gRPCProxy) WriteStdin(ctx context.Context, req *pb.WriteStreamRequest) (*pb.WriteStreamResponse, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if e := p.exec(req.ExecId); e != nil {
+		e.stdin = append(e.stdin, req.Data...)
+		return &pb.WriteStreamResponse{Len: uint32(len(req.Data))}, nil
+	}
+
 	return &pb.WriteStreamResponse{}, nil
 }
 
 func (p *gRPCProxy) ReadStdout(ctx context.Context, req *pb.ReadStreamRequest) (*pb.ReadStreamResponse, error) {
-	return &pb.ReadStreamResponse{}, nil
+	p.Lock()
+	defer p.Unlock()
+
+	e := p.exec(req.ExecId)
+	if e == nil {
+		return &pb.ReadStreamResponse{}, nil
+	}
+
+	// As the agent, the end of the stream is reported as an error.
+	if len(e.stdout) == 0 {
+		return nil, io.EOF
+	}
+
+	n := int(req.Len)
+	if n > len(e.stdout) {
+		n = len(e.stdout)
+	}
+
+	data := e.stdout[:n]
+	e.stdout = e.stdout[n:]
+
+	return &pb.ReadStreamResponse{Data: data}, nil
 }
 
 func (p *gRPCProxy) ReadStderr(ctx context.Context, req *pb.ReadStreamRequest) (*pb.ReadStreamResponse, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.exec(req.ExecId) != nil {
+		return nil, io.EOF
+	}
+
 	return &pb.ReadStreamResponse{}, nil
 }
 
@@ -244,7 +339,9 @@ func (p *gRPCProxy) ReseedRandomDev(ctx context.Context, req *pb.ReseedRandomDev
 }
 
 func (p *gRPCProxy) GetGuestDetails(ctx context.Context, req *pb.GuestDetailsRequest) (*pb.GuestDetailsResponse, error) {
-	return &pb.GuestDetailsResponse{}, nil
+	return &pb.GuestDetailsResponse{
+		AgentDetails: &pb.AgentDetails{InitDaemon: p.initDaemon},
+	}, nil
 }
 
 func (p *gRPCProxy) SetGuestDateTime(ctx context.Context, req *pb.SetGuestDateTimeRequest) (*gpb.Empty, error) {
@@ -361,7 +458,9 @@ func TestKataAgentSendReq(t *testing.T) {
 func TestHandleEphemeralStorage(t *testing.T) {
 	k := kataAgent{}
 	var ociMounts []specs.Mount
-	mountSource := "/tmp/mountPoint"
+	mountSource, err := ioutil.TempDir("", "ephemeral-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(mountSource)
 
 	mount := specs.Mount{
 		Type:   kataEphemeralDevType,
@@ -369,7 +468,8 @@ func TestHandleEphemeralStorage(t *testing.T) {
 	}
 
 	ociMounts = append(ociMounts, mount)
-	epheStorages := k.handleEphemeralStorage(ociMounts)
+	epheStorages, err := k.handleEphemeralStorage(&Container{}, ociMounts, nil)
+	assert.NoError(t, err)
 
 	epheMountPoint := epheStorages[0].GetMountPoint()
 	expected := filepath.Join(ephemeralPath, filepath.Base(mountSource))
@@ -377,6 +477,124 @@ func TestHandleEphemeralStorage(t *testing.T) {
 		"Ephemeral mount point didn't match: got %s, expecting %s", epheMountPoint, expected)
 }
 
+func TestHandleEphemeralStorageHugePages(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	k := kataAgent{}
+	mountSource, err := ioutil.TempDir("", "ephemeral-")
+	assert.NoError(err)
+	defer os.RemoveAll(mountSource)
+
+	if err := syscall.Mount("nodev", mountSource, "hugetlbfs", 0, "pagesize=2M"); err != nil {
+		t.Skipf("Could not mount hugetlbfs: %v", err)
+	}
+	defer syscall.Unmount(mountSource, syscall.MNT_DETACH)
+
+	ociMounts := []specs.Mount{
+		{
+			Type:   kataEphemeralDevType,
+			Source: mountSource,
+		},
+	}
+
+	// the hugetlb limit sizes the volume
+	_, err = k.handleEphemeralStorage(&Container{}, ociMounts, nil)
+	assert.Error(err)
+
+	// the mount source was replaced by the guest one
+	ociMounts[0].Source = mountSource
+	hugepageLimits := []specs.LinuxHugepageLimit{
+		{Pagesize: "1GB", Limit: 1 << 30},
+		{Pagesize: "2MB", Limit: 8 << 20},
+	}
+
+	epheStorages, err := k.handleEphemeralStorage(&Container{}, ociMounts, hugepageLimits)
+	assert.NoError(err)
+	assert.Equal([]*pb.Storage{
+		{
+			Driver:     kataEphemeralDevType,
+			Source:     "nodev",
+			Fstype:     "hugetlbfs",
+			Options:    []string{"pagesize=2097152", "size=8388608"},
+			MountPoint: filepath.Join(ephemeralPath, filepath.Base(mountSource)),
+		},
+	}, epheStorages)
+}
+
+func TestHandleEphemeralStorageBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanUp()
+	defer cleanUp()
+
+	k := kataAgent{}
+	mountSource, err := ioutil.TempDir("", "ephemeral-")
+	assert.NoError(err)
+	defer os.RemoveAll(mountSource)
+
+	id := "test-emptydir-block"
+	devices := []api.Device{
+		&drivers.BlockDevice{
+			GenericDevice: &drivers.GenericDevice{
+				ID: id,
+			},
+			BlockDrive: &config.BlockDrive{
+				PCIAddr: testPCIAddr,
+			},
+		},
+	}
+
+	c := &Container{
+		id: testContainerID,
+		sandbox: &Sandbox{
+			devManager: manager.NewDeviceManager("virtio-blk", devices),
+			config: &SandboxConfig{
+				HypervisorConfig: HypervisorConfig{
+					BlockDeviceDriver: config.VirtioBlock,
+				},
+			},
+		},
+		mounts: []Mount{
+			{
+				Source:        mountSource,
+				Destination:   "/cache",
+				Type:          kataEphemeralDevType,
+				BlockDeviceID: id,
+			},
+		},
+	}
+
+	c.store, err = store.NewVCContainerStore(context.Background(), testSandboxID, c.id)
+	assert.NoError(err)
+
+	ociMounts := []specs.Mount{
+		{
+			Type:        kataEphemeralDevType,
+			Source:      mountSource,
+			Destination: "/cache",
+		},
+	}
+
+	epheStorages, err := k.handleEphemeralStorage(c, ociMounts, nil)
+	assert.NoError(err)
+	assert.Equal([]*pb.Storage{
+		{
+			Driver:     kataBlkDevType,
+			Source:     testPCIAddr,
+			Fstype:     emptyDirFstype,
+			MountPoint: filepath.Join(ephemeralPath, filepath.Base(mountSource)),
+		},
+	}, epheStorages)
+	assert.Equal([]ContainerDevice{{ID: id, ContainerPath: "/cache"}}, c.devices)
+
+	// block-backed emptyDir volumes are not bind mounted
+	assert.Empty(k.handleBlockVolumes(c))
+}
+
 func TestAppendDevicesEmptyContainerDeviceList(t *testing.T) {
 	k := kataAgent{}
 
@@ -485,7 +703,7 @@ func TestConstraintGRPCSpec(t *testing.T) {
 	assert.NotNil(g.Linux.Resources.Memory)
 	assert.Nil(g.Linux.Resources.Pids)
 	assert.Nil(g.Linux.Resources.BlockIO)
-	assert.Nil(g.Linux.Resources.HugepageLimits)
+	assert.NotNil(g.Linux.Resources.HugepageLimits)
 	assert.Nil(g.Linux.Resources.Network)
 	assert.NotNil(g.Linux.Resources.CPU)
 
@@ -536,6 +754,27 @@ func TestHandleShm(t *testing.T) {
 	assert.Equal(g.Mounts[0].Options, []string{"noexec", "nosuid", "nodev", "mode=1777", sizeOption})
 }
 
+func TestSandboxShmStorage(t *testing.T) {
+	assert := assert.New(t)
+
+	sandbox := &Sandbox{
+		shmSize: 8192,
+	}
+
+	storage := sandboxShmStorage(sandbox)
+	assert.Equal("tmpfs", storage.Fstype)
+	assert.Equal(filepath.Join(kataGuestSandboxDir, shmDir), storage.MountPoint)
+	assert.Contains(storage.Options, "size=8192")
+
+	sandbox.shmSize = 4 << 20
+	sandbox.shmPageSize = 2 << 20
+	storage = sandboxShmStorage(sandbox)
+	assert.Equal("hugetlbfs", storage.Fstype)
+	assert.Equal("nodev", storage.Source)
+	assert.Contains(storage.Options, "pagesize=2097152")
+	assert.Contains(storage.Options, "size=4194304")
+}
+
 func testIsPidNamespacePresent(grpcSpec *pb.Spec) bool {
 	for _, ns := range grpcSpec.Linux.Namespaces {
 		if ns.Type == string(specs.PIDNamespace) {
@@ -926,3 +1165,287 @@ func TestKataCopyFile(t *testing.T) {
 	err = k.copyFile(src.Name(), dst.Name())
 	assert.NoError(err)
 }
+
+func TestKataCopyContainerArchive(t *testing.T) {
+	assert := assert.New(t)
+
+	impl := &gRPCProxy{
+		execs: make(map[string]*gRPCProxyExec),
+	}
+
+	proxy := mock.ProxyGRPCMock{
+		GRPCImplementer: impl,
+		GRPCRegister:    gRPCRegister,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	assert.NoError(err)
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	err = proxy.Start(testKataProxyURL)
+	assert.NoError(err)
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		ctx: context.Background(),
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+	}
+
+	orgGrpcMaxDataSize := grpcMaxDataSize
+	grpcMaxDataSize = 4
+	defer func() {
+		grpcMaxDataSize = orgGrpcMaxDataSize
+	}()
+
+	c := &Container{id: testContainerID}
+	data := []byte("abcdefghi123456789")
+
+	// The archive is streamed by parts to tar
+	err = k.copyToContainer(c, "/tmp", bytes.NewReader(data))
+	assert.NoError(err)
+	assert.Len(impl.execs, 1)
+	for id, e := range impl.execs {
+		assert.Equal([]string{"tar", "-x", "-p", "-f", "-", "-C", "/tmp"}, e.args)
+		assert.Equal(data, e.stdin)
+		delete(impl.execs, id)
+	}
+
+	// and read by parts from tar
+	impl.execOutput = data
+
+	var out bytes.Buffer
+	err = k.copyFromContainer(c, "/tmp/foo", &out)
+	assert.NoError(err)
+	assert.Equal(data, out.Bytes())
+	assert.Len(impl.execs, 1)
+	for _, e := range impl.execs {
+		assert.Equal([]string{"tar", "-c", "-f", "-", "-C", "/tmp", "foo"}, e.args)
+	}
+
+	// The connection is released along with the streams
+	assert.Equal(int32(0), k.streams)
+	assert.Nil(k.client)
+
+	// tar failures are reported
+	impl.execStatus = 2
+	err = k.copyToContainer(c, "/tmp", bytes.NewReader(data))
+	assert.Error(err)
+}
+
+func TestKataPortForward(t *testing.T) {
+	assert := assert.New(t)
+
+	impl := &gRPCProxy{
+		execs:      make(map[string]*gRPCProxyExec),
+		execOutput: []byte("pong"),
+	}
+
+	proxy := mock.ProxyGRPCMock{
+		GRPCImplementer: impl,
+		GRPCRegister:    gRPCRegister,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	assert.NoError(err)
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	err = proxy.Start(testKataProxyURL)
+	assert.NoError(err)
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		ctx: context.Background(),
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+	}
+
+	c := &Container{
+		id: testContainerID,
+		state: types.State{
+			State: types.StateRunning,
+		},
+	}
+
+	s := &Sandbox{
+		agent: k,
+		config: &SandboxConfig{
+			Containers: []ContainerConfig{{ID: testContainerID}},
+		},
+		containers: map[string]*Container{testContainerID: c},
+		state: types.State{
+			State: types.StateRunning,
+		},
+	}
+
+	// Several streams are multiplexed over the agent connection
+	first, err := s.PortForward(8080)
+	assert.NoError(err)
+	second, err := s.PortForward(8080)
+	assert.NoError(err)
+	assert.NotNil(k.client)
+	assert.Len(impl.execs, 2)
+	for _, e := range impl.execs {
+		assert.Equal([]string{"socat", "-t", portForwardSocatTimeout, "STDIO", "TCP:localhost:8080"}, e.args)
+	}
+
+	_, err = first.Write([]byte("ping"))
+	assert.NoError(err)
+
+	buf := make([]byte, 16)
+	n, err := first.Read(buf)
+	assert.NoError(err)
+	assert.Equal("pong", string(buf[:n]))
+
+	// The end of the connection in the guest is reported
+	_, err = first.Read(buf)
+	assert.Equal(io.EOF, err)
+
+	// The stream can be half closed
+	halfCloser, ok := first.(interface{ CloseWrite() error })
+	assert.True(ok)
+	assert.NoError(halfCloser.CloseWrite())
+
+	assert.NoError(first.Close())
+	assert.Error(first.Close())
+	assert.NotNil(k.client)
+	assert.Equal(int32(1), k.streams)
+
+	// The connection is released along with the last stream
+	assert.NoError(second.Close())
+	assert.Nil(k.client)
+	assert.Equal(int32(0), k.streams)
+
+	var stdin [][]byte
+	for _, e := range impl.execs {
+		stdin = append(stdin, e.stdin)
+	}
+	assert.Contains(stdin, []byte("ping"))
+
+	_, err = s.PortForward(0)
+	assert.Error(err)
+
+	// The containers without socat are skipped
+	other := &Container{
+		id: "other",
+		state: types.State{
+			State: types.StateRunning,
+		},
+	}
+	s.config.Containers = append([]ContainerConfig{{ID: other.id}}, s.config.Containers...)
+	s.containers[other.id] = other
+	impl.execFailures = map[string]bool{other.id: true}
+
+	stream, err := s.PortForward(8080)
+	assert.NoError(err)
+	assert.NoError(stream.Close())
+
+	impl.execFailures[testContainerID] = true
+	_, err = s.PortForward(8080)
+	assert.Error(err)
+	assert.Contains(err.Error(), "socat")
+	assert.Contains(err.Error(), "container "+other.id)
+	assert.Contains(err.Error(), "container "+testContainerID)
+
+	delete(impl.execFailures, testContainerID)
+	other.state.State = types.StateStopped
+	c.state.State = types.StateStopped
+	_, err = s.PortForward(8080)
+	assert.Error(err)
+
+	s.state.State = types.StateStopped
+	_, err = s.PortForward(8080)
+	assert.Error(err)
+}
+
+func TestKataAgentTraceReqFunc(t *testing.T) {
+	assert := assert.New(t)
+
+	var md metadata.MD
+	handler := traceReqFunc(func(ctx context.Context, req interface{}, opts ...grpc.CallOption) (interface{}, error) {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil, nil
+	})
+
+	// No span, no span context sent
+	_, err := handler(context.Background(), nil)
+	assert.NoError(err)
+	assert.Empty(md)
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("foo", "bar"))
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	_, err = handler(ctx, nil)
+	assert.NoError(err)
+	assert.Equal([]string{"bar"}, md["foo"])
+	assert.Len(md[jaeger.TraceContextHeaderName], 1)
+	assert.True(strings.HasPrefix(md[jaeger.TraceContextHeaderName][0], span.Context().(jaeger.SpanContext).TraceID().String()))
+}
+
+func TestKernelModulesParams(t *testing.T) {
+	assert := assert.New(t)
+
+	params, err := kernelModulesParams(nil)
+	assert.NoError(err)
+	assert.Empty(params)
+
+	params, err = kernelModulesParams([]string{"e1000e", " ", "i915 enable_ppgtt=0  enable_guc=1", "e1000e EEE=1"})
+	assert.NoError(err)
+	assert.Equal([]Param{
+		{Key: "i915.enable_ppgtt", Value: "0"},
+		{Key: "i915.enable_guc", Value: "1"},
+		{Key: "e1000e.EEE", Value: "1"},
+		{Key: "modules-load", Value: "e1000e,i915"},
+	}, params)
+
+	for _, m := range []string{"../foo", "foo,bar", "init=/bin/sh"} {
+		_, err = kernelModulesParams([]string{m})
+		assert.Error(err, m)
+	}
+}
+
+func TestKataAgentCheckKernelModules(t *testing.T) {
+	assert := assert.New(t)
+
+	impl := &gRPCProxy{}
+
+	proxy := mock.ProxyGRPCMock{
+		GRPCImplementer: impl,
+		GRPCRegister:    gRPCRegister,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	assert.NoError(err)
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	err = proxy.Start(testKataProxyURL)
+	assert.NoError(err)
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		ctx: context.Background(),
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+	}
+
+	impl.initDaemon = true
+	assert.NoError(k.checkKernelModules())
+
+	k.kmodules = []string{"e1000e"}
+	assert.Error(k.checkKernelModules())
+
+	impl.initDaemon = false
+	assert.NoError(k.checkKernelModules())
+}
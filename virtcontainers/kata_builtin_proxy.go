@@ -57,7 +57,7 @@ func (p *kataBuiltInProxy) start(params proxyParams) (int, string, error) {
 	p.sandboxID = params.id
 
 	if params.debug {
-		err := p.watchConsole(buildinProxyConsoleProto, params.consoleURL, params.logger)
+		err := p.watchConsole(buildinProxyConsoleProto, params.consoleURL, params.logger, params.consoleHandler)
 		if err != nil {
 			p.sandboxID = ""
 			return -1, "", err
@@ -77,7 +77,7 @@ func (p *kataBuiltInProxy) stop(pid int) error {
 	return nil
 }
 
-func (p *kataBuiltInProxy) watchConsole(proto, console string, logger *logrus.Entry) (err error) {
+func (p *kataBuiltInProxy) watchConsole(proto, console string, logger *logrus.Entry, handler func(string)) (err error) {
 	var (
 		scanner *bufio.Scanner
 		conn    net.Conn
@@ -105,6 +105,10 @@ func (p *kataBuiltInProxy) watchConsole(proto, console string, logger *logrus.En
 				"sandbox":   p.sandboxID,
 				"vmconsole": scanner.Text(),
 			}).Debug("reading guest console")
+
+			if handler != nil {
+				handler(scanner.Text())
+			}
 		}
 
 		if err := scanner.Err(); err != nil {
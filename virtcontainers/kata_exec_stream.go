@@ -0,0 +1,258 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/kata-containers/agent/protocols/grpc"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/uuid"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	grpcStatus "google.golang.org/grpc/status"
+)
+
+// execStreamEnv is the environment of the processes started by the
+// runtime in the containers.
+var execStreamEnv = []types.EnvVar{
+	{
+		Var:   "PATH",
+		Value: "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+	},
+}
+
+// execStream is a process started by the runtime in a container, without
+// any shim: the runtime streams its stdio through the agent itself.
+// Reading from the stream reads the process stdout, writing to it writes
+// the process stdin, and closing it kills the process.
+type execStream struct {
+	k         *kataAgent
+	container *Container
+	execID    string
+
+	sync.Mutex
+	closed bool
+}
+
+// execStderr reads the stderr of an execStream process.
+type execStderr struct {
+	*execStream
+}
+
+// startExecStream starts args as root in the container, with the
+// capabilities of the container process. The agent connection is kept
+// until the process has been waited for.
+func (k *kataAgent) startExecStream(c *Container, args []string) (*execStream, error) {
+	cmd := types.Cmd{
+		Args:         args,
+		Envs:         execStreamEnv,
+		User:         "0",
+		PrimaryGroup: "0",
+		WorkDir:      "/",
+	}
+
+	if c.config != nil {
+		cmd.Capabilities = c.config.Cmd.Capabilities
+	}
+
+	kataProcess, err := cmdToKataProcess(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &grpc.ExecProcessRequest{
+		ContainerId: c.id,
+		ExecId:      uuid.Generate().String(),
+		Process:     kataProcess,
+	}
+
+	// Count the stream first, so that the connection is kept
+	// once the request has been sent.
+	atomic.AddInt32(&k.streams, 1)
+
+	if _, err := k.sendReq(req); err != nil {
+		k.releaseStream()
+		return nil, err
+	}
+
+	return &execStream{
+		k:         k,
+		container: c,
+		execID:    req.ExecId,
+	}, nil
+}
+
+// releaseStream releases the agent connection kept for a stream, once its
+// last request has been sent. The connection is closed along with the
+// last stream.
+func (k *kataAgent) releaseStream() {
+	if atomic.AddInt32(&k.streams, -1) == 0 && !k.keepConn {
+		k.disconnect()
+	}
+}
+
+func (s *execStream) read(data []byte, read func(*Container, string, []byte) (int, error)) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	if int64(len(data)) > grpcMaxDataSize {
+		data = data[:grpcMaxDataSize]
+	}
+
+	n, err := read(s.container, s.execID, data)
+
+	// The agent reports the end of the stream as an error.
+	if err != nil && grpcStatus.Convert(err).Message() == io.EOF.Error() {
+		return n, io.EOF
+	}
+
+	return n, err
+}
+
+// Read implements io.Reader. io.EOF is returned once the process closed
+// its stdout.
+func (s *execStream) Read(data []byte) (int, error) {
+	return s.read(data, s.k.readProcessStdout)
+}
+
+// Read implements io.Reader. io.EOF is returned once the process closed
+// its stderr.
+func (s *execStderr) Read(data []byte) (int, error) {
+	return s.read(data, s.k.readProcessStderr)
+}
+
+func (s *execStream) stderr() io.Reader {
+	return &execStderr{s}
+}
+
+// Write implements io.Writer.
+func (s *execStream) Write(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		chunk := data[written:]
+		if int64(len(chunk)) > grpcMaxDataSize {
+			chunk = chunk[:grpcMaxDataSize]
+		}
+
+		n, err := s.k.writeProcessStdin(s.container, s.execID, chunk)
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			return written, io.ErrShortWrite
+		}
+
+		written += n
+	}
+
+	return written, nil
+}
+
+// CloseWrite closes the stdin of the process, letting it know its input
+// is complete.
+func (s *execStream) CloseWrite() error {
+	return s.k.closeProcessStdin(s.container, s.execID)
+}
+
+// kill kills the process.
+func (s *execStream) kill() error {
+	return s.k.signalProcess(s.container, s.execID, syscall.SIGKILL, false)
+}
+
+// Wait waits for the process to exit, and releases the stream.
+func (s *execStream) Wait() (int32, error) {
+	defer s.k.releaseStream()
+
+	return s.k.waitProcess(s.container, s.execID)
+}
+
+// Close implements io.Closer. The process is killed, unless it has already
+// exited, and waited for.
+func (s *execStream) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return errors.New("stream closed")
+	}
+
+	s.closed = true
+
+	// The process might have exited along with the connection.
+	s.kill()
+
+	_, err := s.Wait()
+
+	return err
+}
+
+// runExecStream runs args in the container, streaming stdin, if any, to
+// the process and its stdout to stdout, if any. The process stdin is
+// closed once stdin is consumed. An error is returned if the process
+// fails, along with what it wrote to its stderr.
+func (k *kataAgent) runExecStream(c *Container, args []string, stdin io.Reader, stdout io.Writer) error {
+	s, err := k.startExecStream(c, args)
+	if err != nil {
+		return err
+	}
+
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+
+	var wg sync.WaitGroup
+	var stderr bytes.Buffer
+	var outErr error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(&stderr, s.stderr())
+	}()
+	go func() {
+		defer wg.Done()
+		if _, outErr = io.Copy(stdout, s); outErr != nil {
+			// The process would block writing its stdout.
+			if err := s.kill(); err != nil {
+				k.Logger().WithError(err).WithField("exec-id", s.execID).Warn("Could not kill process")
+			}
+		}
+	}()
+
+	var inErr error
+	if stdin != nil {
+		_, inErr = io.Copy(s, stdin)
+	}
+
+	if err := s.CloseWrite(); err != nil && inErr == nil {
+		inErr = err
+	}
+
+	wg.Wait()
+
+	status, err := s.Wait()
+	if err != nil {
+		return err
+	}
+
+	if outErr != nil {
+		return outErr
+	}
+
+	if status != 0 {
+		return fmt.Errorf("%s exited with status %d: %s", args[0], status, strings.TrimSpace(stderr.String()))
+	}
+
+	return inErr
+}
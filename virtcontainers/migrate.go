@@ -0,0 +1,575 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+)
+
+// A sandbox is live migrated over a single connection. The migrated sandbox
+// sends a migrationHeader carrying its store, and waits for the incoming
+// sandbox to reply once it has restored the sandbox and started a VM waiting
+// for the hypervisor migration stream. The VM is then migrated over the same
+// connection, and the incoming sandbox replies again once the VM runs and
+// the agent is reachable. Any reply carrying an error aborts the migration.
+
+// migrationVersion is the version of the live migration protocol.
+const migrationVersion = 1
+
+// migrationMaxFrameSize bounds the size of the messages read from the
+// migration connection.
+const migrationMaxFrameSize = 64 * 1024 * 1024
+
+// migrationFilePerms is the permission bits of the restored store files.
+const migrationFilePerms = 0640
+
+// migrationHeader is sent ahead of the hypervisor migration stream.
+type migrationHeader struct {
+	Version   int
+	SandboxID string
+
+	// The sandbox store files, by path relative to the sandbox
+	// configuration and runtime roots.
+	ConfigFiles  map[string][]byte
+	RuntimeFiles map[string][]byte
+}
+
+// migrationReply is sent by the incoming sandbox.
+type migrationReply struct {
+	Error string
+}
+
+// migrationHostConfig is the part of a sandbox configuration deciding what
+// the runtime runs and opens on the host. As it comes from another runtime
+// instance, a migrated sandbox configuration is only trusted when this part
+// matches the local configuration.
+type migrationHostConfig struct {
+	HypervisorType          HypervisorType
+	HypervisorPath          string
+	HypervisorParams        []Param
+	JailerPath              string
+	KernelPath              string
+	ImagePath               string
+	InitrdPath              string
+	FirmwarePath            string
+	EntropySource           string
+	SeccompSandbox          string
+	RunAsUnprivilegedUser   bool
+	EnableChroot            bool
+	UnprivilegedUserIDBase  uint32
+	UnprivilegedUserIDCount uint32
+	AssetTrustedKeys        []string
+	AgentType               AgentType
+	ProxyType               ProxyType
+	ProxyPath               string
+	NetmonPath              string
+}
+
+func newMigrationHostConfig(config *SandboxConfig) migrationHostConfig {
+	hconfig := migrationHostConfig{
+		HypervisorType:          config.HypervisorType,
+		HypervisorPath:          config.HypervisorConfig.HypervisorPath,
+		JailerPath:              config.HypervisorConfig.JailerPath,
+		KernelPath:              config.HypervisorConfig.KernelPath,
+		ImagePath:               config.HypervisorConfig.ImagePath,
+		InitrdPath:              config.HypervisorConfig.InitrdPath,
+		FirmwarePath:            config.HypervisorConfig.FirmwarePath,
+		EntropySource:           config.HypervisorConfig.EntropySource,
+		SeccompSandbox:          config.HypervisorConfig.SeccompSandbox,
+		RunAsUnprivilegedUser:   config.HypervisorConfig.RunAsUnprivilegedUser,
+		EnableChroot:            config.HypervisorConfig.EnableChroot,
+		UnprivilegedUserIDBase:  config.HypervisorConfig.UnprivilegedUserIDBase,
+		UnprivilegedUserIDCount: config.HypervisorConfig.UnprivilegedUserIDCount,
+		AgentType:               config.AgentType,
+		ProxyType:               config.ProxyType,
+		ProxyPath:               config.ProxyConfig.Path,
+		NetmonPath:              config.NetworkConfig.NetmonConfig.Path,
+	}
+
+	// Empty and missing lists are the same once stored.
+	if len(config.HypervisorConfig.HypervisorParams) > 0 {
+		hconfig.HypervisorParams = config.HypervisorConfig.HypervisorParams
+	}
+	if len(config.HypervisorConfig.AssetTrustedKeys) > 0 {
+		hconfig.AssetTrustedKeys = config.HypervisorConfig.AssetTrustedKeys
+	}
+
+	return hconfig
+}
+
+// checkMigrationConfig checks the host part of a migrated sandbox
+// configuration matches the local one.
+func checkMigrationConfig(local, migrated *SandboxConfig) error {
+	l := reflect.ValueOf(newMigrationHostConfig(local))
+	m := reflect.ValueOf(newMigrationHostConfig(migrated))
+
+	for i := 0; i < l.NumField(); i++ {
+		if !reflect.DeepEqual(l.Field(i).Interface(), m.Field(i).Interface()) {
+			return fmt.Errorf("Migrated sandbox %s %v does not match the local %v",
+				l.Type().Field(i).Name, m.Field(i).Interface(), l.Field(i).Interface())
+		}
+	}
+
+	return nil
+}
+
+// writeMigrationFrame sends a JSON message prefixed by its length, so that
+// reading it does not consume the hypervisor migration stream following it.
+func writeMigrationFrame(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+func readMigrationFrame(r io.Reader, v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+
+	n := binary.BigEndian.Uint32(size[:])
+	if n > migrationMaxFrameSize {
+		return fmt.Errorf("Migration message too large: %d bytes", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// readMigrationReply returns the error reported by the incoming sandbox
+// through replyErr, and the failures to read its reply through err.
+func readMigrationReply(r io.Reader) (replyErr error, err error) {
+	var reply migrationReply
+	if err := readMigrationFrame(r, &reply); err != nil {
+		return nil, err
+	}
+
+	if reply.Error != "" {
+		return errors.New(reply.Error), nil
+	}
+
+	return nil, nil
+}
+
+func writeMigrationReply(w io.Writer, replyErr error) error {
+	var reply migrationReply
+	if replyErr != nil {
+		reply.Error = replyErr.Error()
+	}
+
+	return writeMigrationFrame(w, reply)
+}
+
+// migrationFiles reads the files of a sandbox store root, but the agent
+// state which belongs to the local runtime instance.
+func migrationFiles(root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() || info.Name() == store.AgentFile {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files[rel] = data
+		return nil
+	})
+
+	return files, err
+}
+
+// restoreMigrationFiles writes the files of a migrated sandbox store root.
+func restoreMigrationFiles(root string, files map[string][]byte) error {
+	for rel, data := range files {
+		path := filepath.Join(root, rel)
+		if filepath.IsAbs(rel) || !strings.HasPrefix(path, filepath.Clean(root)+string(filepath.Separator)) {
+			return fmt.Errorf("Invalid sandbox store file %q", rel)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), store.DirMode); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(path, data, migrationFilePerms); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MigrateSandbox is the virtcontainers live migration entry point.
+// MigrateSandbox migrates a running sandbox through conn, to the runtime
+// instance receiving it with IncomingSandbox on the other end of the
+// connection. The sandbox is released from this runtime instance once
+// migrated, and keeps running here if the migration fails.
+func MigrateSandbox(ctx context.Context, sandboxID string, conn *os.File) error {
+	span, ctx := trace(ctx, "MigrateSandbox")
+	defer span.Finish()
+
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	lockFile, err := rwLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err := fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return err
+	}
+	defer s.releaseStatelessSandbox()
+
+	return s.migrate(conn)
+}
+
+func (s *Sandbox) migrate(conn *os.File) error {
+	span, _ := s.trace("migrate")
+	defer span.Finish()
+
+	if s.state.State != types.StateRunning {
+		return fmt.Errorf("Sandbox not running, impossible to migrate")
+	}
+
+	for _, d := range s.devManager.GetAllDevices() {
+		if d.GetAttachCount() > 0 {
+			return fmt.Errorf("Cannot migrate a sandbox with attached device %s", d.DeviceID())
+		}
+	}
+
+	header := migrationHeader{
+		Version:   migrationVersion,
+		SandboxID: s.id,
+	}
+
+	var err error
+	if header.ConfigFiles, err = migrationFiles(store.SandboxConfigurationRootPath(s.id)); err != nil {
+		return err
+	}
+
+	if header.RuntimeFiles, err = migrationFiles(store.SandboxRuntimeRootPath(s.id)); err != nil {
+		return err
+	}
+
+	if err := writeMigrationFrame(conn, header); err != nil {
+		return err
+	}
+
+	replyErr, err := readMigrationReply(conn)
+	if err != nil {
+		return fmt.Errorf("Could not reach the incoming sandbox: %v", err)
+	}
+	if replyErr != nil {
+		return fmt.Errorf("Incoming sandbox failed: %v", replyErr)
+	}
+
+	s.Logger().Info("Migrating VM")
+
+	// The VM keeps running when the migration fails.
+	if err := s.hypervisor.migrateSandbox(conn); err != nil {
+		return err
+	}
+
+	// The migrated VM is paused, and only resumed when the incoming
+	// sandbox reports it could not run it. Both VMs could run otherwise.
+	replyErr, err = readMigrationReply(conn)
+	if err != nil {
+		return fmt.Errorf("Lost the incoming sandbox, leaving the migrated VM paused: %v", err)
+	}
+	if replyErr != nil {
+		if err := s.hypervisor.resumeSandbox(); err != nil {
+			s.Logger().WithError(err).Error("Could not resume the VM")
+		}
+		return fmt.Errorf("Incoming sandbox failed: %v", replyErr)
+	}
+
+	s.Logger().Info("VM migrated")
+
+	return s.releaseMigrated()
+}
+
+// releaseMigrated releases a sandbox migrated to another runtime instance.
+// The network namespace, the shared directory and the cgroups are left in
+// place, as they are in use by the incoming sandbox when it runs on the same
+// host.
+func (s *Sandbox) releaseMigrated() error {
+	globalSandboxList.removeSandbox(s.id)
+
+	if s.monitor != nil {
+		s.monitor.stop()
+	}
+
+	if s.config.NetworkConfig.NetmonConfig.Enable {
+		if err := stopNetmon(s.networkNS.NetmonPID); err != nil {
+			s.Logger().WithError(err).Warn("Could not stop the network monitor")
+		}
+	}
+
+	if err := s.agent.disconnect(); err != nil {
+		s.Logger().WithError(err).Warn("Could not disconnect from the agent")
+	}
+
+	if err := s.hypervisor.stopSandbox(); err != nil {
+		return err
+	}
+
+	return s.store.Delete()
+}
+
+// IncomingSandbox is the virtcontainers live migration receiving entry
+// point. IncomingSandbox receives through conn a sandbox migrated by
+// MigrateSandbox from another runtime instance, and returns it once its VM
+// runs. The hypervisor, agent, proxy and network monitor configuration of
+// the sandbox has to match config, the local configuration.
+//
+// The network namespace of the sandbox has to exist. The network plumbing
+// connecting it to the VM is recreated, or shared with the migrated VM when
+// it runs on the same host.
+func IncomingSandbox(ctx context.Context, conn *os.File, config SandboxConfig) (VCSandbox, error) {
+	span, ctx := trace(ctx, "IncomingSandbox")
+	defer span.Finish()
+
+	var header migrationHeader
+	if err := readMigrationFrame(conn, &header); err != nil {
+		return nil, err
+	}
+
+	s, err := incomingSandbox(ctx, conn, header, &config)
+
+	if err := writeMigrationReply(conn, err); err != nil {
+		virtLog.WithError(err).Warn("Could not reply to the migrated sandbox")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func incomingSandbox(ctx context.Context, conn *os.File, header migrationHeader, config *SandboxConfig) (s *Sandbox, err error) {
+	if header.Version != migrationVersion {
+		return nil, fmt.Errorf("Unsupported migration protocol version %d", header.Version)
+	}
+
+	sandboxID := header.SandboxID
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	if store.VCSandboxStoreExists(ctx, sandboxID) {
+		return nil, fmt.Errorf("Sandbox %s already exists", sandboxID)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if s != nil {
+			globalSandboxList.removeSandbox(sandboxID)
+		}
+
+		if vcStore, err := store.NewVCSandboxStore(ctx, sandboxID); err == nil {
+			vcStore.Delete()
+		}
+	}()
+
+	if err := restoreMigrationFiles(store.SandboxConfigurationRootPath(sandboxID), header.ConfigFiles); err != nil {
+		return nil, err
+	}
+
+	if err := restoreMigrationFiles(store.SandboxRuntimeRootPath(sandboxID), header.RuntimeFiles); err != nil {
+		return nil, err
+	}
+
+	// Nothing is started from the migrated configuration until checked.
+	vcStore, err := store.NewVCSandboxStore(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrated SandboxConfig
+	if err := vcStore.Load(store.Configuration, &migrated); err != nil {
+		return nil, err
+	}
+
+	if err := checkMigrationConfig(config, &migrated); err != nil {
+		return nil, err
+	}
+
+	lockFile, err := rwLockSandbox(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockSandbox(ctx, sandboxID, lockFile)
+
+	s, err = fetchSandbox(ctx, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseStatelessSandbox()
+
+	if s.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Sandbox not running, impossible to receive")
+	}
+
+	if err := s.incoming(conn, func() error {
+		return writeMigrationReply(conn, nil)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// incoming recreates the host resources of a sandbox migrated from another
+// runtime instance, and starts the VM receiving the migrated VM. ready is
+// called once this VM waits for the migration stream.
+func (s *Sandbox) incoming(conn *os.File, ready func() error) (err error) {
+	span, _ := s.trace("incoming")
+	defer span.Finish()
+
+	// The shared directory is only left in place by a sandbox migrated
+	// from the same host.
+	sharePath := s.agent.getSharePath(s.id)
+	restoreMounts := false
+	if caps := s.hypervisor.capabilities(); sharePath != "" && caps.IsFsSharingSupported() {
+		_, err := os.Stat(sharePath)
+		restoreMounts = os.IsNotExist(err)
+	}
+
+	// Cold plug the agent devices, as when the sandbox was created.
+	if err := s.agent.createSandbox(s); err != nil {
+		return err
+	}
+
+	if restoreMounts {
+		if err := s.restoreSharedMounts(filepath.Dir(sharePath)); err != nil {
+			return err
+		}
+	}
+
+	started := false
+	defer func() {
+		if err != nil && started {
+			if err := s.hypervisor.stopSandbox(); err != nil {
+				s.Logger().WithError(err).Warn("Could not stop the VM")
+			}
+		}
+	}()
+
+	if err := s.network.Run(s.networkNS.NetNsPath, func() error {
+		for _, endpoint := range s.networkNS.Endpoints {
+			if err := reattachEndpoint(endpoint, s.hypervisor); err != nil {
+				return err
+			}
+		}
+
+		return s.hypervisor.incomingSandbox(conn, vmStartTimeout, func() error {
+			started = true
+			return ready()
+		})
+	}); err != nil {
+		return err
+	}
+
+	s.Logger().Info("VM received")
+
+	if err := s.agent.startProxy(s); err != nil {
+		return err
+	}
+
+	if err := s.agent.check(); err != nil {
+		return err
+	}
+
+	// The cgroup of the sandbox only exists when it was migrated from
+	// the same host, or created by the container manager beforehand.
+	if err := s.updateCgroups(); err != nil {
+		s.Logger().WithError(err).Warn("Could not constrain the VM")
+	} else if err := s.pinVCPUs(); err != nil {
+		s.Logger().WithError(err).Warn("Could not pin the VM vCPUs")
+	}
+
+	if s.config.NetworkConfig.NetmonConfig.Enable {
+		if err := s.startNetworkMonitor(); err != nil {
+			return err
+		}
+
+		if err := s.store.Store(store.Network, s.networkNS); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreSharedMounts bind mounts the root filesystems and the volumes of
+// the containers in the shared directory, as the containers creation did.
+func (s *Sandbox) restoreSharedMounts(sharedDir string) error {
+	sharePath := filepath.Join(sharedDir, s.id)
+
+	for _, c := range s.containers {
+		if c.state.Fstype == "" {
+			if err := bindMountContainerRootfs(c.ctx, sharedDir, s.id, c.id, c.rootFs, false); err != nil {
+				return err
+			}
+		}
+
+		for _, m := range c.mounts {
+			if !strings.HasPrefix(m.HostPath, sharePath+string(filepath.Separator)) {
+				continue
+			}
+
+			if err := bindMount(c.ctx, m.Source, m.HostPath, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/kata-containers/runtime/virtcontainers/store"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMigrationConns(t *testing.T) (*os.File, *os.File) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	assert.NoError(t, err)
+
+	return os.NewFile(uintptr(fds[0]), "migrate"), os.NewFile(uintptr(fds[1]), "incoming")
+}
+
+func TestMigrationFrame(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	header := migrationHeader{
+		Version:      migrationVersion,
+		SandboxID:    testSandboxID,
+		ConfigFiles:  map[string][]byte{store.ConfigurationFile: []byte("{}")},
+		RuntimeFiles: map[string][]byte{},
+	}
+	assert.NoError(writeMigrationFrame(&buf, header))
+
+	// The stream following the frame is left unread.
+	buf.WriteString("stream")
+
+	var received migrationHeader
+	assert.NoError(readMigrationFrame(&buf, &received))
+	assert.Equal(header, received)
+	assert.Equal("stream", buf.String())
+	buf.Reset()
+
+	assert.NoError(writeMigrationReply(&buf, os.ErrNotExist))
+	assert.NoError(writeMigrationReply(&buf, nil))
+
+	replyErr, err := readMigrationReply(&buf)
+	assert.NoError(err)
+	assert.EqualError(replyErr, os.ErrNotExist.Error())
+
+	replyErr, err = readMigrationReply(&buf)
+	assert.NoError(err)
+	assert.NoError(replyErr)
+
+	_, err = readMigrationReply(&buf)
+	assert.Error(err)
+
+	binary.Write(&buf, binary.BigEndian, uint32(migrationMaxFrameSize+1))
+	assert.Error(readMigrationFrame(&buf, &received))
+}
+
+func TestMigrationFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "migrate-")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "source")
+	files := map[string]string{
+		store.StateFile: "state",
+		store.LockFile:  "",
+		store.AgentFile: "agent",
+		filepath.Join(containerID, store.StateFile): "container state",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(os.MkdirAll(filepath.Dir(path), store.DirMode))
+		assert.NoError(ioutil.WriteFile(path, []byte(data), 0640))
+	}
+
+	migrated, err := migrationFiles(root)
+	assert.NoError(err)
+	assert.Equal(map[string][]byte{
+		store.StateFile: []byte("state"),
+		store.LockFile:  []byte(""),
+		filepath.Join(containerID, store.StateFile): []byte("container state"),
+	}, migrated)
+
+	root = filepath.Join(dir, "target")
+	assert.NoError(restoreMigrationFiles(root, migrated))
+
+	restored, err := migrationFiles(root)
+	assert.NoError(err)
+	assert.Equal(migrated, restored)
+
+	for _, name := range []string{"../escaped", "/escaped", ".."} {
+		assert.Error(restoreMigrationFiles(root, map[string][]byte{name: nil}), name)
+	}
+	_, err = os.Stat(filepath.Join(dir, "escaped"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestCheckMigrationConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	local := newTestSandboxConfigNoop()
+	migrated := newTestSandboxConfigNoop()
+	assert.NoError(checkMigrationConfig(&local, &migrated))
+
+	// Only the host configuration has to match.
+	migrated.HypervisorConfig.KernelParams = append(migrated.HypervisorConfig.KernelParams, Param{"foo", "bar"})
+	migrated.HypervisorConfig.HypervisorParams = []Param{}
+	assert.NoError(checkMigrationConfig(&local, &migrated))
+
+	migrated.HypervisorConfig.HypervisorPath = "/tmp/hypervisor"
+	assert.Error(checkMigrationConfig(&local, &migrated))
+
+	migrated = newTestSandboxConfigNoop()
+	migrated.HypervisorConfig.HypervisorParams = []Param{{"-chardev", "file,path=/etc/shadow"}}
+	assert.Error(checkMigrationConfig(&local, &migrated))
+
+	migrated = newTestSandboxConfigNoop()
+	migrated.NetworkConfig.NetmonConfig.Path = "/tmp/netmon"
+	assert.Error(checkMigrationConfig(&local, &migrated))
+}
+
+func TestMigrateSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanUp()
+	defer cleanUp()
+
+	ctx := context.Background()
+
+	p, _, err := createAndStartSandbox(ctx, newTestSandboxConfigNoop())
+	assert.NoError(err)
+
+	migrateConn, incomingConn := testMigrationConns(t)
+	defer migrateConn.Close()
+	defer incomingConn.Close()
+
+	// A sandbox is only migrated once the incoming sandbox is ready.
+	go func() {
+		var header migrationHeader
+		readMigrationFrame(incomingConn, &header)
+		writeMigrationReply(incomingConn, os.ErrExist)
+	}()
+
+	err = MigrateSandbox(ctx, p.ID(), migrateConn)
+	assert.Error(err)
+	assert.True(store.VCSandboxStoreExists(ctx, p.ID()))
+
+	headerCh := make(chan migrationHeader, 1)
+	go func() {
+		var header migrationHeader
+		readMigrationFrame(incomingConn, &header)
+		headerCh <- header
+
+		writeMigrationReply(incomingConn, nil)
+		writeMigrationReply(incomingConn, nil)
+	}()
+
+	assert.NoError(MigrateSandbox(ctx, p.ID(), migrateConn))
+	assert.False(store.VCSandboxStoreExists(ctx, p.ID()))
+
+	header := <-headerCh
+	assert.Equal(p.ID(), header.SandboxID)
+	assert.Contains(header.ConfigFiles, store.ConfigurationFile)
+	assert.Contains(header.RuntimeFiles, store.StateFile)
+	assert.NotContains(header.RuntimeFiles, store.AgentFile)
+
+	replies := make(chan error, 2)
+
+	// The sandbox is only received by a runtime instance configured alike.
+	go func() {
+		writeMigrationFrame(migrateConn, header)
+
+		replyErr, err := readMigrationReply(migrateConn)
+		if err == nil {
+			err = replyErr
+		}
+		replies <- err
+	}()
+
+	local := newTestSandboxConfigNoop()
+	local.HypervisorConfig.HypervisorPath = "/tmp/hypervisor"
+	_, err = IncomingSandbox(ctx, incomingConn, local)
+	assert.Error(err)
+	assert.Error(<-replies)
+	assert.False(store.VCSandboxStoreExists(ctx, p.ID()))
+
+	go func() {
+		writeMigrationFrame(migrateConn, header)
+
+		for i := 0; i < 2; i++ {
+			replyErr, err := readMigrationReply(migrateConn)
+			if err != nil {
+				replyErr = err
+			}
+			replies <- replyErr
+		}
+	}()
+
+	s, err := IncomingSandbox(ctx, incomingConn, newTestSandboxConfigNoop())
+	assert.NoError(err)
+	assert.NoError(<-replies)
+	assert.NoError(<-replies)
+	assert.Equal(p.ID(), s.ID())
+	assert.Equal(types.StateRunning, s.Status().State.State)
+	assert.True(store.VCSandboxStoreExists(ctx, p.ID()))
+
+	// The sandbox cannot be received twice.
+	go func() {
+		writeMigrationFrame(migrateConn, header)
+
+		replyErr, err := readMigrationReply(migrateConn)
+		if err == nil {
+			err = replyErr
+		}
+		replies <- err
+	}()
+
+	_, err = IncomingSandbox(ctx, incomingConn, newTestSandboxConfigNoop())
+	assert.Error(err)
+	assert.Error(<-replies)
+	assert.True(store.VCSandboxStoreExists(ctx, p.ID()))
+}
@@ -54,6 +54,14 @@ func (m *mockHypervisor) saveSandbox() error {
 	return nil
 }
 
+func (m *mockHypervisor) migrateSandbox(conn *os.File) error {
+	return nil
+}
+
+func (m *mockHypervisor) incomingSandbox(conn *os.File, timeout int, ready func() error) error {
+	return ready()
+}
+
 func (m *mockHypervisor) addDevice(devInfo interface{}, devType deviceType) error {
 	return nil
 }
@@ -16,6 +16,7 @@ import (
 	"runtime"
 	"sort"
 	"time"
+	"unsafe"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -166,6 +167,12 @@ type NetworkInterfacePair struct {
 	TapInterface
 	VirtIface NetworkInterface
 	NetInterworkingModel
+
+	// IngressBandwidth and EgressBandwidth are the rates, in bits per
+	// second, the traffic respectively entering and leaving the VM
+	// through this pair is limited to. Zero means unlimited.
+	IngressBandwidth uint64
+	EgressBandwidth  uint64
 }
 
 // NetworkConfig is the network configuration related to a network.
@@ -175,6 +182,11 @@ type NetworkConfig struct {
 	DisableNewNetNs   bool
 	NetmonConfig      NetmonConfig
 	InterworkingModel NetInterworkingModel
+
+	// IngressBandwidth and EgressBandwidth limit, in bits per second,
+	// the traffic entering and leaving the sandbox. Zero means unlimited.
+	IngressBandwidth uint64
+	EgressBandwidth  uint64
 }
 
 func networkLogger() *logrus.Entry {
@@ -472,6 +484,85 @@ func xDisconnectVMNetwork(endpoint Endpoint) error {
 	}
 }
 
+// reattachEndpoint attaches an endpoint to the hypervisor receiving a live
+// migrated VM. When the migrated VM runs on the same host, its network pair
+// is shared with the incoming VM by opening new queues of its TAP interface.
+// The endpoint is attached from scratch otherwise.
+func reattachEndpoint(endpoint Endpoint, h hypervisor) error {
+	switch endpoint.Type() {
+	case VethEndpointType, BridgedMacvlanEndpointType, IPVlanEndpointType:
+	default:
+		return endpoint.Attach(h)
+	}
+
+	netPair := endpoint.NetworkPair()
+
+	tapLink, err := netlink.LinkByName(netPair.TAPIface.Name)
+	if _, ok := err.(netlink.LinkNotFoundError); ok {
+		return endpoint.Attach(h)
+	}
+	if err != nil {
+		return err
+	}
+
+	queues := 0
+	caps := h.capabilities()
+	if caps.IsMultiQueueSupported() {
+		queues = int(h.hypervisorConfig().NumVCPUs)
+	}
+
+	if _, ok := tapLink.(*netlink.Macvtap); ok {
+		netPair.VMFds, err = createMacvtapFds(tapLink.Attrs().Index, queues)
+	} else {
+		netPair.VMFds, err = openTapFds(netPair.TAPIface.Name, queues)
+	}
+	if err != nil {
+		return fmt.Errorf("Could not open TAP interface %s: %s", netPair.TAPIface.Name, err)
+	}
+
+	if !h.hypervisorConfig().DisableVhostNet {
+		vhostFds, err := createVhostFds(queues)
+		if err != nil {
+			return fmt.Errorf("Could not setup vhost fds %s : %s", netPair.VirtIface.Name, err)
+		}
+		netPair.VhostFds = vhostFds
+	}
+
+	return h.addDevice(endpoint, netDev)
+}
+
+// openTapFds opens new queues of an existing TAP interface, created as
+// createLink does.
+func openTapFds(name string, queues int) ([]*os.File, error) {
+	flags := uint16(unix.IFF_TAP | unix.IFF_NO_PI | unix.IFF_VNET_HDR)
+	if queues > 0 {
+		flags |= unix.IFF_MULTI_QUEUE
+	} else {
+		queues = 1
+	}
+
+	fds, err := createFds("/dev/net/tun", queues)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fds {
+		req := struct {
+			name  [unix.IFNAMSIZ]byte
+			flags uint16
+			_     [22]byte
+		}{flags: flags}
+		copy(req.name[:unix.IFNAMSIZ-1], name)
+
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), uintptr(unix.TUNSETIFF), uintptr(unsafe.Pointer(&req))); errno != 0 {
+			utils.CleanupFds(fds, len(fds))
+			return nil, errno
+		}
+	}
+
+	return fds, nil
+}
+
 func createMacvtapFds(linkIndex int, queues int) ([]*os.File, error) {
 	tapDev := fmt.Sprintf("/dev/tap%d", linkIndex)
 	return createFds(tapDev, queues)
@@ -642,7 +733,9 @@ func tapNetworkPair(endpoint Endpoint, queues int, disableVhostNet bool) error {
 		netPair.VhostFds = vhostFds
 	}
 
-	return nil
+	// The traffic received by the macvtap interface is handed over to
+	// the VM without going through any queue that could be shaped.
+	return setupBandwidthLimits(netPair, link, nil)
 }
 
 func bridgeNetworkPair(endpoint Endpoint, queues int, disableVhostNet bool) error {
@@ -726,7 +819,7 @@ func bridgeNetworkPair(endpoint Endpoint, queues int, disableVhostNet bool) erro
 		return fmt.Errorf("Could not enable bridge %s: %s", netPair.Name, err)
 	}
 
-	return nil
+	return setupBandwidthLimits(netPair, link, tapLink)
 }
 
 func setupTCFiltering(endpoint Endpoint, queues int, disableVhostNet bool) error {
@@ -795,6 +888,65 @@ func setupTCFiltering(endpoint Endpoint, queues int, disableVhostNet bool) error
 		return err
 	}
 
+	return setupBandwidthLimits(netPair, link, tapLink)
+}
+
+// setupBandwidthLimits shapes the traffic going through a network pair
+// according to its bandwidth limits. The traffic sent to the VM leaves
+// the host through the TAP interface, which is why the ingress limit is
+// applied to the TAP egress queue. The traffic sent by the VM is always
+// transmitted through the container interface in the end, which is why
+// the egress limit is applied to the egress queue of this interface.
+func setupBandwidthLimits(netPair *NetworkInterfacePair, link, tapLink netlink.Link) error {
+	if netPair.IngressBandwidth > 0 {
+		if tapLink == nil {
+			networkLogger().WithField("interface", netPair.VirtIface.Name).
+				Warn("Ingress bandwidth limit not supported for this network model, ignoring")
+		} else if err := addHTBRateLimiter(tapLink.Attrs().Index, netPair.IngressBandwidth); err != nil {
+			return err
+		}
+	}
+
+	if netPair.EgressBandwidth > 0 {
+		if err := addHTBRateLimiter(link.Attrs().Index, netPair.EgressBandwidth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addHTBRateLimiter limits the rate of the traffic sent through the network
+// interface with the specified network index, to "rate" bits per second.
+//
+// This is equivalent to calling:
+// `tc qdisc add dev eth0 root handle 1: htb default 1`
+// `tc class add dev eth0 parent 1: classid 1:1 htb rate <rate> ceil <rate>`
+func addHTBRateLimiter(index int, rate uint64) error {
+	qdisc := netlink.NewHtb(netlink.QdiscAttrs{
+		LinkIndex: index,
+		Handle:    netlink.MakeHandle(1, 0),
+		Parent:    netlink.HANDLE_ROOT,
+	})
+	qdisc.Defcls = 1
+
+	if err := netlink.QdiscAdd(qdisc); err != nil {
+		return fmt.Errorf("Failed to add htb qdisc for network index %d : %s", index, err)
+	}
+
+	class := netlink.NewHtbClass(netlink.ClassAttrs{
+		LinkIndex: index,
+		Parent:    netlink.MakeHandle(1, 0),
+		Handle:    netlink.MakeHandle(1, 1),
+	}, netlink.HtbClassAttrs{
+		Rate: rate,
+		Ceil: rate,
+	})
+
+	if err := netlink.ClassAdd(class); err != nil {
+		return fmt.Errorf("Failed to add htb class for network index %d : %s", index, err)
+	}
+
 	return nil
 }
 
@@ -901,6 +1053,42 @@ func removeQdiscIngress(link netlink.Link) error {
 	return nil
 }
 
+// removeHTBRateLimiter removes the htb qdisc previously created on "link",
+// along with its classes.
+func removeHTBRateLimiter(link netlink.Link) error {
+	if link == nil {
+		return nil
+	}
+
+	qdiscs, err := netlink.QdiscList(link)
+	if err != nil {
+		return err
+	}
+
+	for _, qdisc := range qdiscs {
+		htb, ok := qdisc.(*netlink.Htb)
+		if !ok || htb.Parent != netlink.HANDLE_ROOT {
+			continue
+		}
+
+		if err := netlink.QdiscDel(htb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeBandwidthLimits removes the shaping set up by setupBandwidthLimits
+// on the container interface. The TAP interface does not need any cleanup
+// since its queues go away with it.
+func removeBandwidthLimits(netPair *NetworkInterfacePair, link netlink.Link) error {
+	if netPair.EgressBandwidth == 0 {
+		return nil
+	}
+
+	return removeHTBRateLimiter(link)
+}
+
 func untapNetworkPair(endpoint Endpoint) error {
 	netHandle, err := netlink.NewHandle()
 	if err != nil {
@@ -924,6 +1112,10 @@ func untapNetworkPair(endpoint Endpoint) error {
 		return err
 	}
 
+	if err := removeBandwidthLimits(netPair, link); err != nil {
+		return err
+	}
+
 	hardAddr, err := net.ParseMAC(netPair.TAPIface.HardAddr)
 	if err != nil {
 		return err
@@ -986,6 +1178,10 @@ func unBridgeNetworkPair(endpoint Endpoint) error {
 		return err
 	}
 
+	if err := removeBandwidthLimits(netPair, link); err != nil {
+		return err
+	}
+
 	hardAddr, err := net.ParseMAC(netPair.TAPIface.HardAddr)
 	if err != nil {
 		return err
@@ -1041,6 +1237,10 @@ func removeTCFiltering(endpoint Endpoint) error {
 		return err
 	}
 
+	if err := removeBandwidthLimits(netPair, link); err != nil {
+		return err
+	}
+
 	if err := netHandle.LinkSetDown(link); err != nil {
 		return fmt.Errorf("Could not disable veth %s: %s", netPair.VirtIface.Name, err)
 	}
@@ -1315,6 +1515,7 @@ func createEndpointsFromScan(networkNSPath string, config *NetworkConfig) ([]End
 		}
 
 		endpoint.SetProperties(netInfo)
+		setEndpointBandwidth(endpoint, config)
 		endpoints = append(endpoints, endpoint)
 
 		idx++
@@ -1329,6 +1530,22 @@ func createEndpointsFromScan(networkNSPath string, config *NetworkConfig) ([]End
 	return endpoints, nil
 }
 
+// setEndpointBandwidth propagates the sandbox bandwidth limits to the
+// network pair of the endpoint, if any.
+func setEndpointBandwidth(endpoint Endpoint, config *NetworkConfig) {
+	netPair := endpoint.NetworkPair()
+	if netPair == nil {
+		if config.IngressBandwidth > 0 || config.EgressBandwidth > 0 {
+			networkLogger().WithField("endpoint-type", endpoint.Type()).
+				Warn("Bandwidth limits not supported for this endpoint, ignoring")
+		}
+		return
+	}
+
+	netPair.IngressBandwidth = config.IngressBandwidth
+	netPair.EgressBandwidth = config.EgressBandwidth
+}
+
 func createEndpoint(netInfo NetworkInfo, idx int, model NetInterworkingModel) (Endpoint, error) {
 	var endpoint Endpoint
 	// TODO: This is the incoming interface
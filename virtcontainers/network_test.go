@@ -304,3 +304,80 @@ func TestTcRedirectNetwork(t *testing.T) {
 	err = netHandle.LinkDel(link)
 	assert.NoError(err)
 }
+
+func TestSetEndpointBandwidth(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &NetworkConfig{
+		IngressBandwidth: 10000000,
+		EgressBandwidth:  20000000,
+	}
+
+	endpoint, err := createVethNetworkEndpoint(1, "eth0", NetXConnectTCFilterModel)
+	assert.NoError(err)
+
+	setEndpointBandwidth(endpoint, config)
+	assert.Equal(config.IngressBandwidth, endpoint.NetworkPair().IngressBandwidth)
+	assert.Equal(config.EgressBandwidth, endpoint.NetworkPair().EgressBandwidth)
+
+	// Endpoints without network pair are left untouched.
+	setEndpointBandwidth(&PhysicalEndpoint{}, config)
+}
+
+func TestTcRedirectNetworkBandwidth(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	netHandle, err := netlink.NewHandle()
+	assert.NoError(err)
+	defer netHandle.Delete()
+
+	// Create a test veth interface.
+	vethName := "foo"
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: vethName, TxQLen: 200, MTU: 1400}, PeerName: "bar"}
+
+	err = netlink.LinkAdd(veth)
+	assert.NoError(err)
+
+	endpoint, err := createVethNetworkEndpoint(1, vethName, NetXConnectTCFilterModel)
+	assert.NoError(err)
+
+	endpoint.NetPair.IngressBandwidth = 10000000
+	endpoint.NetPair.EgressBandwidth = 20000000
+
+	link, err := netlink.LinkByName(vethName)
+	assert.NoError(err)
+
+	err = netHandle.LinkSetUp(link)
+	assert.NoError(err)
+
+	hasHTB := func(l netlink.Link) bool {
+		qdiscs, err := netlink.QdiscList(l)
+		assert.NoError(err)
+		for _, qdisc := range qdiscs {
+			if _, ok := qdisc.(*netlink.Htb); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	err = setupTCFiltering(endpoint, 1, true)
+	assert.NoError(err)
+
+	tapLink, err := netlink.LinkByName(endpoint.NetPair.TAPIface.Name)
+	assert.NoError(err)
+	assert.True(hasHTB(tapLink))
+	assert.True(hasHTB(link))
+
+	err = removeTCFiltering(endpoint)
+	assert.NoError(err)
+	assert.False(hasHTB(link))
+
+	// Remove the veth created for testing.
+	err = netHandle.LinkDel(link)
+	assert.NoError(err)
+}
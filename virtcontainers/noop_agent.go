@@ -6,6 +6,7 @@
 package virtcontainers
 
 import (
+	"io"
 	"syscall"
 	"time"
 
@@ -216,5 +217,20 @@ func (n *noopAgent) copyFile(src, dst string) error {
 	return nil
 }
 
+// copyToContainer is the Noop agent copy to container. It does nothing.
+func (n *noopAgent) copyToContainer(c *Container, dst string, archive io.Reader) error {
+	return nil
+}
+
+// copyFromContainer is the Noop agent copy from container. It does nothing.
+func (n *noopAgent) copyFromContainer(c *Container, src string, archive io.Writer) error {
+	return nil
+}
+
+// portForward is the Noop agent port forwarding stream opener. It does nothing.
+func (n *noopAgent) portForward(c *Container, port uint32) (io.ReadWriteCloser, error) {
+	return nil, nil
+}
+
 func (n *noopAgent) cleanup(id string) {
 }
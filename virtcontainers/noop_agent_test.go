@@ -283,3 +283,22 @@ func TestNoopCopyFile(t *testing.T) {
 	err := n.copyFile("", "")
 	assert.Nil(err)
 }
+
+func TestNoopCopyContainerArchive(t *testing.T) {
+	assert := assert.New(t)
+	n := &noopAgent{}
+
+	err := n.copyToContainer(&Container{}, "", nil)
+	assert.Nil(err)
+
+	err = n.copyFromContainer(&Container{}, "", nil)
+	assert.Nil(err)
+}
+
+func TestNoopPortForward(t *testing.T) {
+	assert := assert.New(t)
+	n := &noopAgent{}
+
+	_, err := n.portForward(&Container{}, 8080)
+	assert.Nil(err)
+}
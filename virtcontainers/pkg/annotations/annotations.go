@@ -17,6 +17,15 @@ const (
 	// InitrdPath is a sandbox annotation for passing a per container path pointing at the guest initrd image that will run in the container VM.
 	InitrdPath = vcAnnotationsPrefix + "InitrdPath"
 
+	// HypervisorProfile is a sandbox annotation for selecting one of the named hypervisor profiles of the runtime configuration.
+	HypervisorProfile = vcAnnotationsPrefix + "HypervisorProfile"
+
+	// KernelParams is a sandbox annotation for passing additional guest kernel parameters, space separated, restricted to the ones allowed by the runtime configuration.
+	KernelParams = vcAnnotationsPrefix + "KernelParams"
+
+	// KernelModules is a sandbox annotation for passing additional guest kernel modules to load, a ";" separated list of module names optionally followed by their parameters, restricted to the ones allowed by the runtime configuration.
+	KernelModules = vcAnnotationsPrefix + "KernelModules"
+
 	// HypervisorPath is a sandbox annotation for passing a per container path pointing at the hypervisor that will run the container VM.
 	HypervisorPath = vcAnnotationsPrefix + "HypervisorPath"
 
@@ -38,6 +47,32 @@ const (
 	// FirmwareHash is an sandbox annotation for passing a container guest firmware SHA-512 hash value.
 	FirmwareHash = vcAnnotationsPrefix + "FirmwareHash"
 
+	// RootfsImagePath is a container annotation for passing the path of a
+	// file-backed disk image holding the container rootfs.
+	RootfsImagePath = vcAnnotationsPrefix + "RootfsImagePath"
+
+	// RootfsImageFormat is a container annotation for passing the format
+	// of the container rootfs disk image, raw or qcow2.
+	RootfsImageFormat = vcAnnotationsPrefix + "RootfsImageFormat"
+
+	// RootfsImageFstype is a container annotation for passing the type of
+	// the filesystem stored in the container rootfs disk image.
+	RootfsImageFstype = vcAnnotationsPrefix + "RootfsImageFstype"
+
+	// RootfsImageOptions is a container annotation for passing the comma
+	// separated options used to mount the container rootfs disk image.
+	RootfsImageOptions = vcAnnotationsPrefix + "RootfsImageOptions"
+
+	// EmptyDirMode is a sandbox annotation for selecting how the Kubernetes
+	// emptyDir volumes are backed in the guest, "tmpfs" or "block".
+	EmptyDirMode = vcAnnotationsPrefix + "EmptyDirMode"
+
+	// EmptyDirSize is a sandbox annotation for passing the sizes of the
+	// block-backed emptyDir volumes, a comma separated list of
+	// "<volume>=<size>" entries, an entry without volume name setting the
+	// size of the other volumes.
+	EmptyDirSize = vcAnnotationsPrefix + "EmptyDirSize"
+
 	// AssetHashType is the hash type used for assets verification
 	AssetHashType = vcAnnotationsPrefix + "AssetHashType"
 
@@ -17,6 +17,7 @@ import (
 	"syscall"
 
 	criContainerdAnnotations "github.com/containerd/cri-containerd/pkg/annotations"
+	units "github.com/docker/go-units"
 	crioAnnotations "github.com/kubernetes-incubator/cri-o/pkg/annotations"
 	spec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
@@ -57,6 +58,17 @@ var (
 	}
 )
 
+const (
+	// k8sIngressBandwidthKey and k8sEgressBandwidthKey are the pod
+	// annotations Kubernetes relies on to limit the bandwidth of the
+	// traffic respectively entering and leaving a pod.
+	k8sIngressBandwidthKey = "kubernetes.io/ingress-bandwidth"
+	k8sEgressBandwidthKey  = "kubernetes.io/egress-bandwidth"
+)
+
+// hugetlbfsMagic is the statfs type of hugetlbfs filesystems.
+const hugetlbfsMagic = 0x958458f6
+
 const (
 	// StateCreated represents a container that has been created and is
 	// ready to be run.
@@ -98,11 +110,25 @@ type FactoryConfig struct {
 	Template bool
 }
 
+// HypervisorProfile is a named hypervisor configuration a sandbox can
+// select.
+type HypervisorProfile struct {
+	HypervisorType   vc.HypervisorType
+	HypervisorConfig vc.HypervisorConfig
+}
+
 // RuntimeConfig aggregates all runtime specific settings
 type RuntimeConfig struct {
 	HypervisorType   vc.HypervisorType
 	HypervisorConfig vc.HypervisorConfig
 
+	// HypervisorProfile is the name of the profile HypervisorType and
+	// HypervisorConfig come from, DefaultHypervisorProfile until a
+	// sandbox selects another one.
+	HypervisorProfile        string
+	DefaultHypervisorProfile string
+	HypervisorProfiles       map[string]HypervisorProfile
+
 	NetmonConfig vc.NetmonConfig
 
 	AgentType   vc.AgentType
@@ -128,6 +154,18 @@ type RuntimeConfig struct {
 
 	//Determines if create a netns for hypervisor process
 	DisableNewNetNs bool
+
+	// AllowedKernelParams are the names of the guest kernel parameters
+	// sandboxes can add through the KernelParams annotation.
+	AllowedKernelParams []string
+
+	// AllowedKernelModules are the names of the guest kernel modules
+	// sandboxes can load through the KernelModules annotation.
+	AllowedKernelModules []string
+
+	// Determines if the shim v2 reclaims the resources of the orphaned
+	// sandboxes when creating a sandbox
+	ShimGC bool
 }
 
 // AddKernelParam allows the addition of new kernel parameters to an existing
@@ -136,6 +174,37 @@ func (config *RuntimeConfig) AddKernelParam(p vc.Param) error {
 	return config.HypervisorConfig.AddKernelParam(p)
 }
 
+// SetHypervisorProfile switches the hypervisor configuration to the named
+// hypervisor profile.
+func (config *RuntimeConfig) SetHypervisorProfile(name string) error {
+	if name == config.HypervisorProfile {
+		return nil
+	}
+
+	profile, ok := config.HypervisorProfiles[name]
+	if !ok {
+		return fmt.Errorf("Unknown hypervisor profile %q", name)
+	}
+
+	config.HypervisorProfile = name
+	config.HypervisorType = profile.HypervisorType
+	config.HypervisorConfig = profile.HypervisorConfig
+
+	return nil
+}
+
+// SelectHypervisorProfile switches the hypervisor configuration to the
+// profile requested by the HypervisorProfile annotation of the OCI spec, if
+// any.
+func (config *RuntimeConfig) SelectHypervisorProfile(ocispec CompatOCISpec) error {
+	name, ok := ocispec.Annotations[vcAnnotations.HypervisorProfile]
+	if !ok {
+		return nil
+	}
+
+	return config.SetHypervisorProfile(name)
+}
+
 var ociLog = logrus.WithFields(logrus.Fields{
 	"source":    "virtcontainers",
 	"subsystem": "oci",
@@ -229,6 +298,44 @@ func newLinuxDeviceInfo(d spec.LinuxDevice) (*config.DeviceInfo, error) {
 	return &deviceInfo, nil
 }
 
+// containerRootfsImage returns the disk image holding the container
+// rootfs, as described by the container annotations.
+func containerRootfsImage(spec CompatOCISpec) (*vc.RootfsImage, error) {
+	path, ok := spec.Annotations[vcAnnotations.RootfsImagePath]
+	if !ok {
+		return nil, nil
+	}
+
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("rootfs disk image path %q must be absolute", path)
+	}
+
+	image := &vc.RootfsImage{
+		Path:   path,
+		Format: config.ImageFormatRaw,
+		Fstype: "ext4",
+	}
+
+	if format := spec.Annotations[vcAnnotations.RootfsImageFormat]; format != "" {
+		switch format {
+		case config.ImageFormatRaw, config.ImageFormatQcow2:
+			image.Format = format
+		default:
+			return nil, fmt.Errorf("unsupported rootfs disk image format %q", format)
+		}
+	}
+
+	if fstype := spec.Annotations[vcAnnotations.RootfsImageFstype]; fstype != "" {
+		image.Fstype = fstype
+	}
+
+	if options := spec.Annotations[vcAnnotations.RootfsImageOptions]; options != "" {
+		image.Options = strings.Split(options, ",")
+	}
+
+	return image, nil
+}
+
 func containerDeviceInfos(spec CompatOCISpec) ([]config.DeviceInfo, error) {
 	ociLinuxDevices := spec.Spec.Linux.Devices
 
@@ -341,9 +448,61 @@ func networkConfig(ocispec CompatOCISpec, config RuntimeConfig) (vc.NetworkConfi
 		Enable: config.NetmonConfig.Enable,
 	}
 
+	var err error
+
+	if netConf.IngressBandwidth, err = bandwidthAnnotation(ocispec, k8sIngressBandwidthKey); err != nil {
+		return vc.NetworkConfig{}, err
+	}
+
+	if netConf.EgressBandwidth, err = bandwidthAnnotation(ocispec, k8sEgressBandwidthKey); err != nil {
+		return vc.NetworkConfig{}, err
+	}
+
 	return netConf, nil
 }
 
+// bandwidthAnnotation returns the rate, in bits per second, described by
+// the bandwidth annotation "key". The value follows the Kubernetes quantity
+// format, meaning "10M" stands for 10^7 bits/s while "10Mi" stands for
+// 10*2^20 bits/s. Zero is returned if the annotation is not set.
+func bandwidthAnnotation(ocispec CompatOCISpec, key string) (uint64, error) {
+	value, ok := ocispec.Annotations[key]
+	if !ok || value == "" {
+		return 0, nil
+	}
+
+	rate, err := parseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s annotation value %q", key, value)
+	}
+
+	return rate, nil
+}
+
+// parseQuantity parses a value following the Kubernetes quantity format.
+func parseQuantity(value string) (uint64, error) {
+	var (
+		quantity int64
+		err      error
+	)
+
+	if strings.HasSuffix(value, "i") {
+		quantity, err = units.RAMInBytes(value)
+	} else {
+		quantity, err = units.FromHumanSize(value)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if quantity < 0 {
+		return 0, fmt.Errorf("Negative quantity %q", value)
+	}
+
+	return uint64(quantity), nil
+}
+
 // getConfigPath returns the full config path from the bundle
 // path provided.
 func getConfigPath(bundlePath string) string {
@@ -360,6 +519,12 @@ func ParseConfigJSON(bundlePath string) (CompatOCISpec, error) {
 		return CompatOCISpec{}, err
 	}
 
+	return ParseConfig(configByte)
+}
+
+// ParseConfig converts the content of an OCI bundle configuration file
+// into a CompatOCISpec structure.
+func ParseConfig(configByte []byte) (CompatOCISpec, error) {
 	var ocispec CompatOCISpec
 	if err := json.Unmarshal(configByte, &ocispec); err != nil {
 		return CompatOCISpec{}, err
@@ -440,9 +605,130 @@ func addAssetAnnotations(ocispec CompatOCISpec, config *vc.SandboxConfig) {
 	}
 }
 
+// addKernelParams adds the guest kernel parameters requested by the
+// KernelParams annotation, each of which has to be allowed by the runtime
+// configuration.
+func addKernelParams(ocispec CompatOCISpec, runtime RuntimeConfig, config *vc.SandboxConfig) error {
+	value, ok := ocispec.Annotations[vcAnnotations.KernelParams]
+	if !ok {
+		return nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range runtime.AllowedKernelParams {
+		allowed[name] = true
+	}
+
+	// Do not append to the runtime configuration parameters in place.
+	params := append([]vc.Param{}, config.HypervisorConfig.KernelParams...)
+
+	for _, p := range vc.DeserializeParams(strings.Fields(value)) {
+		if !allowed[p.Key] {
+			return fmt.Errorf("Guest kernel parameter %q is not allowed", p.Key)
+		}
+
+		params = append(params, p)
+	}
+
+	config.HypervisorConfig.KernelParams = params
+
+	return nil
+}
+
+// addKernelModules adds the guest kernel modules of the KernelModules
+// annotation to the ones of the runtime configuration, each of which has to
+// be allowed by the runtime configuration.
+func addKernelModules(ocispec CompatOCISpec, runtime RuntimeConfig, config *vc.SandboxConfig) error {
+	value, ok := ocispec.Annotations[vcAnnotations.KernelModules]
+	if !ok {
+		return nil
+	}
+
+	agentConfig, ok := config.AgentConfig.(vc.KataAgentConfig)
+	if !ok {
+		return fmt.Errorf("Guest kernel modules are not supported by agent %q", config.AgentType)
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range runtime.AllowedKernelModules {
+		allowed[name] = true
+	}
+
+	// Do not append to the runtime configuration modules in place.
+	modules := append([]string{}, agentConfig.KernelModules...)
+
+	for _, m := range strings.Split(value, ";") {
+		fields := strings.Fields(m)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !allowed[fields[0]] {
+			return fmt.Errorf("Guest kernel module %q is not allowed", fields[0])
+		}
+
+		modules = append(modules, strings.Join(fields, " "))
+	}
+
+	agentConfig.KernelModules = modules
+	config.AgentConfig = agentConfig
+
+	return nil
+}
+
+// addEmptyDirConfig selects the backing of the emptyDir volumes requested
+// by the EmptyDirMode and EmptyDirSize annotations.
+func addEmptyDirConfig(ocispec CompatOCISpec, config *vc.SandboxConfig) error {
+	if mode, ok := ocispec.Annotations[vcAnnotations.EmptyDirMode]; ok {
+		switch vc.EmptyDirMode(mode) {
+		case vc.EmptyDirTmpfs, vc.EmptyDirBlock:
+			config.EmptyDir.Mode = vc.EmptyDirMode(mode)
+		default:
+			return fmt.Errorf("Invalid emptyDir mode %q", mode)
+		}
+	}
+
+	value, ok := ocispec.Annotations[vcAnnotations.EmptyDirSize]
+	if !ok {
+		return nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		var name string
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			name, entry = entry[:i], entry[i+1:]
+		}
+
+		size, err := parseQuantity(entry)
+		if err != nil || size == 0 {
+			return fmt.Errorf("Invalid %s annotation value %q", vcAnnotations.EmptyDirSize, value)
+		}
+
+		if name == "" {
+			config.EmptyDir.Size = size
+			continue
+		}
+
+		if config.EmptyDir.Sizes == nil {
+			config.EmptyDir.Sizes = make(map[string]uint64)
+		}
+		config.EmptyDir.Sizes[name] = size
+	}
+
+	return nil
+}
+
 // SandboxConfig converts an OCI compatible runtime configuration file
 // to a virtcontainers sandbox configuration structure.
 func SandboxConfig(ocispec CompatOCISpec, runtime RuntimeConfig, bundlePath, cid, console string, detach, systemdCgroup bool) (vc.SandboxConfig, error) {
+	if err := runtime.SelectHypervisorProfile(ocispec); err != nil {
+		return vc.SandboxConfig{}, err
+	}
+
 	containerConfig, err := ContainerConfig(ocispec, bundlePath, cid, console, detach)
 	if err != nil {
 		return vc.SandboxConfig{}, err
@@ -453,6 +739,11 @@ func SandboxConfig(ocispec CompatOCISpec, runtime RuntimeConfig, bundlePath, cid
 		return vc.SandboxConfig{}, err
 	}
 
+	shmPageSize, err := getShmPageSize(containerConfig)
+	if err != nil {
+		return vc.SandboxConfig{}, err
+	}
+
 	networkConfig, err := networkConfig(ocispec, runtime)
 	if err != nil {
 		return vc.SandboxConfig{}, err
@@ -489,7 +780,8 @@ func SandboxConfig(ocispec CompatOCISpec, runtime RuntimeConfig, bundlePath, cid
 			vcAnnotations.BundlePathKey: bundlePath,
 		},
 
-		ShmSize: shmSize,
+		ShmSize:     shmSize,
+		ShmPageSize: shmPageSize,
 
 		SystemdCgroup: systemdCgroup,
 
@@ -498,6 +790,18 @@ func SandboxConfig(ocispec CompatOCISpec, runtime RuntimeConfig, bundlePath, cid
 
 	addAssetAnnotations(ocispec, &sandboxConfig)
 
+	if err := addKernelParams(ocispec, runtime, &sandboxConfig); err != nil {
+		return vc.SandboxConfig{}, err
+	}
+
+	if err := addKernelModules(ocispec, runtime, &sandboxConfig); err != nil {
+		return vc.SandboxConfig{}, err
+	}
+
+	if err := addEmptyDirConfig(ocispec, &sandboxConfig); err != nil {
+		return vc.SandboxConfig{}, err
+	}
+
 	return sandboxConfig, nil
 }
 
@@ -538,6 +842,11 @@ func ContainerConfig(ocispec CompatOCISpec, bundlePath, cid, console string, det
 		return vc.ContainerConfig{}, err
 	}
 
+	rootfsImage, err := containerRootfsImage(ocispec)
+	if err != nil {
+		return vc.ContainerConfig{}, err
+	}
+
 	if ocispec.Process != nil {
 		caps, ok := ocispec.Process.Capabilities.(types.LinuxCapabilities)
 		if !ok {
@@ -549,6 +858,7 @@ func ContainerConfig(ocispec CompatOCISpec, bundlePath, cid, console string, det
 	containerConfig := vc.ContainerConfig{
 		ID:             cid,
 		RootFs:         rootfs,
+		RootfsImage:    rootfsImage,
 		ReadonlyRootfs: ocispec.Spec.Root.Readonly,
 		Cmd:            cmd,
 		Annotations: map[string]string{
@@ -596,6 +906,34 @@ func getShmSize(c vc.ContainerConfig) (uint64, error) {
 	return shmSize, nil
 }
 
+// getShmPageSize returns the size of the hugepages backing the shm bind
+// mounted into the container, zero if it is not backed by hugepages.
+func getShmPageSize(c vc.ContainerConfig) (uint64, error) {
+	for _, m := range c.Mounts {
+		if m.Destination != "/dev/shm" {
+			continue
+		}
+
+		if m.Type != "bind" || m.Source == "/dev/shm" {
+			break
+		}
+
+		var s syscall.Statfs_t
+
+		if err := syscall.Statfs(m.Source, &s); err != nil {
+			return 0, err
+		}
+
+		if s.Type == hugetlbfsMagic {
+			ociLog.Infof("shm backed by hugepages of %d bytes", s.Bsize)
+			return uint64(s.Bsize), nil
+		}
+		break
+	}
+
+	return 0, nil
+}
+
 // StatusToOCIState translates a virtcontainers container status into an OCI state.
 func StatusToOCIState(status vc.ContainerStatus) spec.State {
 	return spec.State{
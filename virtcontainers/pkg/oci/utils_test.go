@@ -652,6 +652,48 @@ func TestAddKernelParamInvalid(t *testing.T) {
 	}
 }
 
+func TestSelectHypervisorProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	config := RuntimeConfig{
+		HypervisorType:           vc.QemuHypervisor,
+		HypervisorConfig:         vc.HypervisorConfig{MemorySize: 2048},
+		HypervisorProfile:        "qemu",
+		DefaultHypervisorProfile: "qemu",
+		HypervisorProfiles: map[string]HypervisorProfile{
+			"qemu": {
+				HypervisorType:   vc.QemuHypervisor,
+				HypervisorConfig: vc.HypervisorConfig{MemorySize: 2048},
+			},
+			"firecracker.small": {
+				HypervisorType:   vc.FirecrackerHypervisor,
+				HypervisorConfig: vc.HypervisorConfig{MemorySize: 128},
+			},
+		},
+	}
+
+	// no profile requested
+	var ociSpec CompatOCISpec
+	err := config.SelectHypervisorProfile(ociSpec)
+	assert.NoError(err)
+	assert.Equal("qemu", config.HypervisorProfile)
+
+	ociSpec.Annotations = map[string]string{
+		vcAnnotations.HypervisorProfile: "foo",
+	}
+	err = config.SelectHypervisorProfile(ociSpec)
+	assert.Error(err)
+	assert.Equal("qemu", config.HypervisorProfile)
+
+	ociSpec.Annotations[vcAnnotations.HypervisorProfile] = "firecracker.small"
+	err = config.SelectHypervisorProfile(ociSpec)
+	assert.NoError(err)
+	assert.Equal("firecracker.small", config.HypervisorProfile)
+	assert.Equal(vc.FirecrackerHypervisor, config.HypervisorType)
+	assert.Equal(uint32(128), config.HypervisorConfig.MemorySize)
+	assert.Equal("qemu", config.DefaultHypervisorProfile)
+}
+
 func TestDeviceTypeFailure(t *testing.T) {
 	var ociSpec CompatOCISpec
 
@@ -668,6 +710,47 @@ func TestDeviceTypeFailure(t *testing.T) {
 	assert.NotNil(t, err, "This test should fail as device type [%s] is invalid ", invalidDeviceType)
 }
 
+func TestContainerRootfsImage(t *testing.T) {
+	assert := assert.New(t)
+
+	var ociSpec CompatOCISpec
+	ociSpec.Annotations = map[string]string{}
+
+	image, err := containerRootfsImage(ociSpec)
+	assert.NoError(err)
+	assert.Nil(image)
+
+	ociSpec.Annotations[vcAnnotations.RootfsImagePath] = "/images/rootfs.img"
+	image, err = containerRootfsImage(ociSpec)
+	assert.NoError(err)
+	assert.Equal(&vc.RootfsImage{
+		Path:   "/images/rootfs.img",
+		Format: config.ImageFormatRaw,
+		Fstype: "ext4",
+	}, image)
+
+	ociSpec.Annotations[vcAnnotations.RootfsImageFormat] = config.ImageFormatQcow2
+	ociSpec.Annotations[vcAnnotations.RootfsImageFstype] = "xfs"
+	ociSpec.Annotations[vcAnnotations.RootfsImageOptions] = "ro,noatime"
+	image, err = containerRootfsImage(ociSpec)
+	assert.NoError(err)
+	assert.Equal(&vc.RootfsImage{
+		Path:    "/images/rootfs.img",
+		Format:  config.ImageFormatQcow2,
+		Fstype:  "xfs",
+		Options: []string{"ro", "noatime"},
+	}, image)
+
+	ociSpec.Annotations[vcAnnotations.RootfsImageFormat] = "vmdk"
+	_, err = containerRootfsImage(ociSpec)
+	assert.Error(err)
+
+	ociSpec.Annotations[vcAnnotations.RootfsImageFormat] = config.ImageFormatRaw
+	ociSpec.Annotations[vcAnnotations.RootfsImagePath] = "rootfs.img"
+	_, err = containerRootfsImage(ociSpec)
+	assert.Error(err)
+}
+
 func TestContains(t *testing.T) {
 	s := []string{"char", "block", "pipe"}
 
@@ -839,6 +922,43 @@ func TestGetShmSizeBindMounted(t *testing.T) {
 	assert.Equal(t, shmSize, uint64(size))
 }
 
+func TestGetShmPageSize(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Test disabled as requires root privileges")
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	containerConfig := vc.ContainerConfig{
+		Mounts: []vc.Mount{
+			{
+				Source:      dir,
+				Destination: "/dev/shm",
+				Type:        "bind",
+			},
+		},
+	}
+
+	pageSize, err := getShmPageSize(containerConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), pageSize)
+
+	if err := unix.Mount("nodev", dir, "hugetlbfs", 0, "pagesize=2M,size=8M"); err != nil {
+		t.Skipf("Could not mount hugetlbfs: %v", err)
+	}
+	defer unix.Unmount(dir, 0)
+
+	pageSize, err = getShmPageSize(containerConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2<<20), pageSize)
+
+	shmSize, err := getShmSize(containerConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(8<<20), shmSize)
+}
+
 func TestMain(m *testing.M) {
 	/* Create temp bundle directory if necessary */
 	err := os.MkdirAll(tempBundlePath, dirMode)
@@ -851,3 +971,145 @@ func TestMain(m *testing.M) {
 
 	os.Exit(m.Run())
 }
+
+func TestNetworkConfigBandwidth(t *testing.T) {
+	assert := assert.New(t)
+
+	var ociSpec CompatOCISpec
+	ociSpec.Linux = &specs.Linux{}
+	ociSpec.Annotations = map[string]string{}
+
+	netConf, err := networkConfig(ociSpec, RuntimeConfig{})
+	assert.NoError(err)
+	assert.Zero(netConf.IngressBandwidth)
+	assert.Zero(netConf.EgressBandwidth)
+
+	ociSpec.Annotations[k8sIngressBandwidthKey] = "10M"
+	ociSpec.Annotations[k8sEgressBandwidthKey] = "1Mi"
+	netConf, err = networkConfig(ociSpec, RuntimeConfig{})
+	assert.NoError(err)
+	assert.Equal(uint64(10000000), netConf.IngressBandwidth)
+	assert.Equal(uint64(1048576), netConf.EgressBandwidth)
+
+	ociSpec.Annotations[k8sEgressBandwidthKey] = "fast"
+	_, err = networkConfig(ociSpec, RuntimeConfig{})
+	assert.Error(err)
+}
+
+func TestAddKernelParams(t *testing.T) {
+	assert := assert.New(t)
+
+	runtime := RuntimeConfig{
+		HypervisorConfig: vc.HypervisorConfig{
+			KernelParams: make([]vc.Param, 1, 4),
+		},
+		AllowedKernelParams: []string{"quiet", "systemd.unit"},
+	}
+	runtime.HypervisorConfig.KernelParams[0] = vc.Param{Key: "foo", Value: "bar"}
+
+	var ociSpec CompatOCISpec
+	ociSpec.Annotations = map[string]string{}
+
+	config := vc.SandboxConfig{HypervisorConfig: runtime.HypervisorConfig}
+	err := addKernelParams(ociSpec, runtime, &config)
+	assert.NoError(err)
+	assert.Equal(runtime.HypervisorConfig.KernelParams, config.HypervisorConfig.KernelParams)
+
+	ociSpec.Annotations[vcAnnotations.KernelParams] = "quiet  systemd.unit=foo.target"
+	err = addKernelParams(ociSpec, runtime, &config)
+	assert.NoError(err)
+	assert.Equal([]vc.Param{
+		{Key: "foo", Value: "bar"},
+		{Key: "quiet"},
+		{Key: "systemd.unit", Value: "foo.target"},
+	}, config.HypervisorConfig.KernelParams)
+
+	// the runtime configuration is left untouched
+	assert.Len(runtime.HypervisorConfig.KernelParams, 1)
+	assert.Equal(vc.Param{}, runtime.HypervisorConfig.KernelParams[:2][1])
+
+	ociSpec.Annotations[vcAnnotations.KernelParams] = "quiet init=/bin/sh"
+	config = vc.SandboxConfig{HypervisorConfig: runtime.HypervisorConfig}
+	err = addKernelParams(ociSpec, runtime, &config)
+	assert.Error(err)
+}
+
+func TestAddKernelModules(t *testing.T) {
+	assert := assert.New(t)
+
+	runtime := RuntimeConfig{
+		AllowedKernelModules: []string{"i915", "nvme"},
+	}
+
+	var ociSpec CompatOCISpec
+	ociSpec.Annotations = map[string]string{}
+
+	agentModules := make([]string, 1, 4)
+	agentModules[0] = "e1000e"
+
+	config := vc.SandboxConfig{
+		AgentType: vc.KataContainersAgent,
+		AgentConfig: vc.KataAgentConfig{
+			KernelModules: agentModules,
+		},
+	}
+
+	err := addKernelModules(ociSpec, runtime, &config)
+	assert.NoError(err)
+	assert.Equal([]string{"e1000e"}, config.AgentConfig.(vc.KataAgentConfig).KernelModules)
+
+	// the modules are added to the configured ones
+	ociSpec.Annotations[vcAnnotations.KernelModules] = "i915  enable_guc=1; ;nvme"
+	err = addKernelModules(ociSpec, runtime, &config)
+	assert.NoError(err)
+	assert.Equal([]string{"e1000e", "i915 enable_guc=1", "nvme"}, config.AgentConfig.(vc.KataAgentConfig).KernelModules)
+
+	// the runtime configuration is left untouched
+	assert.Equal("", agentModules[:2][1])
+
+	// only the allowed modules can be added
+	ociSpec.Annotations[vcAnnotations.KernelModules] = "i915;e1000e"
+	err = addKernelModules(ociSpec, runtime, &config)
+	assert.Error(err)
+
+	ociSpec.Annotations[vcAnnotations.KernelModules] = "nvme"
+	err = addKernelModules(ociSpec, RuntimeConfig{}, &config)
+	assert.Error(err)
+
+	config.AgentType = vc.HyperstartAgent
+	config.AgentConfig = vc.HyperConfig{}
+	err = addKernelModules(ociSpec, runtime, &config)
+	assert.Error(err)
+}
+
+func TestAddEmptyDirConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	var ociSpec CompatOCISpec
+	ociSpec.Annotations = map[string]string{}
+
+	var config vc.SandboxConfig
+
+	err := addEmptyDirConfig(ociSpec, &config)
+	assert.NoError(err)
+	assert.Equal(vc.EmptyDirConfig{}, config.EmptyDir)
+
+	ociSpec.Annotations[vcAnnotations.EmptyDirMode] = string(vc.EmptyDirBlock)
+	ociSpec.Annotations[vcAnnotations.EmptyDirSize] = "512Mi, cache=2G,"
+	err = addEmptyDirConfig(ociSpec, &config)
+	assert.NoError(err)
+	assert.Equal(vc.EmptyDirConfig{
+		Mode:  vc.EmptyDirBlock,
+		Size:  512 * 1024 * 1024,
+		Sizes: map[string]uint64{"cache": 2000 * 1000 * 1000},
+	}, config.EmptyDir)
+
+	for _, size := range []string{"cache=", "-1", "0", "foo"} {
+		ociSpec.Annotations[vcAnnotations.EmptyDirSize] = size
+		assert.Error(addEmptyDirConfig(ociSpec, &config), size)
+	}
+
+	delete(ociSpec.Annotations, vcAnnotations.EmptyDirSize)
+	ociSpec.Annotations[vcAnnotations.EmptyDirMode] = "memory"
+	assert.Error(addEmptyDirConfig(ociSpec, &config))
+}
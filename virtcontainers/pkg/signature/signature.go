@@ -0,0 +1,146 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package signature verifies detached signatures of files against a set
+// of trusted public keys. Signatures are computed over the SHA-512 digest
+// of the file content, as produced by:
+//
+//	openssl dgst -sha512 -sign <private key> -out <file>.sig <file>
+//
+// RSA (PKCS #1 v1.5) and ECDSA (ASN.1 DER encoded) signatures are supported.
+// The verification does not need any network access.
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+)
+
+// ErrNoKeys is returned when a verification is requested without any
+// trusted public key.
+var ErrNoKeys = errors.New("no trusted public key provided")
+
+// ErrInvalidSignature is returned when a signature does not match any of
+// the trusted public keys.
+var ErrInvalidSignature = errors.New("signature does not match any trusted public key")
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ParsePublicKey parses a PEM encoded RSA or ECDSA public key.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			return key, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// LoadPublicKeys reads and parses the PEM encoded public keys stored at
+// the given paths.
+func LoadPublicKeys(paths []string) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %s: %v", path, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Verify checks that sig is a valid signature of the SHA-512 digest
+// for one of the keys.
+func Verify(digest, sig []byte, keys []crypto.PublicKey) error {
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+
+	for _, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA512, digest, sig) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			var s ecdsaSignature
+			rest, err := asn1.Unmarshal(sig, &s)
+			if err != nil || len(rest) != 0 || s.R == nil || s.S == nil {
+				continue
+			}
+
+			if ecdsa.Verify(k, digest, s.R, s.S) {
+				return nil
+			}
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// VerifyFile checks that the detached signature stored at sigPath is a
+// valid signature of the file at path for one of the keys.
+func VerifyFile(path, sigPath string, keys []crypto.PublicKey) error {
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+
+	sig, err := ioutil.ReadFile(sigPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	return Verify(h.Sum(nil), sig, keys)
+}
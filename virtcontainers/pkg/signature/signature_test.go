@@ -0,0 +1,107 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePublicKey(t *testing.T, dir, name string, pub crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	assert.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	return path
+}
+
+func TestParsePublicKey(t *testing.T) {
+	assert := assert.New(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+	key, err := ParsePublicKey(data)
+	assert.NoError(err)
+	assert.Equal(&rsaKey.PublicKey, key)
+
+	_, err = ParsePublicKey([]byte("not a key"))
+	assert.Error(err)
+
+	data = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("foo")})
+	_, err = ParsePublicKey(data)
+	assert.Error(err)
+}
+
+func TestVerifyFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "signature")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	asset := filepath.Join(dir, "asset")
+	assert.NoError(ioutil.WriteFile(asset, []byte("guest asset content"), 0644))
+	digest := sha512.Sum512([]byte("guest asset content"))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA512, digest[:])
+	assert.NoError(err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+	ecSig, err := ecKey.Sign(rand.Reader, digest[:], crypto.SHA512)
+	assert.NoError(err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+
+	keys, err := LoadPublicKeys([]string{
+		writePublicKey(t, dir, "rsa.pem", &rsaKey.PublicKey),
+		writePublicKey(t, dir, "ecdsa.pem", &ecKey.PublicKey),
+	})
+	assert.NoError(err)
+	assert.Len(keys, 2)
+
+	otherKeys, err := LoadPublicKeys([]string{writePublicKey(t, dir, "other.pem", &otherKey.PublicKey)})
+	assert.NoError(err)
+
+	sigPath := asset + ".sig"
+	for _, sig := range [][]byte{rsaSig, ecSig} {
+		assert.NoError(ioutil.WriteFile(sigPath, sig, 0644))
+		assert.NoError(VerifyFile(asset, sigPath, keys))
+		assert.Equal(ErrInvalidSignature, VerifyFile(asset, sigPath, otherKeys))
+	}
+
+	assert.Equal(ErrNoKeys, VerifyFile(asset, sigPath, nil))
+
+	// Tampered asset
+	assert.NoError(ioutil.WriteFile(asset, []byte("tampered content"), 0644))
+	assert.Equal(ErrInvalidSignature, VerifyFile(asset, sigPath, keys))
+
+	// Missing signature
+	assert.Error(VerifyFile(asset, filepath.Join(dir, "missing.sig"), keys))
+
+	_, err = LoadPublicKeys([]string{filepath.Join(dir, "missing.pem")})
+	assert.Error(err)
+}
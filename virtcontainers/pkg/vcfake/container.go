@@ -0,0 +1,429 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package vcfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"syscall"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Container is an in-memory implementation of the VCContainer interface.
+type Container struct {
+	sandbox *Sandbox
+
+	id     string
+	config vc.ContainerConfig
+	state  types.State
+
+	// init is the process of the container, identified by the container
+	// ID, and execs are the processes entered into the container,
+	// indexed by token.
+	init  *process
+	execs map[string]*process
+
+	// cgroupStats are the statistics set by VCFake.SetContainerStats(),
+	// if any.
+	cgroupStats *vc.ContainerStats
+}
+
+func newContainer(s *Sandbox, contConfig vc.ContainerConfig) *Container {
+	c := &Container{
+		sandbox: s,
+		id:      contConfig.ID,
+		config:  contConfig,
+		state: types.State{
+			State: types.StateReady,
+		},
+		execs: make(map[string]*process),
+	}
+
+	c.config.Annotations = copyAnnotations(contConfig.Annotations)
+	c.newInit("")
+
+	return c
+}
+
+// newInit creates the process of the container, keeping "token" if not
+// empty. The lock of the fake must be held.
+func (c *Container) newInit(token string) {
+	p := newProcess(c.config.Cmd, c.sandbox.fake.allocatePid())
+	if token != "" {
+		p.Token = token
+	}
+
+	// As in a PID namespace, the processes entered into the container
+	// are killed along with its process.
+	p.onExit = func() {
+		for _, e := range c.execs {
+			e.exit(signalExitCode(syscall.SIGKILL))
+		}
+	}
+
+	c.init = p
+}
+
+// findProcess returns a process of the container, its own process being
+// identified by the container ID or its token. The lock of the fake must
+// be held.
+func (c *Container) findProcess(processID string) (*process, error) {
+	if processID == c.id || processID == c.init.Token {
+		return c.init, nil
+	}
+
+	if p, ok := c.execs[processID]; ok {
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("Process %s not found in container %s", processID, c.id)
+}
+
+// processes returns the processes of the container running, by PID. The
+// lock of the fake must be held.
+func (c *Container) processes() []*process {
+	var procs []*process
+	for _, p := range append([]*process{c.init}, c.execList()...) {
+		if p.started && !p.hasExited() {
+			procs = append(procs, p)
+		}
+	}
+
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].Pid < procs[j].Pid
+	})
+
+	return procs
+}
+
+func (c *Container) execList() []*process {
+	var execs []*process
+	for _, p := range c.execs {
+		execs = append(execs, p)
+	}
+
+	return execs
+}
+
+func (c *Container) checkSandboxRunning(cmd string) error {
+	if c.sandbox.state.State != types.StateRunning {
+		return fmt.Errorf("Sandbox not running, impossible to %s the container", cmd)
+	}
+
+	return nil
+}
+
+func (c *Container) status() vc.ContainerStatus {
+	return vc.ContainerStatus{
+		ID:          c.id,
+		State:       c.state,
+		PID:         c.init.Pid,
+		StartTime:   c.init.StartTime,
+		RootFs:      c.config.RootFs,
+		Annotations: copyAnnotations(c.config.Annotations),
+	}
+}
+
+func (c *Container) start() error {
+	if err := c.checkSandboxRunning("start"); err != nil {
+		return err
+	}
+
+	if c.state.State != types.StateReady &&
+		c.state.State != types.StateStopped {
+		return fmt.Errorf("Container not ready or stopped, impossible to start")
+	}
+
+	if err := c.state.ValidTransition(c.state.State, types.StateRunning); err != nil {
+		return err
+	}
+
+	// A new process is started if the container is restarted.
+	if c.init.hasExited() {
+		c.newInit(c.init.Token)
+	}
+
+	c.init.start(c.sandbox.fake, c.sandbox.fake.Behavior)
+	c.state.State = types.StateRunning
+
+	return nil
+}
+
+func (c *Container) stop() error {
+	if c.state.State == types.StateStopped {
+		return nil
+	}
+
+	if err := c.state.ValidTransition(c.state.State, types.StateStopped); err != nil {
+		return err
+	}
+
+	c.init.signal(syscall.SIGKILL)
+	c.state.State = types.StateStopped
+
+	return nil
+}
+
+func (c *Container) delete() error {
+	if c.state.State != types.StateReady &&
+		c.state.State != types.StateStopped {
+		return fmt.Errorf("Container not ready or stopped, impossible to delete")
+	}
+
+	// The process of a container deleted before being started never
+	// runs.
+	c.init.signal(syscall.SIGKILL)
+
+	return nil
+}
+
+func (c *Container) enter(cmd types.Cmd) (*process, error) {
+	if err := c.checkSandboxRunning("enter"); err != nil {
+		return nil, err
+	}
+
+	if c.state.State != types.StateReady &&
+		c.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Container not ready or running, " +
+			"impossible to enter")
+	}
+
+	p := newProcess(cmd, c.sandbox.fake.allocatePid())
+	c.execs[p.Token] = p
+	p.start(c.sandbox.fake, c.sandbox.fake.Behavior)
+
+	return p, nil
+}
+
+func (c *Container) waitable(processID string) (*process, error) {
+	if c.state.State != types.StateReady &&
+		c.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Container not ready or running, " +
+			"impossible to wait")
+	}
+
+	return c.findProcess(processID)
+}
+
+func (c *Container) signalProcess(processID string, signal syscall.Signal, all bool) error {
+	if c.sandbox.state.State != types.StateReady && c.sandbox.state.State != types.StateRunning {
+		return fmt.Errorf("Sandbox not ready or running, impossible to signal the container")
+	}
+
+	if c.state.State != types.StateReady && c.state.State != types.StateRunning && c.state.State != types.StatePaused {
+		return fmt.Errorf("Container not ready, running or paused, impossible to signal the container")
+	}
+
+	p, err := c.findProcess(processID)
+	if err != nil {
+		return err
+	}
+
+	if !all {
+		p.signal(signal)
+		return nil
+	}
+
+	for _, e := range c.execList() {
+		e.signal(signal)
+	}
+	c.init.signal(signal)
+
+	return nil
+}
+
+func (c *Container) winsizeProcess(processID string, height, width uint32) error {
+	if c.state.State != types.StateReady && c.state.State != types.StateRunning {
+		return fmt.Errorf("Container not ready or running, impossible to signal the container")
+	}
+
+	p, err := c.findProcess(processID)
+	if err != nil {
+		return err
+	}
+
+	p.height, p.width = height, width
+
+	return nil
+}
+
+func (c *Container) ioStream(processID string) (io.WriteCloser, io.Reader, io.Reader, error) {
+	if c.state.State != types.StateReady && c.state.State != types.StateRunning {
+		return nil, nil, nil, fmt.Errorf("Container not ready or running, impossible to signal the container")
+	}
+
+	p, err := c.findProcess(processID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return p.stdin, p.stdout, p.stderr, nil
+}
+
+// processList lists the running processes of the container, as a JSON
+// array of PIDs for the "json" format, or as a table of PIDs and commands
+// otherwise.
+func (c *Container) processList(options vc.ProcessListOptions) (vc.ProcessList, error) {
+	if err := c.checkSandboxRunning("ps"); err != nil {
+		return nil, err
+	}
+
+	if c.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Container not running, impossible to list processes")
+	}
+
+	procs := c.processes()
+
+	if options.Format == "json" {
+		pids := []int{}
+		for _, p := range procs {
+			pids = append(pids, p.Pid)
+		}
+
+		return json.Marshal(pids)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "PID CMD")
+	for _, p := range procs {
+		fmt.Fprintf(&buf, "%d %s\n", p.Pid, strings.Join(p.cmd.Args, " "))
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *Container) checkCopy() error {
+	if err := c.checkSandboxRunning("cp"); err != nil {
+		return err
+	}
+
+	if c.state.State != types.StateRunning {
+		return fmt.Errorf("Container not running, impossible to copy files")
+	}
+
+	return nil
+}
+
+// stats returns the statistics set for the container, or statistics only
+// accounting its running processes otherwise.
+func (c *Container) stats() (vc.ContainerStats, error) {
+	if err := c.checkSandboxRunning("stats"); err != nil {
+		return vc.ContainerStats{}, err
+	}
+
+	if c.cgroupStats != nil {
+		return *c.cgroupStats, nil
+	}
+
+	return vc.ContainerStats{
+		CgroupStats: &vc.CgroupStats{
+			PidsStats: vc.PidsStats{
+				Current: uint64(len(c.processes())),
+			},
+		},
+	}, nil
+}
+
+func (c *Container) update(resources specs.LinuxResources) error {
+	if err := c.checkSandboxRunning("update"); err != nil {
+		return err
+	}
+
+	if state := c.state.State; !(state == types.StateRunning || state == types.StateReady) {
+		return fmt.Errorf("Container(%s) not running or ready, impossible to update", state)
+	}
+
+	c.config.Resources = resources
+
+	return nil
+}
+
+func (c *Container) pause() error {
+	if err := c.checkSandboxRunning("pause"); err != nil {
+		return err
+	}
+
+	if c.state.State != types.StateRunning && c.state.State != types.StateReady {
+		return fmt.Errorf("Container not running or ready, impossible to pause")
+	}
+
+	c.state.State = types.StatePaused
+
+	return nil
+}
+
+func (c *Container) resume() error {
+	if err := c.checkSandboxRunning("resume"); err != nil {
+		return err
+	}
+
+	if c.state.State != types.StatePaused {
+		return fmt.Errorf("Container not paused, impossible to resume")
+	}
+
+	c.state.State = types.StateRunning
+
+	return nil
+}
+
+// ID implements the VCContainer function of the same name.
+func (c *Container) ID() string {
+	return c.id
+}
+
+// Sandbox implements the VCContainer function of the same name.
+func (c *Container) Sandbox() vc.VCSandbox {
+	return c.sandbox
+}
+
+// Process implements the VCContainer function of the same name.
+func (c *Container) Process() vc.Process {
+	c.sandbox.fake.lock.Lock()
+	defer c.sandbox.fake.lock.Unlock()
+
+	return c.init.Process
+}
+
+// GetToken implements the VCContainer function of the same name.
+func (c *Container) GetToken() string {
+	c.sandbox.fake.lock.Lock()
+	defer c.sandbox.fake.lock.Unlock()
+
+	return c.init.Token
+}
+
+// GetPid implements the VCContainer function of the same name.
+func (c *Container) GetPid() int {
+	c.sandbox.fake.lock.Lock()
+	defer c.sandbox.fake.lock.Unlock()
+
+	return c.init.Pid
+}
+
+// SetPid implements the VCContainer function of the same name.
+func (c *Container) SetPid(pid int) error {
+	c.sandbox.fake.lock.Lock()
+	defer c.sandbox.fake.lock.Unlock()
+
+	c.init.Pid = pid
+
+	return nil
+}
+
+// GetAnnotations implements the VCContainer function of the same name.
+func (c *Container) GetAnnotations() map[string]string {
+	c.sandbox.fake.lock.Lock()
+	defer c.sandbox.fake.lock.Unlock()
+
+	return copyAnnotations(c.config.Annotations)
+}
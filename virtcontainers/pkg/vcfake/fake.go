@@ -0,0 +1,689 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Description: An in-memory fake implementation of virtcontainers that can
+// be used for testing, without a hypervisor.
+//
+// Unlike vcmock, the fake keeps the state of its sandboxes, containers and
+// processes, and enforces the same state transitions as virtcontainers:
+// for example, a container can only be started in a running sandbox, and a
+// running sandbox cannot be deleted. Processes run until they are signaled
+// or exit, either through ExitProcess() or the Behavior of the fake.
+//
+// Failures are simulated with InjectFault(), which delays or fails the
+// operations of the fake.
+
+package vcfake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	vcTypes "github.com/kata-containers/runtime/virtcontainers/pkg/types"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrAgentUnavailable is an error to inject with InjectFault(),
+	// simulating an agent which cannot be reached.
+	ErrAgentUnavailable = errors.New("Failed to reach the agent")
+
+	errNeedSandboxID   = errors.New("Sandbox ID cannot be empty")
+	errNeedContainerID = errors.New("Container ID cannot be empty")
+	errNotSupported    = errors.New("Not supported by the fake implementation")
+)
+
+// firstPid is the PID of the first fake process. Fake PIDs are not the
+// ones of host processes.
+const firstPid = 1000
+
+// Fault is a failure injected into an operation of the fake.
+type Fault struct {
+	// Delay is the time the operation is delayed by.
+	Delay time.Duration
+
+	// Err is the error the operation fails with after Delay, if any.
+	Err error
+
+	// Count is the number of calls the fault applies to, or zero if it
+	// applies to all the calls until the faults are cleared.
+	Count int
+}
+
+// VCFake is an in-memory implementation of the VC interface.
+type VCFake struct {
+	// Behavior, if set, simulates the workload of the processes started
+	// afterwards. Otherwise processes run until they are signaled or
+	// ExitProcess() is called.
+	Behavior Behavior
+
+	lock      sync.Mutex
+	sandboxes map[string]*Sandbox
+	faults    map[string]*Fault
+	nextPid   int
+}
+
+// New returns a fake without any sandbox.
+func New() *VCFake {
+	return &VCFake{
+		sandboxes: make(map[string]*Sandbox),
+		faults:    make(map[string]*Fault),
+		nextPid:   firstPid,
+	}
+}
+
+// InjectFault injects a fault into an operation, named after the function
+// of the VC interface, such as "StartContainer". The fault also applies to
+// the VCSandbox function of the same operation, "StartSandbox" covering
+// VCSandbox.Start() for example. The functions only found in VCSandbox,
+// such as "WaitProcess", are named as such.
+func (f *VCFake) InjectFault(op string, fault Fault) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.faults[op] = &fault
+}
+
+// ClearFaults removes all the injected faults.
+func (f *VCFake) ClearFaults() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.faults = make(map[string]*Fault)
+}
+
+// fault applies the fault injected into an operation, if any. The lock of
+// the fake must not be held.
+func (f *VCFake) fault(op string) error {
+	f.lock.Lock()
+
+	fault, ok := f.faults[op]
+	if !ok {
+		f.lock.Unlock()
+		return nil
+	}
+
+	if fault.Count > 0 {
+		fault.Count--
+		if fault.Count == 0 {
+			delete(f.faults, op)
+		}
+	}
+
+	delay, err := fault.Delay, fault.Err
+	f.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	return err
+}
+
+// allocatePid returns a new fake PID. The lock of the fake must be held.
+func (f *VCFake) allocatePid() int {
+	pid := f.nextPid
+	f.nextPid++
+
+	return pid
+}
+
+// lookupSandbox returns a sandbox of the fake.
+func (f *VCFake) lookupSandbox(sandboxID string) (*Sandbox, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	s, ok := f.sandboxes[sandboxID]
+	if !ok {
+		return nil, fmt.Errorf("Sandbox %s does not exist", sandboxID)
+	}
+
+	return s, nil
+}
+
+// lookupProcess returns a process of the fake. The lock of the fake must
+// be held.
+func (f *VCFake) lookupProcess(sandboxID, containerID, processID string) (*process, error) {
+	s, ok := f.sandboxes[sandboxID]
+	if !ok {
+		return nil, fmt.Errorf("Sandbox %s does not exist", sandboxID)
+	}
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.findProcess(processID)
+}
+
+// ExitProcess makes a process exit with "code", as if it had returned on
+// its own. The process of a container is identified by the container ID.
+func (f *VCFake) ExitProcess(sandboxID, containerID, processID string, code int32) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	p, err := f.lookupProcess(sandboxID, containerID, processID)
+	if err != nil {
+		return err
+	}
+
+	if !p.started {
+		return fmt.Errorf("Process %s not started", processID)
+	}
+
+	p.exit(code)
+
+	return nil
+}
+
+// Signals returns the signals delivered to a process, in order.
+func (f *VCFake) Signals(sandboxID, containerID, processID string) ([]syscall.Signal, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	p, err := f.lookupProcess(sandboxID, containerID, processID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]syscall.Signal{}, p.signals...), nil
+}
+
+// SetContainerStats sets the statistics returned for a container.
+func (f *VCFake) SetContainerStats(sandboxID, containerID string, stats vc.ContainerStats) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	s, ok := f.sandboxes[sandboxID]
+	if !ok {
+		return fmt.Errorf("Sandbox %s does not exist", sandboxID)
+	}
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	c.cgroupStats = &stats
+
+	return nil
+}
+
+// CrashSandbox reports an error to the watchers of a sandbox returned by
+// VCSandbox.Monitor(), as if its VM had crashed.
+func (f *VCFake) CrashSandbox(sandboxID string, err error) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	s, ok := f.sandboxes[sandboxID]
+	if !ok {
+		return fmt.Errorf("Sandbox %s does not exist", sandboxID)
+	}
+
+	for _, w := range s.watchers {
+		select {
+		case w <- err:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// SetLogger implements the VC function of the same name.
+func (f *VCFake) SetLogger(ctx context.Context, logger *logrus.Entry) {
+}
+
+// SetFactory implements the VC function of the same name.
+func (f *VCFake) SetFactory(ctx context.Context, factory vc.Factory) {
+}
+
+// CreateSandbox implements the VC function of the same name.
+func (f *VCFake) CreateSandbox(ctx context.Context, sandboxConfig vc.SandboxConfig) (vc.VCSandbox, error) {
+	if sandboxConfig.ID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	if err := f.fault("CreateSandbox"); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.sandboxes[sandboxConfig.ID]; ok {
+		return nil, fmt.Errorf("Sandbox %s already exists", sandboxConfig.ID)
+	}
+
+	s := newSandbox(f, sandboxConfig)
+
+	for _, contConfig := range sandboxConfig.Containers {
+		if _, err := s.createContainer(contConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	f.sandboxes[s.id] = s
+
+	return s, nil
+}
+
+// DeleteSandbox implements the VC function of the same name.
+func (f *VCFake) DeleteSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Delete(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// FetchSandbox implements the VC function of the same name.
+func (f *VCFake) FetchSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+	if err := f.fault("FetchSandbox"); err != nil {
+		return nil, err
+	}
+
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ListSandbox implements the VC function of the same name.
+func (f *VCFake) ListSandbox(ctx context.Context) ([]vc.SandboxStatus, error) {
+	if err := f.fault("ListSandbox"); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var ids []string
+	for id := range f.sandboxes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var statuses []vc.SandboxStatus
+	for _, id := range ids {
+		statuses = append(statuses, f.sandboxes[id].status())
+	}
+
+	return statuses, nil
+}
+
+// PauseSandbox implements the VC function of the same name.
+func (f *VCFake) PauseSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Pause(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ResumeSandbox implements the VC function of the same name.
+func (f *VCFake) ResumeSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Resume(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// RunSandbox implements the VC function of the same name.
+func (f *VCFake) RunSandbox(ctx context.Context, sandboxConfig vc.SandboxConfig) (vc.VCSandbox, error) {
+	s, err := f.CreateSandbox(ctx, sandboxConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// StartSandbox implements the VC function of the same name.
+func (f *VCFake) StartSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// StatusSandbox implements the VC function of the same name.
+func (f *VCFake) StatusSandbox(ctx context.Context, sandboxID string) (vc.SandboxStatus, error) {
+	if err := f.fault("StatusSandbox"); err != nil {
+		return vc.SandboxStatus{}, err
+	}
+
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return vc.SandboxStatus{}, err
+	}
+
+	return s.Status(), nil
+}
+
+// StopSandbox implements the VC function of the same name.
+func (f *VCFake) StopSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Stop(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ProfileSandbox implements the VC function of the same name. The fake
+// does not record any phase.
+func (f *VCFake) ProfileSandbox(ctx context.Context, sandboxID string) (vc.SandboxTimeline, error) {
+	if err := f.fault("ProfileSandbox"); err != nil {
+		return vc.SandboxTimeline{}, err
+	}
+
+	if _, err := f.lookupSandbox(sandboxID); err != nil {
+		return vc.SandboxTimeline{}, err
+	}
+
+	return vc.SandboxTimeline{}, nil
+}
+
+// CreateContainer implements the VC function of the same name.
+func (f *VCFake) CreateContainer(ctx context.Context, sandboxID string, containerConfig vc.ContainerConfig) (vc.VCSandbox, vc.VCContainer, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := s.CreateContainer(containerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, c, nil
+}
+
+// DeleteContainer implements the VC function of the same name.
+func (f *VCFake) DeleteContainer(ctx context.Context, sandboxID, containerID string) (vc.VCContainer, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.DeleteContainer(containerID)
+}
+
+// EnterContainer implements the VC function of the same name.
+func (f *VCFake) EnterContainer(ctx context.Context, sandboxID, containerID string, cmd types.Cmd) (vc.VCSandbox, vc.VCContainer, *vc.Process, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c, p, err := s.EnterContainer(containerID, cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return s, c, p, nil
+}
+
+// KillContainer implements the VC function of the same name.
+func (f *VCFake) KillContainer(ctx context.Context, sandboxID, containerID string, signal syscall.Signal, all bool) error {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.KillContainer(containerID, signal, all)
+}
+
+// StartContainer implements the VC function of the same name.
+func (f *VCFake) StartContainer(ctx context.Context, sandboxID, containerID string) (vc.VCContainer, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.StartContainer(containerID)
+}
+
+// StatusContainer implements the VC function of the same name.
+func (f *VCFake) StatusContainer(ctx context.Context, sandboxID, containerID string) (vc.ContainerStatus, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return vc.ContainerStatus{}, err
+	}
+
+	return s.StatusContainer(containerID)
+}
+
+// StatsContainer implements the VC function of the same name.
+func (f *VCFake) StatsContainer(ctx context.Context, sandboxID, containerID string) (vc.ContainerStats, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return vc.ContainerStats{}, err
+	}
+
+	return s.StatsContainer(containerID)
+}
+
+// StopContainer implements the VC function of the same name.
+func (f *VCFake) StopContainer(ctx context.Context, sandboxID, containerID string) (vc.VCContainer, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.StopContainer(containerID)
+}
+
+// ProcessListContainer implements the VC function of the same name.
+func (f *VCFake) ProcessListContainer(ctx context.Context, sandboxID, containerID string, options vc.ProcessListOptions) (vc.ProcessList, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ProcessListContainer(containerID, options)
+}
+
+// CopyToContainer implements the VC function of the same name.
+func (f *VCFake) CopyToContainer(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.CopyToContainer(containerID, dst, archive)
+}
+
+// CopyFromContainer implements the VC function of the same name.
+func (f *VCFake) CopyFromContainer(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.CopyFromContainer(containerID, src, archive)
+}
+
+// UpdateContainer implements the VC function of the same name.
+func (f *VCFake) UpdateContainer(ctx context.Context, sandboxID, containerID string, resources specs.LinuxResources) error {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateContainer(containerID, resources)
+}
+
+// PauseContainer implements the VC function of the same name.
+func (f *VCFake) PauseContainer(ctx context.Context, sandboxID, containerID string) error {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.PauseContainer(containerID)
+}
+
+// ResumeContainer implements the VC function of the same name.
+func (f *VCFake) ResumeContainer(ctx context.Context, sandboxID, containerID string) error {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.ResumeContainer(containerID)
+}
+
+// AddDevice implements the VC function of the same name.
+func (f *VCFake) AddDevice(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AddDevice(info)
+}
+
+// RemoveDevice implements the VC function of the same name.
+func (f *VCFake) RemoveDevice(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.RemoveDevice(info)
+}
+
+// ListDevices implements the VC function of the same name.
+func (f *VCFake) ListDevices(ctx context.Context, sandboxID string) ([]api.Device, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ListDevices()
+}
+
+// AddInterface implements the VC function of the same name.
+func (f *VCFake) AddInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AddInterface(inf)
+}
+
+// RemoveInterface implements the VC function of the same name.
+func (f *VCFake) RemoveInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.RemoveInterface(inf)
+}
+
+// ListInterfaces implements the VC function of the same name.
+func (f *VCFake) ListInterfaces(ctx context.Context, sandboxID string) ([]*vcTypes.Interface, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ListInterfaces()
+}
+
+// UpdateRoutes implements the VC function of the same name.
+func (f *VCFake) UpdateRoutes(ctx context.Context, sandboxID string, routes []*vcTypes.Route) ([]*vcTypes.Route, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpdateRoutes(routes)
+}
+
+// ListRoutes implements the VC function of the same name.
+func (f *VCFake) ListRoutes(ctx context.Context, sandboxID string) ([]*vcTypes.Route, error) {
+	s, err := f.lookupSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ListRoutes()
+}
+
+// GarbageCollect implements the VC function of the same name. The fake
+// never leaves orphan resources behind.
+func (f *VCFake) GarbageCollect(ctx context.Context, dryRun bool) ([]vc.OrphanResource, error) {
+	if err := f.fault("GarbageCollect"); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// MigrateSandbox implements the VC function of the same name. Migration
+// is not supported by the fake.
+func (f *VCFake) MigrateSandbox(ctx context.Context, sandboxID string, conn *os.File) error {
+	return errNotSupported
+}
+
+// IncomingSandbox implements the VC function of the same name. Migration
+// is not supported by the fake.
+func (f *VCFake) IncomingSandbox(ctx context.Context, conn *os.File, config vc.SandboxConfig) (vc.VCSandbox, error) {
+	return nil, errNotSupported
+}
@@ -0,0 +1,385 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package vcfake
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"syscall"
+	"testing"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSandboxID   = "testSandboxID"
+	testContainerID = "testContainerID"
+)
+
+var (
+	_ vc.VC          = &VCFake{}
+	_ vc.VCSandbox   = &Sandbox{}
+	_ vc.VCContainer = &Container{}
+)
+
+func testSandboxConfig() vc.SandboxConfig {
+	return vc.SandboxConfig{
+		ID: testSandboxID,
+		Containers: []vc.ContainerConfig{
+			{
+				ID:     testContainerID,
+				RootFs: "/rootfs",
+				Cmd: types.Cmd{
+					Args: []string{"/bin/sh"},
+				},
+			},
+		},
+		Annotations: map[string]string{"foo": "bar"},
+	}
+}
+
+func TestSandboxLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	_, err := f.CreateSandbox(ctx, vc.SandboxConfig{})
+	assert.Error(err)
+
+	s, err := f.CreateSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+	assert.Equal(testSandboxID, s.ID())
+
+	_, err = f.CreateSandbox(ctx, testSandboxConfig())
+	assert.Error(err)
+
+	status, err := f.StatusSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+	assert.Equal(types.StateReady, status.State.State)
+	assert.Len(status.ContainersStatus, 1)
+	assert.Equal(types.StateReady, status.ContainersStatus[0].State.State)
+	assert.Equal("/rootfs", status.ContainersStatus[0].RootFs)
+	assert.Equal("bar", status.Annotations["foo"])
+
+	// A container is only started in a running sandbox.
+	_, err = f.StartContainer(ctx, testSandboxID, testContainerID)
+	assert.Error(err)
+
+	_, err = f.StartSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	cStatus, err := f.StatusContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(types.StateRunning, cStatus.State.State)
+	assert.NotZero(cStatus.PID)
+
+	_, err = f.StartSandbox(ctx, testSandboxID)
+	assert.Error(err)
+
+	// A running sandbox cannot be deleted.
+	_, err = f.DeleteSandbox(ctx, testSandboxID)
+	assert.Error(err)
+
+	_, err = f.PauseSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	cStatus, err = f.StatusContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(types.StatePaused, cStatus.State.State)
+
+	_, err = f.PauseSandbox(ctx, testSandboxID)
+	assert.Error(err)
+
+	_, err = f.ResumeSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	cStatus, err = f.StatusContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(types.StateRunning, cStatus.State.State)
+
+	_, err = f.StopSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	cStatus, err = f.StatusContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(types.StateStopped, cStatus.State.State)
+
+	// Stopping a stopped sandbox is a no-op.
+	_, err = f.StopSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	_, err = f.DeleteSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	_, err = f.FetchSandbox(ctx, testSandboxID)
+	assert.Error(err)
+
+	list, err := f.ListSandbox(ctx)
+	assert.NoError(err)
+	assert.Empty(list)
+}
+
+func TestContainerLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	config := testSandboxConfig()
+	config.Containers = nil
+
+	s, err := f.RunSandbox(ctx, config)
+	assert.NoError(err)
+
+	_, c, err := f.CreateContainer(ctx, testSandboxID, vc.ContainerConfig{ID: testContainerID})
+	assert.NoError(err)
+	assert.Equal(s, c.Sandbox())
+	assert.Equal(c, s.GetContainer(testContainerID))
+	assert.Nil(s.GetContainer("unknown"))
+
+	_, _, err = f.CreateContainer(ctx, testSandboxID, vc.ContainerConfig{ID: testContainerID})
+	assert.Error(err)
+
+	assert.Error(f.ResumeContainer(ctx, testSandboxID, testContainerID))
+
+	_, err = f.StartContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+
+	_, err = f.StartContainer(ctx, testSandboxID, testContainerID)
+	assert.Error(err)
+
+	// A running container cannot be deleted.
+	_, err = f.DeleteContainer(ctx, testSandboxID, testContainerID)
+	assert.Error(err)
+
+	assert.NoError(f.PauseContainer(ctx, testSandboxID, testContainerID))
+	assert.NoError(f.ResumeContainer(ctx, testSandboxID, testContainerID))
+
+	_, err = f.StopContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+
+	// A stopped container is restarted with a new process.
+	pid := c.GetPid()
+	_, err = f.StartContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.NotEqual(pid, c.GetPid())
+
+	_, err = f.StopContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+
+	_, err = f.DeleteContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Empty(s.GetAllContainers())
+
+	_, err = f.StatusContainer(ctx, testSandboxID, testContainerID)
+	assert.Error(err)
+}
+
+func TestProcessExit(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	s, err := f.RunSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+
+	_, _, p, err := f.EnterContainer(ctx, testSandboxID, testContainerID, types.Cmd{Args: []string{"top"}})
+	assert.NoError(err)
+
+	list, err := f.ProcessListContainer(ctx, testSandboxID, testContainerID, vc.ProcessListOptions{Format: "json"})
+	assert.NoError(err)
+	assert.Equal("[1000,1001]", string(list))
+
+	stats, err := f.StatsContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(uint64(2), stats.CgroupStats.PidsStats.Current)
+
+	assert.NoError(f.ExitProcess(testSandboxID, testContainerID, p.Token, 3))
+
+	code, err := s.WaitProcess(testContainerID, p.Token)
+	assert.NoError(err)
+	assert.Equal(int32(3), code)
+
+	// As with the agent, a process is gone once waited for.
+	_, err = s.WaitProcess(testContainerID, p.Token)
+	assert.Error(err)
+
+	// Ignored signals do not terminate the process.
+	assert.NoError(s.SignalProcess(testContainerID, testContainerID, syscall.SIGWINCH, false))
+	assert.NoError(f.KillContainer(ctx, testSandboxID, testContainerID, syscall.SIGTERM, false))
+
+	signals, err := f.Signals(testSandboxID, testContainerID, testContainerID)
+	assert.NoError(err)
+	assert.Equal([]syscall.Signal{syscall.SIGWINCH, syscall.SIGTERM}, signals)
+
+	code, err = s.WaitProcess(testContainerID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(int32(128+syscall.SIGTERM), code)
+
+	// The container keeps running until it is stopped.
+	status, err := s.StatusContainer(testContainerID)
+	assert.NoError(err)
+	assert.Equal(types.StateRunning, status.State.State)
+}
+
+func TestProcessExitKillsExecs(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	s, err := f.RunSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+
+	_, _, p, err := f.EnterContainer(ctx, testSandboxID, testContainerID, types.Cmd{})
+	assert.NoError(err)
+
+	// The processes entered into the container are killed along with its
+	// process.
+	assert.NoError(f.ExitProcess(testSandboxID, testContainerID, testContainerID, 0))
+
+	code, err := s.WaitProcess(testContainerID, p.Token)
+	assert.NoError(err)
+	assert.Equal(int32(128+syscall.SIGKILL), code)
+}
+
+func TestProcessIO(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	// The process echoes its input.
+	f.Behavior = func(cmd types.Cmd, stdin io.Reader, stdout, stderr io.Writer) int32 {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil {
+			return 1
+		}
+
+		io.WriteString(stdout, line)
+		return 0
+	}
+
+	s, err := f.RunSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+
+	stdin, stdout, stderr, err := s.IOStream(testContainerID, testContainerID)
+	assert.NoError(err)
+
+	go io.WriteString(stdin, "hello\n")
+
+	out, err := ioutil.ReadAll(stdout)
+	assert.NoError(err)
+	assert.Equal("hello\n", string(out))
+
+	out, err = ioutil.ReadAll(stderr)
+	assert.NoError(err)
+	assert.Empty(out)
+
+	code, err := s.WaitProcess(testContainerID, testContainerID)
+	assert.NoError(err)
+	assert.Zero(code)
+
+	_, err = io.WriteString(stdin, "closed\n")
+	assert.Error(err)
+}
+
+func TestContainerStats(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	_, err := f.CreateSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+
+	// Statistics are only collected in a running sandbox.
+	_, err = f.StatsContainer(ctx, testSandboxID, testContainerID)
+	assert.Error(err)
+
+	_, err = f.StartSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+
+	stats := vc.ContainerStats{
+		CgroupStats: &vc.CgroupStats{
+			MemoryStats: vc.MemoryStats{Cache: 1024},
+		},
+	}
+	assert.NoError(f.SetContainerStats(testSandboxID, testContainerID, stats))
+
+	got, err := f.StatsContainer(ctx, testSandboxID, testContainerID)
+	assert.NoError(err)
+	assert.Equal(stats, got)
+}
+
+func TestFaults(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	f.InjectFault("CreateSandbox", Fault{Err: ErrAgentUnavailable, Count: 1})
+
+	_, err := f.CreateSandbox(ctx, testSandboxConfig())
+	assert.Equal(ErrAgentUnavailable, err)
+
+	s, err := f.CreateSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+
+	// The fault covers the VCSandbox function of the same operation.
+	f.InjectFault("StartSandbox", Fault{Err: ErrAgentUnavailable})
+
+	assert.Equal(ErrAgentUnavailable, s.Start())
+	_, err = f.StartSandbox(ctx, testSandboxID)
+	assert.Equal(ErrAgentUnavailable, err)
+
+	f.ClearFaults()
+
+	delay := 50 * time.Millisecond
+	f.InjectFault("StartSandbox", Fault{Delay: delay})
+
+	start := time.Now()
+	_, err = f.StartSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+	assert.True(time.Since(start) >= delay)
+
+	watcher, err := s.Monitor()
+	assert.NoError(err)
+
+	crash := errors.New("crash")
+	assert.NoError(f.CrashSandbox(testSandboxID, crash))
+	assert.Equal(crash, <-watcher)
+}
+
+func TestDevices(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := New()
+
+	_, err := f.RunSandbox(ctx, testSandboxConfig())
+	assert.NoError(err)
+
+	d, err := f.AddDevice(ctx, testSandboxID, config.DeviceInfo{Major: 8, Minor: 1})
+	assert.NoError(err)
+	assert.NotEmpty(d.DeviceID())
+	assert.Equal(uint(1), d.GetAttachCount())
+
+	_, err = f.AddDevice(ctx, testSandboxID, config.DeviceInfo{ID: d.DeviceID()})
+	assert.Error(err)
+
+	devices, err := f.ListDevices(ctx, testSandboxID)
+	assert.NoError(err)
+	assert.Len(devices, 1)
+
+	removed, err := f.RemoveDevice(ctx, testSandboxID, config.DeviceInfo{Major: 8, Minor: 1})
+	assert.NoError(err)
+	assert.Equal(d, removed)
+
+	_, err = f.RemoveDevice(ctx, testSandboxID, config.DeviceInfo{ID: d.DeviceID()})
+	assert.Error(err)
+}
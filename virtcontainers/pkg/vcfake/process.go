@@ -0,0 +1,159 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package vcfake
+
+import (
+	"io"
+	"syscall"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/uuid"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+)
+
+// Behavior simulates the workload of a process. It is run when the process
+// starts, with the process side of its IO streams, and the process exits
+// with the returned code once it returns.
+//
+// The streams are unbuffered pipes: a process writing to stdout or stderr
+// only makes progress while the other end of the stream is read, as with
+// the real agent. Once the process has exited, reading stdin fails and
+// writing stdout or stderr fails.
+type Behavior func(cmd types.Cmd, stdin io.Reader, stdout, stderr io.Writer) int32
+
+// process is a fake process of a container.
+type process struct {
+	vc.Process
+
+	cmd types.Cmd
+
+	// The caller side of the IO streams.
+	stdin  *io.PipeWriter
+	stdout *io.PipeReader
+	stderr *io.PipeReader
+
+	// The process side of the IO streams.
+	procStdin  *io.PipeReader
+	procStdout *io.PipeWriter
+	procStderr *io.PipeWriter
+
+	started  bool
+	exited   chan struct{}
+	exitCode int32
+
+	// onExit, if set, is called once the process has exited, with the
+	// lock of the fake held.
+	onExit func()
+
+	signals []syscall.Signal
+	height  uint32
+	width   uint32
+}
+
+func newProcess(cmd types.Cmd, pid int) *process {
+	p := &process{
+		Process: vc.Process{
+			Token: uuid.Generate().String(),
+			Pid:   pid,
+		},
+		cmd:    cmd,
+		exited: make(chan struct{}),
+	}
+
+	p.procStdin, p.stdin = io.Pipe()
+	p.stdout, p.procStdout = io.Pipe()
+	p.stderr, p.procStderr = io.Pipe()
+
+	return p
+}
+
+// start starts the process, running behavior in the background if any.
+// The lock of the fake must be held.
+func (p *process) start(f *VCFake, behavior Behavior) {
+	if p.started {
+		return
+	}
+
+	p.started = true
+	p.StartTime = time.Now()
+
+	if behavior == nil {
+		return
+	}
+
+	go func() {
+		code := behavior(p.cmd, p.procStdin, p.procStdout, p.procStderr)
+
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		p.exit(code)
+	}()
+}
+
+// hasExited checks if the process has exited. The lock of the fake must
+// be held.
+func (p *process) hasExited() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// exit makes the process exit with "code", unless it has already exited.
+// The lock of the fake must be held.
+func (p *process) exit(code int32) {
+	if p.hasExited() {
+		return
+	}
+
+	p.exitCode = code
+
+	p.procStdin.Close()
+	p.procStdout.Close()
+	p.procStderr.Close()
+
+	close(p.exited)
+
+	if p.onExit != nil {
+		p.onExit()
+	}
+}
+
+// signal delivers a signal to the process, which exits if the default
+// action of the signal terminates it. The lock of the fake must be held.
+func (p *process) signal(signal syscall.Signal) {
+	if p.hasExited() {
+		return
+	}
+
+	p.signals = append(p.signals, signal)
+
+	if terminates(signal) {
+		p.exit(signalExitCode(signal))
+	}
+}
+
+// terminates checks if the default action of a signal terminates the
+// process receiving it.
+func terminates(signal syscall.Signal) bool {
+	switch signal {
+	case 0, syscall.SIGCHLD, syscall.SIGCONT, syscall.SIGURG, syscall.SIGWINCH,
+		syscall.SIGSTOP, syscall.SIGTSTP, syscall.SIGTTIN, syscall.SIGTTOU:
+		return false
+	}
+
+	return true
+}
+
+// signalExitCode returns the exit code of a process killed by a signal,
+// as reported by a shell.
+func signalExitCode(signal syscall.Signal) int32 {
+	return 128 + int32(signal)
+}
@@ -0,0 +1,933 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package vcfake
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"syscall"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+	vcTypes "github.com/kata-containers/runtime/virtcontainers/pkg/types"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/uuid"
+	"github.com/kata-containers/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Sandbox is an in-memory implementation of the VCSandbox interface.
+type Sandbox struct {
+	fake *VCFake
+
+	id         string
+	config     vc.SandboxConfig
+	state      types.State
+	containers []*Container
+
+	devices    []api.Device
+	interfaces []*vcTypes.Interface
+	routes     []*vcTypes.Route
+
+	watchers []chan error
+}
+
+func newSandbox(f *VCFake, sandboxConfig vc.SandboxConfig) *Sandbox {
+	s := &Sandbox{
+		fake:   f,
+		id:     sandboxConfig.ID,
+		config: sandboxConfig,
+		state: types.State{
+			State: types.StateReady,
+		},
+	}
+
+	// The containers are added as they are created.
+	s.config.Containers = nil
+	s.config.Annotations = copyAnnotations(sandboxConfig.Annotations)
+
+	return s
+}
+
+// findContainer returns a container of the sandbox. The lock of the fake
+// must be held.
+func (s *Sandbox) findContainer(containerID string) (*Container, error) {
+	if containerID == "" {
+		return nil, errNeedContainerID
+	}
+
+	for _, c := range s.containers {
+		if c.id == containerID {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Could not find the container %q from the sandbox %q containers list",
+		containerID, s.id)
+}
+
+// checkRunning checks the sandbox is running. The lock of the fake must be
+// held.
+func (s *Sandbox) checkRunning() error {
+	if s.state.State != types.StateRunning {
+		return fmt.Errorf("Sandbox not running")
+	}
+
+	return nil
+}
+
+// createContainer adds a new container to the sandbox. The lock of the
+// fake must be held.
+func (s *Sandbox) createContainer(contConfig vc.ContainerConfig) (*Container, error) {
+	if contConfig.ID == "" {
+		return nil, errNeedContainerID
+	}
+
+	if s.state.State != types.StateReady && s.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Sandbox not ready or running, impossible to create the container")
+	}
+
+	if _, err := s.findContainer(contConfig.ID); err == nil {
+		return nil, fmt.Errorf("Container %s already exists in sandbox %s", contConfig.ID, s.id)
+	}
+
+	c := newContainer(s, contConfig)
+
+	s.containers = append(s.containers, c)
+	s.config.Containers = append(s.config.Containers, contConfig)
+
+	return c, nil
+}
+
+// status returns the status of the sandbox. The lock of the fake must be
+// held.
+func (s *Sandbox) status() vc.SandboxStatus {
+	var contStatus []vc.ContainerStatus
+	for _, c := range s.containers {
+		contStatus = append(contStatus, c.status())
+	}
+
+	return vc.SandboxStatus{
+		ID:               s.id,
+		State:            s.state,
+		Hypervisor:       s.config.HypervisorType,
+		HypervisorConfig: s.config.HypervisorConfig,
+		Agent:            s.config.AgentType,
+		ContainersStatus: contStatus,
+		Annotations:      copyAnnotations(s.config.Annotations),
+	}
+}
+
+// ID implements the VCSandbox function of the same name.
+func (s *Sandbox) ID() string {
+	return s.id
+}
+
+// Annotations implements the VCSandbox function of the same name.
+func (s *Sandbox) Annotations(key string) (string, error) {
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	value, exist := s.config.Annotations[key]
+	if !exist {
+		return "", fmt.Errorf("Annotations key %s does not exist", key)
+	}
+
+	return value, nil
+}
+
+// SetAnnotations implements the VCSandbox function of the same name.
+func (s *Sandbox) SetAnnotations(annotations map[string]string) error {
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	for k, v := range annotations {
+		s.config.Annotations[k] = v
+	}
+
+	return nil
+}
+
+// GetAnnotations implements the VCSandbox function of the same name.
+func (s *Sandbox) GetAnnotations() map[string]string {
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	return copyAnnotations(s.config.Annotations)
+}
+
+// GetNetNs implements the VCSandbox function of the same name.
+func (s *Sandbox) GetNetNs() string {
+	return s.config.NetworkConfig.NetNSPath
+}
+
+// GetAllContainers implements the VCSandbox function of the same name.
+func (s *Sandbox) GetAllContainers() []vc.VCContainer {
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	ifa := make([]vc.VCContainer, len(s.containers))
+	for i, c := range s.containers {
+		ifa[i] = c
+	}
+
+	return ifa
+}
+
+// GetContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) GetContainer(containerID string) vc.VCContainer {
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil
+	}
+
+	return c
+}
+
+// Release implements the VCSandbox function of the same name.
+func (s *Sandbox) Release() error {
+	return s.fake.fault("Release")
+}
+
+// Start implements the VCSandbox function of the same name.
+func (s *Sandbox) Start() error {
+	if err := s.fake.fault("StartSandbox"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.state.ValidTransition(s.state.State, types.StateRunning); err != nil {
+		return err
+	}
+
+	s.state.State = types.StateRunning
+
+	for _, c := range s.containers {
+		if err := c.start(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop implements the VCSandbox function of the same name.
+func (s *Sandbox) Stop() error {
+	if err := s.fake.fault("StopSandbox"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if s.state.State == types.StateStopped {
+		return nil
+	}
+
+	if err := s.state.ValidTransition(s.state.State, types.StateStopped); err != nil {
+		return err
+	}
+
+	for _, c := range s.containers {
+		if err := c.stop(); err != nil {
+			return err
+		}
+	}
+
+	s.state.State = types.StateStopped
+
+	return nil
+}
+
+// Pause implements the VCSandbox function of the same name.
+func (s *Sandbox) Pause() error {
+	if err := s.fake.fault("PauseSandbox"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.state.ValidTransition(s.state.State, types.StatePaused); err != nil {
+		return err
+	}
+
+	for _, c := range s.containers {
+		if c.state.State == types.StateRunning {
+			c.state.State = types.StatePaused
+		}
+	}
+
+	s.state.State = types.StatePaused
+
+	return nil
+}
+
+// Resume implements the VCSandbox function of the same name.
+func (s *Sandbox) Resume() error {
+	if err := s.fake.fault("ResumeSandbox"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if s.state.State != types.StatePaused {
+		return fmt.Errorf("Sandbox not paused, impossible to resume")
+	}
+
+	for _, c := range s.containers {
+		if c.state.State == types.StatePaused {
+			c.state.State = types.StateRunning
+		}
+	}
+
+	s.state.State = types.StateRunning
+
+	return nil
+}
+
+// Monitor implements the VCSandbox function of the same name. The errors
+// are reported with VCFake.CrashSandbox().
+func (s *Sandbox) Monitor() (chan error, error) {
+	if err := s.fake.fault("Monitor"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	watcher := make(chan error, 1)
+	s.watchers = append(s.watchers, watcher)
+
+	return watcher, nil
+}
+
+// Delete implements the VCSandbox function of the same name.
+func (s *Sandbox) Delete() error {
+	if err := s.fake.fault("DeleteSandbox"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if s.state.State != types.StateReady &&
+		s.state.State != types.StatePaused &&
+		s.state.State != types.StateStopped {
+		return fmt.Errorf("Sandbox not ready, paused or stopped, impossible to delete")
+	}
+
+	for _, c := range s.containers {
+		if err := c.delete(); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range s.watchers {
+		close(w)
+	}
+	s.watchers = nil
+
+	delete(s.fake.sandboxes, s.id)
+
+	return nil
+}
+
+// Status implements the VCSandbox function of the same name.
+func (s *Sandbox) Status() vc.SandboxStatus {
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	return s.status()
+}
+
+// CreateContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) CreateContainer(contConfig vc.ContainerConfig) (vc.VCContainer, error) {
+	if err := s.fake.fault("CreateContainer"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.createContainer(contConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// DeleteContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) DeleteContainer(containerID string) (vc.VCContainer, error) {
+	if err := s.fake.fault("DeleteContainer"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.delete(); err != nil {
+		return nil, err
+	}
+
+	for i, ctr := range s.containers {
+		if ctr == c {
+			s.containers = append(s.containers[:i], s.containers[i+1:]...)
+			break
+		}
+	}
+
+	for i, contConfig := range s.config.Containers {
+		if contConfig.ID == containerID {
+			s.config.Containers = append(s.config.Containers[:i], s.config.Containers[i+1:]...)
+			break
+		}
+	}
+
+	return c, nil
+}
+
+// StartContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) StartContainer(containerID string) (vc.VCContainer, error) {
+	if err := s.fake.fault("StartContainer"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// StopContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) StopContainer(containerID string) (vc.VCContainer, error) {
+	if err := s.fake.fault("StopContainer"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.state.State != types.StateStopped &&
+		s.state.State != types.StateReady && s.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Sandbox not ready or running, impossible to stop the container")
+	}
+
+	if err := c.stop(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// KillContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) KillContainer(containerID string, signal syscall.Signal, all bool) error {
+	if err := s.fake.fault("KillContainer"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.signalProcess(c.id, signal, all)
+}
+
+// StatusContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) StatusContainer(containerID string) (vc.ContainerStatus, error) {
+	if err := s.fake.fault("StatusContainer"); err != nil {
+		return vc.ContainerStatus{}, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return vc.ContainerStatus{}, err
+	}
+
+	return c.status(), nil
+}
+
+// StatsContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) StatsContainer(containerID string) (vc.ContainerStats, error) {
+	if err := s.fake.fault("StatsContainer"); err != nil {
+		return vc.ContainerStats{}, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return vc.ContainerStats{}, err
+	}
+
+	return c.stats()
+}
+
+// PauseContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) PauseContainer(containerID string) error {
+	if err := s.fake.fault("PauseContainer"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.pause()
+}
+
+// ResumeContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) ResumeContainer(containerID string) error {
+	if err := s.fake.fault("ResumeContainer"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.resume()
+}
+
+// EnterContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) EnterContainer(containerID string, cmd types.Cmd) (vc.VCContainer, *vc.Process, error) {
+	if err := s.fake.fault("EnterContainer"); err != nil {
+		return nil, nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := c.enter(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	process := p.Process
+
+	return c, &process, nil
+}
+
+// UpdateContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) UpdateContainer(containerID string, resources specs.LinuxResources) error {
+	if err := s.fake.fault("UpdateContainer"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.update(resources)
+}
+
+// ProcessListContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) ProcessListContainer(containerID string, options vc.ProcessListOptions) (vc.ProcessList, error) {
+	if err := s.fake.fault("ProcessListContainer"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.processList(options)
+}
+
+// CopyToContainer implements the VCSandbox function of the same name. The
+// archive is read and discarded.
+func (s *Sandbox) CopyToContainer(containerID, dst string, archive io.Reader) error {
+	if err := s.checkCopy("CopyToContainer", containerID); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(ioutil.Discard, archive)
+	return err
+}
+
+// CopyFromContainer implements the VCSandbox function of the same name. An
+// empty archive is written.
+func (s *Sandbox) CopyFromContainer(containerID, src string, archive io.Writer) error {
+	if err := s.checkCopy("CopyFromContainer", containerID); err != nil {
+		return err
+	}
+
+	return emptyArchive(archive)
+}
+
+// checkCopy checks files can be copied to or from a container. The archive
+// is streamed without the lock of the fake, as its other end may call the
+// fake.
+func (s *Sandbox) checkCopy(op, containerID string) error {
+	if err := s.fake.fault(op); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.checkCopy()
+}
+
+// WaitProcess implements the VCSandbox function of the same name.
+func (s *Sandbox) WaitProcess(containerID, processID string) (int32, error) {
+	if err := s.fake.fault("WaitProcess"); err != nil {
+		return 0, err
+	}
+
+	s.fake.lock.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.fake.lock.Unlock()
+		return 0, err
+	}
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		s.fake.lock.Unlock()
+		return 0, err
+	}
+
+	p, err := c.waitable(processID)
+	s.fake.lock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	<-p.exited
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	// As with the agent, exec processes are gone once waited for.
+	if c.execs[p.Token] == p {
+		delete(c.execs, p.Token)
+	}
+
+	return p.exitCode, nil
+}
+
+// SignalProcess implements the VCSandbox function of the same name.
+func (s *Sandbox) SignalProcess(containerID, processID string, signal syscall.Signal, all bool) error {
+	if err := s.fake.fault("SignalProcess"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.checkRunning(); err != nil {
+		return err
+	}
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.signalProcess(processID, signal, all)
+}
+
+// WinsizeProcess implements the VCSandbox function of the same name.
+func (s *Sandbox) WinsizeProcess(containerID, processID string, height, width uint32) error {
+	if err := s.fake.fault("WinsizeProcess"); err != nil {
+		return err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.checkRunning(); err != nil {
+		return err
+	}
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.winsizeProcess(processID, height, width)
+}
+
+// IOStream implements the VCSandbox function of the same name.
+func (s *Sandbox) IOStream(containerID, processID string) (io.WriteCloser, io.Reader, io.Reader, error) {
+	if err := s.fake.fault("IOStream"); err != nil {
+		return nil, nil, nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.checkRunning(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return c.ioStream(processID)
+}
+
+// PortForward implements the VCSandbox function of the same name. Port
+// forwarding is not supported by the fake.
+func (s *Sandbox) PortForward(port uint32) (io.ReadWriteCloser, error) {
+	if err := s.fake.fault("PortForward"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if err := s.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	if port == 0 || port > 65535 {
+		return nil, fmt.Errorf("Invalid port %d", port)
+	}
+
+	return nil, errNotSupported
+}
+
+// AddDevice implements the VCSandbox function of the same name.
+func (s *Sandbox) AddDevice(info config.DeviceInfo) (api.Device, error) {
+	if err := s.fake.fault("AddDevice"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	if info.ID == "" {
+		info.ID = uuid.Generate().String()
+	} else if s.findDevice(info) != nil {
+		return nil, fmt.Errorf("Device %s already exists", info.ID)
+	}
+
+	d := drivers.NewGenericDevice(&info)
+	if err := d.Attach(nil); err != nil {
+		return nil, err
+	}
+
+	s.devices = append(s.devices, d)
+
+	return d, nil
+}
+
+// RemoveDevice implements the VCSandbox function of the same name. The
+// device is looked up by its ID if any, or by its major and minor numbers
+// otherwise.
+func (s *Sandbox) RemoveDevice(info config.DeviceInfo) (api.Device, error) {
+	if err := s.fake.fault("RemoveDevice"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	d := s.findDevice(info)
+	if d == nil {
+		return nil, fmt.Errorf("Device not found")
+	}
+
+	for _, c := range s.containers {
+		for _, dev := range c.config.DeviceInfos {
+			if dev.ID == d.DeviceID() {
+				return nil, fmt.Errorf("device %s is used by container %s", d.DeviceID(), c.id)
+			}
+		}
+	}
+
+	if err := d.Detach(nil); err != nil {
+		return nil, err
+	}
+
+	for i, dev := range s.devices {
+		if dev == d {
+			s.devices = append(s.devices[:i], s.devices[i+1:]...)
+			break
+		}
+	}
+
+	return d, nil
+}
+
+// findDevice returns a device of the sandbox, looked up by its ID if any,
+// or by its major and minor numbers otherwise. The lock of the fake must be
+// held.
+func (s *Sandbox) findDevice(info config.DeviceInfo) api.Device {
+	for _, d := range s.devices {
+		if info.ID != "" {
+			if d.DeviceID() == info.ID {
+				return d
+			}
+			continue
+		}
+
+		if major, minor := d.GetMajorMinor(); major == info.Major && minor == info.Minor {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// ListDevices implements the VCSandbox function of the same name.
+func (s *Sandbox) ListDevices() ([]api.Device, error) {
+	if err := s.fake.fault("ListDevices"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	return append([]api.Device{}, s.devices...), nil
+}
+
+// AddInterface implements the VCSandbox function of the same name.
+func (s *Sandbox) AddInterface(inf *vcTypes.Interface) (*vcTypes.Interface, error) {
+	if err := s.fake.fault("AddInterface"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	for _, i := range s.interfaces {
+		if i.HwAddr == inf.HwAddr {
+			return nil, fmt.Errorf("Interface with hardware address %s already exists", inf.HwAddr)
+		}
+	}
+
+	s.interfaces = append(s.interfaces, inf)
+
+	return inf, nil
+}
+
+// RemoveInterface implements the VCSandbox function of the same name.
+func (s *Sandbox) RemoveInterface(inf *vcTypes.Interface) (*vcTypes.Interface, error) {
+	if err := s.fake.fault("RemoveInterface"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	for i, removed := range s.interfaces {
+		if removed.HwAddr == inf.HwAddr {
+			s.interfaces = append(s.interfaces[:i], s.interfaces[i+1:]...)
+			return removed, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ListInterfaces implements the VCSandbox function of the same name.
+func (s *Sandbox) ListInterfaces() ([]*vcTypes.Interface, error) {
+	if err := s.fake.fault("ListInterfaces"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	return append([]*vcTypes.Interface{}, s.interfaces...), nil
+}
+
+// UpdateRoutes implements the VCSandbox function of the same name.
+func (s *Sandbox) UpdateRoutes(routes []*vcTypes.Route) ([]*vcTypes.Route, error) {
+	if err := s.fake.fault("UpdateRoutes"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	s.routes = append([]*vcTypes.Route{}, routes...)
+
+	return routes, nil
+}
+
+// ListRoutes implements the VCSandbox function of the same name.
+func (s *Sandbox) ListRoutes() ([]*vcTypes.Route, error) {
+	if err := s.fake.fault("ListRoutes"); err != nil {
+		return nil, err
+	}
+
+	s.fake.lock.Lock()
+	defer s.fake.lock.Unlock()
+
+	return append([]*vcTypes.Route{}, s.routes...), nil
+}
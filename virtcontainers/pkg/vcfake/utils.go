@@ -0,0 +1,27 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package vcfake
+
+import (
+	"archive/tar"
+	"io"
+)
+
+// copyAnnotations returns a copy of annotations, never nil so that it can
+// be updated.
+func copyAnnotations(annotations map[string]string) map[string]string {
+	copied := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// emptyArchive writes an empty tar archive.
+func emptyArchive(w io.Writer) error {
+	return tar.NewWriter(w).Close()
+}
@@ -18,6 +18,8 @@ package vcmock
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"syscall"
 
 	vc "github.com/kata-containers/runtime/virtcontainers"
@@ -119,6 +121,15 @@ func (m *VCMock) StatusSandbox(ctx context.Context, sandboxID string) (vc.Sandbo
 	return vc.SandboxStatus{}, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
 }
 
+// ProfileSandbox implements the VC function of the same name.
+func (m *VCMock) ProfileSandbox(ctx context.Context, sandboxID string) (vc.SandboxTimeline, error) {
+	if m.ProfileSandboxFunc != nil {
+		return m.ProfileSandboxFunc(ctx, sandboxID)
+	}
+
+	return vc.SandboxTimeline{}, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
+}
+
 // PauseSandbox implements the VC function of the same name.
 func (m *VCMock) PauseSandbox(ctx context.Context, sandboxID string) (vc.VCSandbox, error) {
 	if m.PauseSandboxFunc != nil {
@@ -218,6 +229,24 @@ func (m *VCMock) ProcessListContainer(ctx context.Context, sandboxID, containerI
 	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v, containerID: %v", mockErrorPrefix, getSelf(), m, sandboxID, containerID)
 }
 
+// CopyToContainer implements the VC function of the same name.
+func (m *VCMock) CopyToContainer(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error {
+	if m.CopyToContainerFunc != nil {
+		return m.CopyToContainerFunc(ctx, sandboxID, containerID, dst, archive)
+	}
+
+	return fmt.Errorf("%s: %s (%+v): sandboxID: %v, containerID: %v", mockErrorPrefix, getSelf(), m, sandboxID, containerID)
+}
+
+// CopyFromContainer implements the VC function of the same name.
+func (m *VCMock) CopyFromContainer(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error {
+	if m.CopyFromContainerFunc != nil {
+		return m.CopyFromContainerFunc(ctx, sandboxID, containerID, src, archive)
+	}
+
+	return fmt.Errorf("%s: %s (%+v): sandboxID: %v, containerID: %v", mockErrorPrefix, getSelf(), m, sandboxID, containerID)
+}
+
 // UpdateContainer implements the VC function of the same name.
 func (m *VCMock) UpdateContainer(ctx context.Context, sandboxID, containerID string, resources specs.LinuxResources) error {
 	if m.UpdateContainerFunc != nil {
@@ -254,6 +283,24 @@ func (m *VCMock) AddDevice(ctx context.Context, sandboxID string, info config.De
 	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
 }
 
+// RemoveDevice implements the VC function of the same name.
+func (m *VCMock) RemoveDevice(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error) {
+	if m.RemoveDeviceFunc != nil {
+		return m.RemoveDeviceFunc(ctx, sandboxID, info)
+	}
+
+	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
+}
+
+// ListDevices implements the VC function of the same name.
+func (m *VCMock) ListDevices(ctx context.Context, sandboxID string) ([]api.Device, error) {
+	if m.ListDevicesFunc != nil {
+		return m.ListDevicesFunc(ctx, sandboxID)
+	}
+
+	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
+}
+
 // AddInterface implements the VC function of the same name.
 func (m *VCMock) AddInterface(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error) {
 	if m.AddInterfaceFunc != nil {
@@ -298,3 +345,30 @@ func (m *VCMock) ListRoutes(ctx context.Context, sandboxID string) ([]*vcTypes.R
 
 	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
 }
+
+// GarbageCollect implements the VC function of the same name.
+func (m *VCMock) GarbageCollect(ctx context.Context, dryRun bool) ([]vc.OrphanResource, error) {
+	if m.GarbageCollectFunc != nil {
+		return m.GarbageCollectFunc(ctx, dryRun)
+	}
+
+	return nil, fmt.Errorf("%s: %s (%+v): dryRun: %v", mockErrorPrefix, getSelf(), m, dryRun)
+}
+
+// MigrateSandbox implements the VC function of the same name.
+func (m *VCMock) MigrateSandbox(ctx context.Context, sandboxID string, conn *os.File) error {
+	if m.MigrateSandboxFunc != nil {
+		return m.MigrateSandboxFunc(ctx, sandboxID, conn)
+	}
+
+	return fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
+}
+
+// IncomingSandbox implements the VC function of the same name.
+func (m *VCMock) IncomingSandbox(ctx context.Context, conn *os.File, config vc.SandboxConfig) (vc.VCSandbox, error) {
+	if m.IncomingSandboxFunc != nil {
+		return m.IncomingSandboxFunc(ctx, conn, config)
+	}
+
+	return nil, fmt.Errorf("%s: %s (%+v)", mockErrorPrefix, getSelf(), m)
+}
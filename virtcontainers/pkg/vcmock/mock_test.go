@@ -7,11 +7,15 @@ package vcmock
 
 import (
 	"context"
+	"io"
+	"os"
 	"reflect"
 	"syscall"
 	"testing"
 
 	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	deviceConfig "github.com/kata-containers/runtime/virtcontainers/device/config"
 	"github.com/kata-containers/runtime/virtcontainers/factory"
 	vcTypes "github.com/kata-containers/runtime/virtcontainers/pkg/types"
 	"github.com/kata-containers/runtime/virtcontainers/types"
@@ -332,6 +336,33 @@ func TestVCMockStatusSandbox(t *testing.T) {
 	assert.True(IsMockError(err))
 }
 
+func TestVCMockProfileSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.ProfileSandboxFunc)
+
+	ctx := context.Background()
+	_, err := m.ProfileSandbox(ctx, testSandboxID)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.ProfileSandboxFunc = func(ctx context.Context, sandboxID string) (vc.SandboxTimeline, error) {
+		return vc.SandboxTimeline{}, nil
+	}
+
+	timeline, err := m.ProfileSandbox(ctx, testSandboxID)
+	assert.NoError(err)
+	assert.Equal(timeline, vc.SandboxTimeline{})
+
+	// reset
+	m.ProfileSandboxFunc = nil
+
+	_, err = m.ProfileSandbox(ctx, testSandboxID)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
 func TestVCMockStopSandbox(t *testing.T) {
 	assert := assert.New(t)
 
@@ -622,6 +653,58 @@ func TestVCMockProcessListContainer(t *testing.T) {
 	assert.True(IsMockError(err))
 }
 
+func TestVCMockCopyToContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.CopyToContainerFunc)
+
+	ctx := context.Background()
+	err := m.CopyToContainer(ctx, testSandboxID, testContainerID, "/tmp", nil)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.CopyToContainerFunc = func(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error {
+		return nil
+	}
+
+	err = m.CopyToContainer(ctx, testSandboxID, testContainerID, "/tmp", nil)
+	assert.NoError(err)
+
+	// reset
+	m.CopyToContainerFunc = nil
+
+	err = m.CopyToContainer(ctx, testSandboxID, testContainerID, "/tmp", nil)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
+func TestVCMockCopyFromContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.CopyFromContainerFunc)
+
+	ctx := context.Background()
+	err := m.CopyFromContainer(ctx, testSandboxID, testContainerID, "/tmp", nil)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.CopyFromContainerFunc = func(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error {
+		return nil
+	}
+
+	err = m.CopyFromContainer(ctx, testSandboxID, testContainerID, "/tmp", nil)
+	assert.NoError(err)
+
+	// reset
+	m.CopyFromContainerFunc = nil
+
+	err = m.CopyFromContainer(ctx, testSandboxID, testContainerID, "/tmp", nil)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
 func TestVCMockFetchSandbox(t *testing.T) {
 	assert := assert.New(t)
 
@@ -737,6 +820,60 @@ func TestVCMockSetVMFactory(t *testing.T) {
 	assert.Equal(factoryTriggered, 1)
 }
 
+func TestVCMockRemoveDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	config := &vc.SandboxConfig{}
+	assert.Nil(m.RemoveDeviceFunc)
+
+	ctx := context.Background()
+	_, err := m.RemoveDevice(ctx, config.ID, deviceConfig.DeviceInfo{})
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.RemoveDeviceFunc = func(ctx context.Context, sid string, info deviceConfig.DeviceInfo) (api.Device, error) {
+		return nil, nil
+	}
+
+	_, err = m.RemoveDevice(ctx, config.ID, deviceConfig.DeviceInfo{})
+	assert.NoError(err)
+
+	// reset
+	m.RemoveDeviceFunc = nil
+
+	_, err = m.RemoveDevice(ctx, config.ID, deviceConfig.DeviceInfo{})
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
+func TestVCMockListDevices(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	config := &vc.SandboxConfig{}
+	assert.Nil(m.ListDevicesFunc)
+
+	ctx := context.Background()
+	_, err := m.ListDevices(ctx, config.ID)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.ListDevicesFunc = func(ctx context.Context, sid string) ([]api.Device, error) {
+		return nil, nil
+	}
+
+	_, err = m.ListDevices(ctx, config.ID)
+	assert.NoError(err)
+
+	// reset
+	m.ListDevicesFunc = nil
+
+	_, err = m.ListDevices(ctx, config.ID)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
 func TestVCMockAddInterface(t *testing.T) {
 	assert := assert.New(t)
 
@@ -871,3 +1008,83 @@ func TestVCMockListRoutes(t *testing.T) {
 	assert.Error(err)
 	assert.True(IsMockError(err))
 }
+
+func TestVCMockGarbageCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.GarbageCollectFunc)
+
+	ctx := context.Background()
+	_, err := m.GarbageCollect(ctx, true)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.GarbageCollectFunc = func(ctx context.Context, dryRun bool) ([]vc.OrphanResource, error) {
+		return []vc.OrphanResource{}, nil
+	}
+
+	orphans, err := m.GarbageCollect(ctx, true)
+	assert.NoError(err)
+	assert.Equal([]vc.OrphanResource{}, orphans)
+
+	// reset
+	m.GarbageCollectFunc = nil
+
+	_, err = m.GarbageCollect(ctx, true)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
+func TestVCMockMigrateSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.MigrateSandboxFunc)
+
+	ctx := context.Background()
+	err := m.MigrateSandbox(ctx, testSandboxID, nil)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.MigrateSandboxFunc = func(ctx context.Context, sandboxID string, conn *os.File) error {
+		return nil
+	}
+
+	err = m.MigrateSandbox(ctx, testSandboxID, nil)
+	assert.NoError(err)
+
+	// reset
+	m.MigrateSandboxFunc = nil
+
+	err = m.MigrateSandbox(ctx, testSandboxID, nil)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
+func TestVCMockIncomingSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.IncomingSandboxFunc)
+
+	ctx := context.Background()
+	_, err := m.IncomingSandbox(ctx, nil, vc.SandboxConfig{})
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.IncomingSandboxFunc = func(ctx context.Context, conn *os.File, config vc.SandboxConfig) (vc.VCSandbox, error) {
+		return &Sandbox{MockID: testSandboxID}, nil
+	}
+
+	sandbox, err := m.IncomingSandbox(ctx, nil, vc.SandboxConfig{})
+	assert.NoError(err)
+	assert.Equal(testSandboxID, sandbox.ID())
+
+	// reset
+	m.IncomingSandboxFunc = nil
+
+	_, err = m.IncomingSandbox(ctx, nil, vc.SandboxConfig{})
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
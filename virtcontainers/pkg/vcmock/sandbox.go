@@ -163,6 +163,16 @@ func (s *Sandbox) ProcessListContainer(containerID string, options vc.ProcessLis
 	return nil, nil
 }
 
+// CopyToContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) CopyToContainer(containerID, dst string, archive io.Reader) error {
+	return nil
+}
+
+// CopyFromContainer implements the VCSandbox function of the same name.
+func (s *Sandbox) CopyFromContainer(containerID, src string, archive io.Writer) error {
+	return nil
+}
+
 // WaitProcess implements the VCSandbox function of the same name.
 func (s *Sandbox) WaitProcess(containerID, processID string) (int32, error) {
 	return 0, nil
@@ -183,11 +193,26 @@ func (s *Sandbox) IOStream(containerID, processID string) (io.WriteCloser, io.Re
 	return nil, nil, nil, nil
 }
 
+// PortForward implements the VCSandbox function of the same name.
+func (s *Sandbox) PortForward(port uint32) (io.ReadWriteCloser, error) {
+	return nil, nil
+}
+
 // AddDevice adds a device to sandbox
 func (s *Sandbox) AddDevice(info config.DeviceInfo) (api.Device, error) {
 	return nil, nil
 }
 
+// RemoveDevice implements the VCSandbox function of the same name.
+func (s *Sandbox) RemoveDevice(info config.DeviceInfo) (api.Device, error) {
+	return nil, nil
+}
+
+// ListDevices implements the VCSandbox function of the same name.
+func (s *Sandbox) ListDevices() ([]api.Device, error) {
+	return nil, nil
+}
+
 // AddInterface implements the VCSandbox function of the same name.
 func (s *Sandbox) AddInterface(inf *vcTypes.Interface) (*vcTypes.Interface, error) {
 	return nil, nil
@@ -7,6 +7,8 @@ package vcmock
 
 import (
 	"context"
+	"io"
+	"os"
 	"syscall"
 
 	vc "github.com/kata-containers/runtime/virtcontainers"
@@ -55,6 +57,7 @@ type VCMock struct {
 	StatusSandboxFunc  func(ctx context.Context, sandboxID string) (vc.SandboxStatus, error)
 	StatsContainerFunc func(ctx context.Context, sandboxID, containerID string) (vc.ContainerStats, error)
 	StopSandboxFunc    func(ctx context.Context, sandboxID string) (vc.VCSandbox, error)
+	ProfileSandboxFunc func(ctx context.Context, sandboxID string) (vc.SandboxTimeline, error)
 
 	CreateContainerFunc      func(ctx context.Context, sandboxID string, containerConfig vc.ContainerConfig) (vc.VCSandbox, vc.VCContainer, error)
 	DeleteContainerFunc      func(ctx context.Context, sandboxID, containerID string) (vc.VCContainer, error)
@@ -67,12 +70,21 @@ type VCMock struct {
 	UpdateContainerFunc      func(ctx context.Context, sandboxID, containerID string, resources specs.LinuxResources) error
 	PauseContainerFunc       func(ctx context.Context, sandboxID, containerID string) error
 	ResumeContainerFunc      func(ctx context.Context, sandboxID, containerID string) error
+	CopyToContainerFunc      func(ctx context.Context, sandboxID, containerID, dst string, archive io.Reader) error
+	CopyFromContainerFunc    func(ctx context.Context, sandboxID, containerID, src string, archive io.Writer) error
 
-	AddDeviceFunc func(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error)
+	AddDeviceFunc    func(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error)
+	RemoveDeviceFunc func(ctx context.Context, sandboxID string, info config.DeviceInfo) (api.Device, error)
+	ListDevicesFunc  func(ctx context.Context, sandboxID string) ([]api.Device, error)
 
 	AddInterfaceFunc    func(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error)
 	RemoveInterfaceFunc func(ctx context.Context, sandboxID string, inf *vcTypes.Interface) (*vcTypes.Interface, error)
 	ListInterfacesFunc  func(ctx context.Context, sandboxID string) ([]*vcTypes.Interface, error)
 	UpdateRoutesFunc    func(ctx context.Context, sandboxID string, routes []*vcTypes.Route) ([]*vcTypes.Route, error)
 	ListRoutesFunc      func(ctx context.Context, sandboxID string) ([]*vcTypes.Route, error)
+
+	GarbageCollectFunc func(ctx context.Context, dryRun bool) ([]vc.OrphanResource, error)
+
+	MigrateSandboxFunc  func(ctx context.Context, sandboxID string, conn *os.File) error
+	IncomingSandboxFunc func(ctx context.Context, conn *os.File, config vc.SandboxConfig) (vc.VCSandbox, error)
 }
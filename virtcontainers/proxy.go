@@ -29,6 +29,10 @@ type proxyParams struct {
 	consoleURL string
 	logger     *logrus.Entry
 	debug      bool
+
+	// consoleHandler is called with each line read from the guest
+	// console, by the proxies watching it.
+	consoleHandler func(line string)
 }
 
 // ProxyType describes a proxy type.
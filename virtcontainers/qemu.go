@@ -56,6 +56,14 @@ type QemuState struct {
 	HotpluggedMemory     int
 	UUID                 string
 	HotplugVFIOOnRootBus bool
+
+	// UID is the uid/gid allocated to QEMU when running as an
+	// unprivileged user.
+	UID int
+
+	// LoopDevices are the loop devices backing the files exposed to
+	// the unprivileged QEMU, by resource name.
+	LoopDevices map[string]string
 }
 
 // qemu is an Hypervisor interface implementation for the Linux qemu hypervisor.
@@ -91,6 +99,8 @@ const (
 	qmpCapMigrationBypassSharedMemory = "bypass-shared-memory"
 	qmpExecCatCmd                     = "exec:cat"
 	qmpMigrationWaitTimeout           = 5 * time.Second
+	qmpLiveMigrationWaitTimeout       = 10 * time.Minute
+	qmpMigrationFDName                = "migration"
 
 	scsiControllerID = "scsi0"
 	rngID            = "rng0"
@@ -99,6 +109,12 @@ const (
 var qemuMajorVersion int
 var qemuMinorVersion int
 
+// The chroot of a confined QEMU is created under
+// <qemuChrootBase>/<qemu binary name>/<sandbox ID>/root, next to the
+// firecracker jails. Device nodes are created into it, so this location
+// must not be mounted nodev, as /run usually is.
+var qemuChrootBase = "/srv/kata"
+
 // agnostic list of kernel parameters
 var defaultKernelParameters = []Param{
 	{"panic", "1"},
@@ -578,9 +594,27 @@ func (q *qemu) startSandbox(timeout int) error {
 		}
 	}()
 
+	if err = q.setupConfinement(); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			q.cleanupConfinement()
+		}
+	}()
+
+	// QEMU inherits the memory policy it is launched with, which then
+	// applies to the guest RAM, hotplugged or not.
 	var strErr string
-	strErr, err = govmmQemu.LaunchQemu(q.qemuConfig, newQMPLogger())
+	err = bindMemory(q.config.MemoryHostNodes, func() error {
+		var launchErr error
+		strErr, launchErr = govmmQemu.LaunchQemu(q.qemuConfig, newQMPLogger())
+		return launchErr
+	})
 	if err != nil {
+		if strErr == "" {
+			return err
+		}
 		return fmt.Errorf("%s", strErr)
 	}
 
@@ -665,6 +699,8 @@ func (q *qemu) stopSandbox() error {
 
 func (q *qemu) cleanupVM() error {
 
+	q.cleanupConfinement()
+
 	// cleanup vm path
 	dir := filepath.Join(store.RunVMStoragePath, q.id)
 
@@ -779,18 +815,34 @@ func (q *qemu) removeDeviceFromBridge(ID string) error {
 }
 
 func (q *qemu) hotplugAddBlockDevice(drive *config.BlockDrive, op operation, devID string) error {
-	var err error
+	path, err := q.exposeResource(drive.File, drive.ID)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			q.releaseResource(drive.ID)
+		}
+	}()
 
 	if q.config.BlockDeviceDriver == config.Nvdimm {
+		if drive.Format != "" && drive.Format != config.ImageFormatRaw {
+			return fmt.Errorf("%s disk images can not be attached as NVDIMM devices", drive.Format)
+		}
+
 		var blocksize int64
-		file, err := os.Open(drive.File)
+		var file *os.File
+		file, err = os.Open(drive.File)
 		if err != nil {
 			return err
 		}
-		if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), unix.BLKGETSIZE64, uintptr(unsafe.Pointer(&blocksize))); err != 0 {
+		defer file.Close()
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), unix.BLKGETSIZE64, uintptr(unsafe.Pointer(&blocksize))); errno != 0 {
+			err = errno
 			return err
 		}
-		if err = q.qmpMonitorCh.qmp.ExecuteNVDIMMDeviceAdd(q.qmpMonitorCh.ctx, drive.ID, drive.File, blocksize); err != nil {
+		if err = q.qmpMonitorCh.qmp.ExecuteNVDIMMDeviceAdd(q.qmpMonitorCh.ctx, drive.ID, path, blocksize); err != nil {
 			q.Logger().WithError(err).Errorf("Failed to add NVDIMM device %s", drive.File)
 			return err
 		}
@@ -799,10 +851,18 @@ func (q *qemu) hotplugAddBlockDevice(drive *config.BlockDrive, op operation, dev
 		return nil
 	}
 
+	// The blockdev-add command sent by govmm always uses the raw block
+	// driver, images in other formats can only be hotplugged once govmm
+	// lets the driver be chosen.
+	if drive.Format != "" && drive.Format != config.ImageFormatRaw {
+		err = fmt.Errorf("hotplugging %s disk images is not supported by govmm yet", drive.Format)
+		return err
+	}
+
 	if q.config.BlockDeviceCacheSet {
-		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithCache(q.qmpMonitorCh.ctx, drive.File, drive.ID, q.config.BlockDeviceCacheDirect, q.config.BlockDeviceCacheNoflush)
+		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithCache(q.qmpMonitorCh.ctx, path, drive.ID, q.config.BlockDeviceCacheDirect, q.config.BlockDeviceCacheNoflush)
 	} else {
-		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAdd(q.qmpMonitorCh.ctx, drive.File, drive.ID)
+		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAdd(q.qmpMonitorCh.ctx, path, drive.ID)
 	}
 	if err != nil {
 		return err
@@ -810,7 +870,9 @@ func (q *qemu) hotplugAddBlockDevice(drive *config.BlockDrive, op operation, dev
 
 	if q.config.BlockDeviceDriver == config.VirtioBlock {
 		driver := "virtio-blk-pci"
-		addr, bridge, err := q.addDeviceToBridge(drive.ID)
+		var addr string
+		var bridge types.PCIBridge
+		addr, bridge, err = q.addDeviceToBridge(drive.ID)
 		if err != nil {
 			return err
 		}
@@ -828,7 +890,8 @@ func (q *qemu) hotplugAddBlockDevice(drive *config.BlockDrive, op operation, dev
 		bus := scsiControllerID + ".0"
 
 		// Get SCSI-id and LUN based on the order of attaching drives.
-		scsiID, lun, err := utils.GetSCSIIdLun(drive.Index)
+		var scsiID, lun int
+		scsiID, lun, err = utils.GetSCSIIdLun(drive.Index)
 		if err != nil {
 			return err
 		}
@@ -865,6 +928,8 @@ func (q *qemu) hotplugBlockDevice(drive *config.BlockDrive, op operation) error
 		if err := q.qmpMonitorCh.qmp.ExecuteBlockdevDel(q.qmpMonitorCh.ctx, drive.ID); err != nil {
 			return err
 		}
+
+		q.releaseResource(drive.ID)
 	}
 
 	return err
@@ -879,6 +944,12 @@ func (q *qemu) hotplugVFIODevice(device *config.VFIODev, op operation) error {
 	devID := device.ID
 
 	if op == addDevice {
+		// The VFIO group and the sysfs entries of the device are opened
+		// when hotplugged, a confined QEMU can not access them.
+		if q.config.RunAsUnprivilegedUser || q.config.EnableChroot {
+			return fmt.Errorf("VFIO devices can not be hotplugged into a confined QEMU")
+		}
+
 		// In case HotplugVFIOOnRootBus is true, devices are hotplugged on the root bus
 		// for pc machine type instead of bridge. This is useful for devices that require
 		// a large PCI BAR which is a currently a limitation with PCI bridges.
@@ -898,6 +969,9 @@ func (q *qemu) hotplugVFIODevice(device *config.VFIODev, op operation) error {
 			return err
 		}
 
+		// PCI address is in the format bridge-addr/device-addr eg. "03/02"
+		device.GuestPCIAddr = fmt.Sprintf("%02x", bridge.Addr) + "/" + addr
+
 		switch device.Type {
 		case config.VFIODeviceNormalType:
 			return q.qmpMonitorCh.qmp.ExecutePCIVFIODeviceAdd(q.qmpMonitorCh.ctx, devID, device.BDF, addr, bridge.ID, romFile)
@@ -1267,6 +1341,8 @@ func (q *qemu) addDevice(devInfo interface{}, devType deviceType) error {
 		q.qemuConfig.Devices, err = q.arch.appendVhostUserDevice(q.qemuConfig.Devices, v)
 	case config.VFIODev:
 		q.qemuConfig.Devices = q.arch.appendVFIODevice(q.qemuConfig.Devices, v)
+	case *config.VFIODev:
+		err = q.coldPlugVFIODevice(v)
 	default:
 		break
 	}
@@ -1274,6 +1350,29 @@ func (q *qemu) addDevice(devInfo interface{}, devType deviceType) error {
 	return err
 }
 
+// coldPlugVFIODevice appends a VFIO device to the VM boot command line.
+// Unless VFIO devices must be attached to the root bus, the device is
+// attached to a PCI bridge so that its guest PCI address is known
+// before the VM boots.
+func (q *qemu) coldPlugVFIODevice(device *config.VFIODev) error {
+	if q.state.HotplugVFIOOnRootBus {
+		q.qemuConfig.Devices = q.arch.appendVFIODevice(q.qemuConfig.Devices, *device)
+		return nil
+	}
+
+	addr, bridge, err := q.addDeviceToBridge(device.ID)
+	if err != nil {
+		return err
+	}
+
+	// PCI address is in the format bridge-addr/device-addr eg. "03/02"
+	device.GuestPCIAddr = fmt.Sprintf("%02x", bridge.Addr) + "/" + addr
+
+	q.qemuConfig.Devices = q.arch.appendBridgeVFIODevice(q.qemuConfig.Devices, *device, bridge.ID, addr)
+
+	return q.store.Store(store.Hypervisor, q.state)
+}
+
 // getSandboxConsole builds the path of the console where we can read
 // logs coming from the sandbox.
 func (q *qemu) getSandboxConsole(id string) (string, error) {
@@ -1312,7 +1411,13 @@ func (q *qemu) saveSandbox() error {
 		return err
 	}
 
-	t := time.NewTimer(qmpMigrationWaitTimeout)
+	return q.waitMigration(qmpMigrationWaitTimeout)
+}
+
+// waitMigration waits for the outgoing or incoming migration of the VM to
+// complete.
+func (q *qemu) waitMigration(timeout time.Duration) error {
+	t := time.NewTimer(timeout)
 	defer t.Stop()
 	for {
 		status, err := q.qmpMonitorCh.qmp.ExecuteQueryMigration(q.qmpMonitorCh.ctx)
@@ -1320,22 +1425,98 @@ func (q *qemu) saveSandbox() error {
 			q.Logger().WithError(err).Error("failed to query migration status")
 			return err
 		}
-		if status.Status == "completed" {
-			break
+		switch status.Status {
+		case "completed":
+			return nil
+		case "failed", "cancelled":
+			q.Logger().WithField("migration-status", status).Error("qemu migration failed")
+			return fmt.Errorf("qemu migration %s", status.Status)
 		}
 
 		select {
 		case <-t.C:
 			q.Logger().WithField("migration-status", status).Error("timeout waiting for qemu migration")
-			return fmt.Errorf("timed out after %d seconds waiting for qemu migration", qmpMigrationWaitTimeout)
+			return fmt.Errorf("timed out after %v waiting for qemu migration", timeout)
 		default:
 			// migration in progress
 			q.Logger().WithField("migration-status", status).Debug("migration in progress")
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
+}
 
-	return nil
+// migrateSandbox live migrates the VM through conn, to the VM started by
+// incomingSandbox on the other end of the connection. The VM is left
+// paused once migrated.
+func (q *qemu) migrateSandbox(conn *os.File) error {
+	span, _ := q.trace("migrateSandbox")
+	defer span.Finish()
+
+	q.Logger().Info("migrate sandbox")
+
+	err := q.qmpSetup()
+	if err != nil {
+		return err
+	}
+
+	if err := q.qmpMonitorCh.qmp.ExecuteGetFD(q.qmpMonitorCh.ctx, qmpMigrationFDName, conn); err != nil {
+		q.Logger().WithError(err).Error("pass migration fd")
+		return err
+	}
+
+	err = q.qmpMonitorCh.qmp.ExecSetMigrateArguments(q.qmpMonitorCh.ctx, "fd:"+qmpMigrationFDName)
+	if err != nil {
+		q.Logger().WithError(err).Error("fd migration")
+		return err
+	}
+
+	return q.waitMigration(qmpLiveMigrationWaitTimeout)
+}
+
+// incomingSandbox starts the VM receiving the VM live migrated through conn
+// by migrateSandbox. ready is called once the VM waits for the migration
+// stream, and incomingSandbox returns when the migrated VM runs.
+func (q *qemu) incomingSandbox(conn *os.File, timeout int, ready func() error) error {
+	span, _ := q.trace("incomingSandbox")
+	defer span.Finish()
+
+	// The devices hotplugged into the migrated VM are not part of the
+	// command line, which has to describe the same VM on both ends.
+	if len(q.state.HotpluggedVCPUs) > 0 || q.state.HotpluggedMemory > 0 {
+		return fmt.Errorf("Cannot receive a VM with hotplugged vCPUs or memory")
+	}
+
+	for _, b := range q.state.Bridges {
+		if len(b.Address) > 0 {
+			return fmt.Errorf("Cannot receive a VM with devices hotplugged on bridge %s", b.ID)
+		}
+	}
+
+	if q.config.BootToBeTemplate || q.config.BootFromTemplate {
+		return fmt.Errorf("Cannot receive a VM template")
+	}
+
+	q.qemuConfig.Incoming = govmmQemu.Incoming{
+		MigrationType: govmmQemu.MigrationFD,
+		FD:            conn,
+	}
+
+	if err := q.startSandbox(timeout); err != nil {
+		return err
+	}
+
+	// Let the VM run as soon as the migration completes.
+	if err := q.resumeSandbox(); err != nil {
+		return err
+	}
+
+	if err := ready(); err != nil {
+		return err
+	}
+
+	// The destination reports the incoming migration complete once
+	// the VM runs, from QEMU 3.0.
+	return q.waitMigration(qmpLiveMigrationWaitTimeout)
 }
 
 func (q *qemu) disconnect() {
@@ -1581,6 +1762,244 @@ func (q *qemu) cleanup() error {
 	return nil
 }
 
+// qemuConfinement is the govmm device adding the options confining QEMU
+// once initialized, which the govmm configuration does not support.
+type qemuConfinement struct {
+	seccompSandbox string
+	runAs          string
+	chroot         string
+}
+
+// Valid returns true if QEMU is confined.
+func (c qemuConfinement) Valid() bool {
+	return c.seccompSandbox != "" || c.runAs != "" || c.chroot != ""
+}
+
+// QemuParams returns the qemu parameters confining QEMU.
+func (c qemuConfinement) QemuParams(config *govmmQemu.Config) []string {
+	var qemuParams []string
+
+	if c.seccompSandbox != "" {
+		qemuParams = append(qemuParams, "-sandbox", c.seccompSandbox)
+	}
+
+	if c.runAs != "" {
+		qemuParams = append(qemuParams, "-runas", c.runAs)
+	}
+
+	if c.chroot != "" {
+		qemuParams = append(qemuParams, "-chroot", c.chroot)
+	}
+
+	return qemuParams
+}
+
+// setupConfinement allocates the uid/gid of the unprivileged QEMU, creates
+// its chroot and adds the confinement options to its configuration.
+func (q *qemu) setupConfinement() error {
+	confinement := qemuConfinement{
+		seccompSandbox: q.config.SeccompSandbox,
+	}
+
+	if q.config.RunAsUnprivilegedUser {
+		if q.state.UID == 0 {
+			id, err := allocateHypervisorUserID(&q.config, q.id)
+			if err != nil {
+				return err
+			}
+
+			q.state.UID = id
+			if err := q.store.Store(store.Hypervisor, q.state); err != nil {
+				return err
+			}
+		}
+
+		confinement.runAs = fmt.Sprintf("%d:%d", q.state.UID, q.state.UID)
+	}
+
+	if q.config.EnableChroot {
+		// The chroot only holds the resources hotplugged once QEMU is
+		// initialized, and has to be accessible to the unprivileged user.
+		if err := os.MkdirAll(q.chrootPath(), store.DirMode); err != nil {
+			return err
+		}
+
+		if err := os.Chmod(q.chrootPath(), 0755); err != nil {
+			return err
+		}
+
+		if err := q.checkChrootMount(); err != nil {
+			return err
+		}
+
+		confinement.chroot = q.chrootPath()
+	}
+
+	q.qemuConfig.Devices = append(q.qemuConfig.Devices, confinement)
+
+	return nil
+}
+
+// cleanupConfinement releases the resources exposed to the chrooted QEMU,
+// so that removing the chroot leaves the host files untouched, and the
+// uid/gid of the unprivileged QEMU.
+func (q *qemu) cleanupConfinement() {
+	for name := range q.state.LoopDevices {
+		q.releaseResource(name)
+	}
+
+	if q.config.EnableChroot {
+		filepath.Walk(q.chrootPath(), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				syscall.Unmount(path, syscall.MNT_DETACH)
+			}
+			return nil
+		})
+
+		if err := os.RemoveAll(filepath.Dir(q.chrootPath())); err != nil {
+			q.Logger().WithError(err).Warn("Could not remove the qemu chroot")
+		}
+	}
+
+	if q.state.UID == 0 {
+		return
+	}
+
+	if err := releaseHypervisorUserID(q.state.UID, q.id); err != nil {
+		q.Logger().WithError(err).WithField("uid", q.state.UID).Warn("Could not release the qemu uid")
+		return
+	}
+
+	q.state.UID = 0
+	if err := q.store.Store(store.Hypervisor, q.state); err != nil {
+		q.Logger().WithError(err).Warn("Could not store the qemu state")
+	}
+}
+
+func (q *qemu) chrootPath() string {
+	return filepath.Join(qemuChrootBase, filepath.Base(q.config.HypervisorPath), q.id, "root")
+}
+
+// checkChrootMount makes sure the device nodes created into the chroot can
+// be opened, which a nodev mount prevents.
+func (q *qemu) checkChrootMount() error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(q.chrootPath(), &st); err != nil {
+		return err
+	}
+
+	// statfs reports ST_NODEV, which has the value of MS_NODEV.
+	if st.Flags&unix.MS_NODEV != 0 {
+		return fmt.Errorf("QEMU chroot %v is on a nodev mount, device nodes can not be opened from there", q.chrootPath())
+	}
+
+	return nil
+}
+
+// exposeResource makes the host file or device src available to a
+// chrooted QEMU, and returns the path QEMU has to use to open it.
+// The host file is never handed over to the unprivileged user: a device
+// node is created as name into the chroot, owned by the unprivileged
+// user and backed by a loop device for a regular file. Regular files
+// are bind mounted when QEMU keeps running as root.
+func (q *qemu) exposeResource(src, name string) (string, error) {
+	if !q.config.EnableChroot {
+		return src, nil
+	}
+
+	path := filepath.Join(q.chrootPath(), name)
+
+	// The resource might already be exposed, when a drive is updated.
+	q.releaseResource(name)
+
+	var st unix.Stat_t
+	if err := unix.Stat(src, &st); err != nil {
+		return "", err
+	}
+
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFBLK, unix.S_IFCHR:
+		if err := q.exposeDevice(path, st.Mode, st.Rdev); err != nil {
+			return "", err
+		}
+	case unix.S_IFREG:
+		if !q.config.RunAsUnprivilegedUser {
+			if err := bindMount(q.ctx, src, path, false); err != nil {
+				return "", err
+			}
+			break
+		}
+
+		loop, err := utils.AttachLoopDevice(src)
+		if err != nil {
+			return "", err
+		}
+
+		if q.state.LoopDevices == nil {
+			q.state.LoopDevices = make(map[string]string)
+		}
+		q.state.LoopDevices[name] = loop
+
+		if err := q.store.Store(store.Hypervisor, q.state); err != nil {
+			return "", err
+		}
+
+		if err := unix.Stat(loop, &st); err != nil {
+			return "", err
+		}
+
+		if err := q.exposeDevice(path, unix.S_IFBLK|0600, st.Rdev); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("Unsupported resource %v", src)
+	}
+
+	return filepath.Join("/", name), nil
+}
+
+// exposeDevice creates a device node into the chroot, owned by the
+// unprivileged user if any.
+func (q *qemu) exposeDevice(path string, mode uint32, rdev uint64) error {
+	if err := unix.Mknod(path, mode, int(rdev)); err != nil {
+		return fmt.Errorf("Could not create device %v: %v", path, err)
+	}
+
+	if !q.config.RunAsUnprivilegedUser {
+		return nil
+	}
+
+	return os.Chown(path, q.state.UID, q.state.UID)
+}
+
+// releaseResource removes a resource exposed to a chrooted QEMU.
+func (q *qemu) releaseResource(name string) {
+	if !q.config.EnableChroot {
+		return
+	}
+
+	path := filepath.Join(q.chrootPath(), name)
+
+	// Regular files are bind mounted when QEMU runs as root.
+	syscall.Unmount(path, syscall.MNT_DETACH)
+	os.Remove(path)
+
+	loop, ok := q.state.LoopDevices[name]
+	if !ok {
+		return
+	}
+
+	if err := utils.DetachLoopDevice(loop); err != nil {
+		q.Logger().WithError(err).WithField("loop", loop).Warn("Could not detach loop device")
+	}
+
+	delete(q.state.LoopDevices, name)
+
+	if err := q.store.Store(store.Hypervisor, q.state); err != nil {
+		q.Logger().WithError(err).Warn("Could not store the qemu state")
+	}
+}
+
 func (q *qemu) pidFile() string {
 	return filepath.Join(store.RunVMStoragePath, q.id, "pid")
 }
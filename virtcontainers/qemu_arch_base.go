@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	govmmQemu "github.com/intel/govmm/qemu"
 
@@ -92,6 +93,9 @@ type qemuArch interface {
 	// appendVFIODevice appends a VFIO device to devices
 	appendVFIODevice(devices []govmmQemu.Device, vfioDevice config.VFIODev) []govmmQemu.Device
 
+	// appendBridgeVFIODevice appends a VFIO device attached to a PCI bridge to devices
+	appendBridgeVFIODevice(devices []govmmQemu.Device, vfioDevice config.VFIODev, bus, addr string) []govmmQemu.Device
+
 	// appendRNGDevice appends a RNG device to devices
 	appendRNGDevice(devices []govmmQemu.Device, rngDevice config.RNGDev) []govmmQemu.Device
 
@@ -550,6 +554,61 @@ func (q *qemuArchBase) appendVFIODevice(devices []govmmQemu.Device, vfioDev conf
 	return devices
 }
 
+// bridgeVFIODevice is a VFIO device attached at a given address of a
+// PCI bridge, govmm VFIO devices can only be attached to the root bus.
+type bridgeVFIODevice struct {
+	id       string
+	bdf      string
+	sysfsDev string
+	bus      string
+	addr     string
+}
+
+func (d bridgeVFIODevice) Valid() bool {
+	return (d.bdf != "" || d.sysfsDev != "") && d.bus != "" && d.addr != ""
+}
+
+func (d bridgeVFIODevice) QemuParams(config *govmmQemu.Config) []string {
+	var deviceParams []string
+
+	deviceParams = append(deviceParams, string(govmmQemu.Vfio))
+	if d.bdf != "" {
+		deviceParams = append(deviceParams, fmt.Sprintf("host=%s", d.bdf))
+	} else {
+		deviceParams = append(deviceParams, fmt.Sprintf("sysfsdev=%s", d.sysfsDev))
+	}
+
+	if d.id != "" {
+		deviceParams = append(deviceParams, fmt.Sprintf("id=%s", d.id))
+	}
+
+	deviceParams = append(deviceParams, fmt.Sprintf("bus=%s", d.bus))
+	deviceParams = append(deviceParams, fmt.Sprintf("addr=%s", d.addr))
+
+	return []string{"-device", strings.Join(deviceParams, ",")}
+}
+
+func (q *qemuArchBase) appendBridgeVFIODevice(devices []govmmQemu.Device, vfioDev config.VFIODev, bus, addr string) []govmmQemu.Device {
+	d := bridgeVFIODevice{
+		id:   vfioDev.ID,
+		bus:  bus,
+		addr: addr,
+	}
+
+	switch vfioDev.Type {
+	case config.VFIODeviceNormalType:
+		d.bdf = vfioDev.BDF
+	case config.VFIODeviceMediatedType:
+		d.sysfsDev = vfioDev.SysfsDev
+	}
+
+	if !d.Valid() {
+		return devices
+	}
+
+	return append(devices, d)
+}
+
 func (q *qemuArchBase) appendRNGDevice(devices []govmmQemu.Device, rngDev config.RNGDev) []govmmQemu.Device {
 	devices = append(devices,
 		govmmQemu.RngDevice{
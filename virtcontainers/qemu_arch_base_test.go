@@ -419,6 +419,37 @@ func TestQemuArchBaseAppendVFIODevice(t *testing.T) {
 	testQemuArchBaseAppend(t, vfDevice, expectedOut)
 }
 
+func TestQemuArchBaseAppendBridgeVFIODevice(t *testing.T) {
+	var devices []govmmQemu.Device
+	assert := assert.New(t)
+	qemuArchBase := newQemuArchBase()
+
+	vfDevice := config.VFIODev{
+		ID:   "vfio-1",
+		Type: config.VFIODeviceNormalType,
+		BDF:  "02:10.1",
+	}
+
+	expectedOut := []govmmQemu.Device{
+		bridgeVFIODevice{
+			id:   "vfio-1",
+			bdf:  "02:10.1",
+			bus:  "pci-bridge-0",
+			addr: "01",
+		},
+	}
+
+	devices = qemuArchBase.appendBridgeVFIODevice(devices, vfDevice, "pci-bridge-0", "01")
+	assert.Equal(expectedOut, devices)
+
+	params := devices[0].QemuParams(nil)
+	assert.Equal([]string{"-device", "vfio-pci,host=02:10.1,id=vfio-1,bus=pci-bridge-0,addr=01"}, params)
+
+	// no bus, device is not appended
+	devices = qemuArchBase.appendBridgeVFIODevice(devices, vfDevice, "", "01")
+	assert.Len(devices, 1)
+}
+
 func TestQemuArchBaseAppendSCSIController(t *testing.T) {
 	var devices []govmmQemu.Device
 	assert := assert.New(t)
@@ -12,9 +12,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"syscall"
 	"testing"
 
 	govmmQemu "github.com/intel/govmm/qemu"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
 	"github.com/kata-containers/runtime/virtcontainers/store"
 	"github.com/kata-containers/runtime/virtcontainers/types"
 	"github.com/stretchr/testify/assert"
@@ -115,6 +117,202 @@ func TestQemuCreateSandbox(t *testing.T) {
 	}
 }
 
+func TestQemuConfinement(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "qemu-confinement-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	savedUserIDsPath := hypervisorUserIDsPath
+	hypervisorUserIDsPath = filepath.Join(tmpdir, "uids")
+	defer func() {
+		hypervisorUserIDsPath = savedUserIDsPath
+	}()
+
+	qemuConfig := newQemuConfig()
+	qemuConfig.SeccompSandbox = "on,obsolete=deny"
+	qemuConfig.RunAsUnprivilegedUser = true
+	qemuConfig.EnableChroot = true
+	qemuConfig.UnprivilegedUserIDBase = 4000000000
+	qemuConfig.UnprivilegedUserIDCount = 65536
+
+	q := &qemu{}
+	ctx := context.Background()
+	sandboxID := "testSandboxConfinement"
+
+	vcStore, err := store.NewVCSandboxStore(ctx, sandboxID)
+	assert.NoError(err)
+	defer vcStore.Delete()
+
+	err = q.createSandbox(ctx, sandboxID, &qemuConfig, vcStore)
+	assert.NoError(err)
+
+	err = q.setupConfinement()
+	assert.NoError(err)
+	defer q.cleanupVM()
+
+	assert.Equal(4000000000, q.state.UID)
+
+	var state QemuState
+	err = vcStore.Load(store.Hypervisor, &state)
+	assert.NoError(err)
+	assert.Equal(q.state.UID, state.UID)
+
+	confinement := q.qemuConfig.Devices[len(q.qemuConfig.Devices)-1]
+	assert.Equal([]string{
+		"-sandbox", "on,obsolete=deny",
+		"-runas", "4000000000:4000000000",
+		"-chroot", filepath.Join(qemuChrootBase, filepath.Base(q.config.HypervisorPath), sandboxID, "root"),
+	}, confinement.QemuParams(&q.qemuConfig))
+
+	info, err := os.Stat(q.chrootPath())
+	assert.NoError(err)
+	assert.True(info.IsDir())
+
+	// The device nodes created into the chroot have to be usable
+	var st syscall.Statfs_t
+	err = syscall.Statfs(q.chrootPath(), &st)
+	assert.NoError(err)
+	assert.Zero(st.Flags&syscall.MS_NODEV, "chroot base %v mounted nodev", qemuChrootBase)
+
+	// VFIO devices can not be hotplugged into a confined QEMU
+	q.qmpMonitorCh.qmp = &govmmQemu.QMP{}
+	err = q.hotplugVFIODevice(&config.VFIODev{}, addDevice)
+	assert.Error(err)
+	q.qmpMonitorCh.qmp = nil
+
+	// The uid is released along with the VM
+	q.cleanupConfinement()
+	assert.Equal(0, q.state.UID)
+	_, err = os.Stat(filepath.Dir(q.chrootPath()))
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(hypervisorUserIDsPath, "4000000000"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestQemuChrootMount(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "qemu-chroot-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	savedChrootBase := qemuChrootBase
+	qemuChrootBase = tmpdir
+	defer func() {
+		qemuChrootBase = savedChrootBase
+	}()
+
+	q := &qemu{
+		id: testSandboxID,
+		config: HypervisorConfig{
+			HypervisorPath: "/usr/bin/qemu-system-x86_64",
+		},
+	}
+	assert.Equal(filepath.Join(tmpdir, "qemu-system-x86_64", testSandboxID, "root"), q.chrootPath())
+
+	err = syscall.Mount("tmpfs", tmpdir, "tmpfs", syscall.MS_NODEV, "")
+	assert.NoError(err)
+	defer syscall.Unmount(tmpdir, syscall.MNT_DETACH)
+
+	err = os.MkdirAll(q.chrootPath(), store.DirMode)
+	assert.NoError(err)
+
+	// Device nodes can not be opened from a nodev mount
+	err = q.checkChrootMount()
+	assert.Error(err)
+
+	err = syscall.Mount("", tmpdir, "", syscall.MS_REMOUNT, "")
+	assert.NoError(err)
+
+	err = q.checkChrootMount()
+	assert.NoError(err)
+}
+
+func TestQemuExposeResource(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	vcStore, err := store.NewVCSandboxStore(ctx, testSandboxID)
+	assert.NoError(err)
+	defer store.DeleteAll()
+
+	q := &qemu{
+		id:    testSandboxID,
+		ctx:   ctx,
+		store: vcStore,
+	}
+
+	tmpfile, err := ioutil.TempFile("", "qemu-resource-")
+	assert.NoError(err)
+	defer os.Remove(tmpfile.Name())
+	err = tmpfile.Truncate(1024 * 1024)
+	assert.NoError(err)
+	tmpfile.Close()
+
+	// Not confined
+	path, err := q.exposeResource(tmpfile.Name(), "drive")
+	assert.NoError(err)
+	assert.Equal(tmpfile.Name(), path)
+
+	q.config.EnableChroot = true
+	q.config.RunAsUnprivilegedUser = true
+	q.state.UID = 4000000000
+
+	err = os.MkdirAll(q.chrootPath(), store.DirMode)
+	assert.NoError(err)
+	defer os.RemoveAll(filepath.Dir(q.chrootPath()))
+
+	// Regular files are exposed through a loop device
+	path, err = q.exposeResource(tmpfile.Name(), "drive")
+	if err != nil && os.IsNotExist(err) {
+		t.Skip("Loop devices are not supported")
+	}
+	assert.NoError(err)
+	assert.Equal("/drive", path)
+	assert.Contains(q.state.LoopDevices, "drive")
+
+	var stat syscall.Stat_t
+	exposed := filepath.Join(q.chrootPath(), "drive")
+	err = syscall.Stat(exposed, &stat)
+	assert.NoError(err)
+	assert.Equal(uint32(syscall.S_IFBLK), stat.Mode&syscall.S_IFMT)
+	assert.Equal(uint32(4000000000), stat.Uid)
+	assert.Equal(uint32(4000000000), stat.Gid)
+
+	// Devices are exposed through a device node
+	path, err = q.exposeResource("/dev/null", "null")
+	assert.NoError(err)
+	assert.Equal("/null", path)
+
+	err = syscall.Stat(filepath.Join(q.chrootPath(), "null"), &stat)
+	assert.NoError(err)
+	assert.Equal(uint32(syscall.S_IFCHR), stat.Mode&syscall.S_IFMT)
+	assert.Equal(uint32(4000000000), stat.Uid)
+
+	q.releaseResource("drive")
+	q.releaseResource("null")
+	assert.Empty(q.state.LoopDevices)
+	_, err = os.Stat(exposed)
+	assert.True(os.IsNotExist(err))
+
+	// The host files are left untouched
+	for _, host := range []string{tmpfile.Name(), "/dev/null"} {
+		err = syscall.Stat(host, &stat)
+		assert.NoError(err)
+		assert.Equal(uint32(0), stat.Uid)
+	}
+}
+
 func TestQemuCreateSandboxMissingParentDirFail(t *testing.T) {
 	qemuConfig := newQemuConfig()
 	q := &qemu{}
@@ -398,6 +596,54 @@ func TestHotplugUnsupportedDeviceType(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestQemuColdPlugVFIODevice(t *testing.T) {
+	assert := assert.New(t)
+
+	qemuConfig := newQemuConfig()
+	q := &qemu{
+		ctx:    context.Background(),
+		id:     "qemuTest",
+		config: qemuConfig,
+		arch:   &qemuArchBase{},
+	}
+
+	vcStore, err := store.NewVCSandboxStore(q.ctx, q.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.store = vcStore
+
+	q.state.Bridges = []types.PCIBridge{
+		{
+			Type:    types.PCI,
+			ID:      "pci-bridge-0",
+			Addr:    2,
+			Address: make(map[uint32]string),
+		},
+	}
+
+	device := &config.VFIODev{
+		ID:   "vfio-1",
+		Type: config.VFIODeviceNormalType,
+		BDF:  "02:10.1",
+	}
+
+	err = q.addDevice(device, vfioDev)
+	assert.NoError(err)
+	assert.Equal("02/01", device.GuestPCIAddr)
+	assert.Len(q.qemuConfig.Devices, 1)
+
+	// Devices are attached to the root bus when asked to.
+	q.qemuConfig.Devices = nil
+	q.state.HotplugVFIOOnRootBus = true
+	device.GuestPCIAddr = ""
+
+	err = q.addDevice(device, vfioDev)
+	assert.NoError(err)
+	assert.Empty(device.GuestPCIAddr)
+	assert.Equal([]govmmQemu.Device{govmmQemu.VFIODevice{BDF: "02:10.1"}}, q.qemuConfig.Devices)
+}
+
 func TestQMPSetupShutdown(t *testing.T) {
 	assert := assert.New(t)
 
@@ -424,3 +670,35 @@ func TestQemuCleanup(t *testing.T) {
 	err := q.cleanup()
 	assert.Nil(err)
 }
+
+func TestQemuIncomingSandboxHotplugged(t *testing.T) {
+	assert := assert.New(t)
+
+	q := &qemu{
+		ctx:    context.Background(),
+		config: newQemuConfig(),
+	}
+
+	ready := func() error {
+		t.Fatal("VM started")
+		return nil
+	}
+
+	q.state.HotpluggedVCPUs = []CPUDevice{{ID: "cpu-0"}}
+	assert.Error(q.incomingSandbox(nil, 1, ready))
+
+	q.state.HotpluggedVCPUs = nil
+	q.state.Bridges = []types.PCIBridge{
+		{
+			Type:    types.PCI,
+			ID:      "pci-bridge-0",
+			Address: map[uint32]string{1: "net-0"},
+		},
+	}
+	assert.Error(q.incomingSandbox(nil, 1, ready))
+
+	q.state.Bridges = nil
+	q.config.BootFromTemplate = true
+	assert.Error(q.incomingSandbox(nil, 1, ready))
+	assert.Equal(govmmQemu.Incoming{}, q.qemuConfig.Incoming)
+}
@@ -11,10 +11,16 @@ import (
 	"io"
 	"net"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/containernetworking/plugins/pkg/ns"
+	units "github.com/docker/go-units"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
@@ -36,6 +42,11 @@ const (
 	// vmStartTimeout represents the time in seconds a sandbox can wait before
 	// to consider the VM starting operation failed.
 	vmStartTimeout = 10
+
+	// defaultHugePageSize is the guest hugepage size of the hugepages
+	// kernel parameters not preceded by a hugepagesz one, on amd64 and
+	// arm64 guests.
+	defaultHugePageSize = 2 << 20
 )
 
 // SandboxStatus describes a sandbox status.
@@ -88,6 +99,13 @@ type SandboxConfig struct {
 
 	ShmSize uint64
 
+	// ShmPageSize is the size of the hugepages backing the sandbox shm,
+	// zero when it is backed by tmpfs.
+	ShmPageSize uint64
+
+	// EmptyDir describes how the Kubernetes emptyDir volumes are backed.
+	EmptyDir EmptyDirConfig
+
 	// SharePidNs sets all containers to share the same sandbox level pid namespace.
 	SharePidNs bool
 
@@ -166,11 +184,18 @@ type Sandbox struct {
 
 	networkNS NetworkNamespace
 
+	timeline SandboxTimeline
+
+	// guestKernelBoot is the guest kernel boot time read from the guest
+	// console, in nanoseconds. It is set by the console watcher.
+	guestKernelBoot int64
+
 	annotationsLock *sync.RWMutex
 
 	wg *sync.WaitGroup
 
 	shmSize          uint64
+	shmPageSize      uint64
 	sharePidNs       bool
 	stateful         bool
 	seccompSupported bool
@@ -366,6 +391,200 @@ func (s *Sandbox) IOStream(containerID, processID string) (io.WriteCloser, io.Re
 	return c.ioStream(processID)
 }
 
+// addKernelModulesParams adds the guest kernel parameters loading the kernel
+// modules of the agent configuration. The parameters already added, when
+// the sandbox is fetched from its stored configuration, are not added
+// again.
+func (s *Sandbox) addKernelModulesParams() error {
+	agentConfig, ok := s.config.AgentConfig.(KataAgentConfig)
+	if !ok {
+		return nil
+	}
+
+	params, err := kernelModulesParams(agentConfig.KernelModules)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[Param]bool)
+	for _, p := range s.config.HypervisorConfig.KernelParams {
+		existing[p] = true
+	}
+
+	// Do not append to the runtime configuration parameters in place.
+	kernelParams := append([]Param{}, s.config.HypervisorConfig.KernelParams...)
+	for _, p := range params {
+		if !existing[p] {
+			kernelParams = append(kernelParams, p)
+		}
+	}
+
+	s.config.HypervisorConfig.KernelParams = kernelParams
+
+	return nil
+}
+
+// hugePages returns the number of guest hugepages by page size backing the
+// hugetlb limits of some containers and the shm of the sandbox.
+func (s *Sandbox) hugePages(containers []ContainerConfig) (map[uint64]uint64, error) {
+	pages := make(map[uint64]uint64)
+
+	for _, c := range containers {
+		for _, l := range c.Resources.HugepageLimits {
+			pageSize, err := units.RAMInBytes(l.Pagesize)
+			if err != nil || pageSize <= 0 {
+				return nil, fmt.Errorf("Invalid hugepage size %q", l.Pagesize)
+			}
+			pages[uint64(pageSize)] += (l.Limit + uint64(pageSize) - 1) / uint64(pageSize)
+		}
+	}
+
+	if s.shmPageSize > 0 {
+		pages[s.shmPageSize] += (s.shmSize + s.shmPageSize - 1) / s.shmPageSize
+	}
+
+	return pages, nil
+}
+
+// reservedHugePages returns the number of guest hugepages by page size
+// reserved at boot by the hugepagesz and hugepages kernel parameters, the
+// hugepages parameters preceding any hugepagesz one being of the default
+// page size.
+func (s *Sandbox) reservedHugePages() map[uint64]uint64 {
+	pages := make(map[uint64]uint64)
+	pageSize := uint64(defaultHugePageSize)
+
+	for _, p := range s.config.HypervisorConfig.KernelParams {
+		switch p.Key {
+		case "hugepagesz":
+			if size, err := units.RAMInBytes(p.Value); err == nil && size > 0 {
+				pageSize = uint64(size)
+			}
+		case "hugepages":
+			if n, err := strconv.ParseUint(p.Value, 10, 64); err == nil {
+				pages[pageSize] += n
+			}
+		}
+	}
+
+	return pages
+}
+
+// addHugePagesParams reserves at boot the guest hugepages backing the
+// hugetlb limits of the containers the sandbox is created with and its shm,
+// through the hugepagesz and hugepages kernel parameters, and grows the guest
+// memory by the reserved hugepages. Nothing is reserved when the kernel
+// parameters already configure hugepages. It must not be called for a
+// sandbox fetched from its stored configuration, the hugepages of which
+// were reserved when it was created.
+func (s *Sandbox) addHugePagesParams() error {
+	for _, p := range s.config.HypervisorConfig.KernelParams {
+		if p.Key == "hugepagesz" || p.Key == "hugepages" {
+			return nil
+		}
+	}
+
+	pages, err := s.hugePages(s.config.Containers)
+	if err != nil {
+		return err
+	}
+
+	var pageSizes []uint64
+	for pageSize := range pages {
+		if pages[pageSize] > 0 {
+			pageSizes = append(pageSizes, pageSize)
+		}
+	}
+
+	if len(pageSizes) == 0 {
+		return nil
+	}
+
+	sort.Slice(pageSizes, func(i, j int) bool { return pageSizes[i] < pageSizes[j] })
+
+	// Do not append to the runtime configuration parameters in place.
+	kernelParams := append([]Param{}, s.config.HypervisorConfig.KernelParams...)
+
+	var reserved uint64
+	for _, pageSize := range pageSizes {
+		// Each hugepages parameter applies to the hugepagesz one
+		// preceding it.
+		kernelParams = append(kernelParams,
+			Param{"hugepagesz", fmt.Sprintf("%dK", pageSize>>10)},
+			Param{"hugepages", fmt.Sprintf("%d", pages[pageSize])})
+		reserved += pages[pageSize] * pageSize
+	}
+
+	s.config.HypervisorConfig.KernelParams = kernelParams
+	s.config.HypervisorConfig.MemorySize += uint32((reserved + (1 << utils.MibToBytesShift) - 1) >> utils.MibToBytesShift)
+
+	s.Logger().WithField("hugepages", pages).Info("Reserving guest hugepages")
+
+	return nil
+}
+
+// checkHugePages checks that the guest hugepages reserved at boot can back
+// the hugetlb limits of a container added to the sandbox, along with the
+// ones of the other containers and of the shm. The agent protocol has no
+// request growing the guest hugepages pool once the guest runs, so that
+// the hugepages of the containers added after the sandbox creation have to
+// be reserved through the hugepagesz and hugepages kernel parameters.
+func (s *Sandbox) checkHugePages(contConfig ContainerConfig) error {
+	containers := append([]ContainerConfig{contConfig}, s.config.Containers...)
+	pages, err := s.hugePages(containers)
+	if err != nil {
+		return err
+	}
+
+	reserved := s.reservedHugePages()
+	for pageSize, n := range pages {
+		if n > reserved[pageSize] {
+			return fmt.Errorf("Not enough guest hugepages of %s for container %s: %d needed, %d reserved when the sandbox was created",
+				units.BytesSize(float64(pageSize)), contConfig.ID, n, reserved[pageSize])
+		}
+	}
+
+	return nil
+}
+
+// PortForward opens a byte stream to a TCP port of the sandbox network
+// namespace, through the agent. The connection is forwarded by a process
+// run in the first running container of the sandbox able to run it: the
+// kata agent runs socat, which has to be provided by the image of at least
+// one of the running containers.
+func (s *Sandbox) PortForward(port uint32) (io.ReadWriteCloser, error) {
+	if s.state.State != types.StateRunning {
+		return nil, fmt.Errorf("Sandbox not running")
+	}
+
+	if port == 0 || port > 65535 {
+		return nil, fmt.Errorf("Invalid port %d", port)
+	}
+
+	var errs []string
+
+	for _, contConfig := range s.config.Containers {
+		c, ok := s.containers[contConfig.ID]
+		if !ok || c.state.State != types.StateRunning {
+			continue
+		}
+
+		stream, err := s.agent.portForward(c, port)
+		if err == nil {
+			return stream, nil
+		}
+
+		s.Logger().WithError(err).WithField("container", c.id).Debug("Could not forward port from container")
+		errs = append(errs, fmt.Sprintf("container %s: %v", c.id, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("Could not forward port %d: no running container", port)
+	}
+
+	return nil, fmt.Errorf("Could not forward port %d from any running container: %s", port, strings.Join(errs, "; "))
+}
+
 func createAssets(ctx context.Context, sandboxConfig *SandboxConfig) error {
 	span, _ := trace(ctx, "createAssets")
 	defer span.Finish()
@@ -395,16 +614,27 @@ func createAssets(ctx context.Context, sandboxConfig *SandboxConfig) error {
 		}
 	}
 
-	return nil
+	return sandboxConfig.HypervisorConfig.verifyAssets()
+}
+
+// readGuestConsole is called with each line read from the guest console,
+// to get the guest kernel boot time out of the kernel log.
+func (s *Sandbox) readGuestConsole(line string) {
+	if boot, ok := guestKernelBootTime(line); ok {
+		atomic.StoreInt64(&s.guestKernelBoot, int64(boot))
+	}
 }
 
 func (s *Sandbox) getAndStoreGuestDetails() error {
+	start := time.Now()
 	guestDetailRes, err := s.agent.getGuestDetails(&grpc.GuestDetailsRequest{
 		MemBlockSize: true,
 	})
 	if err != nil {
 		return err
 	}
+	s.timeline.record(phaseGuestDetails, start)
+	s.timeline.GuestKernelBoot = time.Duration(atomic.LoadInt64(&s.guestKernelBoot))
 
 	if guestDetailRes != nil {
 		s.state.GuestMemoryBlockSizeMB = uint32(guestDetailRes.MemBlockSizeBytes >> 20)
@@ -457,6 +687,13 @@ func createSandbox(ctx context.Context, sandboxConfig SandboxConfig, factory Fac
 	state, err := s.store.LoadState()
 	if err == nil && state.State != "" {
 		s.state = state
+
+		// The timeline is not stored by older runtimes.
+		var timeline SandboxTimeline
+		if err := s.store.Load(store.Timeline, &timeline); err == nil {
+			s.timeline = timeline
+		}
+
 		return s, nil
 	}
 
@@ -502,6 +739,7 @@ func newSandbox(ctx context.Context, sandboxConfig SandboxConfig, factory Factor
 		annotationsLock: &sync.RWMutex{},
 		wg:              &sync.WaitGroup{},
 		shmSize:         sandboxConfig.ShmSize,
+		shmPageSize:     sandboxConfig.ShmPageSize,
 		sharePidNs:      sandboxConfig.SharePidNs,
 		stateful:        sandboxConfig.Stateful,
 		ctx:             ctx,
@@ -531,6 +769,24 @@ func newSandbox(ctx context.Context, sandboxConfig SandboxConfig, factory Factor
 		}
 	}()
 
+	if sandboxConfig.HypervisorConfig.PinVCPUs || sandboxConfig.HypervisorConfig.JailerPath != "" {
+		if sandboxConfig.HypervisorConfig.MemoryHostNodes, err = s.memoryHostNodes(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.addKernelModulesParams(); err != nil {
+		return nil, err
+	}
+
+	// The guest hugepages of a sandbox fetched from its stored
+	// configuration were reserved when it was created.
+	if state, loadErr := s.store.LoadState(); loadErr != nil || state.State == "" {
+		if err = s.addHugePagesParams(); err != nil {
+			return nil, err
+		}
+	}
+
 	if err = s.hypervisor.createSandbox(ctx, s.id, &sandboxConfig.HypervisorConfig, s.store); err != nil {
 		return nil, err
 	}
@@ -564,7 +820,7 @@ func (s *Sandbox) storeSandbox() error {
 		}
 	}
 
-	return nil
+	return s.store.Store(store.Timeline, s.timeline)
 }
 
 func rLockSandbox(ctx context.Context, sandboxID string) (string, error) {
@@ -767,10 +1023,12 @@ func (s *Sandbox) createNetwork() error {
 	// after vm is started.
 	if s.factory == nil {
 		// Add the network
+		start := time.Now()
 		endpoints, err := s.network.Add(s.ctx, &s.config.NetworkConfig, s.hypervisor, false)
 		if err != nil {
 			return err
 		}
+		s.timeline.record(phaseNetwork, start)
 
 		s.networkNS.Endpoints = endpoints
 
@@ -841,6 +1099,7 @@ func (s *Sandbox) AddInterface(inf *vcTypes.Interface) (*vcTypes.Interface, erro
 	}
 
 	endpoint.SetProperties(netInfo)
+	setEndpointBandwidth(endpoint, &s.config.NetworkConfig)
 	if err := doNetNS(s.networkNS.NetNsPath, func(_ ns.NetNS) error {
 		s.Logger().WithField("endpoint-type", endpoint.Type()).Info("Hot attaching endpoint")
 		return endpoint.HotAttach(s.hypervisor)
@@ -899,7 +1158,13 @@ func (s *Sandbox) startVM() error {
 
 	s.Logger().Info("Starting VM")
 
+	if err := s.coldPlugVFIODevices(); err != nil {
+		return err
+	}
+
 	if err := s.network.Run(s.networkNS.NetNsPath, func() error {
+		start := time.Now()
+
 		if s.factory != nil {
 			vm, err := s.factory.GetVM(ctx, VMConfig{
 				HypervisorType:   s.config.HypervisorType,
@@ -912,6 +1177,8 @@ func (s *Sandbox) startVM() error {
 			if err != nil {
 				return err
 			}
+			s.timeline.record(phaseFactoryGetVM, start)
+
 			err = vm.assignSandbox(s)
 			if err != nil {
 				return err
@@ -919,7 +1186,12 @@ func (s *Sandbox) startVM() error {
 			return nil
 		}
 
-		return s.hypervisor.startSandbox(vmStartTimeout)
+		if err := s.hypervisor.startSandbox(vmStartTimeout); err != nil {
+			return err
+		}
+		s.timeline.record(phaseHypervisorStart, start)
+
+		return nil
 	}); err != nil {
 		return err
 	}
@@ -927,10 +1199,12 @@ func (s *Sandbox) startVM() error {
 	// In case of vm factory, network interfaces are hotplugged
 	// after vm is started.
 	if s.factory != nil {
+		start := time.Now()
 		endpoints, err := s.network.Add(s.ctx, &s.config.NetworkConfig, s.hypervisor, true)
 		if err != nil {
 			return err
 		}
+		s.timeline.record(phaseNetwork, start)
 
 		s.networkNS.Endpoints = endpoints
 
@@ -950,9 +1224,11 @@ func (s *Sandbox) startVM() error {
 	// we want to guarantee that it is manageable.
 	// For that we need to ask the agent to start the
 	// sandbox inside the VM.
+	start := time.Now()
 	if err := s.agent.startSandbox(s); err != nil {
 		return err
 	}
+	s.timeline.record(phaseAgentStart, start)
 
 	s.Logger().Info("Agent started in the sandbox")
 
@@ -1012,18 +1288,23 @@ func (s *Sandbox) fetchContainers() error {
 // This should be called only when the sandbox is already created.
 // It will add new container config to sandbox.config.Containers
 func (s *Sandbox) CreateContainer(contConfig ContainerConfig) (VCContainer, error) {
+	start := time.Now()
+
 	// Create the container.
 	c, err := newContainer(s, contConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.checkHugePages(contConfig); err != nil {
+		return nil, err
+	}
+
 	// Update sandbox config.
 	s.config.Containers = append(s.config.Containers, contConfig)
 
 	// Sandbox is reponsable to update VM resources needed by Containers
-	s.updateResources()
-	if err != nil {
+	if err := s.updateResources(); err != nil {
 		return nil, err
 	}
 
@@ -1051,6 +1332,13 @@ func (s *Sandbox) CreateContainer(contConfig ContainerConfig) (VCContainer, erro
 		return nil, err
 	}
 
+	if !s.timeline.recorded(phaseCreateContainer) {
+		s.timeline.record(phaseCreateContainer, start)
+		if err := s.store.Store(store.Timeline, s.timeline); err != nil {
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -1147,6 +1435,30 @@ func (s *Sandbox) ProcessListContainer(containerID string, options ProcessListOp
 	return c.processList(options)
 }
 
+// CopyToContainer extracts a tar archive into the dst directory of a
+// container root filesystem.
+func (s *Sandbox) CopyToContainer(containerID, dst string, archive io.Reader) error {
+	// Fetch the container.
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.copyTo(dst, archive)
+}
+
+// CopyFromContainer writes a tar archive of the src path of a container
+// root filesystem.
+func (s *Sandbox) CopyFromContainer(containerID, src string, archive io.Writer) error {
+	// Fetch the container.
+	c, err := s.findContainer(containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.copyFrom(src, archive)
+}
+
 // StatusContainer gets the status of a container
 // TODO: update container status properly, see kata-containers/runtime#253
 func (s *Sandbox) StatusContainer(containerID string) (ContainerStatus, error) {
@@ -1258,6 +1570,7 @@ func (s *Sandbox) createContainers() error {
 	}
 
 	for _, contConfig := range s.config.Containers {
+		start := time.Now()
 
 		c, err := newContainer(s, contConfig)
 		if err != nil {
@@ -1267,6 +1580,10 @@ func (s *Sandbox) createContainers() error {
 			return err
 		}
 
+		if !s.timeline.recorded(phaseCreateContainer) {
+			s.timeline.record(phaseCreateContainer, start)
+		}
+
 		if err := s.addContainer(c); err != nil {
 			return err
 		}
@@ -1572,17 +1889,78 @@ func (s *Sandbox) DecrementSandboxBlockIndex() error {
 	return s.decrementSandboxBlockIndex()
 }
 
-// AppendDevice can only handle vhost user device currently, it adds a
-// vhost user device to sandbox
+// AppendDevice can handle vhost user and cold plugged VFIO devices, it adds
+// them to the sandbox hypervisor boot command line.
 // Sandbox implement DeviceReceiver interface from device/api/interface.go
 func (s *Sandbox) AppendDevice(device api.Device) error {
 	switch device.DeviceType() {
 	case config.VhostUserSCSI, config.VhostUserNet, config.VhostUserBlk:
 		return s.hypervisor.addDevice(device.GetDeviceInfo().(*config.VhostUserDeviceAttrs), vhostuserDev)
+	case config.DeviceVFIO:
+		vfioDevices, ok := device.GetDeviceInfo().([]*config.VFIODev)
+		if !ok {
+			return fmt.Errorf("device type mismatch, expect device type to be %s", config.DeviceVFIO)
+		}
+
+		// appending a group of VFIO devices
+		for _, dev := range vfioDevices {
+			if err := s.hypervisor.addDevice(dev, vfioDev); err != nil {
+				s.Logger().
+					WithFields(logrus.Fields{
+						"sandbox":         s.id,
+						"vfio-device-ID":  dev.ID,
+						"vfio-device-BDF": dev.BDF,
+					}).WithError(err).Error("failed to cold plug VFIO device")
+				return err
+			}
+		}
+		return nil
 	}
 	return fmt.Errorf("unsupported device type")
 }
 
+// coldPlugVFIODevices creates the VFIO devices of the sandbox containers
+// and appends them to the hypervisor boot command line. This must be
+// called before the VM is started. The containers later find those
+// devices through the device manager and do not hotplug them again.
+func (s *Sandbox) coldPlugVFIODevices() error {
+	if !s.config.HypervisorConfig.ColdPlugVFIO {
+		return nil
+	}
+
+	if s.factory != nil {
+		s.Logger().Warn("VM factory enabled, VFIO devices will be hotplugged")
+		return nil
+	}
+
+	for i, contConfig := range s.config.Containers {
+		for j, info := range contConfig.DeviceInfos {
+			hostPath, err := config.GetHostPathFunc(info)
+			if err != nil {
+				return err
+			}
+
+			if !deviceManager.IsVFIO(hostPath) {
+				continue
+			}
+
+			info.ColdPlug = true
+			s.config.Containers[i].DeviceInfos[j].ColdPlug = true
+
+			dev, err := s.devManager.NewDevice(info)
+			if err != nil {
+				return err
+			}
+
+			if err := s.devManager.AttachDevice(dev.DeviceID(), s); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.storeSandboxDevices()
+}
+
 // AddDevice will add a device to sandbox
 func (s *Sandbox) AddDevice(info config.DeviceInfo) (api.Device, error) {
 	if s.devManager == nil {
@@ -1605,6 +1983,69 @@ func (s *Sandbox) AddDevice(info config.DeviceInfo) (api.Device, error) {
 	return b, nil
 }
 
+// RemoveDevice will remove a device from sandbox. The device is looked up
+// by its ID if any, or by its major and minor numbers otherwise.
+func (s *Sandbox) RemoveDevice(info config.DeviceInfo) (api.Device, error) {
+	if s.devManager == nil {
+		return nil, fmt.Errorf("device manager isn't initialized")
+	}
+
+	d, err := s.findDevice(info)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range s.containers {
+		for _, dev := range c.devices {
+			if dev.ID == d.DeviceID() {
+				return nil, fmt.Errorf("device %s is used by container %s", d.DeviceID(), c.id)
+			}
+		}
+	}
+
+	if err := s.devManager.DetachDevice(d.DeviceID(), s); err != nil {
+		return nil, err
+	}
+
+	if err := s.devManager.RemoveDevice(d.DeviceID()); err != nil {
+		return nil, err
+	}
+
+	if err := s.storeSandboxDevices(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// ListDevices will list all the devices of a sandbox
+func (s *Sandbox) ListDevices() ([]api.Device, error) {
+	if s.devManager == nil {
+		return nil, fmt.Errorf("device manager isn't initialized")
+	}
+
+	return s.devManager.GetAllDevices(), nil
+}
+
+func (s *Sandbox) findDevice(info config.DeviceInfo) (api.Device, error) {
+	if info.ID != "" {
+		if d := s.devManager.GetDeviceByID(info.ID); d != nil {
+			return d, nil
+		}
+
+		return nil, fmt.Errorf("device %s not found", info.ID)
+	}
+
+	for _, d := range s.devManager.GetAllDevices() {
+		major, minor := d.GetMajorMinor()
+		if major == info.Major && minor == info.Minor {
+			return d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("device %d:%d not found", info.Major, info.Minor)
+}
+
 func (s *Sandbox) updateResources() error {
 	// the hypervisor.MemorySize is the amount of memory reserved for
 	// the VM and contaniners without memory limit
@@ -1652,6 +2093,12 @@ func (s *Sandbox) updateResources() error {
 			return err
 		}
 	}
+
+	// The vCPUs are pinned again, since they may have changed along with
+	// the sandbox cpuset.
+	if err := s.pinVCPUs(); err != nil {
+		return err
+	}
 	s.Logger().Debugf("Sandbox CPUs: %d", newCPUs)
 
 	// Update Memory
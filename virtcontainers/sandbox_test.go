@@ -7,7 +7,13 @@ package virtcontainers
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -26,6 +32,7 @@ import (
 	"github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
 	"github.com/kata-containers/runtime/virtcontainers/store"
 	"github.com/kata-containers/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 )
 
@@ -950,6 +957,96 @@ func TestSandboxAttachDevicesVFIO(t *testing.T) {
 	assert.Nil(t, err, "Error while detaching devices %s", err)
 }
 
+func TestSandboxColdPlugVFIODevices(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	testFDIOGroup := "2"
+	testDeviceBDFPath := "0000:00:1c.0"
+
+	devicesDir := filepath.Join(tmpDir, testFDIOGroup, "devices")
+	err = os.MkdirAll(devicesDir, store.DirMode)
+	assert.Nil(err)
+
+	_, err = os.Create(filepath.Join(devicesDir, testDeviceBDFPath))
+	assert.Nil(err)
+
+	savedIOMMUPath := config.SysIOMMUPath
+	config.SysIOMMUPath = tmpDir
+
+	savedGetHostPathFunc := config.GetHostPathFunc
+	config.GetHostPathFunc = func(devInfo config.DeviceInfo) (string, error) {
+		return devInfo.ContainerPath, nil
+	}
+
+	defer func() {
+		config.SysIOMMUPath = savedIOMMUPath
+		config.GetHostPathFunc = savedGetHostPathFunc
+	}()
+
+	path := filepath.Join(vfioPath, testFDIOGroup)
+	vfioInfo := config.DeviceInfo{
+		ContainerPath: path,
+		DevType:       "c",
+		Major:         241,
+		Minor:         2,
+	}
+	blockInfo := config.DeviceInfo{
+		ContainerPath: "/dev/sda",
+		DevType:       "b",
+		Major:         8,
+		Minor:         0,
+	}
+
+	sandbox := Sandbox{
+		id:         "100",
+		hypervisor: &mockHypervisor{},
+		devManager: manager.NewDeviceManager(manager.VirtioSCSI, nil),
+		ctx:        context.Background(),
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				ColdPlugVFIO: true,
+			},
+			Containers: []ContainerConfig{
+				{
+					ID:          "100",
+					DeviceInfos: []config.DeviceInfo{vfioInfo, blockInfo},
+				},
+			},
+		},
+	}
+
+	vcStore, err := store.NewVCSandboxStore(sandbox.ctx, sandbox.id)
+	assert.Nil(err)
+	sandbox.store = vcStore
+
+	err = sandbox.coldPlugVFIODevices()
+	assert.Nil(err)
+
+	// Only the VFIO device is created and attached
+	devices := sandbox.devManager.GetAllDevices()
+	assert.Len(devices, 1)
+	assert.Equal(devices[0].DeviceType(), config.DeviceVFIO)
+	assert.True(sandbox.devManager.IsDeviceAttached(devices[0].DeviceID()))
+	assert.True(sandbox.config.Containers[0].DeviceInfos[0].ColdPlug)
+	assert.False(sandbox.config.Containers[0].DeviceInfos[1].ColdPlug)
+
+	// The container finds the cold plugged device
+	dev, err := sandbox.devManager.NewDevice(vfioInfo)
+	assert.Nil(err)
+	assert.Equal(dev.DeviceID(), devices[0].DeviceID())
+
+	// Nothing is cold plugged when disabled
+	sandbox.devManager = manager.NewDeviceManager(manager.VirtioSCSI, nil)
+	sandbox.config.HypervisorConfig.ColdPlugVFIO = false
+	err = sandbox.coldPlugVFIODevices()
+	assert.Nil(err)
+	assert.Len(sandbox.devManager.GetAllDevices(), 0)
+}
+
 var assetContent = []byte("FakeAsset fake asset FAKE ASSET")
 var assetContentHash = "92549f8d2018a95a294d28a65e795ed7d1a9d150009a28cea108ae10101178676f04ab82a6950d0099e4924f9c5e41dcba8ece56b75fc8b4e0a7492cb2a8c880"
 var assetContentWrongHash = "92549f8d2018a95a294d28a65e795ed7d1a9d150009a28cea108ae10101178676f04ab82a6950d0099e4924f9c5e41dcba8ece56b75fc8b4e0a7492cb2a8c881"
@@ -1004,6 +1101,105 @@ func TestSandboxCreateAssets(t *testing.T) {
 	assert.NotNil(err)
 }
 
+func TestSandboxCreateAssetsSignatureVerification(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "virtcontainers-signature-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(err)
+
+	keyPath := filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644)
+	assert.Nil(err)
+
+	sigDir := filepath.Join(dir, "signatures")
+	err = os.Mkdir(sigDir, 0755)
+	assert.Nil(err)
+
+	kernelPath := filepath.Join(dir, "vmlinuz")
+	imagePath := filepath.Join(dir, "image")
+	for _, path := range []string{kernelPath, imagePath} {
+		err = ioutil.WriteFile(path, assetContent, 0644)
+		assert.Nil(err)
+	}
+
+	digest := sha512.Sum512(assetContent)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA512, digest[:])
+	assert.Nil(err)
+
+	hc := HypervisorConfig{
+		KernelPath:         kernelPath,
+		ImagePath:          imagePath,
+		AssetTrustedKeys:   []string{keyPath},
+		AssetSignaturesDir: sigDir,
+	}
+
+	// Missing signatures
+	results, err := hc.VerifyAssets()
+	assert.Nil(err)
+	assert.Len(results, 2)
+	for _, r := range results {
+		assert.NotNil(r.Err)
+	}
+
+	err = createAssets(context.Background(), &SandboxConfig{HypervisorConfig: hc})
+	assert.NotNil(err)
+
+	// Valid signatures
+	for _, name := range []string{"vmlinuz.sig", "image.sig"} {
+		err = ioutil.WriteFile(filepath.Join(sigDir, name), sig, 0644)
+		assert.Nil(err)
+	}
+
+	results, err = hc.VerifyAssets()
+	assert.Nil(err)
+	assert.Len(results, 2)
+	for _, r := range results {
+		assert.Nil(r.Err)
+		assert.Equal(filepath.Join(sigDir, filepath.Base(r.Path)+".sig"), r.Signature)
+	}
+
+	err = createAssets(context.Background(), &SandboxConfig{HypervisorConfig: hc})
+	assert.Nil(err)
+
+	// A custom asset needs to be signed as well
+	err = ioutil.WriteFile(filepath.Join(dir, "custom-vmlinuz"), assetContent, 0644)
+	assert.Nil(err)
+
+	p := &SandboxConfig{
+		Annotations: map[string]string{
+			annotations.KernelPath: filepath.Join(dir, "custom-vmlinuz"),
+			annotations.KernelHash: assetContentHash,
+		},
+		HypervisorConfig: hc,
+	}
+
+	err = createAssets(context.Background(), p)
+	assert.NotNil(err)
+
+	// Tampered asset
+	err = ioutil.WriteFile(imagePath, []byte("tampered"), 0644)
+	assert.Nil(err)
+
+	err = createAssets(context.Background(), &SandboxConfig{HypervisorConfig: hc})
+	assert.NotNil(err)
+
+	// Nothing is verified without trusted keys
+	hc.AssetTrustedKeys = nil
+	results, err = hc.VerifyAssets()
+	assert.Nil(err)
+	assert.Nil(results)
+
+	err = createAssets(context.Background(), &SandboxConfig{HypervisorConfig: hc})
+	assert.Nil(err)
+}
+
 func testFindContainerFailure(t *testing.T, sandbox *Sandbox, cid string) {
 	c, err := sandbox.findContainer(cid)
 	assert.Nil(t, c, "Container pointer should be nil")
@@ -1476,6 +1672,75 @@ func TestPreAddDevice(t *testing.T) {
 		"ignoreMounts should contain nothing because it only contains a block device")
 }
 
+func TestSandboxRemoveAndListDevices(t *testing.T) {
+	assert := assert.New(t)
+
+	dm := manager.NewDeviceManager(config.VirtioBlock, nil)
+	sandbox := &Sandbox{
+		id:         testSandboxID,
+		hypervisor: &mockHypervisor{},
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				BlockDeviceDriver: config.VirtioBlock,
+			},
+		},
+		devManager: dm,
+		containers: map[string]*Container{},
+		ctx:        context.Background(),
+	}
+
+	vcStore, err := store.NewVCSandboxStore(sandbox.ctx, sandbox.id)
+	assert.NoError(err)
+	sandbox.store = vcStore
+	defer vcStore.Delete()
+
+	devices, err := sandbox.ListDevices()
+	assert.NoError(err)
+	assert.Empty(devices)
+
+	path := "/dev/hda"
+	deviceInfo := config.DeviceInfo{
+		HostPath:      path,
+		ContainerPath: path,
+		DevType:       "b",
+		Major:         3,
+		Minor:         0,
+	}
+
+	dev, err := sandbox.AddDevice(deviceInfo)
+	assert.NoError(err)
+
+	devices, err = sandbox.ListDevices()
+	assert.NoError(err)
+	assert.Len(devices, 1)
+	assert.Equal(dev.DeviceID(), devices[0].DeviceID())
+
+	// Devices used by a container can not be removed.
+	sandbox.containers["100"] = &Container{
+		id:      "100",
+		devices: []ContainerDevice{{ID: dev.DeviceID()}},
+	}
+	_, err = sandbox.RemoveDevice(config.DeviceInfo{ID: dev.DeviceID()})
+	assert.Error(err)
+	delete(sandbox.containers, "100")
+
+	_, err = sandbox.RemoveDevice(config.DeviceInfo{Major: 3, Minor: 1})
+	assert.Error(err)
+
+	removed, err := sandbox.RemoveDevice(config.DeviceInfo{Major: 3, Minor: 0})
+	assert.NoError(err)
+	assert.Equal(dev.DeviceID(), removed.DeviceID())
+
+	devices, err = sandbox.ListDevices()
+	assert.NoError(err)
+	assert.Empty(devices)
+
+	// The removal has been persisted.
+	stored, err := sandbox.store.LoadDevices()
+	assert.NoError(err)
+	assert.Empty(stored)
+}
+
 func TestGetNetNs(t *testing.T) {
 	s := Sandbox{}
 
@@ -1525,3 +1790,168 @@ func TestSandboxStopStopped(t *testing.T) {
 
 	assert.Nil(t, err)
 }
+
+func TestSandboxAddKernelModulesParams(t *testing.T) {
+	assert := assert.New(t)
+
+	kernelParams := make([]Param, 1, 4)
+	kernelParams[0] = Param{Key: "foo", Value: "bar"}
+
+	s := &Sandbox{
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				KernelParams: kernelParams,
+			},
+			AgentConfig: KataAgentConfig{
+				KernelModules: []string{"i915 enable_guc=1"},
+			},
+		},
+	}
+
+	expected := []Param{
+		{Key: "foo", Value: "bar"},
+		{Key: "i915.enable_guc", Value: "1"},
+		{Key: "modules-load", Value: "i915"},
+	}
+
+	err := s.addKernelModulesParams()
+	assert.NoError(err)
+	assert.Equal(expected, s.config.HypervisorConfig.KernelParams)
+
+	// the runtime configuration is left untouched
+	assert.Equal(Param{}, kernelParams[:2][1])
+
+	// the parameters are not added again to a fetched sandbox
+	err = s.addKernelModulesParams()
+	assert.NoError(err)
+	assert.Equal(expected, s.config.HypervisorConfig.KernelParams)
+
+	s.config.AgentConfig = KataAgentConfig{
+		KernelModules: []string{"../i915"},
+	}
+	err = s.addKernelModulesParams()
+	assert.Error(err)
+
+	// only the kata agent supports it
+	s.config.AgentConfig = HyperConfig{}
+	err = s.addKernelModulesParams()
+	assert.NoError(err)
+}
+
+func TestSandboxAddHugePagesParams(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				MemorySize:   2048,
+				KernelParams: []Param{{Key: "foo", Value: "bar"}},
+			},
+		},
+	}
+
+	// nothing to reserve
+	assert.NoError(s.addHugePagesParams())
+	assert.Equal([]Param{{Key: "foo", Value: "bar"}}, s.config.HypervisorConfig.KernelParams)
+	assert.Equal(uint32(2048), s.config.HypervisorConfig.MemorySize)
+
+	s.config.Containers = []ContainerConfig{
+		{
+			Resources: specs.LinuxResources{
+				HugepageLimits: []specs.LinuxHugepageLimit{
+					{Pagesize: "1GB", Limit: 1 << 30},
+					{Pagesize: "2MB", Limit: 5 << 20},
+				},
+			},
+		},
+	}
+	s.shmSize = 4 << 20
+	s.shmPageSize = 2 << 20
+
+	expected := []Param{
+		{Key: "foo", Value: "bar"},
+		{Key: "hugepagesz", Value: "2048K"},
+		{Key: "hugepages", Value: "5"},
+		{Key: "hugepagesz", Value: "1048576K"},
+		{Key: "hugepages", Value: "1"},
+	}
+
+	assert.NoError(s.addHugePagesParams())
+	assert.Equal(expected, s.config.HypervisorConfig.KernelParams)
+	assert.Equal(uint32(2048+10+1024), s.config.HypervisorConfig.MemorySize)
+
+	// the hugepages are not reserved again when already configured
+	assert.NoError(s.addHugePagesParams())
+	assert.Equal(expected, s.config.HypervisorConfig.KernelParams)
+	assert.Equal(uint32(2048+10+1024), s.config.HypervisorConfig.MemorySize)
+
+	s.config.HypervisorConfig.KernelParams = nil
+	s.config.Containers[0].Resources.HugepageLimits[0].Pagesize = "foo"
+	assert.Error(s.addHugePagesParams())
+}
+
+func TestSandboxReservedHugePages(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		config: &SandboxConfig{},
+	}
+	assert.Empty(s.reservedHugePages())
+
+	s.config.HypervisorConfig.KernelParams = []Param{
+		{Key: "hugepages", Value: "3"},
+		{Key: "hugepagesz", Value: "1048576K"},
+		{Key: "hugepages", Value: "1"},
+		{Key: "hugepagesz", Value: "2048K"},
+		{Key: "hugepages", Value: "5"},
+		{Key: "hugepagesz", Value: "foo"},
+		{Key: "hugepages", Value: "bar"},
+	}
+	assert.Equal(map[uint64]uint64{
+		2 << 20: 8,
+		1 << 30: 1,
+	}, s.reservedHugePages())
+}
+
+func TestSandboxCheckHugePages(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		config: &SandboxConfig{
+			Containers: []ContainerConfig{
+				{
+					ID: "pause",
+					Resources: specs.LinuxResources{
+						HugepageLimits: []specs.LinuxHugepageLimit{
+							{Pagesize: "2MB", Limit: 0},
+						},
+					},
+				},
+			},
+		},
+		shmSize:     4 << 20,
+		shmPageSize: 2 << 20,
+	}
+
+	contConfig := ContainerConfig{
+		ID: "foo",
+		Resources: specs.LinuxResources{
+			HugepageLimits: []specs.LinuxHugepageLimit{
+				{Pagesize: "2MB", Limit: 5 << 20},
+			},
+		},
+	}
+
+	// only the shm hugepages are reserved
+	s.config.HypervisorConfig.KernelParams = []Param{
+		{Key: "hugepagesz", Value: "2048K"},
+		{Key: "hugepages", Value: "2"},
+	}
+	assert.Error(s.checkHugePages(contConfig))
+
+	s.config.HypervisorConfig.KernelParams[1].Value = "5"
+	assert.NoError(s.checkHugePages(contConfig))
+
+	contConfig.Resources.HugepageLimits[0].Pagesize = "foo"
+	assert.Error(s.checkHugePages(contConfig))
+}
@@ -7,7 +7,6 @@ package store
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -46,6 +45,9 @@ const (
 
 	// DevicesFile is the file name storing a container's devices.
 	DevicesFile = "devices.json"
+
+	// TimelineFile is the file name storing a sandbox boot timeline.
+	TimelineFile = "timeline.json"
 )
 
 // DirMode is the permission bits used for creating a directory
@@ -75,6 +77,15 @@ var RunStoragePath = filepath.Join("/run", StoragePathSuffix, SandboxPathSuffix)
 // It will contain all guest vm sockets and shared mountpoints.
 var RunVMStoragePath = filepath.Join("/run", StoragePathSuffix, VMPathSuffix)
 
+// SetStorageRoot relocates the sandbox configuration, runtime and vm
+// directories under root, so that several runtime instances can share a
+// host.
+func SetStorageRoot(root string) {
+	ConfigStoragePath = filepath.Join(root, "lib", StoragePathSuffix, SandboxPathSuffix)
+	RunStoragePath = filepath.Join(root, StoragePathSuffix, SandboxPathSuffix)
+	RunVMStoragePath = filepath.Join(root, StoragePathSuffix, VMPathSuffix)
+}
+
 func itemToFile(item Item) (string, error) {
 	switch item {
 	case Configuration:
@@ -95,6 +106,8 @@ func itemToFile(item Item) (string, error) {
 		return MountsFile, nil
 	case Devices, DeviceIDs:
 		return DevicesFile, nil
+	case Timeline:
+		return TimelineFile, nil
 	}
 
 	return "", fmt.Errorf("Unknown item %s", item)
@@ -208,11 +221,7 @@ func (f *filesystem) load(item Item, data interface{}) error {
 		return err
 	}
 
-	if err := json.Unmarshal(fileData, data); err != nil {
-		return err
-	}
-
-	return nil
+	return loadItem(item, fileData, data)
 }
 
 func (f *filesystem) store(item Item, data interface{}) error {
@@ -232,7 +241,7 @@ func (f *filesystem) store(item Item, data interface{}) error {
 	}
 	defer file.Close()
 
-	jsonOut, err := json.Marshal(data)
+	jsonOut, err := encodeItem(item, data)
 	if err != nil {
 		return fmt.Errorf("Could not marshall data: %s", err)
 	}
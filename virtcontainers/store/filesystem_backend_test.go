@@ -147,3 +147,23 @@ func TestStoreFilesystemLockExclusive(t *testing.T) {
 	err = f.unlock(Lock, token)
 	assert.NotNil(t, err)
 }
+
+func TestStoreSetStorageRoot(t *testing.T) {
+	assert := assert.New(t)
+
+	savedConfigStoragePath := ConfigStoragePath
+	savedRunStoragePath := RunStoragePath
+	savedRunVMStoragePath := RunVMStoragePath
+	defer func() {
+		ConfigStoragePath = savedConfigStoragePath
+		RunStoragePath = savedRunStoragePath
+		RunVMStoragePath = savedRunVMStoragePath
+	}()
+
+	SetStorageRoot("/run/foo")
+
+	assert.Equal("/run/foo/lib/vc/sbs", ConfigStoragePath)
+	assert.Equal("/run/foo/vc/sbs", RunStoragePath)
+	assert.Equal("/run/foo/vc/vm", RunVMStoragePath)
+	assert.Equal("/run/foo/vc/sbs/bar", SandboxRuntimeRootPath("bar"))
+}
@@ -48,6 +48,9 @@ const (
 
 	// DeviceIDs represents a set of reference IDs item to be stored.
 	DeviceIDs
+
+	// Timeline represents a sandbox boot timeline item to be stored.
+	Timeline
 )
 
 func (i Item) String() string {
@@ -72,6 +75,8 @@ func (i Item) String() string {
 		return "Devices"
 	case DeviceIDs:
 		return "Device IDs"
+	case Timeline:
+		return "Timeline"
 	}
 
 	return ""
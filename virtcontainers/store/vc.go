@@ -32,7 +32,7 @@ func (s *VCStore) itemToStore(item Item) *Store {
 	switch item {
 	case Configuration:
 		return s.config
-	case State, Network, Hypervisor, Agent, Process, Lock, Mounts, Devices, DeviceIDs:
+	case State, Network, Hypervisor, Agent, Process, Lock, Mounts, Devices, DeviceIDs, Timeline:
 		return s.state
 	}
 
@@ -0,0 +1,341 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ItemVersion is the version of the on-disk format of a Store item.
+// It must be bumped, and a migration registered, every time the
+// structure persisted for an item changes in an incompatible way.
+//
+// Items are stored in the legacy, unversioned, format as long as their
+// version is LegacyItemVersion. This keeps the store readable by older
+// runtimes: downgrading the runtime is only possible as long as all the
+// items of the existing sandboxes are stored with LegacyItemVersion, which
+// "kata-runtime state-migrate --check" reports. Items stored with a newer
+// version are wrapped into a versioned envelope and can not be loaded by
+// older runtimes, their sandboxes have to be deleted before downgrading.
+type ItemVersion uint32
+
+// LegacyItemVersion is the version of items stored by runtimes that
+// did not version their on-disk format. Items with this version are
+// not wrapped into a versioned envelope.
+const LegacyItemVersion ItemVersion = 1
+
+// itemVersionKey is the JSON key holding the version of a stored item.
+// It is voluntarily verbose so that it can not clash with any field of
+// a legacy, unversioned item.
+const itemVersionKey = "kataStoreItemVersion"
+
+// versionedItem is the on-disk representation of the Store items
+// stored with a version newer than LegacyItemVersion.
+type versionedItem struct {
+	Version ItemVersion     `json:"kataStoreItemVersion"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// MigrationFunc converts the JSON representation of an item from one
+// version to the next one.
+type MigrationFunc func(data json.RawMessage) (json.RawMessage, error)
+
+type migrationRegistry struct {
+	sync.RWMutex
+
+	// current is the version each item is stored with.
+	current map[Item]ItemVersion
+
+	// migrations holds, for each item, the migration functions indexed
+	// by the version they migrate from.
+	migrations map[Item]map[ItemVersion]MigrationFunc
+}
+
+var registry = &migrationRegistry{
+	current: map[Item]ItemVersion{
+		Configuration: LegacyItemVersion,
+		State:         LegacyItemVersion,
+		Network:       LegacyItemVersion,
+		Hypervisor:    LegacyItemVersion,
+		Agent:         LegacyItemVersion,
+		Process:       LegacyItemVersion,
+		Mounts:        LegacyItemVersion,
+		Devices:       LegacyItemVersion,
+		DeviceIDs:     LegacyItemVersion,
+		Timeline:      LegacyItemVersion,
+	},
+	migrations: make(map[Item]map[ItemVersion]MigrationFunc),
+}
+
+// CurrentItemVersion returns the version an item is stored with.
+func CurrentItemVersion(item Item) ItemVersion {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.current[item]
+}
+
+// RegisterMigration registers the function migrating an item from
+// version from to version from + 1.
+func RegisterMigration(item Item, from ItemVersion, fn MigrationFunc) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.migrations[item] == nil {
+		registry.migrations[item] = make(map[ItemVersion]MigrationFunc)
+	}
+
+	registry.migrations[item][from] = fn
+}
+
+// migrate applies all the registered migrations needed to bring
+// an item from version to the current version.
+func migrate(item Item, version ItemVersion, data json.RawMessage) (json.RawMessage, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	current := registry.current[item]
+	if version > current {
+		return nil, fmt.Errorf("%s item version %d is newer than the supported version %d", item, version, current)
+	}
+
+	for v := version; v < current; v++ {
+		fn := registry.migrations[item][v]
+		if fn == nil {
+			return nil, fmt.Errorf("No migration registered for %s item version %d", item, v)
+		}
+
+		migrated, err := fn(data)
+		if err != nil {
+			return nil, fmt.Errorf("Could not migrate %s item from version %d: %v", item, v, err)
+		}
+
+		data = migrated
+	}
+
+	return data, nil
+}
+
+// decodeItem extracts the version and the JSON payload of a stored item.
+// Items not wrapped into a versioned envelope are legacy ones.
+func decodeItem(fileData []byte) (ItemVersion, json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(fileData, &fields); err == nil {
+		if _, ok := fields[itemVersionKey]; ok {
+			var v versionedItem
+			if err := json.Unmarshal(fileData, &v); err != nil {
+				return 0, nil, err
+			}
+
+			return v.Version, v.Data, nil
+		}
+	}
+
+	if !json.Valid(fileData) {
+		return 0, nil, fmt.Errorf("Invalid JSON item")
+	}
+
+	return LegacyItemVersion, json.RawMessage(fileData), nil
+}
+
+// encodeItem wraps an item payload into a versioned envelope, unless
+// the item is still stored with the legacy version.
+func encodeItem(item Item, data interface{}) ([]byte, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	version := CurrentItemVersion(item)
+	if version == LegacyItemVersion {
+		return payload, nil
+	}
+
+	return json.Marshal(versionedItem{
+		Version: version,
+		Data:    payload,
+	})
+}
+
+// loadItem decodes a stored item, migrates it to the current
+// version and unmarshals it into data.
+func loadItem(item Item, fileData []byte, data interface{}) error {
+	version, payload, err := decodeItem(fileData)
+	if err != nil {
+		return err
+	}
+
+	payload, err = migrate(item, version, payload)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload, data)
+}
+
+// ItemStatus describes the on-disk format of a stored item file.
+type ItemStatus struct {
+	// Path is the item file path.
+	Path string
+
+	// Item is the item stored in the file.
+	Item Item
+
+	// Version is the version the item is currently stored with.
+	Version ItemVersion
+
+	// Error is set when the item can not be migrated to the
+	// current version, i.e. when it can not be loaded.
+	Error error
+}
+
+// NeedsMigration returns true if the item is not stored with
+// the current version.
+func (i ItemStatus) NeedsMigration() bool {
+	return i.Error == nil && i.Version != CurrentItemVersion(i.Item)
+}
+
+func fileToItem(name string) (Item, bool) {
+	switch name {
+	case ConfigurationFile:
+		return Configuration, true
+	case StateFile:
+		return State, true
+	case NetworkFile:
+		return Network, true
+	case HypervisorFile:
+		return Hypervisor, true
+	case AgentFile:
+		return Agent, true
+	case ProcessFile:
+		return Process, true
+	case MountsFile:
+		return Mounts, true
+	case DevicesFile:
+		return Devices, true
+	case TimelineFile:
+		return Timeline, true
+	}
+
+	return 0, false
+}
+
+// itemFiles returns all the item files found under a store root path,
+// and under its children container store paths.
+func itemFiles(root string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == "raw" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if _, ok := fileToItem(info.Name()); ok {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+func checkItemFile(path string, migrateItem bool) ItemStatus {
+	item, _ := fileToItem(filepath.Base(path))
+	status := ItemStatus{
+		Path: path,
+		Item: item,
+	}
+
+	fileData, err := ioutil.ReadFile(path)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	version, payload, err := decodeItem(fileData)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	status.Version = version
+
+	payload, err = migrate(item, version, payload)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	if !migrateItem || version == CurrentItemVersion(item) {
+		return status
+	}
+
+	out, err := encodeItem(item, payload)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	if err := ioutil.WriteFile(path, out, 0640); err != nil {
+		status.Error = err
+		return status
+	}
+
+	status.Version = CurrentItemVersion(item)
+
+	return status
+}
+
+func sandboxItemStatus(sandboxID string, migrateItems bool) ([]ItemStatus, error) {
+	if sandboxID == "" {
+		return nil, fmt.Errorf("sandbox ID can not be empty")
+	}
+
+	var statuses []ItemStatus
+
+	for _, root := range []string{SandboxConfigurationRootPath(sandboxID), SandboxRuntimeRootPath(sandboxID)} {
+		files, err := itemFiles(root)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files {
+			statuses = append(statuses, checkItemFile(f, migrateItems))
+		}
+	}
+
+	return statuses, nil
+}
+
+// CheckVCSandboxItems reports the on-disk format of all the items stored
+// for a sandbox and its containers, and whether they can be migrated to
+// the current version.
+func CheckVCSandboxItems(sandboxID string) ([]ItemStatus, error) {
+	return sandboxItemStatus(sandboxID, false)
+}
+
+// MigrateVCSandboxItems rewrites all the items stored for a sandbox and
+// its containers with the current version. The caller is expected to hold
+// the sandbox lock.
+func MigrateVCSandboxItems(sandboxID string) ([]ItemStatus, error) {
+	return sandboxItemStatus(sandboxID, true)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var legacyFilesystemData = "{\"Field1\":\"value1\",\"Field2\":\"value2\"}"
+
+func TestStoreVersionLoadLegacyItem(t *testing.T) {
+	f := filesystem{}
+
+	err := f.new(context.Background(), rootPath, "")
+	defer f.delete()
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(rootPath, StateFile), []byte(legacyFilesystemData), 0640)
+	assert.Nil(t, err)
+
+	data := TestNoopStructure{}
+	err = f.load(State, &data)
+	assert.Nil(t, err)
+	assert.Equal(t, data.Field1, "value1")
+	assert.Equal(t, data.Field2, "value2")
+}
+
+func TestStoreVersionLoadLegacyArray(t *testing.T) {
+	version, payload, err := decodeItem([]byte("[{\"Type\":\"block\"}]"))
+	assert.Nil(t, err)
+	assert.Equal(t, version, LegacyItemVersion)
+	assert.Equal(t, string(payload), "[{\"Type\":\"block\"}]")
+
+	_, _, err = decodeItem([]byte("{invalid"))
+	assert.NotNil(t, err)
+}
+
+func TestStoreVersionMigrate(t *testing.T) {
+	registry.Lock()
+	savedVersion := registry.current[Process]
+	registry.current[Process] = savedVersion + 1
+	registry.Unlock()
+
+	// No migration registered yet.
+	_, err := migrate(Process, savedVersion, json.RawMessage(legacyFilesystemData))
+	assert.NotNil(t, err)
+
+	registry.Lock()
+	registry.current[Process] = savedVersion
+	registry.Unlock()
+
+	restore := bumpItemVersion(Process)
+	defer restore()
+
+	// From legacy, through all registered migrations.
+	data := TestNoopStructure{}
+	err = loadItem(Process, []byte(legacyFilesystemData), &data)
+	assert.Nil(t, err)
+	assert.Equal(t, data.Field1, "value2")
+	assert.Equal(t, data.Field2, "value1")
+
+	// Items newer than what we support can not be loaded.
+	_, err = migrate(Process, savedVersion+2, json.RawMessage(legacyFilesystemData))
+	assert.NotNil(t, err)
+}
+
+// bumpItemVersion makes an item stored with a version newer than the
+// legacy one, swapping its fields, and returns the function restoring the
+// registry.
+func bumpItemVersion(item Item) func() {
+	registry.Lock()
+	savedVersion := registry.current[item]
+	registry.current[item] = savedVersion + 1
+	registry.Unlock()
+
+	RegisterMigration(item, savedVersion, func(data json.RawMessage) (json.RawMessage, error) {
+		var old TestNoopStructure
+		if err := json.Unmarshal(data, &old); err != nil {
+			return nil, err
+		}
+
+		old.Field1, old.Field2 = old.Field2, old.Field1
+
+		return json.Marshal(old)
+	})
+
+	return func() {
+		registry.Lock()
+		registry.current[item] = savedVersion
+		delete(registry.migrations[item], savedVersion)
+		registry.Unlock()
+	}
+}
+
+func TestStoreVersionEncodeItem(t *testing.T) {
+	// Legacy items are not wrapped, so that older runtimes can load them.
+	out, err := encodeItem(State, TestNoopStructure{Field1: "value1", Field2: "value2"})
+	assert.Nil(t, err)
+	assert.Equal(t, string(out), expectedFilesystemData)
+
+	restore := bumpItemVersion(State)
+	defer restore()
+
+	out, err = encodeItem(State, TestNoopStructure{Field1: "value1", Field2: "value2"})
+	assert.Nil(t, err)
+	assert.Equal(t, string(out), fmt.Sprintf("{\"%s\":%d,\"data\":%s}",
+		itemVersionKey, LegacyItemVersion+1, expectedFilesystemData))
+
+	version, payload, err := decodeItem(out)
+	assert.Nil(t, err)
+	assert.Equal(t, version, LegacyItemVersion+1)
+	assert.Equal(t, string(payload), expectedFilesystemData)
+}
+
+func TestStoreVersionCheckAndMigrateSandbox(t *testing.T) {
+	sandboxID := "version-sandbox"
+	containerID := "version-container"
+
+	configRoot := SandboxConfigurationRootPath(sandboxID)
+	containerRoot := ContainerRuntimeRootPath(sandboxID, containerID)
+	defer os.RemoveAll(configRoot)
+	defer os.RemoveAll(SandboxRuntimeRootPath(sandboxID))
+
+	assert.Nil(t, os.MkdirAll(configRoot, DirMode))
+	assert.Nil(t, os.MkdirAll(filepath.Join(containerRoot, "raw"), DirMode))
+
+	configFile := filepath.Join(configRoot, ConfigurationFile)
+	stateFile := filepath.Join(containerRoot, StateFile)
+	newerFile := filepath.Join(containerRoot, ProcessFile)
+
+	assert.Nil(t, ioutil.WriteFile(configFile, []byte(legacyFilesystemData), 0640))
+	assert.Nil(t, ioutil.WriteFile(stateFile, []byte(legacyFilesystemData), 0640))
+	newer := fmt.Sprintf("{\"%s\":%d,\"data\":{}}", itemVersionKey, CurrentItemVersion(Process)+1)
+	assert.Nil(t, ioutil.WriteFile(newerFile, []byte(newer), 0640))
+	// Raw files are not store items.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(containerRoot, "raw", StateFile), []byte("raw"), 0640))
+
+	restore := bumpItemVersion(State)
+	defer restore()
+
+	statuses, err := CheckVCSandboxItems(sandboxID)
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 3)
+
+	for _, s := range statuses {
+		switch s.Path {
+		case configFile:
+			assert.Nil(t, s.Error)
+			assert.Equal(t, s.Version, LegacyItemVersion)
+			assert.False(t, s.NeedsMigration())
+		case stateFile:
+			assert.Nil(t, s.Error)
+			assert.Equal(t, s.Version, LegacyItemVersion)
+			assert.True(t, s.NeedsMigration())
+		case newerFile:
+			assert.NotNil(t, s.Error)
+			assert.False(t, s.NeedsMigration())
+		default:
+			t.Fatalf("Unexpected item %s", s.Path)
+		}
+	}
+
+	// Checking must not modify anything.
+	content, err := ioutil.ReadFile(stateFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), legacyFilesystemData)
+
+	statuses, err = MigrateVCSandboxItems(sandboxID)
+	assert.Nil(t, err)
+
+	for _, s := range statuses {
+		if s.Path == newerFile {
+			continue
+		}
+		assert.Nil(t, s.Error)
+		assert.Equal(t, s.Version, CurrentItemVersion(s.Item))
+	}
+
+	// Items still stored with the legacy version are left untouched.
+	content, err = ioutil.ReadFile(configFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), legacyFilesystemData)
+
+	content, err = ioutil.ReadFile(stateFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), fmt.Sprintf("{\"%s\":%d,\"data\":%s}",
+		itemVersionKey, LegacyItemVersion+1, "{\"Field1\":\"value2\",\"Field2\":\"value1\"}"))
+
+	_, err = CheckVCSandboxItems("")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Sandbox startup phases recorded in the sandbox timeline.
+const (
+	phaseCreateSandbox   = "createSandbox"
+	phaseNetwork         = "network"
+	phaseFactoryGetVM    = "factory.GetVM"
+	phaseHypervisorStart = "hypervisor.startSandbox"
+	phaseAgentStart      = "agent.startSandbox"
+	phaseGuestDetails    = "agent.getGuestDetails"
+	phaseCreateContainer = "createContainer"
+)
+
+// TimelinePhase is a timed phase of a sandbox startup.
+type TimelinePhase struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// End returns the time the phase ended.
+func (p TimelinePhase) End() time.Time {
+	return p.Start.Add(p.Duration)
+}
+
+// SandboxTimeline records the phases of a sandbox startup, from the
+// sandbox creation to the creation of its first container.
+// The hypervisor.startSandbox phase includes waiting for the VM to be
+// ready, and is replaced by the factory.GetVM phase when the VM is
+// provided by a VM factory.
+type SandboxTimeline struct {
+	Phases []TimelinePhase `json:"phases"`
+
+	// GuestKernelBoot is the time the guest kernel took to boot, from
+	// the kernel log read on the guest console. It is zero if the
+	// console was not watched, or if the kernel log was not printed on
+	// it, e.g. because of the "quiet" kernel parameter.
+	GuestKernelBoot time.Duration `json:"guestKernelBoot"`
+}
+
+// record adds a phase which started at start and ends now.
+func (t *SandboxTimeline) record(name string, start time.Time) {
+	t.Phases = append(t.Phases, TimelinePhase{
+		Name:     name,
+		Start:    start,
+		Duration: time.Since(start),
+	})
+}
+
+// recorded returns true if the phase has already been recorded.
+func (t *SandboxTimeline) recorded(name string) bool {
+	for _, p := range t.Phases {
+		if p.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Total returns the time elapsed between the start of the first phase
+// and the end of the last one.
+func (t *SandboxTimeline) Total() time.Duration {
+	if len(t.Phases) == 0 {
+		return 0
+	}
+
+	start := t.Phases[0].Start
+	end := t.Phases[0].End()
+
+	for _, p := range t.Phases[1:] {
+		if p.Start.Before(start) {
+			start = p.Start
+		}
+		if p.End().After(end) {
+			end = p.End()
+		}
+	}
+
+	return end.Sub(start)
+}
+
+// kernelLogTimestamp matches the timestamp prefixing the kernel log lines,
+// e.g. "[    0.412503] ", in seconds since the kernel started.
+var kernelLogTimestamp = regexp.MustCompile(`^\[\s*([0-9]+\.[0-9]+)\]\s`)
+
+// guestKernelBootTime returns the time the guest kernel took to boot, if
+// line is the kernel log line printed on the guest console when the kernel
+// frees its init memory, right before running init.
+func guestKernelBootTime(line string) (time.Duration, bool) {
+	match := kernelLogTimestamp.FindStringSubmatch(line)
+	if match == nil || !strings.Contains(line, "Freeing unused kernel") {
+		return 0, false
+	}
+
+	boot, err := time.ParseDuration(match[1] + "s")
+	if err != nil {
+		return 0, false
+	}
+
+	return boot, true
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandboxTimeline(t *testing.T) {
+	assert := assert.New(t)
+
+	var timeline SandboxTimeline
+	assert.Equal(time.Duration(0), timeline.Total())
+	assert.False(timeline.recorded(phaseCreateSandbox))
+
+	start := time.Now().Add(-time.Second)
+	timeline.record(phaseCreateSandbox, start)
+	assert.True(timeline.recorded(phaseCreateSandbox))
+	assert.False(timeline.recorded(phaseCreateContainer))
+	assert.True(timeline.Phases[0].Duration >= time.Second)
+
+	timeline.Phases = append(timeline.Phases, TimelinePhase{
+		Name:     phaseCreateContainer,
+		Start:    start.Add(2 * time.Second),
+		Duration: time.Second,
+	})
+	assert.Equal(3*time.Second, timeline.Total())
+}
+
+func TestGuestKernelBootTime(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, d := range []struct {
+		line string
+		boot time.Duration
+		ok   bool
+	}{
+		{"[    0.412503] Freeing unused kernel memory: 1460K", 412503 * time.Microsecond, true},
+		{"[   12.000001] Freeing unused kernel image memory: 1460K", 12000001 * time.Microsecond, true},
+		{"[    0.300000] Run /sbin/init as init process", 0, false},
+		{"Freeing unused kernel memory: 1460K", 0, false},
+		{"", 0, false},
+	} {
+		boot, ok := guestKernelBootTime(d.line)
+		assert.Equal(d.ok, ok, "%q", d.line)
+		assert.Equal(d.boot, boot, "%q", d.line)
+	}
+
+	s := &Sandbox{}
+	s.readGuestConsole("[    0.100000] Freeing unused kernel memory: 1460K")
+	s.readGuestConsole("[    0.200000] Freeing unused kernel image memory: 2048K")
+	s.readGuestConsole("[    0.300000] random: crng init done")
+	assert.Equal(int64(200*time.Millisecond), s.guestKernelBoot)
+}
@@ -12,6 +12,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 const cpBinaryName = "cp"
@@ -139,6 +142,46 @@ func ConstraintsToVCPUs(quota int64, period uint64) uint {
 	return 0
 }
 
+// ParseCPUList parses a list of CPUs or NUMA nodes in the cpuset format,
+// such as "0-3,8", and returns them sorted, without duplicates.
+func ParseCPUList(list string) ([]int, error) {
+	set := make(map[int]bool)
+
+	for _, r := range strings.Split(list, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(r, "-", 2)
+
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("Invalid CPU list %q", list)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil, fmt.Errorf("Invalid CPU list %q", list)
+			}
+		}
+
+		for i := first; i <= last; i++ {
+			set[i] = true
+		}
+	}
+
+	var cpus []int
+	for cpu := range set {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+
+	return cpus, nil
+}
+
 // GetVirtDriveName returns the disk name format for virtio-blk
 // Reference: https://github.com/torvalds/linux/blob/master/drivers/block/virtio_blk.c @c0aa3e0916d7e531e69b02e426f7162dfb1c6c0
 func GetVirtDriveName(index int) (string, error) {
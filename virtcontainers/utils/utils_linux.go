@@ -90,3 +90,74 @@ func FindContextID() (*os.File, uint64, error) {
 	vsockFd.Close()
 	return nil, 0, fmt.Errorf("Could not get a unique context ID for the vsock")
 }
+
+// from <linux/loop.h>
+const (
+	ioctlLoopSetFd      = 0x4C00
+	ioctlLoopClrFd      = 0x4C01
+	ioctlLoopCtlGetFree = 0x4C82
+)
+
+// LoopControlPath is the path of the loop devices control device.
+var LoopControlPath = "/dev/loop-control"
+
+// AttachLoopDevice attaches the file at path to a free loop device, and
+// returns the path of the loop device. It is the caller's responsibility
+// to detach the loop device once it is not needed anymore.
+func AttachLoopDevice(path string) (string, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	ctl, err := os.OpenFile(LoopControlPath, os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer ctl.Close()
+
+	// A free loop device can be grabbed by another process before the
+	// file is attached to it, in which case another one is looked up.
+	for retries := 0; retries < 10; retries++ {
+		index, _, errno := unix.Syscall(unix.SYS_IOCTL, ctl.Fd(), ioctlLoopCtlGetFree, 0)
+		if errno != 0 {
+			return "", os.NewSyscallError("ioctl", errno)
+		}
+
+		loopPath := fmt.Sprintf("/dev/loop%d", index)
+
+		loop, err := os.OpenFile(loopPath, os.O_RDWR, 0)
+		if err != nil {
+			return "", err
+		}
+
+		_, _, errno = unix.Syscall(unix.SYS_IOCTL, loop.Fd(), ioctlLoopSetFd, file.Fd())
+		loop.Close()
+
+		if errno == 0 {
+			return loopPath, nil
+		}
+
+		if errno != unix.EBUSY {
+			return "", os.NewSyscallError("ioctl", errno)
+		}
+	}
+
+	return "", fmt.Errorf("Could not find a free loop device for %s", path)
+}
+
+// DetachLoopDevice detaches the file attached to the loop device at path.
+func DetachLoopDevice(path string) error {
+	loop, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer loop.Close()
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, loop.Fd(), ioctlLoopClrFd, 0); errno != 0 {
+		return os.NewSyscallError("ioctl", errno)
+	}
+
+	return nil
+}
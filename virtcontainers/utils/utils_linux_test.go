@@ -7,6 +7,8 @@ package utils
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,3 +35,37 @@ func TestFindContextID(t *testing.T) {
 	assert.Zero(cid)
 	assert.Error(err)
 }
+
+func TestAttachLoopDevice(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Test disabled as requires root privileges")
+	}
+
+	assert := assert.New(t)
+
+	if _, err := os.Stat(LoopControlPath); err != nil {
+		t.Skip("Test disabled as loop devices are not available")
+	}
+
+	_, err := AttachLoopDevice("/does/not/exist")
+	assert.Error(err)
+
+	file, err := ioutil.TempFile("", "loop-")
+	assert.NoError(err)
+	defer os.Remove(file.Name())
+
+	assert.NoError(file.Truncate(1 << 20))
+	file.Close()
+
+	loop, err := AttachLoopDevice(file.Name())
+	assert.NoError(err)
+
+	// The content of the file is accessed through the loop device
+	assert.NoError(ioutil.WriteFile(loop, []byte("loop"), 0))
+	content, err := ioutil.ReadFile(file.Name())
+	assert.NoError(err)
+	assert.Equal("loop", string(content[:4]))
+
+	assert.NoError(DetachLoopDevice(loop))
+	assert.Error(DetachLoopDevice(loop))
+}
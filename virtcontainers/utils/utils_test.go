@@ -171,6 +171,34 @@ func TestConstraintsToVCPUs(t *testing.T) {
 	assert.Equal(expectedVCPUs, vcpus)
 }
 
+func TestParseCPUList(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, d := range []struct {
+		list  string
+		cpus  []int
+		valid bool
+	}{
+		{"", nil, true},
+		{"3", []int{3}, true},
+		{"0-3,8", []int{0, 1, 2, 3, 8}, true},
+		{" 8, 2-3,3 ", []int{2, 3, 8}, true},
+		{"a", nil, false},
+		{"3-1", nil, false},
+		{"-1", nil, false},
+		{"1-b", nil, false},
+	} {
+		cpus, err := ParseCPUList(d.list)
+		if !d.valid {
+			assert.Error(err, "%q", d.list)
+			continue
+		}
+
+		assert.NoError(err, "%q", d.list)
+		assert.Equal(d.cpus, cpus, "%q", d.list)
+	}
+}
+
 func TestGetVirtDriveNameInvalidIndex(t *testing.T) {
 	_, err := GetVirtDriveName(-1)
 
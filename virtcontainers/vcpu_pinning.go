@@ -0,0 +1,330 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/kata-containers/runtime/virtcontainers/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// maxHostCPUs is the number of CPUs a thread affinity mask can hold.
+const maxHostCPUs = 1024
+
+// maxHostNodes is the number of NUMA nodes a memory policy mask can hold.
+const maxHostNodes = 1024
+
+// The memory policy modes, from linux/mempolicy.h.
+const (
+	mpolDefault = 0
+	mpolBind    = 2
+)
+
+var (
+	sysCPUPath         = "/sys/devices/system/cpu"
+	procSelfStatusPath = "/proc/self/status"
+)
+
+var (
+	setThreadAffinity = schedSetAffinity
+	setMemoryPolicy   = setMempolicy
+	cgroupCpusetPath  = cpusetPath
+)
+
+// cpusetPath returns the directory of a cgroup in the cpuset hierarchy.
+func cpusetPath(path string) (string, error) {
+	root, err := cgroupV1MountPoint()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(root, "cpuset", path), nil
+}
+
+// schedSetAffinity restricts a thread to a single host CPU.
+func schedSetAffinity(tid, cpu int) error {
+	if cpu < 0 || cpu >= maxHostCPUs {
+		return fmt.Errorf("Invalid CPU %d", cpu)
+	}
+
+	var mask [maxHostCPUs / 64]uint64
+	mask[cpu/64] |= 1 << uint(cpu%64)
+
+	_, _, errno := unix.RawSyscall(unix.SYS_SCHED_SETAFFINITY, uintptr(tid), uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0])))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// setMempolicy sets the memory policy of the calling thread, binding its
+// allocations to the host NUMA nodes, or restoring the default policy when
+// no node is given.
+func setMempolicy(nodes []int) error {
+	var mask [maxHostNodes / 64]uint64
+	mode := mpolDefault
+
+	for _, node := range nodes {
+		if node < 0 || node >= maxHostNodes {
+			return fmt.Errorf("Invalid NUMA node %d", node)
+		}
+
+		mask[node/64] |= 1 << uint(node%64)
+		mode = mpolBind
+	}
+
+	var maskPtr, maxNode uintptr
+	if mode != mpolDefault {
+		maskPtr = uintptr(unsafe.Pointer(&mask[0]))
+		maxNode = maxHostNodes + 1
+	}
+
+	_, _, errno := unix.RawSyscall(unix.SYS_SET_MEMPOLICY, uintptr(mode), maskPtr, maxNode)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// bindMemory runs launch with a memory policy binding the allocations to
+// the host NUMA nodes, if any: the hypervisor process launch starts
+// inherits it, so that the guest memory, huge pages and hotplugged memory
+// included, is allocated on those nodes. The memory of the hypervisor
+// cannot be bound again once it has been launched.
+func bindMemory(nodes []int, launch func() error) error {
+	if len(nodes) == 0 {
+		return launch()
+	}
+
+	// The policy is the one of the thread forking the hypervisor.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := setMemoryPolicy(nodes); err != nil {
+		return fmt.Errorf("Could not bind memory to NUMA nodes %v: %v", nodes, err)
+	}
+	defer func() {
+		if err := setMemoryPolicy(nil); err != nil {
+			virtLog.WithError(err).Warn("Could not restore the default memory policy")
+		}
+	}()
+
+	return launch()
+}
+
+// cpuset returns the host CPUs the sandbox runs on, i.e. the union of the
+// cpusets of its containers, or the CPUs the runtime is allowed to run on
+// when none of them is restricted. The NUMA nodes the containers memory is
+// restricted to are returned as well, if any.
+func (s *Sandbox) cpuset() ([]int, []int, error) {
+	var cpuList, memList []string
+
+	for _, c := range s.config.Containers {
+		if cpu := c.Resources.CPU; cpu != nil {
+			cpuList = append(cpuList, cpu.Cpus)
+			memList = append(memList, cpu.Mems)
+		}
+	}
+
+	cpus, err := utils.ParseCPUList(strings.Join(cpuList, ","))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mems, err := utils.ParseCPUList(strings.Join(memList, ","))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(cpus) == 0 {
+		if cpus, err = allowedCPUs(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return cpus, mems, nil
+}
+
+// allowedCPUs returns the host CPUs the runtime is allowed to run on.
+func allowedCPUs() ([]int, error) {
+	f, err := os.Open(procSelfStatusPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) == 2 && fields[0] == "Cpus_allowed_list" {
+			return utils.ParseCPUList(fields[1])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("Could not find the allowed CPUs in %s", procSelfStatusPath)
+}
+
+// cpuNUMANodes returns the NUMA nodes of host CPUs. Nothing is returned on
+// hosts without NUMA support.
+func cpuNUMANodes(cpus []int) ([]int, error) {
+	set := make(map[int]bool)
+
+	for _, cpu := range cpus {
+		paths, err := filepath.Glob(filepath.Join(sysCPUPath, fmt.Sprintf("cpu%d", cpu), "node*"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			node, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "node"))
+			if err == nil {
+				set[node] = true
+			}
+		}
+	}
+
+	var nodes []int
+	for node := range set {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+
+	return nodes, nil
+}
+
+// memoryHostNodes returns the host NUMA nodes the guest memory is bound to:
+// the ones the containers memory is restricted to, or else the ones of the
+// sandbox CPUs.
+func (s *Sandbox) memoryHostNodes() ([]int, error) {
+	cpus, mems, err := s.cpuset()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mems) > 0 {
+		return mems, nil
+	}
+
+	return cpuNUMANodes(cpus)
+}
+
+// bindCgroupMemory binds the guest memory to the host NUMA nodes of the
+// sandbox once containers joined it. Under CRI, the sandbox is created with
+// its pause container only, which the Kubernetes CPU manager gives no
+// cpuset, so that the memory policy the hypervisor is launched with does not
+// bind anything. The sandbox cpuset cgroup, holding the vCPU threads, is
+// restricted to those nodes with memory_migrate enabled: the guest memory
+// already allocated is migrated to them, and the vCPU threads allocate the
+// rest of it from them.
+func (s *Sandbox) bindCgroupMemory(path string) error {
+	if !s.config.HypervisorConfig.PinVCPUs {
+		return nil
+	}
+
+	nodes, err := s.memoryHostNodes()
+	if err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	var mems []string
+	for _, node := range nodes {
+		mems = append(mems, strconv.Itoa(node))
+	}
+
+	dir, err := cgroupCpusetPath(path)
+	if err != nil {
+		return err
+	}
+
+	// Memory migration has to be enabled before the nodes are changed.
+	for _, f := range []struct {
+		name  string
+		value string
+	}{
+		{"cpuset.memory_migrate", "1"},
+		{"cpuset.mems", strings.Join(mems, ",")},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, f.name), []byte(f.value), 0); err != nil {
+			return fmt.Errorf("Could not bind memory to NUMA nodes %v: %v", nodes, err)
+		}
+	}
+
+	s.Logger().WithField("nodes", nodes).Info("Guest memory bound to host NUMA nodes")
+
+	return nil
+}
+
+// pinVCPUs pins each vCPU thread onto one of the sandbox CPUs, so that the
+// vCPUs do not float across the whole cpuset. The vCPUs are spread over the
+// CPUs when they outnumber them. It has to run again whenever vCPUs are
+// hotplugged.
+func (s *Sandbox) pinVCPUs() error {
+	if !s.config.HypervisorConfig.PinVCPUs {
+		return nil
+	}
+
+	cpus, _, err := s.cpuset()
+	if err != nil {
+		return err
+	}
+
+	if len(cpus) == 0 {
+		return nil
+	}
+
+	tids, err := s.hypervisor.getThreadIDs()
+	if err != nil {
+		return fmt.Errorf("failed to get thread ids from hypervisor: %v", err)
+	}
+	if tids == nil || len(tids.vcpus) == 0 {
+		s.Logger().Warn("No vCPU thread to pin")
+		return nil
+	}
+
+	if len(tids.vcpus) > len(cpus) {
+		s.Logger().WithFields(logrus.Fields{
+			"vcpus": len(tids.vcpus),
+			"cpus":  len(cpus),
+		}).Warn("vCPUs outnumber the sandbox CPUs, some of them share a CPU")
+	}
+
+	for i, tid := range tids.vcpus {
+		cpu := cpus[i%len(cpus)]
+
+		if err := setThreadAffinity(tid, cpu); err != nil {
+			return fmt.Errorf("Could not pin vCPU %d thread %d onto CPU %d: %v", i, tid, cpu, err)
+		}
+
+		s.Logger().WithFields(logrus.Fields{
+			"vcpu":   i,
+			"thread": tid,
+			"cpu":    cpu,
+		}).Debug("vCPU pinned")
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func vcpuPinningTestSandbox(cpus ...string) *Sandbox {
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			PinVCPUs: true,
+		},
+	}
+
+	for _, c := range cpus {
+		config.Containers = append(config.Containers, ContainerConfig{
+			Resources: specs.LinuxResources{
+				CPU: &specs.LinuxCPU{Cpus: c},
+			},
+		})
+	}
+
+	return &Sandbox{
+		id:         testSandboxID,
+		config:     config,
+		hypervisor: &mockHypervisor{},
+	}
+}
+
+func TestSandboxCPUSet(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	savedStatusPath := procSelfStatusPath
+	procSelfStatusPath = filepath.Join(tmpdir, "status")
+	defer func() {
+		procSelfStatusPath = savedStatusPath
+	}()
+
+	err = ioutil.WriteFile(procSelfStatusPath, []byte("Name:\tkata-runtime\nCpus_allowed_list:\t0-7\n"), 0644)
+	assert.NoError(err)
+
+	// the containers cpusets are merged
+	s := vcpuPinningTestSandbox("", "4-5", "2,4")
+	s.config.Containers[1].Resources.CPU.Mems = "1"
+
+	cpus, mems, err := s.cpuset()
+	assert.NoError(err)
+	assert.Equal([]int{2, 4, 5}, cpus)
+	assert.Equal([]int{1}, mems)
+
+	// the allowed CPUs are used when no container is restricted
+	s = vcpuPinningTestSandbox()
+
+	cpus, mems, err = s.cpuset()
+	assert.NoError(err)
+	assert.Equal([]int{0, 1, 2, 3, 4, 5, 6, 7}, cpus)
+	assert.Empty(mems)
+
+	s = vcpuPinningTestSandbox("foo")
+	_, _, err = s.cpuset()
+	assert.Error(err)
+}
+
+func TestSandboxMemoryHostNodes(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	savedSysCPUPath := sysCPUPath
+	sysCPUPath = tmpdir
+	defer func() {
+		sysCPUPath = savedSysCPUPath
+	}()
+
+	for cpu := 0; cpu < 4; cpu++ {
+		err = os.MkdirAll(filepath.Join(tmpdir, fmt.Sprintf("cpu%d", cpu), fmt.Sprintf("node%d", cpu/2)), 0755)
+		assert.NoError(err)
+	}
+
+	s := vcpuPinningTestSandbox("2-3")
+	nodes, err := s.memoryHostNodes()
+	assert.NoError(err)
+	assert.Equal([]int{1}, nodes)
+
+	s = vcpuPinningTestSandbox("1-2")
+	nodes, err = s.memoryHostNodes()
+	assert.NoError(err)
+	assert.Equal([]int{0, 1}, nodes)
+
+	// the memory nodes of the containers take precedence
+	s.config.Containers[0].Resources.CPU.Mems = "3"
+	nodes, err = s.memoryHostNodes()
+	assert.NoError(err)
+	assert.Equal([]int{3}, nodes)
+
+	// no NUMA support
+	s = vcpuPinningTestSandbox("8")
+	nodes, err = s.memoryHostNodes()
+	assert.NoError(err)
+	assert.Empty(nodes)
+}
+
+func TestSandboxBindCgroupMemory(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	savedSysCPUPath := sysCPUPath
+	savedCgroupCpusetPath := cgroupCpusetPath
+	sysCPUPath = filepath.Join(tmpdir, "cpu")
+	cgroupCpusetPath = func(path string) (string, error) {
+		return filepath.Join(tmpdir, "cpuset", path), nil
+	}
+	defer func() {
+		sysCPUPath = savedSysCPUPath
+		cgroupCpusetPath = savedCgroupCpusetPath
+	}()
+
+	for cpu := 0; cpu < 4; cpu++ {
+		err = os.MkdirAll(filepath.Join(sysCPUPath, fmt.Sprintf("cpu%d", cpu), fmt.Sprintf("node%d", cpu/2)), 0755)
+		assert.NoError(err)
+	}
+
+	cgroupDir := filepath.Join(tmpdir, "cpuset", "sandbox")
+	err = os.MkdirAll(cgroupDir, 0755)
+	assert.NoError(err)
+
+	readFile := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(cgroupDir, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	// The pause container has no cpuset, the containers joining the
+	// sandbox have one.
+	s := vcpuPinningTestSandbox("", "2-3")
+
+	// pinning is disabled
+	s.config.HypervisorConfig.PinVCPUs = false
+	err = s.bindCgroupMemory("/sandbox")
+	assert.NoError(err)
+	assert.Empty(readFile("cpuset.mems"))
+
+	s.config.HypervisorConfig.PinVCPUs = true
+	err = s.bindCgroupMemory("/sandbox")
+	assert.NoError(err)
+	assert.Equal("1", readFile("cpuset.memory_migrate"))
+	assert.Equal("1", readFile("cpuset.mems"))
+
+	// the memory nodes of the containers take precedence
+	s.config.Containers[1].Resources.CPU.Mems = "0-1"
+	err = s.bindCgroupMemory("/sandbox")
+	assert.NoError(err)
+	assert.Equal("0,1", readFile("cpuset.mems"))
+
+	// the cgroup does not exist
+	err = s.bindCgroupMemory("/missing")
+	assert.Error(err)
+}
+
+func TestSandboxPinVCPUs(t *testing.T) {
+	assert := assert.New(t)
+
+	pinned := make(map[int]int)
+	var pinErr error
+
+	savedSetThreadAffinity := setThreadAffinity
+	setThreadAffinity = func(tid, cpu int) error {
+		pinned[tid] = cpu
+		return pinErr
+	}
+	defer func() {
+		setThreadAffinity = savedSetThreadAffinity
+	}()
+
+	s := vcpuPinningTestSandbox("3,5")
+
+	// pinning is disabled
+	s.config.HypervisorConfig.PinVCPUs = false
+	err := s.pinVCPUs()
+	assert.NoError(err)
+	assert.Empty(pinned)
+
+	s.config.HypervisorConfig.PinVCPUs = true
+	err = s.pinVCPUs()
+	assert.NoError(err)
+	assert.Equal(map[int]int{os.Getpid(): 3}, pinned)
+
+	pinErr = errors.New("pinning failure")
+	err = s.pinVCPUs()
+	assert.Error(err)
+}
+
+func TestBindMemory(t *testing.T) {
+	assert := assert.New(t)
+
+	var policies [][]int
+	savedSetMemoryPolicy := setMemoryPolicy
+	setMemoryPolicy = func(nodes []int) error {
+		policies = append(policies, nodes)
+		return nil
+	}
+	defer func() {
+		setMemoryPolicy = savedSetMemoryPolicy
+	}()
+
+	// the memory is not bound without any node
+	launched := false
+	err := bindMemory(nil, func() error {
+		launched = true
+		return nil
+	})
+	assert.NoError(err)
+	assert.True(launched)
+	assert.Empty(policies)
+
+	// the policy is set while launching only
+	err = bindMemory([]int{0, 2}, func() error {
+		assert.Equal([][]int{{0, 2}}, policies)
+		return errors.New("launch failure")
+	})
+	assert.Error(err)
+	assert.Equal([][]int{{0, 2}, nil}, policies)
+
+	setMemoryPolicy = func(nodes []int) error {
+		return errors.New("set_mempolicy failure")
+	}
+
+	err = bindMemory([]int{0}, func() error {
+		assert.Fail("launched without the memory policy")
+		return nil
+	})
+	assert.Error(err)
+}
+
+func TestSetMempolicyInvalidNode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Error(setMempolicy([]int{-1}))
+	assert.Error(setMempolicy([]int{maxHostNodes}))
+
+	// restoring the default policy always works
+	assert.NoError(setMempolicy(nil))
+}